@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/casbin/casbin/v2"
@@ -8,10 +9,13 @@ import (
 	"github.com/lukinairina90/banking_backend/internal/repository"
 	"github.com/lukinairina90/banking_backend/internal/service"
 	"github.com/lukinairina90/banking_backend/internal/transport/rest"
+	"github.com/lukinairina90/banking_backend/pkg/cache"
 	"github.com/lukinairina90/banking_backend/pkg/config"
 	"github.com/lukinairina90/banking_backend/pkg/database"
 	"github.com/lukinairina90/banking_backend/pkg/hash"
 	"github.com/lukinairina90/banking_backend/pkg/lib/generator"
+	"github.com/lukinairina90/banking_backend/pkg/mailer"
+	"github.com/lukinairina90/banking_backend/pkg/webhook"
 	"github.com/sirupsen/logrus"
 )
 
@@ -29,51 +33,206 @@ func main() {
 
 	defer db.Close()
 
-	// Init casbin for RBAC middleware.
-	enforser, err := casbin.NewEnforcer(cfg.RBACConfig.ModelFilePath, cfg.RBACConfig.PolicyFilePath)
-	if err != nil {
-		logrus.WithError(err).Fatal("error initialization casbin enforcer")
-	}
-
 	// init deps
-	hasher := hash.NewSHA1Hasher(cfg.UserPasswordSalt)
+	hasher := hash.NewArgon2Hasher(hash.Argon2Params{
+		Memory:      cfg.PasswordConfig.Argon2Memory,
+		Iterations:  cfg.PasswordConfig.Argon2Iterations,
+		Parallelism: cfg.PasswordConfig.Argon2Parallelism,
+	}, cfg.UserPasswordSalt)
 	randomGenerator := generator.NewGenerator("UA", "123456")
 
 	//initialize repositories
+	transactor := repository.NewTransactor(db)
 	usersRepository := repository.NewUsers(db)
+	revokedTokensRepository := repository.NewRevokedTokens(db)
 	tokensRepository := repository.NewTokens(db)
 	rolesRepository := repository.NewRoles(db)
 	accountRepository := repository.NewAccount(db)
 	transactionRepository := repository.NewTransactions(db)
+	ledgerRepository := repository.NewLedger(db)
+	currencyRepository := repository.NewCurrency(db)
 	cardRepository := repository.NewCard(db)
 	eventRepository := repository.NewEvent(db)
+	idempotencyRepository := repository.NewIdempotency(db)
+	fxQuoteRepository := repository.NewFXQuotes(db)
+	twoFactorRepository := repository.NewTwoFactor(db)
+	oneTimeTokenRepository := repository.NewOneTimeTokens(db)
+	signInThrottleRepository := repository.NewSignInThrottles(db)
+	webhookRepository := repository.NewWebhooks(db)
+	casbinRulesRepository := repository.NewCasbinRules(db)
+
+	signingKeyRepository, err := repository.NewSigningKeys(cfg.JWTConfig.KeysDir)
+	if err != nil {
+		logrus.WithError(err).Fatal("error initialization signing keys directory")
+	}
+
+	var rateProvider service.RateProvider
+	switch cfg.FXConfig.RateProvider {
+	case "file":
+		rateProvider, err = repository.NewFileExchangeRates(cfg.FXConfig.RatesFilePath)
+		if err != nil {
+			logrus.WithError(err).Fatal("error loading exchange rates file")
+		}
+	case "db":
+		rateProvider = repository.NewExchangeRates(db)
+	default:
+		logrus.Fatalf("unsupported exchange rate provider %q", cfg.FXConfig.RateProvider)
+	}
+
+	// the policy file seeds the policy table on the first start, afterwards the policy is managed by the admin API
+	noPolicy, err := casbinRulesRepository.IsEmpty(context.Background())
+	if err != nil {
+		logrus.WithError(err).Fatal("error checking RBAC policy")
+	}
+
+	if noPolicy {
+		fileEnforcer, err := casbin.NewEnforcer(cfg.RBACConfig.ModelFilePath, cfg.RBACConfig.PolicyFilePath)
+		if err != nil {
+			logrus.WithError(err).Fatal("error loading RBAC policy file")
+		}
+
+		if err := casbinRulesRepository.SavePolicy(fileEnforcer.GetModel()); err != nil {
+			logrus.WithError(err).Fatal("error importing RBAC policy file")
+		}
+	}
+
+	// Init casbin for RBAC middleware.
+	enforser, err := casbin.NewSyncedEnforcer(cfg.RBACConfig.ModelFilePath, casbinRulesRepository)
+	if err != nil {
+		logrus.WithError(err).Fatal("error initialization casbin enforcer")
+	}
+
+	var mail service.Mailer
+	switch cfg.MailConfig.Driver {
+	case "smtp":
+		mail = mailer.NewSMTP(cfg.MailConfig.SMTPHost, cfg.MailConfig.SMTPPort, cfg.MailConfig.SMTPUsername, cfg.MailConfig.SMTPPassword, cfg.MailConfig.From)
+	case "file":
+		mail, err = mailer.NewFile(cfg.MailConfig.FileDir, cfg.MailConfig.From)
+		if err != nil {
+			logrus.WithError(err).Fatal("error initialization mail directory")
+		}
+	case "log":
+		mail = mailer.NewLog()
+	default:
+		logrus.Fatalf("unsupported mail driver %q", cfg.MailConfig.Driver)
+	}
+
+	var cacheBackend service.CacheBackend
+	switch cfg.CacheConfig.Driver {
+	case "redis":
+		cacheBackend = cache.NewRedis(cfg.CacheConfig.RedisAddr, cfg.CacheConfig.RedisPassword, cfg.CacheConfig.RedisDB, cfg.CacheConfig.RedisTimeout)
+	case "memory":
+		cacheBackend = cache.NewLRU(cfg.CacheConfig.Size)
+	default:
+		logrus.Fatalf("unsupported cache driver %q", cfg.CacheConfig.Driver)
+	}
+
+	// a retired key must outlive every access token it has signed
+	if cfg.JWTConfig.KeyOverlap < cfg.TokenTTL {
+		logrus.Fatalf("signing key overlap %s is shorter than the token TTL %s", cfg.JWTConfig.KeyOverlap, cfg.TokenTTL)
+	}
+
+	// the engine is created before the services, since the policy service validates policies against its routes
+	g := gin.New()
+	// the handlers pass the gin context to the services, it must carry the deadline and cancellation of the request
+	g.ContextWithFallback = true
+
+	// the client IP address sign in attempts are throttled by is taken from X-Forwarded-For only behind the trusted proxies
+	if err := g.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logrus.WithError(err).Fatal("error parsing trusted proxies")
+	}
+	//g.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	//initialize services
-	usersService := service.NewUsers(usersRepository, tokensRepository, rolesRepository, eventRepository, hasher, []byte(cfg.TokenSecret), cfg.TokenTTL)
-	accountService := service.NewAccount(accountRepository, transactionRepository, eventRepository, randomGenerator)
-	transactionService := service.NewTransaction(transactionRepository, accountRepository)
+	keys, err := service.NewKeys(signingKeyRepository, cfg.JWTConfig.SigningAlgorithm, cfg.JWTConfig.KeyRotationInterval, cfg.JWTConfig.KeyOverlap, cfg.JWTConfig.KeyCheckInterval)
+	if err != nil {
+		logrus.WithError(err).Fatal("error initialization signing keys")
+	}
+
+	if err := keys.Rotate(context.Background()); err != nil {
+		logrus.WithError(err).Fatal("error loading signing keys")
+	}
+
+	twoFactorService, err := service.NewTwoFactor(transactor, twoFactorRepository, usersRepository, cfg.TwoFactorConfig.Issuer, cfg.TwoFactorConfig.StepUpAmount, cfg.TwoFactorConfig.ChallengeTTL, cfg.TwoFactorConfig.ChallengeMaxAttempts,
+		cfg.TwoFactorConfig.CodeMaxFailures, cfg.TwoFactorConfig.CodeLockout)
+	if err != nil {
+		logrus.WithError(err).Fatal("error initialization two-factor service")
+	}
+
+	fxService, err := service.NewFX(rateProvider, fxQuoteRepository, currencyRepository, cfg.FXConfig.SpreadBps, cfg.FXConfig.QuoteTTL)
+	if err != nil {
+		logrus.WithError(err).Fatal("error initialization fx service")
+	}
+
+	accessCache := service.NewAccessCache(cacheBackend, usersRepository, rolesRepository, revokedTokensRepository, cfg.CacheConfig.TTL)
+	verificationService := service.NewVerification(transactor, usersRepository, tokensRepository, oneTimeTokenRepository, hasher, mail, accessCache,
+		cfg.MailConfig.EmailVerificationURL, cfg.MailConfig.PasswordResetURL, cfg.MailConfig.EmailVerificationTTL, cfg.MailConfig.PasswordResetTTL)
+	signInGuard := service.NewSignInGuard(signInThrottleRepository, eventRepository, service.SignInLimits{
+		MaxEmailFailures: cfg.SignInConfig.MaxEmailFailures,
+		MaxIPFailures:    cfg.SignInConfig.MaxIPFailures,
+		Window:           cfg.SignInConfig.FailureWindow,
+		Lockout:          cfg.SignInConfig.LockoutDuration,
+		BaseDelay:        cfg.SignInConfig.BaseDelay,
+		MaxDelay:         cfg.SignInConfig.MaxDelay,
+	})
+	usersService := service.NewUsers(transactor, usersRepository, tokensRepository, revokedTokensRepository, rolesRepository, eventRepository, hasher, keys, twoFactorService, verificationService, signInGuard, accessCache, cfg.TokenTTL)
+	profileService := service.NewProfile(transactor, usersRepository, cardRepository, tokensRepository, hasher, verificationService, accessCache)
+	accountService := service.NewAccount(transactor, accountRepository, transactionRepository, ledgerRepository, currencyRepository, fxService, eventRepository, usersRepository, twoFactorService, randomGenerator, cfg.BlockedReceiveOnly)
+	transactionService := service.NewTransaction(transactor, transactionRepository, accountRepository, ledgerRepository, eventRepository)
 	cardService := service.NewCard(cardRepository, usersRepository, accountRepository, eventRepository, randomGenerator)
 	eventService := service.NewEvent(eventRepository)
+	userDirectoryService := service.NewUserDirectory(usersRepository, accountRepository, cardRepository, eventRepository)
+	roleService := service.NewRoles(transactor, rolesRepository, usersRepository, accessCache)
+	ownershipService := service.NewOwnership(accountRepository, cardRepository)
+	policyService := service.NewPolicies(enforser, casbinRulesRepository, rolesRepository, rest.NewRoutes(g))
+	idempotencyService := service.NewIdempotency(idempotencyRepository, cfg.IdempotencyKeyTTL, cfg.IdempotencyLock)
+	transactionSweeper := service.NewTransactionSweeper(transactor, transactionRepository, ledgerRepository, cfg.TransactionPreparedTimeout)
+	webhookService := service.NewWebhooks(webhookRepository, webhook.NewAddressChecker())
+	webhookDispatcher := service.NewWebhookDispatcher(webhookRepository, webhook.NewHTTPSender(cfg.WebhookConfig.Timeout), cfg.WebhookConfig.BatchSize, cfg.WebhookConfig.Timeout, service.WebhookRetryPolicy{
+		MaxAttempts: cfg.WebhookConfig.MaxAttempts,
+		BaseBackoff: cfg.WebhookConfig.BaseBackoff,
+		MaxBackoff:  cfg.WebhookConfig.MaxBackoff,
+	})
+
+	// resolve transactions stuck in PREPARED status in background
+	go transactionSweeper.Run(context.Background(), cfg.TransactionSweepInterval)
+
+	// push events to the webhook subscriptions in background
+	go webhookDispatcher.Run(context.Background(), cfg.WebhookConfig.DispatchInterval)
+
+	if err := policyService.Reload(context.Background()); err != nil {
+		logrus.WithError(err).Fatal("error loading RBAC policy")
+	}
+
+	// pick up RBAC policy changes made by other instances in background
+	go policyService.Run(context.Background(), cfg.RBACConfig.ReloadInterval)
+
+	// rotate signing keys and pick up keys created by other instances in background
+	go keys.Run(context.Background(), cfg.JWTConfig.KeyCheckInterval)
 
 	//initialize transports
-	authTransport := rest.NewAuth(usersService)
-	accountTransport := rest.NewAccount(accountService)
-	transactionTransport := rest.NewTransaction(transactionService)
-	cardTransport := rest.NewCard(cardService)
-	eventTransport := rest.NewEvent(eventService)
+	transports := rest.Transports{
+		Auth:        rest.NewAuth(usersService, verificationService),
+		JWKS:        rest.NewJWKS(keys),
+		TwoFactor:   rest.NewTwoFactor(twoFactorService),
+		Profile:     rest.NewProfile(profileService),
+		Account:     rest.NewAccount(accountService, idempotencyService),
+		Transaction: rest.NewTransaction(transactionService),
+		Card:        rest.NewCard(cardService),
+		Event:       rest.NewEvent(eventService),
+		FX:          rest.NewFX(fxService),
+		AdminUsers:  rest.NewAdminUsers(userDirectoryService),
+		Role:        rest.NewRole(roleService),
+		Policy:      rest.NewPolicy(policyService),
+		AdminCache:  rest.NewAdminCache(accessCache),
+		Webhook:     rest.NewWebhook(webhookService),
+	}
 
-	rbacMiddleware := rest.RBACMiddleware(enforser, rolesRepository)
+	rbacMiddleware := rest.RBACMiddleware(enforser, accessCache, ownershipService)
 
 	// init routes
-	g := gin.New()
-	//g.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-
-	g.Use(rest.LoggingMiddleware())
-	authTransport.InjectRoutes(g, rbacMiddleware)
-	accountTransport.InjectRoutes(g, authTransport.AuthMiddleware(), rbacMiddleware)
-	transactionTransport.InjectRoutes(g, authTransport.AuthMiddleware(), rbacMiddleware)
-	cardTransport.InjectRoutes(g, authTransport.AuthMiddleware(), rbacMiddleware)
-	eventTransport.InjectRoutes(g, authTransport.AuthMiddleware(), rbacMiddleware)
+	g.Use(rest.LoggingMiddleware(), rest.ErrorMiddleware())
+	transports.InjectRoutes(g, rbacMiddleware)
 
 	fmt.Println("Server run...")
 	if err := g.Run(fmt.Sprintf(":%s", cfg.Port)); err != nil {
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/lukinairina90/banking_backend/internal/service"
+	"github.com/lukinairina90/banking_backend/internal/transport/rest"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// rbac-coverage prints which RBAC groups have access to every route of the API according to the policy files.
+// With -golden the report is compared with the expectation file and the command fails when they differ,
+// -update rewrites the expectation file instead.
+func main() {
+	modelPath := flag.String("model", "docker/rbac/model.conf", "RBAC model file path")
+	policyPath := flag.String("policy", "docker/rbac/policy.csv", "RBAC policy file path")
+	goldenPath := flag.String("golden", "", "expected report file path")
+	update := flag.Bool("update", false, "rewrite the expected report file")
+	flag.Parse()
+
+	gin.SetMode(gin.ReleaseMode)
+
+	report, err := coverage(*modelPath, *policyPath)
+	if err != nil {
+		logrus.WithError(err).Fatal("error evaluating RBAC coverage")
+	}
+
+	var b bytes.Buffer
+	if err := service.WriteCoverage(&b, report); err != nil {
+		logrus.WithError(err).Fatal("error writing RBAC coverage")
+	}
+
+	fmt.Print(b.String())
+
+	if *goldenPath == "" {
+		return
+	}
+
+	if *update {
+		if err := os.WriteFile(*goldenPath, b.Bytes(), 0o644); err != nil {
+			logrus.WithError(err).Fatal("error writing expected report")
+		}
+
+		return
+	}
+
+	golden, err := os.ReadFile(*goldenPath)
+	if err != nil {
+		logrus.WithError(err).Fatal("error reading expected report")
+	}
+
+	if !bytes.Equal(golden, b.Bytes()) {
+		logrus.Fatalf("RBAC coverage differs from %s, review the policy and rerun with -update", *goldenPath)
+	}
+}
+
+// coverage evaluates the access of every group to the routes of the API according to the policy files.
+func coverage(modelPath, policyPath string) (domain.CoverageReport, error) {
+	enforcer, err := casbin.NewEnforcer(modelPath, policyPath)
+	if err != nil {
+		return domain.CoverageReport{}, errors.Wrap(err, "casbin enforcer initialization error")
+	}
+
+	// the routes are registered without services, the handlers are never called
+	g := gin.New()
+	rest.RouteTransports().InjectRoutes(g, func(c *gin.Context) {})
+
+	report, err := service.NewPolicyCoverage(enforcer, rest.NewRoutes(g)).GetCoverage(context.Background())
+	if err != nil {
+		return domain.CoverageReport{}, errors.Wrap(err, "getting coverage error")
+	}
+
+	return report, nil
+}
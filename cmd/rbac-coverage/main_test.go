@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lukinairina90/banking_backend/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCoverage_Golden checks the access of every group to the routes of the API against the expected report,
+// rerun make rbac-coverage-update after reviewing a policy or route change.
+func TestCoverage_Golden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	report, err := coverage("../../docker/rbac/model.conf", "../../docker/rbac/policy.csv")
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	require.NoError(t, service.WriteCoverage(&b, report))
+
+	golden, err := os.ReadFile("../../docker/rbac/coverage.golden")
+	require.NoError(t, err)
+
+	assert.Equal(t, string(golden), b.String())
+	assert.Empty(t, report.Unreachable())
+}
@@ -0,0 +1,2 @@
+ALTER TABLE currency
+    DROP COLUMN precision;
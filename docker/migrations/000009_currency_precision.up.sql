@@ -0,0 +1,6 @@
+ALTER TABLE currency
+    ADD COLUMN precision SMALLINT NOT NULL DEFAULT 2 CHECK (precision BETWEEN 0 AND 8);
+
+UPDATE currency
+SET precision = 2
+WHERE code IN ('USD', 'UAH', 'PLN');
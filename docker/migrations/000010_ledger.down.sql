@@ -0,0 +1,16 @@
+ALTER TABLE accounts
+    ADD COLUMN amount DECIMAL NOT NULL DEFAULT 0;
+
+UPDATE accounts a
+SET amount = b.balance
+FROM (SELECT account_id, SUM(CASE WHEN direction = 'CREDIT' THEN amount ELSE -amount END) AS balance
+      FROM postings
+      WHERE account_id IS NOT NULL
+      GROUP BY account_id) b
+WHERE b.account_id = a.id;
+
+DROP TABLE postings;
+DROP FUNCTION reject_posting_change;
+DROP FUNCTION check_journal_entry_balance;
+DROP TABLE journal_entries;
+DROP TABLE system_accounts;
@@ -0,0 +1,98 @@
+CREATE TABLE system_accounts
+(
+    id          SERIAL UNIQUE                NOT NULL,
+    code        VARCHAR(50)                  NOT NULL,
+    currency_id INT REFERENCES currency (id) NOT NULL,
+    UNIQUE (code, currency_id)
+);
+
+INSERT INTO system_accounts (code, currency_id)
+SELECT s.code, c.id
+FROM currency c
+         CROSS JOIN (VALUES ('CASH_IN'), ('FEES'), ('FX')) AS s (code);
+
+CREATE TABLE journal_entries
+(
+    id             SERIAL UNIQUE                    NOT NULL,
+    transaction_id INT REFERENCES transactions (id),
+    description    VARCHAR(250)                     NOT NULL,
+    date_created   TIMESTAMP                        NOT NULL
+);
+
+CREATE TABLE postings
+(
+    id                SERIAL UNIQUE                       NOT NULL,
+    journal_entry_id  INT REFERENCES journal_entries (id) NOT NULL,
+    account_id        INT REFERENCES accounts (id),
+    system_account_id INT REFERENCES system_accounts (id),
+    currency_id       INT REFERENCES currency (id)        NOT NULL,
+    direction         VARCHAR(6)                          NOT NULL CHECK (direction IN ('DEBIT', 'CREDIT')),
+    amount            DECIMAL                             NOT NULL CHECK (amount > 0),
+    CHECK ((account_id IS NULL) <> (system_account_id IS NULL))
+);
+
+CREATE INDEX postings_account_id_idx ON postings (account_id);
+CREATE INDEX postings_journal_entry_id_idx ON postings (journal_entry_id);
+
+-- every journal entry must have equal debits and credits in each currency when the database transaction commits
+CREATE FUNCTION check_journal_entry_balance() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF EXISTS(SELECT 1
+              FROM postings
+              WHERE journal_entry_id = NEW.journal_entry_id
+              GROUP BY currency_id
+              HAVING SUM(CASE WHEN direction = 'DEBIT' THEN amount ELSE -amount END) <> 0) THEN
+        RAISE EXCEPTION 'journal entry % is not balanced', NEW.journal_entry_id;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER postings_balance_check
+    AFTER INSERT
+    ON postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW
+EXECUTE FUNCTION check_journal_entry_balance();
+
+-- postings are append-only, corrections are made with compensating journal entries
+CREATE FUNCTION reject_posting_change() RETURNS TRIGGER AS
+$$
+BEGIN
+    RAISE EXCEPTION 'postings are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER postings_append_only
+    BEFORE UPDATE OR DELETE
+    ON postings
+    FOR EACH ROW
+EXECUTE FUNCTION reject_posting_change();
+
+-- move existing balances into the ledger as opening balance entries funded by the cash-in system account
+DO
+$$
+    DECLARE
+        r        RECORD;
+        entry_id INT;
+    BEGIN
+        FOR r IN SELECT a.id, a.currency_id, a.amount, s.id AS cash_in_id
+                 FROM accounts a
+                          INNER JOIN system_accounts s ON s.code = 'CASH_IN' AND s.currency_id = a.currency_id
+                 WHERE a.amount <> 0
+            LOOP
+                INSERT INTO journal_entries (description, date_created)
+                VALUES ('opening balance', NOW())
+                RETURNING id INTO entry_id;
+
+                INSERT INTO postings (journal_entry_id, account_id, system_account_id, currency_id, direction, amount)
+                VALUES (entry_id, NULL, r.cash_in_id, r.currency_id, CASE WHEN r.amount > 0 THEN 'DEBIT' ELSE 'CREDIT' END, ABS(r.amount)),
+                       (entry_id, r.id, NULL, r.currency_id, CASE WHEN r.amount > 0 THEN 'CREDIT' ELSE 'DEBIT' END, ABS(r.amount));
+            END LOOP;
+    END
+$$;
+
+ALTER TABLE accounts
+    DROP COLUMN amount;
@@ -0,0 +1,11 @@
+CREATE TABLE idempotency_keys
+(
+    key           VARCHAR(255)              NOT NULL,
+    user_id       INT REFERENCES users (id) NOT NULL,
+    fingerprint   VARCHAR(64)               NOT NULL,
+    status_code   INT,
+    response_body BYTEA,
+    date_created  TIMESTAMP                 NOT NULL,
+    expires_at    TIMESTAMP                 NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
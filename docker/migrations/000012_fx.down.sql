@@ -0,0 +1,8 @@
+ALTER TABLE transactions
+    DROP COLUMN exchange_rate,
+    DROP COLUMN to_currency_id,
+    DROP COLUMN to_amount,
+    DROP COLUMN currency_id;
+
+DROP TABLE fx_quotes;
+DROP TABLE exchange_rates;
@@ -0,0 +1,46 @@
+CREATE TABLE exchange_rates
+(
+    base_currency_id  INT REFERENCES currency (id) NOT NULL,
+    quote_currency_id INT REFERENCES currency (id) NOT NULL,
+    rate              DECIMAL                      NOT NULL CHECK (rate > 0),
+    date_updated      TIMESTAMP                    NOT NULL,
+    PRIMARY KEY (base_currency_id, quote_currency_id)
+);
+
+INSERT INTO exchange_rates (base_currency_id, quote_currency_id, rate, date_updated)
+SELECT b.id, q.id, r.rate, NOW()
+FROM (VALUES ('USD', 'UAH', 36.9), ('USD', 'PLN', 4.05), ('PLN', 'UAH', 9.1)) AS r (base, quote, rate)
+         INNER JOIN currency b ON b.code = r.base
+         INNER JOIN currency q ON q.code = r.quote;
+
+CREATE TABLE fx_quotes
+(
+    id               SERIAL UNIQUE                NOT NULL,
+    user_id          INT REFERENCES users (id)    NOT NULL,
+    from_currency_id INT REFERENCES currency (id) NOT NULL,
+    to_currency_id   INT REFERENCES currency (id) NOT NULL,
+    rate             DECIMAL                      NOT NULL,
+    source_amount    DECIMAL                      NOT NULL,
+    target_amount    DECIMAL                      NOT NULL,
+    used             BOOLEAN                      NOT NULL DEFAULT FALSE,
+    date_created     TIMESTAMP                    NOT NULL,
+    expires_at       TIMESTAMP                    NOT NULL
+);
+
+ALTER TABLE transactions
+    ADD COLUMN currency_id    INT REFERENCES currency (id),
+    ADD COLUMN to_amount      DECIMAL,
+    ADD COLUMN to_currency_id INT REFERENCES currency (id),
+    ADD COLUMN exchange_rate  DECIMAL;
+
+UPDATE transactions t
+SET currency_id    = a.currency_id,
+    to_amount      = t.amount,
+    to_currency_id = a.currency_id
+FROM accounts a
+WHERE a.id = t.to_account;
+
+ALTER TABLE transactions
+    ALTER COLUMN currency_id SET NOT NULL,
+    ALTER COLUMN to_amount SET NOT NULL,
+    ALTER COLUMN to_currency_id SET NOT NULL;
@@ -0,0 +1,7 @@
+DROP INDEX transactions_status_date_created_idx;
+
+ALTER TABLE transactions
+    DROP CONSTRAINT transactions_status_check;
+
+ALTER TABLE transactions
+    DROP COLUMN failure_reason;
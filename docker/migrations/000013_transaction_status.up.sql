@@ -0,0 +1,15 @@
+ALTER TABLE transactions
+    ADD COLUMN failure_reason VARCHAR(500);
+
+-- transactions left in PREPARED before the state machine was introduced never moved money
+UPDATE transactions t
+SET status         = 'FAILED',
+    failure_reason = 'abandoned before settlement',
+    date_updated   = NOW()
+WHERE t.status = 'PREPARED'
+  AND NOT EXISTS(SELECT 1 FROM journal_entries j WHERE j.transaction_id = t.id);
+
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_status_check CHECK (status IN ('PREPARED', 'SENT', 'FAILED', 'REVERSED', 'CANCELLED'));
+
+CREATE INDEX transactions_status_date_created_idx ON transactions (status, date_created);
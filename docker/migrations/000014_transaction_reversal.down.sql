@@ -0,0 +1,29 @@
+DROP INDEX transactions_reversal_of_idx;
+
+-- to_account stays nullable, reversed deposits have no recipient account
+ALTER TABLE transactions
+    DROP COLUMN reversal_of;
+
+DELETE
+FROM event
+WHERE type IN ('TRANSACTION_REVERSED', 'TRANSACTION_REFUNDED');
+
+-- values can not be removed from the enum type, so it is recreated without them
+ALTER TYPE event_type RENAME TO event_type_old;
+
+CREATE TYPE event_type AS ENUM (
+    'ACCOUNT_CREATED',
+    'ACCOUNT_DELETED',
+    'ACCOUNT_BLOCKED',
+    'ACCOUNT_UNBLOCKED',
+    'CARD_CREATED',
+    'USER_BLOCKED',
+    'USER_UNBLOCKED',
+    'WITHDRAWAL',
+    'DEPOSIT'
+    );
+
+ALTER TABLE event
+    ALTER COLUMN type TYPE event_type USING type::text::event_type;
+
+DROP TYPE event_type_old;
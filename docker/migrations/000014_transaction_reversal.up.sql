@@ -0,0 +1,9 @@
+ALTER TYPE event_type ADD VALUE 'TRANSACTION_REVERSED';
+ALTER TYPE event_type ADD VALUE 'TRANSACTION_REFUNDED';
+
+-- reversal of a deposit returns the money to the bank, so the compensating transaction has no recipient account
+ALTER TABLE transactions
+    ALTER COLUMN to_account DROP NOT NULL,
+    ADD COLUMN reversal_of INT REFERENCES transactions (id);
+
+CREATE INDEX transactions_reversal_of_idx ON transactions (reversal_of) WHERE reversal_of IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TYPE event_type ADD VALUE 'TRANSFER';
@@ -0,0 +1,3 @@
+UPDATE event
+SET type = 'WITHDRAWAL'
+WHERE type = 'TRANSFER';
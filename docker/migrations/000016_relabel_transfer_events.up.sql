@@ -0,0 +1,5 @@
+-- transfers used to be recorded as withdrawals, they are told apart by the recipient account in the metadata
+UPDATE event
+SET type = 'TRANSFER'
+WHERE type = 'WITHDRAWAL'
+  AND metadata ? 'to_account_id';
@@ -0,0 +1,15 @@
+DROP INDEX refresh_tokens_user_id_idx;
+DROP INDEX refresh_tokens_family_id_idx;
+
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens
+    DROP COLUMN revoked_at,
+    DROP COLUMN rotated_at,
+    DROP COLUMN created_at,
+    DROP COLUMN ip,
+    DROP COLUMN user_agent,
+    DROP COLUMN family_id;
+
+ALTER TABLE refresh_tokens
+    RENAME COLUMN token_hash TO token;
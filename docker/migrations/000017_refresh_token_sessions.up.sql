@@ -0,0 +1,16 @@
+-- refresh tokens were stored in plain text, the sessions are dropped and users sign in again
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens
+    RENAME COLUMN token TO token_hash;
+
+ALTER TABLE refresh_tokens
+    ADD COLUMN family_id  VARCHAR(64)  NOT NULL,
+    ADD COLUMN user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN ip         VARCHAR(64)  NOT NULL DEFAULT '',
+    ADD COLUMN created_at TIMESTAMP    NOT NULL DEFAULT NOW(),
+    ADD COLUMN rotated_at TIMESTAMP,
+    ADD COLUMN revoked_at TIMESTAMP;
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
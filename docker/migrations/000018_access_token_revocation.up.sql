@@ -0,0 +1,11 @@
+ALTER TABLE users
+    ADD COLUMN token_version INT NOT NULL DEFAULT 0;
+
+CREATE TABLE revoked_tokens
+(
+    jti        VARCHAR(64) PRIMARY KEY                     NOT NULL,
+    user_id    INT REFERENCES users (id) ON DELETE CASCADE NOT NULL,
+    expires_at TIMESTAMP                                   NOT NULL
+);
+
+CREATE INDEX revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
@@ -0,0 +1,26 @@
+ALTER TABLE users
+    ADD COLUMN totp_secret    VARCHAR(64),
+    ADD COLUMN totp_enabled   BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN totp_last_step BIGINT  NOT NULL DEFAULT 0;
+
+CREATE TABLE recovery_codes
+(
+    id         SERIAL PRIMARY KEY                          NOT NULL,
+    user_id    INT REFERENCES users (id) ON DELETE CASCADE NOT NULL,
+    code_hash  VARCHAR(64)                                 NOT NULL,
+    created_at TIMESTAMP                                   NOT NULL DEFAULT NOW(),
+    used_at    TIMESTAMP,
+    UNIQUE (user_id, code_hash)
+);
+
+CREATE TABLE two_factor_challenges
+(
+    id         SERIAL PRIMARY KEY                          NOT NULL,
+    user_id    INT REFERENCES users (id) ON DELETE CASCADE NOT NULL,
+    token_hash VARCHAR(64) UNIQUE                          NOT NULL,
+    attempts   INT                                         NOT NULL DEFAULT 0,
+    created_at TIMESTAMP                                   NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP                                   NOT NULL
+);
+
+CREATE INDEX two_factor_challenges_expires_at_idx ON two_factor_challenges (expires_at);
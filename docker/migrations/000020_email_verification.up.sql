@@ -0,0 +1,18 @@
+ALTER TABLE users
+    ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- users registered before the verification was introduced keep their access
+UPDATE users SET email_verified = TRUE;
+
+CREATE TABLE one_time_tokens
+(
+    id         SERIAL PRIMARY KEY                          NOT NULL,
+    user_id    INT REFERENCES users (id) ON DELETE CASCADE NOT NULL,
+    purpose    VARCHAR(32)                                 NOT NULL,
+    token_hash VARCHAR(64) UNIQUE                          NOT NULL,
+    created_at TIMESTAMP                                   NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP                                   NOT NULL,
+    used_at    TIMESTAMP
+);
+
+CREATE INDEX one_time_tokens_user_id_purpose_idx ON one_time_tokens (user_id, purpose);
@@ -0,0 +1,28 @@
+DROP TABLE sign_in_throttles;
+
+DELETE
+FROM event
+WHERE type = 'USER_LOCKED';
+
+-- values can not be removed from the enum type, so it is recreated without it
+ALTER TYPE event_type RENAME TO event_type_old;
+
+CREATE TYPE event_type AS ENUM (
+    'ACCOUNT_CREATED',
+    'ACCOUNT_DELETED',
+    'ACCOUNT_BLOCKED',
+    'ACCOUNT_UNBLOCKED',
+    'CARD_CREATED',
+    'USER_BLOCKED',
+    'USER_UNBLOCKED',
+    'WITHDRAWAL',
+    'DEPOSIT',
+    'TRANSACTION_REVERSED',
+    'TRANSACTION_REFUNDED',
+    'TRANSFER'
+    );
+
+ALTER TABLE event
+    ALTER COLUMN type TYPE event_type USING type::text::event_type;
+
+DROP TYPE event_type_old;
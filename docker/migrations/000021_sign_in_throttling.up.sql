@@ -0,0 +1,12 @@
+ALTER TYPE event_type ADD VALUE 'USER_LOCKED';
+
+-- failed sign in attempts counted per email and per client IP address
+CREATE TABLE sign_in_throttles
+(
+    scope           VARCHAR(16)  NOT NULL,
+    subject         VARCHAR(255) NOT NULL,
+    failures        INT          NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP    NOT NULL,
+    locked_until    TIMESTAMP,
+    PRIMARY KEY (scope, subject)
+);
@@ -0,0 +1,22 @@
+ALTER TABLE users
+    ADD COLUMN role_id INT REFERENCES roles (id);
+
+-- a user keeps the role with the lowest ID, users without roles become users
+UPDATE users u
+SET role_id = COALESCE((SELECT MIN(ur.role_id) FROM user_roles ur WHERE ur.user_id = u.id),
+                       (SELECT id FROM roles WHERE name = 'user'));
+
+ALTER TABLE users
+    ALTER COLUMN role_id SET NOT NULL;
+
+DROP TABLE user_roles;
+
+DELETE
+FROM roles
+WHERE name NOT IN ('admin', 'user');
+
+ALTER TABLE roles
+    DROP CONSTRAINT roles_name_key,
+    ALTER COLUMN id DROP DEFAULT;
+
+DROP SEQUENCE roles_id_seq;
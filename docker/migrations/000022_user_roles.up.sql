@@ -0,0 +1,25 @@
+-- roles created by the admin get the next free ID
+CREATE SEQUENCE roles_id_seq OWNED BY roles.id;
+
+SELECT setval('roles_id_seq', (SELECT MAX(id) FROM roles));
+
+ALTER TABLE roles
+    ALTER COLUMN id SET DEFAULT nextval('roles_id_seq'),
+    ADD CONSTRAINT roles_name_key UNIQUE (name);
+
+CREATE TABLE user_roles
+(
+    user_id     INT REFERENCES users (id) ON DELETE CASCADE NOT NULL,
+    role_id     INT REFERENCES roles (id) ON DELETE CASCADE NOT NULL,
+    assigned_at TIMESTAMP                                   NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX user_roles_role_id_idx ON user_roles (role_id);
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT id, role_id
+FROM users;
+
+ALTER TABLE users
+    DROP COLUMN role_id;
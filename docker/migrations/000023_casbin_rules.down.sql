@@ -0,0 +1,3 @@
+DROP SEQUENCE casbin_rules_revision_seq;
+
+DROP TABLE casbin_rules;
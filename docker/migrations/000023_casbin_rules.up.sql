@@ -0,0 +1,15 @@
+CREATE TABLE casbin_rules
+(
+    id    SERIAL PRIMARY KEY  NOT NULL,
+    ptype VARCHAR(10)         NOT NULL,
+    v0    VARCHAR(255)        NOT NULL DEFAULT '',
+    v1    VARCHAR(255)        NOT NULL DEFAULT '',
+    v2    VARCHAR(255)        NOT NULL DEFAULT '',
+    v3    VARCHAR(255)        NOT NULL DEFAULT '',
+    v4    VARCHAR(255)        NOT NULL DEFAULT '',
+    v5    VARCHAR(255)        NOT NULL DEFAULT '',
+    UNIQUE (ptype, v0, v1, v2, v3, v4, v5)
+);
+
+-- bumped on every policy change, instances reload the policy when it moves
+CREATE SEQUENCE casbin_rules_revision_seq;
@@ -0,0 +1,3 @@
+UPDATE casbin_rules SET ptype = 'p' WHERE ptype = 'p2';
+
+SELECT nextval('casbin_rules_revision_seq');
@@ -0,0 +1,4 @@
+-- account routes of users are owner scoped, the owner of the account in the path is checked by the RBAC middleware
+UPDATE casbin_rules SET ptype = 'p2' WHERE ptype = 'p' AND v0 IN ('user', 'unverified') AND v1 LIKE '/account/{id}%';
+
+SELECT nextval('casbin_rules_revision_seq');
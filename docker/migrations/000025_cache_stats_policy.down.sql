@@ -0,0 +1,3 @@
+DELETE FROM casbin_rules WHERE ptype = 'p' AND v0 = 'admin' AND v1 = '/admin/cache/stats' AND v2 = 'GET';
+
+SELECT nextval('casbin_rules_revision_seq');
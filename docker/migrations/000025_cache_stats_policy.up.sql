@@ -0,0 +1,7 @@
+-- an empty table is seeded from policy.csv on the first start, which already has the rule
+INSERT INTO casbin_rules (ptype, v0, v1, v2)
+SELECT 'p', 'admin', '/admin/cache/stats', 'GET'
+WHERE EXISTS (SELECT 1 FROM casbin_rules)
+ON CONFLICT DO NOTHING;
+
+SELECT nextval('casbin_rules_revision_seq');
@@ -0,0 +1,9 @@
+DELETE FROM casbin_rules WHERE ptype = 'p' AND (v1 LIKE '/webhook/%' OR v1 LIKE '/admin/webhooks%');
+
+SELECT nextval('casbin_rules_revision_seq');
+
+DROP TABLE webhook_delivery_attempts;
+
+DROP TABLE webhook_deliveries;
+
+DROP TABLE webhook_subscriptions;
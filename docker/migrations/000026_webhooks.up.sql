@@ -0,0 +1,58 @@
+-- subscriptions without a user receive the events of all users, they are managed by the admins
+CREATE TABLE webhook_subscriptions
+(
+    id          SERIAL PRIMARY KEY                          NOT NULL,
+    user_id     INT REFERENCES users (id) ON DELETE CASCADE,
+    url         VARCHAR(2048)                               NOT NULL,
+    event_types event_type[]                                NOT NULL DEFAULT '{}',
+    secret      VARCHAR(64)                                 NOT NULL,
+    created_at  TIMESTAMP                                   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX webhook_subscriptions_user_id_idx ON webhook_subscriptions (user_id);
+
+CREATE TABLE webhook_deliveries
+(
+    id              SERIAL PRIMARY KEY                                          NOT NULL,
+    subscription_id INT REFERENCES webhook_subscriptions (id) ON DELETE CASCADE NOT NULL,
+    event_id        INT REFERENCES event (id) ON DELETE CASCADE                 NOT NULL,
+    status          VARCHAR(20)                                                 NOT NULL,
+    attempts        INT                                                         NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP                                                   NOT NULL DEFAULT NOW(),
+    last_error      VARCHAR(500)                                                NOT NULL DEFAULT '',
+    created_at      TIMESTAMP                                                   NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP                                                   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX webhook_deliveries_subscription_id_idx ON webhook_deliveries (subscription_id);
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'PENDING';
+
+CREATE TABLE webhook_delivery_attempts
+(
+    id           SERIAL PRIMARY KEY                                       NOT NULL,
+    delivery_id  INT REFERENCES webhook_deliveries (id) ON DELETE CASCADE NOT NULL,
+    status_code  INT                                                      NOT NULL DEFAULT 0,
+    error        VARCHAR(500)                                             NOT NULL DEFAULT '',
+    duration_ms  BIGINT                                                   NOT NULL,
+    attempted_at TIMESTAMP                                                NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX webhook_delivery_attempts_delivery_id_idx ON webhook_delivery_attempts (delivery_id);
+
+-- an empty table is seeded from policy.csv on the first start, which already has the rules
+INSERT INTO casbin_rules (ptype, v0, v1, v2)
+SELECT rule.ptype, rule.v0, rule.v1, rule.v2
+FROM (VALUES ('p', 'user', '/webhook/', '(GET)|(POST)'),
+             ('p', 'user', '/webhook/{id}', '(GET)|(DELETE)'),
+             ('p', 'user', '/webhook/{id}/deliveries/', 'GET'),
+             ('p', 'user', '/webhook/{id}/deliveries/{delivery_id}', 'GET'),
+             ('p', 'user', '/webhook/{id}/deliveries/{delivery_id}/redeliver', 'POST'),
+             ('p', 'admin', '/admin/webhooks', '(GET)|(POST)'),
+             ('p', 'admin', '/admin/webhooks/{id}', '(GET)|(DELETE)'),
+             ('p', 'admin', '/admin/webhooks/{id}/deliveries', 'GET'),
+             ('p', 'admin', '/admin/webhooks/{id}/deliveries/{delivery_id}', 'GET'),
+             ('p', 'admin', '/admin/webhooks/{id}/deliveries/{delivery_id}/redeliver', 'POST')) AS rule (ptype, v0, v1, v2)
+WHERE EXISTS (SELECT 1 FROM casbin_rules)
+ON CONFLICT DO NOTHING;
+
+SELECT nextval('casbin_rules_revision_seq');
@@ -0,0 +1,2 @@
+ALTER TABLE idempotency_keys
+    ADD COLUMN token VARCHAR(64) NOT NULL DEFAULT '';
@@ -7,8 +7,16 @@ type Account struct {
 	UserID     int
 	CurrencyID int
 	Blocked    bool
-	Amount     float64
+	Amount     Money
 }
 
 // Orderings type map[string]string for filters
 type Orderings map[string]string
+
+// errors for accounts
+var (
+	ErrInsufficientFunds       = newError(KindConflict, "INSUFFICIENT_FUNDS", "insufficient funds")
+	ErrAccountBlocked          = newError(KindLocked, "ACCOUNT_BLOCKED", "account is blocked")
+	ErrRecipientAccountBlocked = newError(KindConflict, "RECIPIENT_ACCOUNT_BLOCKED", "recipient account is blocked")
+	ErrRecipientUserBlocked    = newError(KindConflict, "RECIPIENT_USER_BLOCKED", "recipient account owner is blocked")
+)
@@ -0,0 +1,11 @@
+package domain
+
+// CacheStats business layer statistics of a cache, HitRatio is the share of lookups answered by the cache.
+// Errors counts the failed cache reads and writes, the lookups are answered by the database then.
+type CacheStats struct {
+	Name     string
+	Hits     int64
+	Misses   int64
+	Errors   int64
+	HitRatio float64
+}
@@ -0,0 +1,9 @@
+package domain
+
+// Currency business layer currency definition
+type Currency struct {
+	ID        int
+	Name      string
+	Code      string
+	Precision int
+}
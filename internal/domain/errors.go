@@ -0,0 +1,46 @@
+package domain
+
+// ErrorKind category of a business error, transport layers map it to their status codes.
+type ErrorKind int
+
+// kinds of business errors
+const (
+	KindInvalid ErrorKind = iota + 1
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindUnprocessable
+	KindLocked
+	KindUnauthorized
+	KindTooManyRequests
+)
+
+// Error business layer typed error.
+// Code is a stable machine-readable identifier of the error, Message is a human-readable description.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+}
+
+// newError constructor for Error.
+func newError(kind ErrorKind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// Error implements error interface.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// errors for accounts, cards, users and currencies lookups and ownership checks
+var (
+	ErrAccountNotFound     = newError(KindNotFound, "ACCOUNT_NOT_FOUND", "account not found")
+	ErrNotAccountOwner     = newError(KindForbidden, "NOT_ACCOUNT_OWNER", "account belongs to another user")
+	ErrSameAccountTransfer = newError(KindUnprocessable, "SAME_ACCOUNT_TRANSFER", "transfer to the same account")
+	ErrCardNotFound        = newError(KindNotFound, "CARD_NOT_FOUND", "card not found")
+	ErrUserNotFound        = newError(KindNotFound, "USER_NOT_FOUND", "user with such credentials not found")
+	ErrUserAlreadyExists   = newError(KindConflict, "USER_ALREADY_EXISTS", "user with such email already exists")
+	ErrCannotBlockSelf     = newError(KindUnprocessable, "CANNOT_BLOCK_SELF", "user can not block himself")
+	ErrCurrencyNotFound    = newError(KindUnprocessable, "CURRENCY_NOT_FOUND", "currency not found")
+)
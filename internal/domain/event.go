@@ -30,10 +30,18 @@ func NewEventTypeFromString(et string) (eventType, error) {
 		return UserBlockedEvent, nil
 	case UserUnblockedEvent.String():
 		return UserUnblockedEvent, nil
+	case UserLockedEvent.String():
+		return UserLockedEvent, nil
 	case WithdrawalEvent.String():
 		return WithdrawalEvent, nil
 	case DepositEvent.String():
 		return DepositEvent, nil
+	case TransferEvent.String():
+		return TransferEvent, nil
+	case TransactionReversedEvent.String():
+		return TransactionReversedEvent, nil
+	case TransactionRefundedEvent.String():
+		return TransactionRefundedEvent, nil
 	default:
 		return "", errors.New("unsupported event type")
 	}
@@ -48,8 +56,13 @@ const (
 	CardCreatedEvent      eventType = "CARD_CREATED"
 	UserBlockedEvent      eventType = "USER_BLOCKED"
 	UserUnblockedEvent    eventType = "USER_UNBLOCKED"
+	UserLockedEvent       eventType = "USER_LOCKED"
 	WithdrawalEvent       eventType = "WITHDRAWAL"
 	DepositEvent          eventType = "DEPOSIT"
+	TransferEvent         eventType = "TRANSFER"
+
+	TransactionReversedEvent eventType = "TRANSACTION_REVERSED"
+	TransactionRefundedEvent eventType = "TRANSACTION_REFUNDED"
 )
 
 // Event business layer event definition
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"math/big"
+	"strings"
+	"time"
+)
+
+// errors for exchange rates and quotes
+var (
+	ErrExchangeRateNotFound = newError(KindUnprocessable, "EXCHANGE_RATE_NOT_FOUND", "exchange rate not found")
+	ErrInvalidExchangeRate  = newError(KindInvalid, "INVALID_EXCHANGE_RATE", "invalid exchange rate")
+	ErrQuoteNotFound        = newError(KindUnprocessable, "QUOTE_NOT_FOUND", "exchange quote not found, expired or already used")
+	ErrQuoteMismatch        = newError(KindUnprocessable, "QUOTE_MISMATCH", "exchange quote does not match the transfer")
+	ErrQuoteSameCurrency    = newError(KindUnprocessable, "QUOTE_SAME_CURRENCY", "quote currencies must differ")
+)
+
+// rateScale number of fractional digits exchange rates are stored with.
+const rateScale = 10
+
+// BasisPointsPerUnit number of basis points in a whole rate, the spread must be below it.
+const BasisPointsPerUnit = 10000
+
+// ParseRate parses a positive decimal exchange rate such as "36.9512".
+func ParseRate(value string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || rate.Sign() <= 0 {
+		return nil, ErrInvalidExchangeRate
+	}
+
+	return rate, nil
+}
+
+// FormatRate returns the decimal representation of the exchange rate without trailing zeros.
+func FormatRate(rate *big.Rat) string {
+	s := rate.FloatString(rateScale)
+	s = strings.TrimRight(s, "0")
+
+	return strings.TrimSuffix(s, ".")
+}
+
+// ApplySpread returns the rate reduced by the spread in basis points, the difference is kept by the bank.
+func ApplySpread(rate *big.Rat, spreadBps int64) *big.Rat {
+	factor := big.NewRat(BasisPointsPerUnit-spreadBps, BasisPointsPerUnit)
+	applied := new(big.Rat).Mul(rate, factor)
+
+	// round the rate to the stored scale, so the persisted rate reproduces the converted amount
+	applied, _ = new(big.Rat).SetString(applied.FloatString(rateScale))
+
+	return applied
+}
+
+// Convert converts the amount into the provided currency by the rate, rounding half away from zero to whole minor units.
+func (m Money) Convert(rate *big.Rat, to Currency) (Money, error) {
+	// units * rate * 10^(to.Precision - m.Precision)
+	value := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Units), rate)
+	shift := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(to.Precision-m.Precision))), nil)
+	if to.Precision >= m.Precision {
+		value.Mul(value, new(big.Rat).SetInt(shift))
+	} else {
+		value.Quo(value, new(big.Rat).SetInt(shift))
+	}
+
+	// round(num / den) = (2*num + sign(num)*den) / (2*den), truncated
+	num := new(big.Int).Mul(value.Num(), big.NewInt(2))
+	den := new(big.Int).Mul(value.Denom(), big.NewInt(2))
+	if num.Sign() >= 0 {
+		num.Add(num, value.Denom())
+	} else {
+		num.Sub(num, value.Denom())
+	}
+
+	units := new(big.Int).Quo(num, den)
+	if !units.IsInt64() {
+		return Money{}, ErrAmountOverflow
+	}
+
+	return NewMoney(units.Int64(), to), nil
+}
+
+// FXQuote business layer exchange quote definition.
+// The quote locks the applied rate for the user until ExpiresAt, it can be used by a single transfer.
+type FXQuote struct {
+	ID           int
+	UserID       int
+	Rate         *big.Rat
+	SourceAmount Money
+	TargetAmount Money
+	ExpiresAt    time.Time
+}
+
+// abs returns the absolute value of x.
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"time"
+)
+
+// errors for idempotent requests handling
+var (
+	ErrIdempotencyKeyReused         = newError(KindUnprocessable, "IDEMPOTENCY_KEY_REUSED", "idempotency key was already used for a different request")
+	ErrIdempotencyRequestInProgress = newError(KindConflict, "IDEMPOTENCY_REQUEST_IN_PROGRESS", "request with the same idempotency key is still in progress")
+	ErrIdempotencyReservationLost   = newError(KindConflict, "IDEMPOTENCY_RESERVATION_LOST", "idempotency key was taken over by a retry of the request")
+)
+
+// IdempotencyKey business layer idempotency key definition.
+// StatusCode and ResponseBody are empty until the original request is completed.
+// The key of the request that is not completed until LockedUntil can be taken over by a retry,
+// Token identifies the reservation, so the request which lost the key can not complete or release it.
+type IdempotencyKey struct {
+	Key          string
+	UserID       int
+	Fingerprint  string
+	StatusCode   int
+	ResponseBody []byte
+	DateCreated  time.Time
+	ExpiresAt    time.Time
+	LockedUntil  time.Time
+	Token        string
+}
+
+// Completed reports whether the response of the original request is stored.
+func (k IdempotencyKey) Completed() bool {
+	return k.StatusCode != 0
+}
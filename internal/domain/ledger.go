@@ -0,0 +1,168 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// errors for journal entries validation
+var (
+	ErrUnbalancedJournalEntry = errors.New("journal entry debits and credits are not balanced")
+	ErrInvalidPosting         = errors.New("invalid journal entry posting")
+)
+
+// PostingDirection side of the ledger the posting is written to.
+type PostingDirection string
+
+// constants for posting directions
+const (
+	DebitPosting  PostingDirection = "DEBIT"
+	CreditPosting PostingDirection = "CREDIT"
+)
+
+// SystemAccount code of the bank own ledger account, one exists per currency.
+type SystemAccount string
+
+// constants for system accounts
+const (
+	CashInSystemAccount SystemAccount = "CASH_IN"
+	FeesSystemAccount   SystemAccount = "FEES"
+	FXSystemAccount     SystemAccount = "FX"
+)
+
+// Posting business layer posting definition, a single debit or credit line of the journal entry.
+// Exactly one of AccountID and SystemAccount is set.
+type Posting struct {
+	AccountID     int
+	SystemAccount SystemAccount
+	Direction     PostingDirection
+	Amount        Money
+}
+
+// JournalEntry business layer journal entry definition.
+// Customer accounts are liabilities of the bank, so a credit increases their balance and a debit decreases it.
+type JournalEntry struct {
+	ID            int
+	TransactionID int
+	Description   string
+	Postings      []Posting
+	DateCreated   time.Time
+}
+
+// NewDepositJournalEntry creates journal entry moving money from the cash-in system account to the customer account.
+func NewDepositJournalEntry(transactionID, accountID int, amount Money) JournalEntry {
+	return JournalEntry{
+		TransactionID: transactionID,
+		Description:   "deposit",
+		Postings: []Posting{
+			{SystemAccount: CashInSystemAccount, Direction: DebitPosting, Amount: amount},
+			{AccountID: accountID, Direction: CreditPosting, Amount: amount},
+		},
+	}
+}
+
+// NewWithdrawalJournalEntry creates journal entry moving cash out of the customer account to the cash-in system account.
+func NewWithdrawalJournalEntry(transactionID, accountID int, amount Money) JournalEntry {
+	return JournalEntry{
+		TransactionID: transactionID,
+		Description:   "withdrawal",
+		Postings: []Posting{
+			{AccountID: accountID, Direction: DebitPosting, Amount: amount},
+			{SystemAccount: CashInSystemAccount, Direction: CreditPosting, Amount: amount},
+		},
+	}
+}
+
+// NewDepositReversalJournalEntry creates journal entry returning deposited money from the customer account to the cash-in system account.
+func NewDepositReversalJournalEntry(transactionID, accountID int, amount Money) JournalEntry {
+	return JournalEntry{
+		TransactionID: transactionID,
+		Description:   "deposit reversal",
+		Postings: []Posting{
+			{AccountID: accountID, Direction: DebitPosting, Amount: amount},
+			{SystemAccount: CashInSystemAccount, Direction: CreditPosting, Amount: amount},
+		},
+	}
+}
+
+// NewWithdrawalReversalJournalEntry creates journal entry returning withdrawn money from the cash-in system account to the customer account.
+func NewWithdrawalReversalJournalEntry(transactionID, accountID int, amount Money) JournalEntry {
+	return JournalEntry{
+		TransactionID: transactionID,
+		Description:   "withdrawal reversal",
+		Postings: []Posting{
+			{SystemAccount: CashInSystemAccount, Direction: DebitPosting, Amount: amount},
+			{AccountID: accountID, Direction: CreditPosting, Amount: amount},
+		},
+	}
+}
+
+// NewTransferJournalEntry creates journal entry moving money between two customer accounts.
+// When the received amount is in another currency, the exchange goes through the FX system accounts of both currencies.
+func NewTransferJournalEntry(transactionID, fromAccountID, toAccountID int, amount, toAmount Money) JournalEntry {
+	if amount.Currency == toAmount.Currency {
+		return JournalEntry{
+			TransactionID: transactionID,
+			Description:   "transfer",
+			Postings: []Posting{
+				{AccountID: fromAccountID, Direction: DebitPosting, Amount: amount},
+				{AccountID: toAccountID, Direction: CreditPosting, Amount: toAmount},
+			},
+		}
+	}
+
+	return JournalEntry{
+		TransactionID: transactionID,
+		Description:   "currency exchange transfer",
+		Postings: []Posting{
+			{AccountID: fromAccountID, Direction: DebitPosting, Amount: amount},
+			{SystemAccount: FXSystemAccount, Direction: CreditPosting, Amount: amount},
+			{SystemAccount: FXSystemAccount, Direction: DebitPosting, Amount: toAmount},
+			{AccountID: toAccountID, Direction: CreditPosting, Amount: toAmount},
+		},
+	}
+}
+
+// Validate checks that every posting is well-formed and that debits equal credits in every currency of the entry.
+func (e JournalEntry) Validate() error {
+	if len(e.Postings) < 2 {
+		return ErrUnbalancedJournalEntry
+	}
+
+	totals := make(map[string]Money)
+	for _, p := range e.Postings {
+		if (p.AccountID == 0) == (p.SystemAccount == "") || !p.Amount.IsPositive() {
+			return ErrInvalidPosting
+		}
+
+		amount := p.Amount
+		switch p.Direction {
+		case DebitPosting:
+		case CreditPosting:
+			amount.Units = -amount.Units
+		default:
+			return ErrInvalidPosting
+		}
+
+		total, ok := totals[amount.Currency]
+		if !ok {
+			totals[amount.Currency] = amount
+			continue
+		}
+
+		sum, err := total.Add(amount)
+		if err != nil {
+			return err
+		}
+
+		totals[amount.Currency] = sum
+	}
+
+	for _, total := range totals {
+		if !total.IsZero() {
+			return ErrUnbalancedJournalEntry
+		}
+	}
+
+	return nil
+}
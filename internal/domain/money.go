@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// errors for money parsing and arithmetic
+var (
+	ErrInvalidAmount     = newError(KindInvalid, "INVALID_AMOUNT", "invalid amount")
+	ErrAmountPrecision   = newError(KindInvalid, "AMOUNT_PRECISION", "amount has too many fractional digits for the currency")
+	ErrAmountOverflow    = newError(KindInvalid, "AMOUNT_OVERFLOW", "amount is out of range")
+	ErrCurrencyMismatch  = newError(KindUnprocessable, "CURRENCY_MISMATCH", "currency mismatch")
+	ErrNonPositiveAmount = newError(KindInvalid, "NON_POSITIVE_AMOUNT", "amount must be greater than zero")
+)
+
+// Money fixed-point amount of money, stored in minor units of its currency.
+type Money struct {
+	Units     int64
+	Currency  string
+	Precision int
+}
+
+// NewMoney creates Money from minor units of the provided currency.
+func NewMoney(units int64, currency Currency) Money {
+	return Money{
+		Units:     units,
+		Currency:  currency.Code,
+		Precision: currency.Precision,
+	}
+}
+
+// ParseMoney parses a decimal string such as "12.30" into Money of the provided currency.
+// Trailing zeros are ignored, any other fractional digits beyond the currency precision are rejected.
+func ParseMoney(value string, currency Currency) (Money, error) {
+	s := strings.TrimSpace(value)
+	if s == "" {
+		return Money{}, ErrInvalidAmount
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart, hasFrac := strings.Cut(s, ".")
+	if intPart == "" || (hasFrac && fracPart == "") || !isDigits(intPart) || !isDigits(fracPart) {
+		return Money{}, ErrInvalidAmount
+	}
+
+	fracPart = strings.TrimRight(fracPart, "0")
+	if len(fracPart) > currency.Precision {
+		return Money{}, ErrAmountPrecision
+	}
+
+	fracPart += strings.Repeat("0", currency.Precision-len(fracPart))
+
+	units, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil {
+		return Money{}, ErrAmountOverflow
+	}
+
+	if negative {
+		units = -units
+	}
+
+	return NewMoney(units, currency), nil
+}
+
+// String returns the decimal representation of the amount, e.g. "12.30".
+func (m Money) String() string {
+	units := m.Units
+	sign := ""
+	if units < 0 {
+		sign = "-"
+	}
+
+	digits := strconv.FormatUint(absUnits(units), 10)
+	if m.Precision <= 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= m.Precision {
+		digits = strings.Repeat("0", m.Precision-len(digits)+1) + digits
+	}
+
+	point := len(digits) - m.Precision
+
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// MarshalJSON encodes the amount as a JSON string to avoid floating point rounding on clients.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(m.String())), nil
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.Units == 0
+}
+
+// IsPositive reports whether the amount is greater than zero.
+func (m Money) IsPositive() bool {
+	return m.Units > 0
+}
+
+// IsNegative reports whether the amount is less than zero.
+func (m Money) IsNegative() bool {
+	return m.Units < 0
+}
+
+// Add returns the sum of two amounts of the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return Money{}, err
+	}
+
+	if (o.Units > 0 && m.Units > math.MaxInt64-o.Units) || (o.Units < 0 && m.Units < math.MinInt64-o.Units) {
+		return Money{}, ErrAmountOverflow
+	}
+
+	m.Units += o.Units
+
+	return m, nil
+}
+
+// Sub returns the difference of two amounts of the same currency.
+func (m Money) Sub(o Money) (Money, error) {
+	if o.Units == math.MinInt64 {
+		return Money{}, ErrAmountOverflow
+	}
+
+	o.Units = -o.Units
+
+	return m.Add(o)
+}
+
+// Cmp compares two amounts of the same currency and returns -1, 0 or +1.
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case m.Units < o.Units:
+		return -1, nil
+	case m.Units > o.Units:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// checkCurrency checks that both amounts are expressed in the same currency.
+func (m Money) checkCurrency(o Money) error {
+	if m.Currency != o.Currency || m.Precision != o.Precision {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+
+	return nil
+}
+
+// isDigits reports whether the string consists of ASCII digits only.
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// absUnits returns the absolute value of minor units without overflowing on math.MinInt64.
+func absUnits(units int64) uint64 {
+	if units < 0 {
+		return uint64(-(units + 1)) + 1
+	}
+
+	return uint64(units)
+}
@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testUSD = Currency{ID: 1, Code: "USD", Precision: 2}
+	testUAH = Currency{ID: 2, Code: "UAH", Precision: 2}
+	testJPY = Currency{ID: 3, Code: "JPY", Precision: 0}
+	testKWD = Currency{ID: 4, Code: "KWD", Precision: 3}
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		currency Currency
+		want     int64
+		wantErr  error
+	}{
+		{name: "integer", value: "12", currency: testUSD, want: 1200},
+		{name: "decimal", value: "12.30", currency: testUSD, want: 1230},
+		{name: "one_fractional_digit", value: "12.3", currency: testUSD, want: 1230},
+		{name: "trailing_zeros_beyond_precision", value: "12.3000", currency: testUSD, want: 1230},
+		{name: "leading_zeros", value: "007.05", currency: testUSD, want: 705},
+		{name: "surrounding_spaces", value: " 1.50 ", currency: testUSD, want: 150},
+		{name: "explicit_plus", value: "+1.50", currency: testUSD, want: 150},
+		{name: "negative", value: "-1.50", currency: testUSD, want: -150},
+		{name: "negative_zero", value: "-0.00", currency: testUSD, want: 0},
+		{name: "zero_precision", value: "1500", currency: testJPY, want: 1500},
+		{name: "zero_precision_trailing_zero", value: "1500.0", currency: testJPY, want: 1500},
+		{name: "three_digit_precision", value: "1.005", currency: testKWD, want: 1005},
+		{name: "max_units", value: "92233720368547758.07", currency: testUSD, want: math.MaxInt64},
+		{name: "too_many_decimals_error", value: "12.305", currency: testUSD, wantErr: ErrAmountPrecision},
+		{name: "fraction_of_zero_precision_error", value: "1500.5", currency: testJPY, wantErr: ErrAmountPrecision},
+		{name: "overflow_error", value: "92233720368547758.08", currency: testUSD, wantErr: ErrAmountOverflow},
+		{name: "huge_overflow_error", value: "100000000000000000000", currency: testJPY, wantErr: ErrAmountOverflow},
+		{name: "negative_overflow_error", value: "-92233720368547758.09", currency: testUSD, wantErr: ErrAmountOverflow},
+		{name: "exponent_error", value: "1e3", currency: testUSD, wantErr: ErrInvalidAmount},
+		{name: "exponent_with_sign_error", value: "1.5E+2", currency: testUSD, wantErr: ErrInvalidAmount},
+		{name: "empty_error", value: "", currency: testUSD, wantErr: ErrInvalidAmount},
+		{name: "spaces_only_error", value: "   ", currency: testUSD, wantErr: ErrInvalidAmount},
+		{name: "sign_only_error", value: "-", currency: testUSD, wantErr: ErrInvalidAmount},
+		{name: "double_sign_error", value: "--1", currency: testUSD, wantErr: ErrInvalidAmount},
+		{name: "missing_integer_part_error", value: ".50", currency: testUSD, wantErr: ErrInvalidAmount},
+		{name: "missing_fractional_part_error", value: "1.", currency: testUSD, wantErr: ErrInvalidAmount},
+		{name: "two_points_error", value: "1.2.3", currency: testUSD, wantErr: ErrInvalidAmount},
+		{name: "comma_error", value: "1,50", currency: testUSD, wantErr: ErrInvalidAmount},
+		{name: "inner_space_error", value: "1 000", currency: testUSD, wantErr: ErrInvalidAmount},
+		{name: "non_ascii_digits_error", value: "١٢", currency: testUSD, wantErr: ErrInvalidAmount},
+		{name: "infinity_error", value: "Inf", currency: testUSD, wantErr: ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.value, tt.currency)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, NewMoney(tt.want, tt.currency), got)
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	tests := []struct {
+		name  string
+		money Money
+		want  string
+	}{
+		{name: "zero", money: NewMoney(0, testUSD), want: "0.00"},
+		{name: "minor_units_only", money: NewMoney(5, testUSD), want: "0.05"},
+		{name: "less_than_one", money: NewMoney(50, testUSD), want: "0.50"},
+		{name: "whole", money: NewMoney(1200, testUSD), want: "12.00"},
+		{name: "fractional", money: NewMoney(1230, testUSD), want: "12.30"},
+		{name: "negative", money: NewMoney(-5, testUSD), want: "-0.05"},
+		{name: "negative_whole", money: NewMoney(-1230, testUSD), want: "-12.30"},
+		{name: "zero_precision", money: NewMoney(1500, testJPY), want: "1500"},
+		{name: "negative_zero_precision", money: NewMoney(-1500, testJPY), want: "-1500"},
+		{name: "three_digit_precision", money: NewMoney(1005, testKWD), want: "1.005"},
+		{name: "max_units", money: NewMoney(math.MaxInt64, testUSD), want: "92233720368547758.07"},
+		{name: "min_units", money: NewMoney(math.MinInt64, testUSD), want: "-92233720368547758.08"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.money.String())
+		})
+	}
+}
+
+func TestMoney_StringRoundTrip(t *testing.T) {
+	for _, units := range []int64{0, 1, -1, 99, 100, -12345, math.MaxInt64, math.MinInt64 + 1} {
+		money := NewMoney(units, testUSD)
+
+		parsed, err := ParseMoney(money.String(), testUSD)
+		assert.NoError(t, err)
+		assert.Equal(t, money, parsed)
+	}
+}
+
+func TestMoney_Convert(t *testing.T) {
+	tests := []struct {
+		name    string
+		money   Money
+		rate    *big.Rat
+		to      Currency
+		want    int64
+		wantErr error
+	}{
+		{name: "same_precision", money: NewMoney(1000, testUSD), rate: big.NewRat(369512, 10000), to: testUAH, want: 36951},
+		{name: "identity_rate", money: NewMoney(1234, testUSD), rate: big.NewRat(1, 1), to: testUAH, want: 1234},
+		{name: "round_half_up", money: NewMoney(1, testUSD), rate: big.NewRat(25, 10), to: testUAH, want: 3},
+		{name: "round_down", money: NewMoney(1, testUSD), rate: big.NewRat(24, 10), to: testUAH, want: 2},
+		{name: "round_half_away_from_zero_negative", money: NewMoney(-1, testUSD), rate: big.NewRat(25, 10), to: testUAH, want: -3},
+		{name: "round_down_negative", money: NewMoney(-1, testUSD), rate: big.NewRat(24, 10), to: testUAH, want: -2},
+		{name: "to_lower_precision", money: NewMoney(1000, testUSD), rate: big.NewRat(15015, 100), to: testJPY, want: 1502},
+		{name: "to_lower_precision_round_half", money: NewMoney(50, testUSD), rate: big.NewRat(1, 1), to: testJPY, want: 1},
+		{name: "to_higher_precision", money: NewMoney(1000, testJPY), rate: big.NewRat(3075, 1000000), to: testKWD, want: 3075},
+		{name: "to_higher_precision_rounded", money: NewMoney(1, testJPY), rate: big.NewRat(1, 3), to: testUSD, want: 33},
+		{name: "zero", money: NewMoney(0, testUSD), rate: big.NewRat(369512, 10000), to: testUAH, want: 0},
+		{name: "overflow_error", money: NewMoney(math.MaxInt64, testUSD), rate: big.NewRat(2, 1), to: testUAH, wantErr: ErrAmountOverflow},
+		{name: "precision_overflow_error", money: NewMoney(math.MaxInt64/10, testJPY), rate: big.NewRat(1, 1), to: testUSD, wantErr: ErrAmountOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.money.Convert(tt.rate, tt.to)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, NewMoney(tt.want, tt.to), got)
+		})
+	}
+}
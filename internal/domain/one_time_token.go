@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// OneTimeTokenPurpose action a one-time token is issued for.
+type OneTimeTokenPurpose string
+
+// one-time token purposes
+const (
+	EmailVerificationPurpose OneTimeTokenPurpose = "email_verification"
+	PasswordResetPurpose     OneTimeTokenPurpose = "password_reset"
+)
+
+// errors for one-time tokens handling
+var (
+	ErrInvalidOneTimeToken  = newError(KindInvalid, "INVALID_ONE_TIME_TOKEN", "token is invalid, expired or already used")
+	ErrEmailAlreadyVerified = newError(KindConflict, "EMAIL_ALREADY_VERIFIED", "email is already verified")
+)
+
+// OneTimeToken business layer single-use expiring token sent to the user by email.
+// Only the hash of the token is stored, issuing a new token invalidates the unused ones of the same purpose.
+type OneTimeToken struct {
+	ID        int
+	UserID    int
+	Purpose   OneTimeTokenPurpose
+	TokenHash string
+	ExpiresAt time.Time
+}
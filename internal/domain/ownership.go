@@ -0,0 +1,17 @@
+package domain
+
+// ResourceKind kind of the resource a path parameter refers to, it is the path segment preceding the parameter,
+// e.g. account for /account/{id}.
+type ResourceKind string
+
+// kinds of the resources whose ownership is checked
+const (
+	AccountResource ResourceKind = "account"
+	CardResource    ResourceKind = "card"
+)
+
+// ResourceRef business layer reference to the resource by a request path.
+type ResourceRef struct {
+	Kind ResourceKind
+	ID   int
+}
@@ -0,0 +1,75 @@
+package domain
+
+// errors for the RBAC policy management
+var (
+	ErrPolicyAlreadyExists    = newError(KindConflict, "POLICY_ALREADY_EXISTS", "policy rule already exists")
+	ErrPolicyNotFound         = newError(KindNotFound, "POLICY_NOT_FOUND", "policy rule not found")
+	ErrInvalidPolicyAction    = newError(KindInvalid, "INVALID_POLICY_ACTION", "policy action must be * or a regular expression of HTTP methods")
+	ErrUnknownRoute           = newError(KindUnprocessable, "UNKNOWN_ROUTE", "no route matches the policy path and action")
+	ErrInvalidRoleInheritance = newError(KindInvalid, "INVALID_ROLE_INHERITANCE", "role can not inherit itself")
+	ErrInvalidOwnerPolicy     = newError(KindInvalid, "INVALID_OWNER_POLICY", "owner only policy path must have account or card ID params")
+)
+
+// Policy business layer RBAC policy rule, allows the subject role to call the routes matching the object path with the action methods.
+// Object is a path template such as /account/{id}, Action is * or a regular expression such as (GET)|(POST).
+// An OwnerOnly rule allows the call only when the user owns the resources of the path parameters.
+type Policy struct {
+	Subject   string
+	Object    string
+	Action    string
+	OwnerOnly bool
+}
+
+// RoleInheritance business layer RBAC grouping rule, Role gets all permissions of Parent.
+type RoleInheritance struct {
+	Role   string
+	Parent string
+}
+
+// Route business layer HTTP route, the path parameters are written as {name}.
+type Route struct {
+	Method string
+	Path   string
+}
+
+// Access access of an RBAC group to a route.
+type Access string
+
+// accesses of the RBAC coverage report
+const (
+	AccessDenied    Access = "-"
+	AccessAllowed   Access = "allow"
+	AccessOwnerOnly Access = "owner"
+)
+
+// RouteCoverage business layer access of every group of the coverage report to the route.
+type RouteCoverage struct {
+	Route  Route
+	Access []Access
+}
+
+// CoverageReport business layer report of the RBAC policy coverage of the routes, Access of every route follows the order of Groups.
+type CoverageReport struct {
+	Groups []string
+	Routes []RouteCoverage
+}
+
+// Unreachable returns the routes no group has access to.
+func (r CoverageReport) Unreachable() []Route {
+	var routes []Route
+	for _, route := range r.Routes {
+		reachable := false
+		for _, access := range route.Access {
+			if access != AccessDenied {
+				reachable = true
+				break
+			}
+		}
+
+		if !reachable {
+			routes = append(routes, route.Route)
+		}
+	}
+
+	return routes
+}
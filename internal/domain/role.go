@@ -1,5 +1,19 @@
 package domain
 
+// errors for the role management
+var (
+	ErrRoleNotFound      = newError(KindNotFound, "ROLE_NOT_FOUND", "role not found")
+	ErrRoleAlreadyExists = newError(KindConflict, "ROLE_ALREADY_EXISTS", "role with such name already exists")
+	ErrReservedRoleName  = newError(KindUnprocessable, "RESERVED_ROLE_NAME", "role name is reserved")
+	ErrLastUserRole      = newError(KindConflict, "LAST_USER_ROLE", "the last role of the user can not be revoked")
+)
+
+// Reserved RBAC groups of requests without an authenticated verified user, they can not be used as role names.
+const (
+	AnonymousRoleName  = "anonymous"
+	UnverifiedRoleName = "unverified"
+)
+
 // Role business layer role definition
 type Role struct {
 	ID   int
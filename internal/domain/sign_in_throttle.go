@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// errors for sign in brute-force protection
+var (
+	ErrSignInLocked    = newError(KindLocked, "SIGN_IN_LOCKED", "sign in is temporarily locked after too many failed attempts")
+	ErrSignInThrottled = newError(KindTooManyRequests, "SIGN_IN_THROTTLED", "too many failed sign in attempts, retry later")
+)
+
+// SignInThrottleScope what failed sign in attempts are counted by.
+type SignInThrottleScope string
+
+// scopes of failed sign in attempts
+const (
+	EmailThrottleScope SignInThrottleScope = "email"
+	IPThrottleScope    SignInThrottleScope = "ip"
+)
+
+// SignInThrottle business layer failed sign in attempts of an email or a client IP address.
+// LockedUntil is zero when the subject is not locked.
+type SignInThrottle struct {
+	Scope         SignInThrottleScope
+	Subject       string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+// RetryError business error of an operation which can be retried at a known time.
+type RetryError struct {
+	Err     *Error
+	RetryAt time.Time
+}
+
+// NewRetryError constructor for RetryError.
+func NewRetryError(err *Error, retryAt time.Time) *RetryError {
+	return &RetryError{Err: err, RetryAt: retryAt}
+}
+
+// Error implements error interface.
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%s until %s", e.Err.Message, e.RetryAt.UTC().Format(time.RFC3339))
+}
+
+// Unwrap returns the business error, so the error keeps its kind and code.
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"crypto"
+	"time"
+)
+
+// supported access token signing algorithms
+const (
+	SigningAlgorithmRS256 = "RS256"
+	SigningAlgorithmEdDSA = "EdDSA"
+)
+
+// errors for access token signing keys
+var (
+	ErrSigningKeyNotFound = newError(KindUnauthorized, "SIGNING_KEY_NOT_FOUND", "access token signing key is unknown or retired")
+)
+
+// SigningKey business layer access token signing key definition.
+// ID is published as the kid header of the signed tokens and in the JWKS.
+type SigningKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.Signer
+	CreatedAt  time.Time
+}
+
+// PublicKey returns the public part of the key used to verify tokens.
+func (k SigningKey) PublicKey() crypto.PublicKey {
+	return k.PrivateKey.Public()
+}
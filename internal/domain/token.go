@@ -2,10 +2,54 @@ package domain
 
 import "time"
 
-// RefreshSession business layer refreshSession definition
+// errors for refresh tokens handling
+var (
+	ErrInvalidRefreshToken = newError(KindUnauthorized, "INVALID_REFRESH_TOKEN", "refresh token is invalid or revoked")
+	ErrRefreshTokenExpired = newError(KindUnauthorized, "REFRESH_TOKEN_EXPIRED", "refresh token expired")
+	ErrRefreshTokenReused  = newError(KindUnauthorized, "REFRESH_TOKEN_REUSED", "refresh token was already used, the session is revoked")
+	ErrAccessTokenRevoked  = newError(KindUnauthorized, "ACCESS_TOKEN_REVOKED", "access token is revoked")
+)
+
+// RefreshSession business layer refreshSession definition.
+// Every sign in starts a new session family, each refresh rotates the token within the family.
+// Only the hash of the token is stored.
 type RefreshSession struct {
 	ID        int
 	UserID    int
-	Token     string
+	FamilyID  string
+	TokenHash string
+	UserAgent string
+	IP        string
+	CreatedAt time.Time
 	ExpiresAt time.Time
+	Rotated   bool
+	Revoked   bool
+}
+
+// Session business layer active session definition, one per session family.
+type Session struct {
+	FamilyID   string
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+	Current    bool
+}
+
+// Device business layer client device definition.
+type Device struct {
+	UserAgent string
+	IP        string
+}
+
+// AccessClaims business layer access token claims definition.
+// Version must match the token version of the user, it is bumped to invalidate all issued access tokens.
+// EmailVerified is false until the user verifies the email, such users are limited by RBAC.
+type AccessClaims struct {
+	ID            string
+	UserID        int
+	Version       int
+	EmailVerified bool
+	ExpiresAt     time.Time
 }
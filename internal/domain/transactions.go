@@ -1,15 +1,81 @@
 package domain
 
-import "time"
+import (
+	"math/big"
+	"time"
+)
 
-// Transaction business layer transaction definition
+// errors for transactions
+var (
+	ErrInvalidTransactionStatusTransition = newError(KindConflict, "INVALID_TRANSACTION_STATUS_TRANSITION", "invalid transaction status transition")
+	ErrTransactionNotFound                = newError(KindNotFound, "TRANSACTION_NOT_FOUND", "transaction not found")
+	ErrTransactionNotReversible           = newError(KindConflict, "TRANSACTION_NOT_REVERSIBLE", "transaction can not be reversed")
+	ErrReversalAmountExceeded             = newError(KindUnprocessable, "REVERSAL_AMOUNT_EXCEEDED", "reversal amount exceeds the amount left to reverse")
+	ErrReversalNegativeBalance            = newError(KindConflict, "REVERSAL_NEGATIVE_BALANCE", "reversal would make the recipient account balance negative")
+)
+
+// TransactionStatus status of the transaction lifecycle.
+type TransactionStatus string
+
+// constants for transaction statuses
+const (
+	TransactionPrepared  TransactionStatus = "PREPARED"
+	TransactionSent      TransactionStatus = "SENT"
+	TransactionFailed    TransactionStatus = "FAILED"
+	TransactionReversed  TransactionStatus = "REVERSED"
+	TransactionCancelled TransactionStatus = "CANCELLED"
+)
+
+// transactionTransitions statuses every status can be moved to.
+// A prepared transaction is either settled, fails or is cancelled, only a sent transaction can be reversed.
+var transactionTransitions = map[TransactionStatus][]TransactionStatus{
+	TransactionPrepared: {TransactionSent, TransactionFailed, TransactionCancelled},
+	TransactionSent:     {TransactionReversed},
+}
+
+// String stringer interface implementation
+func (s TransactionStatus) String() string {
+	return string(s)
+}
+
+// CanTransitionTo reports whether the transaction in this status can be moved to the next status.
+func (s TransactionStatus) CanTransitionTo(next TransactionStatus) bool {
+	for _, allowed := range transactionTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TransactionStatusesBefore returns the statuses the transaction can be moved to the provided status from.
+func TransactionStatusesBefore(next TransactionStatus) []TransactionStatus {
+	var statuses []TransactionStatus
+	for status := range transactionTransitions {
+		if status.CanTransitionTo(next) {
+			statuses = append(statuses, status)
+		}
+	}
+
+	return statuses
+}
+
+// Transaction business layer transaction definition.
+// Amount is taken in the currency of the sender, ToAmount is received in the currency of the recipient,
+// ExchangeRate is set only when the currencies differ. FailureReason is set for failed transactions.
+// ReversalOf is set for compensating transactions and holds the ID of the reversed transaction.
 type Transaction struct {
-	ID          int
-	FromAccount int
-	ToAccount   int
-	Amount      float64
-	Type        string
-	Status      string
-	DateCreated time.Time
-	DateUpdated time.Time
+	ID            int
+	FromAccount   int
+	ToAccount     int
+	Amount        Money
+	ToAmount      Money
+	ExchangeRate  *big.Rat
+	Type          string
+	Status        TransactionStatus
+	FailureReason string
+	ReversalOf    int
+	DateCreated   time.Time
+	DateUpdated   time.Time
 }
@@ -0,0 +1,42 @@
+package domain
+
+import "time"
+
+// errors for two-factor authentication
+var (
+	ErrTwoFactorAlreadyEnabled   = newError(KindConflict, "TWO_FACTOR_ALREADY_ENABLED", "two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled      = newError(KindConflict, "TWO_FACTOR_NOT_ENROLLED", "two-factor authentication enrollment is not started")
+	ErrTwoFactorNotEnabled       = newError(KindConflict, "TWO_FACTOR_NOT_ENABLED", "two-factor authentication is not enabled")
+	ErrInvalidTwoFactorCode      = newError(KindUnauthorized, "INVALID_TWO_FACTOR_CODE", "two-factor authentication code is invalid")
+	ErrInvalidTwoFactorChallenge = newError(KindUnauthorized, "INVALID_TWO_FACTOR_CHALLENGE", "two-factor challenge is invalid or expired")
+	ErrStepUpRequired            = newError(KindForbidden, "STEP_UP_REQUIRED", "two-factor authentication code is required for this operation")
+	ErrTwoFactorLocked           = newError(KindLocked, "TWO_FACTOR_LOCKED", "two-factor authentication codes are temporarily refused after too many wrong codes")
+)
+
+// TwoFactor business layer TOTP settings of the user.
+// The secret is set on enrollment, two-factor authentication is enabled once the first code is confirmed.
+// LastStep is the time step of the last accepted code, codes of this or earlier steps are not accepted again.
+// Failures counts the wrong codes since the last accepted one, no code is accepted until LockedUntil.
+type TwoFactor struct {
+	UserID      int
+	Secret      string
+	Enabled     bool
+	LastStep    int64
+	Failures    int
+	LockedUntil time.Time
+}
+
+// TOTPEnrollment business layer TOTP enrollment definition.
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// TwoFactorChallenge business layer pending second step of the sign in, only the hash of the token is stored.
+type TwoFactorChallenge struct {
+	ID        int
+	UserID    int
+	TokenHash string
+	Attempts  int
+	ExpiresAt time.Time
+}
@@ -4,6 +4,11 @@ import (
 	"time"
 )
 
+// errors for the user profile management
+var (
+	ErrInvalidCurrentPassword = newError(KindUnprocessable, "INVALID_CURRENT_PASSWORD", "current password is wrong")
+)
+
 // User business layer user definition
 type User struct {
 	ID           int
@@ -11,9 +16,13 @@ type User struct {
 	Surname      string
 	Email        string
 	Password     string
-	RoleId       int
+	Roles        []string
 	Blocked      bool
 	RegisteredAt time.Time
+	TokenVersion int
+
+	TwoFactorEnabled bool
+	EmailVerified    bool
 }
 
 // SignUpInput business signUpInput user definition
@@ -29,3 +38,47 @@ type SignInInput struct {
 	Email    string
 	Password string
 }
+
+// SignInResult business layer result of the sign in.
+// ChallengeToken is set instead of the tokens when the user has two-factor authentication enabled,
+// the sign in is completed by verifying the code against the challenge.
+type SignInResult struct {
+	AccessToken    string
+	RefreshToken   string
+	ChallengeToken string
+}
+
+// UpdateProfileInput business layer user profile update definition.
+// Only the provided fields are changed, a new email has to be verified again.
+// UpdateCardholderName renames the existing cards of the user after the new name and surname.
+type UpdateProfileInput struct {
+	Name                 *string
+	Surname              *string
+	Email                *string
+	UpdateCardholderName bool
+}
+
+// ChangePasswordInput business layer password change definition.
+type ChangePasswordInput struct {
+	CurrentPassword string
+	NewPassword     string
+}
+
+// UserFilter business layer filter of the user directory, zero fields do not filter.
+// Email and Name match a part of the email or of the name and surname ignoring the case.
+type UserFilter struct {
+	Email          string
+	Name           string
+	Blocked        *bool
+	Role           string
+	RegisteredFrom time.Time
+	RegisteredTo   time.Time
+}
+
+// UserDetails business layer user with their accounts, cards and recent events.
+type UserDetails struct {
+	User     User
+	Accounts []Account
+	Cards    []Card
+	Events   []Event
+}
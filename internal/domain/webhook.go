@@ -0,0 +1,78 @@
+package domain
+
+import "time"
+
+// errors for the webhooks
+var (
+	ErrWebhookNotFound         = newError(KindNotFound, "WEBHOOK_NOT_FOUND", "webhook subscription not found")
+	ErrWebhookDeliveryNotFound = newError(KindNotFound, "WEBHOOK_DELIVERY_NOT_FOUND", "webhook delivery not found")
+	ErrInvalidWebhookURL       = newError(KindInvalid, "INVALID_WEBHOOK_URL", "webhook URL must be an absolute http or https URL")
+	ErrForbiddenWebhookURL     = newError(KindInvalid, "FORBIDDEN_WEBHOOK_URL", "webhook URL must resolve to public addresses only")
+	ErrUnsupportedEventType    = newError(KindInvalid, "UNSUPPORTED_EVENT_TYPE", "unsupported event type")
+)
+
+// WebhookDeliveryStatus status of the webhook delivery.
+type WebhookDeliveryStatus string
+
+// String stringer interface implementation
+func (s WebhookDeliveryStatus) String() string {
+	return string(s)
+}
+
+// statuses of the webhook deliveries
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "SUCCEEDED"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "FAILED"
+)
+
+// WebhookSubscription business layer subscription of an URL to the events.
+// A subscription without UserID receives the events of all users, otherwise the events of the user.
+// Empty EventTypes means all event types.
+type WebhookSubscription struct {
+	ID         int
+	UserID     int
+	URL        string
+	EventTypes []eventType
+	Secret     string
+	CreatedAt  time.Time
+}
+
+// WebhookSubscriptionInput business layer webhook subscription creation input.
+type WebhookSubscriptionInput struct {
+	URL        string
+	EventTypes []string
+}
+
+// WebhookDelivery business layer delivery of the event to the subscription.
+// A pending delivery is attempted at NextAttemptAt, Attempts counts the failed attempts since the delivery was (re)queued.
+type WebhookDelivery struct {
+	ID             int
+	SubscriptionID int
+	EventID        int
+	EventType      eventType
+	Status         WebhookDeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastError      string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// WebhookDeliveryAttempt business layer record of a single delivery attempt, StatusCode is zero when no response was received.
+type WebhookDeliveryAttempt struct {
+	ID          int
+	DeliveryID  int
+	StatusCode  int
+	Error       string
+	Duration    time.Duration
+	AttemptedAt time.Time
+}
+
+// WebhookDispatch business layer due delivery with everything needed to send it.
+type WebhookDispatch struct {
+	Delivery WebhookDelivery
+	URL      string
+	Secret   string
+	Event    Event
+}
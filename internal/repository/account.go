@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strings"
 
@@ -16,6 +17,12 @@ import (
 const block = true
 const unblock = false
 
+// accountColumns selects account columns together with the account currency code and precision.
+// The amount is the account balance derived from the ledger postings: credits minus debits.
+const accountColumns = "a.id, a.iban, a.user_id, a.currency_id, a.blocked, " +
+	"COALESCE((SELECT SUM(CASE WHEN p.direction = 'CREDIT' THEN p.amount ELSE -p.amount END) FROM postings p WHERE p.account_id = a.id), 0) AS amount, " +
+	"c.code AS currency_code, c.precision AS currency_precision"
+
 // Account repository layer struct.
 type Account struct {
 	db *sqlx.DB
@@ -37,7 +44,7 @@ func (r Account) GetUserIDByAccountID(ctx context.Context, accountID int) (int,
 
 	var userID int
 
-	row := r.db.QueryRowxContext(ctx, "SELECT user_id FROM accounts WHERE id = $1", accountID)
+	row := conn(ctx, r.db).QueryRowxContext(ctx, "SELECT user_id FROM accounts WHERE id = $1", accountID)
 	if err := row.Err(); err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
@@ -47,6 +54,10 @@ func (r Account) GetUserIDByAccountID(ctx context.Context, accountID int) (int,
 	}
 
 	if err := row.Scan(&userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domain.ErrAccountNotFound
+		}
+
 		logrus.WithError(err).
 			WithFields(fields).
 			Error("scanning userID error")
@@ -68,8 +79,12 @@ func (r Account) GetAccountIDByIban(ctx context.Context, iban string) (int, erro
 
 	var accountID int
 
-	row := r.db.QueryRowxContext(ctx, "SELECT id FROM accounts WHERE iban = $1", iban)
+	row := conn(ctx, r.db).QueryRowxContext(ctx, "SELECT id FROM accounts WHERE iban = $1", iban)
 	if err := row.Scan(&accountID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domain.ErrAccountNotFound
+		}
+
 		logrus.WithError(err).
 			WithFields(fields).
 			Error("scanning accountID error")
@@ -80,97 +95,52 @@ func (r Account) GetAccountIDByIban(ctx context.Context, iban string) (int, erro
 	return accountID, nil
 }
 
-// GetAccountCurrencyIDByID returns account currency ID by provided account ID.
-func (r Account) GetAccountCurrencyIDByID(ctx context.Context, accountID int) (int, error) {
+// LockAccount locks the account row until the end of the current database transaction and returns the account as provided account ID.
+// The balance is read by a separate statement after the lock is acquired, so it includes postings committed while waiting for the lock.
+func (r Account) LockAccount(ctx context.Context, accountID int) (domain.Account, error) {
 	fields := logrus.Fields{
 		"layer":      "repository",
 		"repository": "Account",
-		"method":     "GetAccountCurrencyIDByID",
+		"method":     "LockAccount",
 		"account_id": accountID,
 	}
 
-	var currencyID int
-
-	row := r.db.QueryRowxContext(ctx, "SELECT currency_id FROM accounts WHERE id = $1", accountID)
-	if err := row.Scan(&currencyID); err != nil {
-		logrus.WithError(err).
-			WithFields(fields).
-			Error("scanning currencyID error")
-
-		return 0, errors.Wrap(err, "scanning currencyID error")
-	}
-
-	return currencyID, nil
-}
+	var lockedID int
 
-// GetAccountCurrencyIDByIban returns account currency ID by provided account iban.
-func (r Account) GetAccountCurrencyIDByIban(ctx context.Context, iban string) (int, error) {
-	fields := logrus.Fields{
-		"layer":      "repository",
-		"repository": "Account",
-		"method":     "GetAccountCurrencyIDByIban",
-		"iban":       iban,
-	}
-
-	var currencyID int
+	if err := conn(ctx, r.db).QueryRowxContext(ctx, "SELECT id FROM accounts WHERE id = $1 FOR UPDATE", accountID).Scan(&lockedID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Account{}, domain.ErrAccountNotFound
+		}
 
-	row := r.db.QueryRowxContext(ctx, "SELECT currency_id FROM accounts WHERE iban = $1", iban)
-	if err := row.Scan(&currencyID); err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
-			Error("scanning currencyID error")
+			Error("execution locking account by ID query error")
 
-		return 0, errors.Wrap(err, "scanning currencyID error")
+		return domain.Account{}, errors.Wrap(err, "execution locking account by ID query error")
 	}
 
-	return currencyID, nil
-}
-
-// GetAccountAmount returns account amount by provided account ID, userID.
-func (r Account) GetAccountAmount(ctx context.Context, accountID, userID int) (float64, error) {
-	fields := logrus.Fields{
-		"layer":      "repository",
-		"repository": "Account",
-		"method":     "GetAccountAmount",
-		"user_id":    userID,
-		"account_id": accountID,
-	}
+	var account models.Account
 
-	var amount float64
+	query := "SELECT " + accountColumns + " FROM accounts a INNER JOIN currency c ON c.id = a.currency_id WHERE a.id = $1"
 
-	row := r.db.QueryRowxContext(ctx, "SELECT amount FROM  accounts WHERE id=$1 AND user_id=$2", accountID, userID)
-	if err := row.Scan(&amount); err != nil {
+	if err := conn(ctx, r.db).QueryRowxContext(ctx, query, lockedID).StructScan(&account); err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
-			Error("scanning amount error")
-
-		return 0, errors.Wrap(err, "scanning amount error")
-	}
+			Error("execution getting locked account by ID query error")
 
-	return amount, nil
-}
-
-// ExistsAccount checks if the account exists by provided account ID.
-func (r Account) ExistsAccount(ctx context.Context, accountID int) (bool, error) {
-	fields := logrus.Fields{
-		"layer":      "repository",
-		"repository": "Account",
-		"method":     "ExistsAccount",
-		"account_id": accountID,
+		return domain.Account{}, errors.Wrap(err, "execution getting locked account by ID query error")
 	}
 
-	var exists bool
-
-	query := "select exists(select amount from accounts where id = $1)"
-	if err := r.db.QueryRowContext(ctx, query, accountID).Scan(&exists); err != nil {
+	domainAccount, err := account.ToDomain()
+	if err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
-			Error("scanning exists error")
+			Error("converting account error")
 
-		return false, errors.Wrap(err, "scanning exists error")
+		return domain.Account{}, errors.Wrap(err, "converting account error")
 	}
 
-	return exists, nil
+	return domainAccount, nil
 }
 
 // Create creates an account in the database.
@@ -184,8 +154,9 @@ func (r Account) Create(ctx context.Context, userID, currencyID int, iban string
 		"iban":        iban,
 	}
 
-	q := "INSERT INTO accounts (iban, user_id, currency_id, blocked) VALUES ($1, $2, $3, $4) returning *"
-	row := r.db.QueryRowContext(ctx, q, iban, userID, currencyID, false)
+	q := "WITH a AS (INSERT INTO accounts (iban, user_id, currency_id, blocked) VALUES ($1, $2, $3, $4) RETURNING *) " +
+		"SELECT " + accountColumns + " FROM a INNER JOIN currency c ON c.id = a.currency_id"
+	row := conn(ctx, r.db).QueryRowxContext(ctx, q, iban, userID, currencyID, false)
 	if row.Err() != nil {
 		logrus.WithError(row.Err()).
 			WithFields(fields).
@@ -195,14 +166,7 @@ func (r Account) Create(ctx context.Context, userID, currencyID int, iban string
 	}
 
 	account := models.Account{}
-	if err := row.Scan(
-		&account.ID,
-		&account.Iban,
-		&account.UserID,
-		&account.CurrencyID,
-		&account.Blocked,
-		&account.Amount,
-	); err != nil {
+	if err := row.StructScan(&account); err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
 			Error("scanning row into struct error")
@@ -210,7 +174,14 @@ func (r Account) Create(ctx context.Context, userID, currencyID int, iban string
 		return domain.Account{}, errors.Wrap(err, "scanning row into struct error")
 	}
 
-	domainAccount := account.ToDomain()
+	domainAccount, err := account.ToDomain()
+	if err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("converting account error")
+
+		return domain.Account{}, errors.Wrap(err, "converting account error")
+	}
 
 	return domainAccount, nil
 }
@@ -226,19 +197,21 @@ func (r Account) GetAccountsList(ctx context.Context, userID int, paginator doma
 
 	listAccounts := make([]models.Account, 0, paginator.PerPage)
 
-	qb := squirrel.Select("*").
-		From("accounts").
-		Where("user_id = ?", userID)
+	qb := squirrel.Select(accountColumns).
+		From("accounts a").
+		InnerJoin("currency c ON c.id = a.currency_id").
+		Where("a.user_id = ?", userID)
 
 	if ordering != nil {
 		var parts []string
 		for field, direction := range ordering {
+			// ordering refers to the output columns, so the derived amount can be used as well
 			parts = append(parts, fmt.Sprintf("%s %s", field, strings.ToUpper(direction)))
 		}
 
 		qb = qb.OrderBy(parts...)
 	} else {
-		qb = qb.OrderBy("id ASC")
+		qb = qb.OrderBy("a.id ASC")
 	}
 
 	qb = qb.Limit(uint64(paginator.PerPage)).
@@ -247,7 +220,7 @@ func (r Account) GetAccountsList(ctx context.Context, userID int, paginator doma
 
 	query, params, err := qb.ToSql()
 
-	rows, err := r.db.QueryxContext(ctx, query, params...)
+	rows, err := conn(ctx, r.db).QueryxContext(ctx, query, params...)
 	if err != nil || rows.Err() != nil {
 		logrus.WithError(err).
 			WithFields(fields).
@@ -266,7 +239,15 @@ func (r Account) GetAccountsList(ctx context.Context, userID int, paginator doma
 
 	domainListAccount := make([]domain.Account, 0)
 	for _, account := range listAccounts {
-		domainListAccount = append(domainListAccount, account.ToDomain())
+		domainAccount, err := account.ToDomain()
+		if err != nil {
+			logrus.WithError(err).
+				WithFields(fields).
+				Error("converting account error")
+
+			return nil, errors.Wrap(err, "converting account error")
+		}
+		domainListAccount = append(domainListAccount, domainAccount)
 	}
 
 	return domainListAccount, nil
@@ -284,7 +265,9 @@ func (r Account) GetAccount(ctx context.Context, accountID, userID int) (domain.
 
 	var account models.Account
 
-	row := r.db.QueryRowxContext(ctx, "SELECT * FROM  accounts WHERE id=$1 AND user_id=$2", accountID, userID)
+	query := "SELECT " + accountColumns + " FROM accounts a INNER JOIN currency c ON c.id = a.currency_id WHERE a.id=$1 AND a.user_id=$2"
+
+	row := conn(ctx, r.db).QueryRowxContext(ctx, query, accountID, userID)
 	if err := row.Err(); err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
@@ -294,6 +277,10 @@ func (r Account) GetAccount(ctx context.Context, accountID, userID int) (domain.
 	}
 
 	if err := row.StructScan(&account); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Account{}, domain.ErrAccountNotFound
+		}
+
 		logrus.WithError(err).
 			WithFields(fields).
 			Error("scanning account query result into struct error")
@@ -301,7 +288,14 @@ func (r Account) GetAccount(ctx context.Context, accountID, userID int) (domain.
 		return domain.Account{}, errors.Wrap(err, "error scanning result into struct")
 	}
 
-	domainAccount := account.ToDomain()
+	domainAccount, err := account.ToDomain()
+	if err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("converting account error")
+
+		return domain.Account{}, errors.Wrap(err, "converting account error")
+	}
 
 	return domainAccount, nil
 }
@@ -315,7 +309,7 @@ func (r Account) DeleteAccount(ctx context.Context, accountID int) error {
 		"account_id": accountID,
 	}
 
-	if _, err := r.db.ExecContext(ctx, "DELETE FROM accounts WHERE id=$1", accountID); err != nil {
+	if _, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM accounts WHERE id=$1", accountID); err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
 			Error("execution deleting account by accountID query error")
@@ -325,117 +319,67 @@ func (r Account) DeleteAccount(ctx context.Context, accountID int) error {
 	return nil
 }
 
-// DepositAccount replenishes the user's account by account id for the provided amount.
-func (r Account) DepositAccount(ctx context.Context, accountID int, amount float64) error {
+// BlockAccount blocks the user account to the provided account ID and user ID.
+func (r Account) BlockAccount(ctx context.Context, accountID, userID int) error {
 	fields := logrus.Fields{
 		"layer":      "repository",
 		"repository": "Account",
-		"method":     "DepositAccount",
+		"method":     "BlockAccount",
+		"user_id":    userID,
 		"account_id": accountID,
-		"amount":     amount,
-	}
-
-	query := "UPDATE accounts SET amount = amount + $1 WHERE id = $2"
-	if _, err := r.db.ExecContext(ctx, query, amount, accountID); err != nil {
-		logrus.WithError(err).
-			WithFields(fields).
-			Error("execution updating account by accountID query error")
-
-		return errors.Wrap(err, "execution updating account by accountID query error")
 	}
-	return nil
-}
 
-// TransferAccount transfer money from the user's account to another account with the same currency ID.
-func (r Account) TransferAccount(ctx context.Context, fromAccountID, userID int, amount float64, toAccountIban string) error {
-	fields := logrus.Fields{
-		"layer":           "repository",
-		"repository":      "Account",
-		"method":          "TransferAccount",
-		"user_id":         userID,
-		"from_account_id": fromAccountID,
-		"to_account_iban": toAccountIban,
-		"amount":          amount,
-	}
+	query := "update accounts set blocked = $1 where id = $2 and user_id = $3"
 
-	tx, err := r.db.Begin()
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, block, accountID, userID)
 	if err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
-			Error("begin transaction error")
-
-		return errors.Wrap(err, "begin transaction error")
-	}
-
-	if _, err := tx.ExecContext(ctx, "UPDATE accounts SET amount = amount - $1 WHERE id = $2 AND user_id = $3", amount, fromAccountID, userID); err != nil {
-		if err := tx.Rollback(); err != nil {
-			return errors.Wrap(err, "rollback transaction error")
-		}
+			Error("execution updating account by ID and userID query error")
 
-		return errors.Wrap(err, "debit transaction error")
+		return errors.Wrap(err, "execution updating account by ID and userID query error")
 	}
 
-	if _, err = tx.ExecContext(ctx, "UPDATE accounts SET amount = amount + $1 WHERE iban = $2", amount, toAccountIban); err != nil {
+	affected, err := res.RowsAffected()
+	if err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
-			Error("execution updating account by iban query error")
-
-		if err := tx.Rollback(); err != nil {
-			return errors.Wrap(err, "rollback transaction error")
-		}
+			Error("getting affected accounts error")
 
-		return errors.Wrap(err, "money transfer transaction error")
+		return errors.Wrap(err, "getting affected accounts error")
 	}
 
-	if err = tx.Commit(); err != nil {
-		return errors.Wrap(err, "commit transaction error")
+	if affected == 0 {
+		return domain.ErrAccountNotFound
 	}
 
 	return nil
 }
 
-// BlockAccount blocks the user account to the provided account ID and user ID.
-func (r Account) BlockAccount(ctx context.Context, accountID, userID int) error {
+// UnblockAccount unblocks the account with the provided ID whoever owns it and returns the ID of its owner.
+func (r Account) UnblockAccount(ctx context.Context, accountID int) (int, error) {
 	fields := logrus.Fields{
 		"layer":      "repository",
 		"repository": "Account",
-		"method":     "BlockAccount",
-		"user_id":    userID,
+		"method":     "UnblockAccount",
 		"account_id": accountID,
 	}
 
-	query := "update accounts set blocked = $1 where id = $2 and user_id = $3"
-
-	if _, err := r.db.ExecContext(ctx, query, block, accountID, userID); err != nil {
-		logrus.WithError(err).
-			WithFields(fields).
-			Error("execution updating account by ID and userID query error")
-
-		return errors.Wrap(err, "execution updating account by ID and userID query error")
-	}
+	var userID int
 
-	return nil
-}
+	query := "update accounts set blocked = $1 where id = $2 returning user_id"
 
-// UnblockAccount unblocks the user account to the provided account ID and user ID.
-func (r Account) UnblockAccount(ctx context.Context, accountID, userID int) error {
-	fields := logrus.Fields{
-		"layer":      "repository",
-		"repository": "Account",
-		"method":     "UnblockAccount",
-		"user_id":    userID,
-		"account_id": accountID,
-	}
-
-	query := "update accounts set blocked = $1 where id = $2 and user_id = $3"
+	if err := conn(ctx, r.db).QueryRowxContext(ctx, query, unblock, accountID).Scan(&userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domain.ErrAccountNotFound
+		}
 
-	if _, err := r.db.ExecContext(ctx, query, unblock, accountID, userID); err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
-			Error("execution updating account by ID and userID query error")
+			Error("execution updating account by ID query error")
 
-		return errors.Wrap(err, "execution updating account by ID and userID query error")
+		return 0, errors.Wrap(err, "execution updating account by ID query error")
 	}
 
-	return nil
+	return userID, nil
 }
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -39,7 +40,7 @@ func (r Card) CreateCard(ctx context.Context, accountID int, cardNumber string,
 
 	query := "insert into cards (account_id, card_number, cardholder_name, expiration_date, cvv_code) values ($1, $2, $3, $4, $5) RETURNING *"
 
-	row := r.db.QueryRowxContext(ctx, query, accountID, cardNumber, cardholderName, expirationDate, cvvCode)
+	row := conn(ctx, r.db).QueryRowxContext(ctx, query, accountID, cardNumber, cardholderName, expirationDate, cvvCode)
 	if row.Err() != nil {
 		logrus.WithError(row.Err()).
 			WithFields(fields).
@@ -76,8 +77,12 @@ func (r Card) GetCard(ctx context.Context, id, accountID int) (domain.Card, erro
 
 	var card models.Card
 
-	row := r.db.QueryRowxContext(ctx, query, id, accountID)
+	row := conn(ctx, r.db).QueryRowxContext(ctx, query, id, accountID)
 	if err := row.StructScan(&card); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Card{}, domain.ErrCardNotFound
+		}
+
 		logrus.WithError(err).
 			WithFields(fields).
 			Error("scanning row into struct error")
@@ -90,6 +95,34 @@ func (r Card) GetCard(ctx context.Context, id, accountID int) (domain.Card, erro
 	return domainCard, nil
 }
 
+// GetAccountIDByCardID returns the ID of the account the card belongs to.
+func (r Card) GetAccountIDByCardID(ctx context.Context, cardID int) (int, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Card",
+		"method":     "GetAccountIDByCardID",
+		"card_id":    cardID,
+	}
+
+	var accountID int
+
+	query := "SELECT account_id FROM cards WHERE id = $1"
+
+	if err := conn(ctx, r.db).GetContext(ctx, &accountID, query, cardID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domain.ErrCardNotFound
+		}
+
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting account ID by card ID query error")
+
+		return 0, errors.Wrap(err, "execution getting account ID by card ID query error")
+	}
+
+	return accountID, nil
+}
+
 // GetCardListUser returns all cards for all user accounts.
 func (r Card) GetCardListUser(ctx context.Context, userID int) ([]domain.Card, error) {
 	fields := logrus.Fields{
@@ -101,7 +134,7 @@ func (r Card) GetCardListUser(ctx context.Context, userID int) ([]domain.Card, e
 
 	query := "SELECT c.* FROM cards c INNER JOIN accounts a on a.id = c.account_id WHERE a.user_id = $1 ORDER BY a.currency_id DESC"
 
-	rows, err := r.db.QueryxContext(ctx, query, userID)
+	rows, err := conn(ctx, r.db).QueryxContext(ctx, query, userID)
 	if err != nil && rows.Err() != nil {
 		logrus.WithError(err).
 			WithFields(fields).
@@ -138,7 +171,7 @@ func (r Card) GetCardListByAccount(ctx context.Context, userID, accountID int) (
 
 	query := "SELECT c.* FROM cards c INNER JOIN accounts a on a.id = c.account_id WHERE a.user_id = $1 AND a.id = $2 ORDER BY a.currency_id DESC"
 
-	rows, err := r.db.QueryxContext(ctx, query, userID, accountID)
+	rows, err := conn(ctx, r.db).QueryxContext(ctx, query, userID, accountID)
 	if err != nil && rows.Err() != nil {
 		logrus.WithError(err).
 			WithFields(fields).
@@ -162,3 +195,26 @@ func (r Card) GetCardListByAccount(ctx context.Context, userID, accountID int) (
 
 	return domainListCards, nil
 }
+
+// UpdateCardholderName renames the cardholder on all cards of the user accounts.
+func (r Card) UpdateCardholderName(ctx context.Context, userID int, cardholderName string) error {
+	fields := logrus.Fields{
+		"layer":           "repository",
+		"repository":      "Card",
+		"method":          "UpdateCardholderName",
+		"user_id":         userID,
+		"cardholder_name": cardholderName,
+	}
+
+	query := "UPDATE cards c SET cardholder_name = $2 FROM accounts a WHERE a.id = c.account_id AND a.user_id = $1"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID, cardholderName); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution updating cardholder name query error")
+
+		return errors.Wrap(err, "execution updating cardholder name query error")
+	}
+
+	return nil
+}
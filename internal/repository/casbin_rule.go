@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	"github.com/jmoiron/sqlx"
+	"github.com/lukinairina90/banking_backend/internal/repository/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// casbinRuleValues number of the rule value columns v0..v5.
+const casbinRuleValues = 6
+
+// CasbinRules repository layer struct, the Casbin adapter keeping the RBAC policy in the database.
+// Every change moves the policy revision, so other instances know the policy has to be reloaded.
+type CasbinRules struct {
+	db         *sqlx.DB
+	transactor *Transactor
+}
+
+// NewCasbinRules constructor for CasbinRules repository layer.
+func NewCasbinRules(db *sqlx.DB) *CasbinRules {
+	return &CasbinRules{db: db, transactor: NewTransactor(db)}
+}
+
+// LoadPolicy loads all policy rules into the model.
+func (r CasbinRules) LoadPolicy(m model.Model) error {
+	ctx := context.Background()
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "CasbinRules",
+		"method":     "LoadPolicy",
+	}
+
+	var rules []models.CasbinRule
+
+	query := "SELECT id, ptype, v0, v1, v2, v3, v4, v5 FROM casbin_rules ORDER BY id"
+
+	if err := conn(ctx, r.db).SelectContext(ctx, &rules, query); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting policy rules query error")
+
+		return errors.Wrap(err, "execution getting policy rules query error")
+	}
+
+	for _, rule := range rules {
+		if err := persist.LoadPolicyArray(rule.Rule(), m); err != nil {
+			return errors.Wrapf(err, "loading policy rule %d error", rule.ID)
+		}
+	}
+
+	return nil
+}
+
+// SavePolicy replaces all policy rules by the rules of the model.
+func (r CasbinRules) SavePolicy(m model.Model) error {
+	ctx := context.Background()
+
+	return r.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		fields := logrus.Fields{
+			"layer":      "repository",
+			"repository": "CasbinRules",
+			"method":     "SavePolicy",
+		}
+
+		if _, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM casbin_rules"); err != nil {
+			logrus.WithError(err).
+				WithFields(fields).
+				Error("execution deleting policy rules query error")
+
+			return errors.Wrap(err, "execution deleting policy rules query error")
+		}
+
+		for _, sec := range []string{"p", "g"} {
+			for ptype, assertion := range m[sec] {
+				for _, rule := range assertion.Policy {
+					if err := r.insert(ctx, ptype, rule); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		return r.bumpRevision(ctx)
+	})
+}
+
+// AddPolicy adds the policy rule.
+func (r CasbinRules) AddPolicy(_ string, ptype string, rule []string) error {
+	ctx := context.Background()
+
+	return r.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := r.insert(ctx, ptype, rule); err != nil {
+			return err
+		}
+
+		return r.bumpRevision(ctx)
+	})
+}
+
+// RemovePolicy removes the policy rule.
+func (r CasbinRules) RemovePolicy(_ string, ptype string, rule []string) error {
+	if len(rule) > casbinRuleValues {
+		return errors.Errorf("policy rule has %d values, at most %d are supported", len(rule), casbinRuleValues)
+	}
+
+	values := padRule(rule)
+
+	query := "DELETE FROM casbin_rules WHERE ptype = $1 AND v0 = $2 AND v1 = $3 AND v2 = $4 AND v3 = $5 AND v4 = $6 AND v5 = $7"
+
+	return r.delete(context.Background(), "RemovePolicy", query, ptype, values[0], values[1], values[2], values[3], values[4], values[5])
+}
+
+// RemoveFilteredPolicy removes the policy rules whose values starting from fieldIndex are the provided ones, empty values match any value.
+func (r CasbinRules) RemoveFilteredPolicy(_ string, ptype string, fieldIndex int, fieldValues ...string) error {
+	ctx := context.Background()
+
+	if fieldIndex < 0 || fieldIndex+len(fieldValues) > casbinRuleValues {
+		return errors.Errorf("policy rule filter out of range: index %d, %d values", fieldIndex, len(fieldValues))
+	}
+
+	conditions := []string{"ptype = $1"}
+	args := []any{ptype}
+	for i, value := range fieldValues {
+		if value == "" {
+			continue
+		}
+
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf("v%d = $%d", fieldIndex+i, len(args)))
+	}
+
+	query := "DELETE FROM casbin_rules WHERE " + strings.Join(conditions, " AND ")
+
+	return r.delete(ctx, "RemoveFilteredPolicy", query, args...)
+}
+
+// delete removes the policy rules selected by the query.
+func (r CasbinRules) delete(ctx context.Context, method, query string, args ...any) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "CasbinRules",
+		"method":     method,
+	}
+
+	return r.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := conn(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+			logrus.WithError(err).
+				WithFields(fields).
+				Error("execution deleting policy rules query error")
+
+			return errors.Wrap(err, "execution deleting policy rules query error")
+		}
+
+		return r.bumpRevision(ctx)
+	})
+}
+
+// IsEmpty reports whether there are no policy rules yet.
+func (r CasbinRules) IsEmpty(ctx context.Context) (bool, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "CasbinRules",
+		"method":     "IsEmpty",
+	}
+
+	var exists bool
+
+	query := "SELECT EXISTS(SELECT 1 FROM casbin_rules)"
+
+	if err := conn(ctx, r.db).GetContext(ctx, &exists, query); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution checking policy rules query error")
+
+		return false, errors.Wrap(err, "execution checking policy rules query error")
+	}
+
+	return !exists, nil
+}
+
+// GetRevision returns the revision of the policy, it changes whenever the policy is changed.
+func (r CasbinRules) GetRevision(ctx context.Context) (int64, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "CasbinRules",
+		"method":     "GetRevision",
+	}
+
+	var revision int64
+
+	query := "SELECT last_value FROM casbin_rules_revision_seq"
+
+	if err := conn(ctx, r.db).GetContext(ctx, &revision, query); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting policy revision query error")
+
+		return 0, errors.Wrap(err, "execution getting policy revision query error")
+	}
+
+	return revision, nil
+}
+
+// insert inserts the policy rule, an already existing rule is kept as it is.
+func (r CasbinRules) insert(ctx context.Context, ptype string, rule []string) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "CasbinRules",
+		"method":     "insert",
+		"ptype":      ptype,
+		"rule":       rule,
+	}
+
+	if len(rule) > casbinRuleValues {
+		return errors.Errorf("policy rule has %d values, at most %d are supported", len(rule), casbinRuleValues)
+	}
+
+	values := padRule(rule)
+
+	query := "INSERT INTO casbin_rules (ptype, v0, v1, v2, v3, v4, v5) VALUES ($1, $2, $3, $4, $5, $6, $7) " +
+		"ON CONFLICT (ptype, v0, v1, v2, v3, v4, v5) DO NOTHING"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, ptype, values[0], values[1], values[2], values[3], values[4], values[5]); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution inserting policy rule query error")
+
+		return errors.Wrap(err, "execution inserting policy rule query error")
+	}
+
+	return nil
+}
+
+// bumpRevision moves the policy revision.
+func (r CasbinRules) bumpRevision(ctx context.Context) error {
+	if _, err := conn(ctx, r.db).ExecContext(ctx, "SELECT nextval('casbin_rules_revision_seq')"); err != nil {
+		logrus.WithError(err).
+			WithFields(logrus.Fields{
+				"layer":      "repository",
+				"repository": "CasbinRules",
+				"method":     "bumpRevision",
+			}).
+			Error("execution moving policy revision query error")
+
+		return errors.Wrap(err, "execution moving policy revision query error")
+	}
+
+	return nil
+}
+
+// padRule returns the rule values padded by empty values to the number of the value columns.
+func padRule(rule []string) []string {
+	values := make([]string, casbinRuleValues)
+	copy(values, rule)
+
+	return values
+}
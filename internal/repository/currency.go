@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/lukinairina90/banking_backend/internal/repository/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Currency repository layer struct.
+type Currency struct {
+	db *sqlx.DB
+}
+
+// NewCurrency constructor for Currency repository layer.
+func NewCurrency(db *sqlx.DB) *Currency {
+	return &Currency{db: db}
+}
+
+// GetByID returns the currency as provided currency ID.
+func (r Currency) GetByID(ctx context.Context, id int) (domain.Currency, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Currency",
+		"method":     "GetByID",
+		"id":         id,
+	}
+
+	var currency models.Currency
+
+	query := "SELECT id, name, code, precision FROM currency WHERE id = $1"
+
+	if err := conn(ctx, r.db).GetContext(ctx, &currency, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Currency{}, domain.ErrCurrencyNotFound
+		}
+
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting currency by ID query error")
+
+		return domain.Currency{}, errors.Wrap(err, "execution getting currency by ID query error")
+	}
+
+	return currency.ToDomain(), nil
+}
+
+// GetByCode returns the currency as provided currency code.
+func (r Currency) GetByCode(ctx context.Context, code string) (domain.Currency, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Currency",
+		"method":     "GetByCode",
+		"code":       code,
+	}
+
+	var currency models.Currency
+
+	query := "SELECT id, name, code, precision FROM currency WHERE code = $1"
+
+	if err := conn(ctx, r.db).GetContext(ctx, &currency, query, code); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Currency{}, domain.ErrCurrencyNotFound
+		}
+
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting currency by code query error")
+
+		return domain.Currency{}, errors.Wrap(err, "execution getting currency by code query error")
+	}
+
+	return currency.ToDomain(), nil
+}
@@ -23,6 +23,7 @@ func NewEvent(db *sqlx.DB) *Event {
 }
 
 // CreateEvent creates a event in the database by provides event.
+// A webhook delivery is queued in the same statement for every subscription matching the user and the type of the event.
 func (e Event) CreateEvent(ctx context.Context, event domain.Event) error {
 	fields := logrus.Fields{
 		"layer":      "repository",
@@ -38,9 +39,12 @@ func (e Event) CreateEvent(ctx context.Context, event domain.Event) error {
 		Metadata: event.Metadata,
 	}
 
-	query := "INSERT INTO event (user_id, type, metadata, time) VALUES ($1, $2, $3, NOW())"
+	query := "WITH e AS (INSERT INTO event (user_id, type, metadata, time) VALUES ($1, $2, $3, NOW()) RETURNING id, user_id, type) " +
+		"INSERT INTO webhook_deliveries (subscription_id, event_id, status) " +
+		"SELECT s.id, e.id, $4 FROM e INNER JOIN webhook_subscriptions s ON (s.user_id = e.user_id OR s.user_id IS NULL) " +
+		"AND (cardinality(s.event_types) = 0 OR e.type = ANY(s.event_types))"
 
-	if _, err := e.db.ExecContext(ctx, query, mEvent.UserID, mEvent.Type, mEvent.Metadata); err != nil {
+	if _, err := conn(ctx, e.db).ExecContext(ctx, query, mEvent.UserID, mEvent.Type, mEvent.Metadata, domain.WebhookDeliveryPending.String()); err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
 			Error("execution event insertion query error")
@@ -53,16 +57,24 @@ func (e Event) CreateEvent(ctx context.Context, event domain.Event) error {
 
 // GetEventsList provides event list.
 func (e Event) GetEventsList(ctx context.Context, userID int) ([]domain.Event, error) {
+	return e.list(ctx, "GetEventsList", "SELECT * FROM event WHERE user_id = $1 ORDER BY time DESC", userID)
+}
+
+// GetRecentEvents provides the latest events of the user, at most limit of them.
+func (e Event) GetRecentEvents(ctx context.Context, userID, limit int) ([]domain.Event, error) {
+	return e.list(ctx, "GetRecentEvents", "SELECT * FROM event WHERE user_id = $1 ORDER BY time DESC LIMIT $2", userID, limit)
+}
+
+// list returns the events selected by the query.
+func (e Event) list(ctx context.Context, method, query string, userID int, args ...any) ([]domain.Event, error) {
 	fields := logrus.Fields{
 		"layer":      "repository",
 		"repository": "Event",
-		"method":     "GetEventsList",
+		"method":     method,
 		"user_id":    userID,
 	}
 
-	query := "SELECT * FROM event WHERE user_id = $1 ORDER BY time DESC"
-
-	rows, err := e.db.QueryxContext(ctx, query, userID)
+	rows, err := conn(ctx, e.db).QueryxContext(ctx, query, append([]any{userID}, args...)...)
 	if err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
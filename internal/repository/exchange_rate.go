@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math/big"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// ExchangeRates database backed exchange rate provider.
+type ExchangeRates struct {
+	db *sqlx.DB
+}
+
+// NewExchangeRates constructor for ExchangeRates repository layer.
+func NewExchangeRates(db *sqlx.DB) *ExchangeRates {
+	return &ExchangeRates{db: db}
+}
+
+// GetRate returns the mid-market rate of the quote currency for one unit of the base currency.
+func (r ExchangeRates) GetRate(ctx context.Context, base, quote string) (*big.Rat, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "ExchangeRates",
+		"method":     "GetRate",
+		"base":       base,
+		"quote":      quote,
+	}
+
+	var rate string
+
+	query := "SELECT r.rate FROM exchange_rates r " +
+		"INNER JOIN currency b ON b.id = r.base_currency_id " +
+		"INNER JOIN currency q ON q.id = r.quote_currency_id " +
+		"WHERE b.code = $1 AND q.code = $2"
+
+	if err := conn(ctx, r.db).GetContext(ctx, &rate, query, base, quote); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrExchangeRateNotFound
+		}
+
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting exchange rate query error")
+
+		return nil, errors.Wrap(err, "execution getting exchange rate query error")
+	}
+
+	parsed, err := domain.ParseRate(rate)
+	if err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("parsing exchange rate error")
+
+		return nil, errors.Wrap(err, "parsing exchange rate error")
+	}
+
+	return parsed, nil
+}
+
+// FileExchangeRates exchange rate provider reading rates from a JSON file, intended for local development.
+type FileExchangeRates struct {
+	rates map[string]map[string]*big.Rat
+}
+
+// fileExchangeRate single rate record of the rates file.
+type fileExchangeRate struct {
+	Base  string `json:"base"`
+	Quote string `json:"quote"`
+	Rate  string `json:"rate"`
+}
+
+// NewFileExchangeRates constructor for FileExchangeRates, loads rates from the JSON file at the provided path.
+// The file contains an array of {"base": "USD", "quote": "UAH", "rate": "36.9"} records.
+func NewFileExchangeRates(path string) (*FileExchangeRates, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading exchange rates file error")
+	}
+
+	var records []fileExchangeRate
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, errors.Wrap(err, "decoding exchange rates file error")
+	}
+
+	rates := make(map[string]map[string]*big.Rat)
+	for _, record := range records {
+		rate, err := domain.ParseRate(record.Rate)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing %s/%s exchange rate error", record.Base, record.Quote)
+		}
+
+		if rates[record.Base] == nil {
+			rates[record.Base] = make(map[string]*big.Rat)
+		}
+
+		rates[record.Base][record.Quote] = rate
+	}
+
+	return &FileExchangeRates{rates: rates}, nil
+}
+
+// GetRate returns the mid-market rate of the quote currency for one unit of the base currency.
+func (r FileExchangeRates) GetRate(_ context.Context, base, quote string) (*big.Rat, error) {
+	rate, ok := r.rates[base][quote]
+	if !ok {
+		return nil, domain.ErrExchangeRateNotFound
+	}
+
+	return new(big.Rat).Set(rate), nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/lukinairina90/banking_backend/internal/repository/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// fxQuoteColumns selects quote columns together with the codes and precisions of the quote currencies.
+const fxQuoteColumns = "q.id, q.user_id, q.rate, q.source_amount, q.target_amount, q.expires_at, " +
+	"fc.code AS from_currency_code, fc.precision AS from_currency_precision, " +
+	"tc.code AS to_currency_code, tc.precision AS to_currency_precision"
+
+// fxQuoteCurrencyJoins joins quote currencies to the fx_quotes table aliased as q.
+const fxQuoteCurrencyJoins = "INNER JOIN currency fc ON fc.id = q.from_currency_id INNER JOIN currency tc ON tc.id = q.to_currency_id"
+
+// FXQuotes repository layer struct.
+type FXQuotes struct {
+	db *sqlx.DB
+}
+
+// NewFXQuotes constructor for FXQuotes repository layer.
+func NewFXQuotes(db *sqlx.DB) *FXQuotes {
+	return &FXQuotes{db: db}
+}
+
+// CreateQuote stores the exchange quote locked for the user.
+func (r FXQuotes) CreateQuote(ctx context.Context, quote domain.FXQuote) (domain.FXQuote, error) {
+	fields := logrus.Fields{
+		"layer":         "repository",
+		"repository":    "FXQuotes",
+		"method":        "CreateQuote",
+		"user_id":       quote.UserID,
+		"source_amount": quote.SourceAmount.String(),
+		"target_amount": quote.TargetAmount.String(),
+	}
+
+	var created models.FXQuote
+
+	query := "WITH q AS (INSERT INTO fx_quotes (user_id, from_currency_id, to_currency_id, rate, source_amount, target_amount, date_created, expires_at) " +
+		"VALUES ($1, (SELECT id FROM currency WHERE code = $2), (SELECT id FROM currency WHERE code = $3), $4, $5, $6, NOW(), $7) RETURNING *) " +
+		"SELECT " + fxQuoteColumns + " FROM q " + fxQuoteCurrencyJoins
+
+	if err := conn(ctx, r.db).GetContext(ctx, &created, query, quote.UserID, quote.SourceAmount.Currency, quote.TargetAmount.Currency,
+		domain.FormatRate(quote.Rate), quote.SourceAmount.String(), quote.TargetAmount.String(), quote.ExpiresAt); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution inserting into fx quotes query error")
+
+		return domain.FXQuote{}, errors.Wrap(err, "execution inserting into fx quotes query error")
+	}
+
+	domainQuote, err := created.ToDomain()
+	if err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("converting fx quote error")
+
+		return domain.FXQuote{}, errors.Wrap(err, "converting fx quote error")
+	}
+
+	return domainQuote, nil
+}
+
+// UseQuote marks the user's quote as used and returns it, if the quote is neither expired nor used yet.
+func (r FXQuotes) UseQuote(ctx context.Context, quoteID, userID int) (domain.FXQuote, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "FXQuotes",
+		"method":     "UseQuote",
+		"quote_id":   quoteID,
+		"user_id":    userID,
+	}
+
+	var quote models.FXQuote
+
+	query := "WITH q AS (UPDATE fx_quotes SET used = TRUE WHERE id = $1 AND user_id = $2 AND NOT used AND expires_at > NOW() RETURNING *) " +
+		"SELECT " + fxQuoteColumns + " FROM q " + fxQuoteCurrencyJoins
+
+	if err := conn(ctx, r.db).GetContext(ctx, &quote, query, quoteID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.FXQuote{}, domain.ErrQuoteNotFound
+		}
+
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution using fx quote query error")
+
+		return domain.FXQuote{}, errors.Wrap(err, "execution using fx quote query error")
+	}
+
+	domainQuote, err := quote.ToDomain()
+	if err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("converting fx quote error")
+
+		return domain.FXQuote{}, errors.Wrap(err, "converting fx quote error")
+	}
+
+	return domainQuote, nil
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/lukinairina90/banking_backend/internal/repository/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Idempotency repository layer struct.
+type Idempotency struct {
+	db *sqlx.DB
+}
+
+// NewIdempotency constructor for Idempotency repository layer.
+func NewIdempotency(db *sqlx.DB) *Idempotency {
+	return &Idempotency{db: db}
+}
+
+// Reserve stores the idempotency key if the user has not used it yet or the previous usage has expired.
+// The key of the same request left not completed after its lock has expired, e.g. by a crashed instance, is reserved again.
+// Returns the stored key and true when the key was reserved, or the already existing key and false otherwise.
+func (r Idempotency) Reserve(ctx context.Context, key domain.IdempotencyKey) (domain.IdempotencyKey, bool, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Idempotency",
+		"method":     "Reserve",
+		"user_id":    key.UserID,
+		"key":        key.Key,
+	}
+
+	var stored models.IdempotencyKey
+
+	query := "INSERT INTO idempotency_keys (key, user_id, fingerprint, date_created, expires_at, locked_until, token) VALUES ($1, $2, $3, NOW(), $4, $5, $6) " +
+		"ON CONFLICT (user_id, key) DO UPDATE SET fingerprint = EXCLUDED.fingerprint, status_code = NULL, response_body = NULL, " +
+		"date_created = EXCLUDED.date_created, expires_at = EXCLUDED.expires_at, locked_until = EXCLUDED.locked_until, token = EXCLUDED.token " +
+		"WHERE idempotency_keys.expires_at < NOW() OR (idempotency_keys.status_code IS NULL AND " +
+		"idempotency_keys.locked_until < NOW() AND idempotency_keys.fingerprint = EXCLUDED.fingerprint) " +
+		"RETURNING *"
+
+	err := conn(ctx, r.db).GetContext(ctx, &stored, query, key.Key, key.UserID, key.Fingerprint, key.ExpiresAt, key.LockedUntil, key.Token)
+	if err == nil {
+		return stored.ToDomain(), true, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution reserving idempotency key query error")
+
+		return domain.IdempotencyKey{}, false, errors.Wrap(err, "execution reserving idempotency key query error")
+	}
+
+	query = "SELECT * FROM idempotency_keys WHERE user_id = $1 AND key = $2"
+
+	if err := conn(ctx, r.db).GetContext(ctx, &stored, query, key.UserID, key.Key); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting idempotency key query error")
+
+		return domain.IdempotencyKey{}, false, errors.Wrap(err, "execution getting idempotency key query error")
+	}
+
+	return stored.ToDomain(), false, nil
+}
+
+// Complete stores the response of the request made with the idempotency key reserved with the token.
+// Returns domain.ErrIdempotencyReservationLost when the key was taken over by a retry of the request.
+func (r Idempotency) Complete(ctx context.Context, userID int, key, token string, statusCode int, responseBody []byte) error {
+	fields := logrus.Fields{
+		"layer":       "repository",
+		"repository":  "Idempotency",
+		"method":      "Complete",
+		"user_id":     userID,
+		"key":         key,
+		"status_code": statusCode,
+	}
+
+	query := "UPDATE idempotency_keys SET status_code = $1, response_body = $2 WHERE user_id = $3 AND key = $4 AND token = $5 AND status_code IS NULL"
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, statusCode, responseBody, userID, key, token)
+	if err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution completing idempotency key query error")
+
+		return errors.Wrap(err, "execution completing idempotency key query error")
+	}
+
+	return checkReservationAffected(res, fields)
+}
+
+// Release removes the idempotency key reserved with the token, so the request can be retried with it.
+// Returns domain.ErrIdempotencyReservationLost when the key was taken over by a retry of the request.
+func (r Idempotency) Release(ctx context.Context, userID int, key, token string) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Idempotency",
+		"method":     "Release",
+		"user_id":    userID,
+		"key":        key,
+	}
+
+	query := "DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND token = $3 AND status_code IS NULL"
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, userID, key, token)
+	if err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution releasing idempotency key query error")
+
+		return errors.Wrap(err, "execution releasing idempotency key query error")
+	}
+
+	return checkReservationAffected(res, fields)
+}
+
+// checkReservationAffected returns domain.ErrIdempotencyReservationLost when the query did not find the reservation.
+func checkReservationAffected(res sql.Result, fields logrus.Fields) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("getting affected idempotency keys error")
+
+		return errors.Wrap(err, "getting affected idempotency keys error")
+	}
+
+	if affected == 0 {
+		return domain.ErrIdempotencyReservationLost
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Ledger repository layer struct.
+type Ledger struct {
+	db *sqlx.DB
+}
+
+// NewLedger constructor for Ledger repository layer.
+func NewLedger(db *sqlx.DB) *Ledger {
+	return &Ledger{db: db}
+}
+
+// CreateJournalEntry writes the journal entry with all its postings.
+// Unbalanced entries are rejected here and by the deferred database constraint when the transaction commits.
+func (r Ledger) CreateJournalEntry(ctx context.Context, entry domain.JournalEntry) (domain.JournalEntry, error) {
+	fields := logrus.Fields{
+		"layer":          "repository",
+		"repository":     "Ledger",
+		"method":         "CreateJournalEntry",
+		"transaction_id": entry.TransactionID,
+		"description":    entry.Description,
+	}
+
+	if err := entry.Validate(); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("validating journal entry error")
+
+		return domain.JournalEntry{}, errors.Wrap(err, "validating journal entry error")
+	}
+
+	nullableTransactionID := sql.NullInt64{}
+	if entry.TransactionID != 0 {
+		nullableTransactionID.Int64 = int64(entry.TransactionID)
+		nullableTransactionID.Valid = true
+	}
+
+	query := "INSERT INTO journal_entries (transaction_id, description, date_created) VALUES ($1, $2, NOW()) RETURNING id, date_created"
+
+	if err := conn(ctx, r.db).QueryRowxContext(ctx, query, nullableTransactionID, entry.Description).Scan(&entry.ID, &entry.DateCreated); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution inserting into journal entries query error")
+
+		return domain.JournalEntry{}, errors.Wrap(err, "execution inserting into journal entries query error")
+	}
+
+	postingQuery := "INSERT INTO postings (journal_entry_id, account_id, system_account_id, currency_id, direction, amount) " +
+		"SELECT $1, $2, (SELECT s.id FROM system_accounts s WHERE s.code = $3 AND s.currency_id = c.id), c.id, $4, $5::numeric " +
+		"FROM currency c WHERE c.code = $6"
+
+	for _, posting := range entry.Postings {
+		nullableAccountID := sql.NullInt64{}
+		if posting.AccountID != 0 {
+			nullableAccountID.Int64 = int64(posting.AccountID)
+			nullableAccountID.Valid = true
+		}
+
+		res, err := conn(ctx, r.db).ExecContext(ctx, postingQuery, entry.ID, nullableAccountID, string(posting.SystemAccount),
+			string(posting.Direction), posting.Amount.String(), posting.Amount.Currency)
+		if err != nil {
+			logrus.WithError(err).
+				WithFields(fields).
+				Error("execution inserting into postings query error")
+
+			return domain.JournalEntry{}, errors.Wrap(err, "execution inserting into postings query error")
+		}
+
+		if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+			logrus.WithError(err).
+				WithFields(fields).
+				Error("posting currency does not exist error")
+
+			return domain.JournalEntry{}, errors.New("posting currency does not exist error")
+		}
+	}
+
+	return entry, nil
+}
+
+// HasJournalEntry reports whether any journal entry was written for the provided transaction ID.
+func (r Ledger) HasJournalEntry(ctx context.Context, transactionID int) (bool, error) {
+	fields := logrus.Fields{
+		"layer":          "repository",
+		"repository":     "Ledger",
+		"method":         "HasJournalEntry",
+		"transaction_id": transactionID,
+	}
+
+	var exists bool
+
+	query := "SELECT EXISTS(SELECT 1 FROM journal_entries WHERE transaction_id = $1)"
+
+	if err := conn(ctx, r.db).GetContext(ctx, &exists, query, transactionID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution checking journal entry existence query error")
+
+		return false, errors.Wrap(err, "execution checking journal entry existence query error")
+	}
+
+	return exists, nil
+}
@@ -2,24 +2,40 @@ package models
 
 import "github.com/lukinairina90/banking_backend/internal/domain"
 
-// Account object representation of the database table accounts
+// Account object representation of the database table accounts joined with the account currency
 type Account struct {
-	ID         int     `db:"id"`
-	Iban       string  `db:"iban"`
-	UserID     int     `db:"user_id"`
-	CurrencyID int     `db:"currency_id"`
-	Blocked    bool    `db:"blocked"`
-	Amount     float64 `db:"amount"`
+	ID                int    `db:"id"`
+	Iban              string `db:"iban"`
+	UserID            int    `db:"user_id"`
+	CurrencyID        int    `db:"currency_id"`
+	Blocked           bool   `db:"blocked"`
+	Amount            string `db:"amount"`
+	CurrencyCode      string `db:"currency_code"`
+	CurrencyPrecision int    `db:"currency_precision"`
 }
 
 // ToDomain converts Account to domain.Account
-func (a Account) ToDomain() domain.Account {
+func (a Account) ToDomain() (domain.Account, error) {
+	amount, err := domain.ParseMoney(a.Amount, a.currency())
+	if err != nil {
+		return domain.Account{}, err
+	}
+
 	return domain.Account{
 		ID:         a.ID,
 		Iban:       a.Iban,
 		UserID:     a.UserID,
 		CurrencyID: a.CurrencyID,
 		Blocked:    a.Blocked,
-		Amount:     a.Amount,
+		Amount:     amount,
+	}, nil
+}
+
+// currency returns the account currency.
+func (a Account) currency() domain.Currency {
+	return domain.Currency{
+		ID:        a.CurrencyID,
+		Code:      a.CurrencyCode,
+		Precision: a.CurrencyPrecision,
 	}
 }
@@ -0,0 +1,23 @@
+package models
+
+// CasbinRule object representation of the database table casbin_rules
+type CasbinRule struct {
+	ID    int    `db:"id"`
+	PType string `db:"ptype"`
+	V0    string `db:"v0"`
+	V1    string `db:"v1"`
+	V2    string `db:"v2"`
+	V3    string `db:"v3"`
+	V4    string `db:"v4"`
+	V5    string `db:"v5"`
+}
+
+// Rule returns the policy line of the rule without the trailing empty values.
+func (r CasbinRule) Rule() []string {
+	rule := []string{r.PType, r.V0, r.V1, r.V2, r.V3, r.V4, r.V5}
+	for len(rule) > 1 && rule[len(rule)-1] == "" {
+		rule = rule[:len(rule)-1]
+	}
+
+	return rule
+}
@@ -0,0 +1,21 @@
+package models
+
+import "github.com/lukinairina90/banking_backend/internal/domain"
+
+// Currency object representation of the database table currency
+type Currency struct {
+	ID        int    `db:"id"`
+	Name      string `db:"name"`
+	Code      string `db:"code"`
+	Precision int    `db:"precision"`
+}
+
+// ToDomain converts Currency to domain.Currency
+func (c Currency) ToDomain() domain.Currency {
+	return domain.Currency{
+		ID:        c.ID,
+		Name:      c.Name,
+		Code:      c.Code,
+		Precision: c.Precision,
+	}
+}
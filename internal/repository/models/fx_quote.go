@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+)
+
+// FXQuote object representation of the database table fx_quotes joined with the quote currencies
+type FXQuote struct {
+	ID                    int       `db:"id"`
+	UserID                int       `db:"user_id"`
+	Rate                  string    `db:"rate"`
+	SourceAmount          string    `db:"source_amount"`
+	TargetAmount          string    `db:"target_amount"`
+	ExpiresAt             time.Time `db:"expires_at"`
+	FromCurrencyCode      string    `db:"from_currency_code"`
+	FromCurrencyPrecision int       `db:"from_currency_precision"`
+	ToCurrencyCode        string    `db:"to_currency_code"`
+	ToCurrencyPrecision   int       `db:"to_currency_precision"`
+}
+
+// ToDomain converts FXQuote to domain.FXQuote
+func (q FXQuote) ToDomain() (domain.FXQuote, error) {
+	rate, err := domain.ParseRate(q.Rate)
+	if err != nil {
+		return domain.FXQuote{}, err
+	}
+
+	sourceAmount, err := domain.ParseMoney(q.SourceAmount, domain.Currency{Code: q.FromCurrencyCode, Precision: q.FromCurrencyPrecision})
+	if err != nil {
+		return domain.FXQuote{}, err
+	}
+
+	targetAmount, err := domain.ParseMoney(q.TargetAmount, domain.Currency{Code: q.ToCurrencyCode, Precision: q.ToCurrencyPrecision})
+	if err != nil {
+		return domain.FXQuote{}, err
+	}
+
+	return domain.FXQuote{
+		ID:           q.ID,
+		UserID:       q.UserID,
+		Rate:         rate,
+		SourceAmount: sourceAmount,
+		TargetAmount: targetAmount,
+		ExpiresAt:    q.ExpiresAt,
+	}, nil
+}
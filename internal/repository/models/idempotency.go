@@ -0,0 +1,41 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+)
+
+// IdempotencyKey object representation of the database table idempotency_keys
+type IdempotencyKey struct {
+	Key          string        `db:"key"`
+	UserID       int           `db:"user_id"`
+	Fingerprint  string        `db:"fingerprint"`
+	StatusCode   sql.NullInt64 `db:"status_code"`
+	ResponseBody []byte        `db:"response_body"`
+	DateCreated  time.Time     `db:"date_created"`
+	ExpiresAt    time.Time     `db:"expires_at"`
+	LockedUntil  time.Time     `db:"locked_until"`
+	Token        string        `db:"token"`
+}
+
+// ToDomain converts IdempotencyKey to domain.IdempotencyKey
+func (k IdempotencyKey) ToDomain() domain.IdempotencyKey {
+	var statusCode int
+	if k.StatusCode.Valid {
+		statusCode = int(k.StatusCode.Int64)
+	}
+
+	return domain.IdempotencyKey{
+		Key:          k.Key,
+		UserID:       k.UserID,
+		Fingerprint:  k.Fingerprint,
+		StatusCode:   statusCode,
+		ResponseBody: k.ResponseBody,
+		DateCreated:  k.DateCreated,
+		ExpiresAt:    k.ExpiresAt,
+		LockedUntil:  k.LockedUntil,
+		Token:        k.Token,
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+)
+
+// OneTimeToken object representation of the database table one_time_tokens
+type OneTimeToken struct {
+	ID        int       `db:"id"`
+	UserID    int       `db:"user_id"`
+	Purpose   string    `db:"purpose"`
+	TokenHash string    `db:"token_hash"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// ToDomain converts OneTimeToken to domain.OneTimeToken
+func (t OneTimeToken) ToDomain() domain.OneTimeToken {
+	return domain.OneTimeToken{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		Purpose:   domain.OneTimeTokenPurpose(t.Purpose),
+		TokenHash: t.TokenHash,
+		ExpiresAt: t.ExpiresAt,
+	}
+}
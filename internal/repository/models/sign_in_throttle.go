@@ -0,0 +1,28 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+)
+
+// SignInThrottle object representation of the database table sign_in_throttles
+type SignInThrottle struct {
+	Scope         string       `db:"scope"`
+	Subject       string       `db:"subject"`
+	Failures      int          `db:"failures"`
+	LastFailureAt time.Time    `db:"last_failure_at"`
+	LockedUntil   sql.NullTime `db:"locked_until"`
+}
+
+// ToDomain converts SignInThrottle to domain.SignInThrottle
+func (t SignInThrottle) ToDomain() domain.SignInThrottle {
+	return domain.SignInThrottle{
+		Scope:         domain.SignInThrottleScope(t.Scope),
+		Subject:       t.Subject,
+		Failures:      t.Failures,
+		LastFailureAt: t.LastFailureAt,
+		LockedUntil:   t.LockedUntil.Time,
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"database/sql"
 	"time"
 
 	"github.com/lukinairina90/banking_backend/internal/domain"
@@ -8,10 +9,16 @@ import (
 
 // RefreshSession object representation of the database table refresh_tokens
 type RefreshSession struct {
-	ID        int       `db:"id"`
-	UserID    int       `db:"user_id"`
-	Token     string    `db:"token"`
-	ExpiresAt time.Time `db:"expires_at"`
+	ID        int          `db:"id"`
+	UserID    int          `db:"user_id"`
+	FamilyID  string       `db:"family_id"`
+	TokenHash string       `db:"token_hash"`
+	UserAgent string       `db:"user_agent"`
+	IP        string       `db:"ip"`
+	CreatedAt time.Time    `db:"created_at"`
+	ExpiresAt time.Time    `db:"expires_at"`
+	RotatedAt sql.NullTime `db:"rotated_at"`
+	RevokedAt sql.NullTime `db:"revoked_at"`
 }
 
 // ToDomain converts RefreshSession to domain.RefreshSession
@@ -19,7 +26,35 @@ func (s RefreshSession) ToDomain() domain.RefreshSession {
 	return domain.RefreshSession{
 		ID:        s.ID,
 		UserID:    s.UserID,
-		Token:     s.Token,
+		FamilyID:  s.FamilyID,
+		TokenHash: s.TokenHash,
+		UserAgent: s.UserAgent,
+		IP:        s.IP,
+		CreatedAt: s.CreatedAt,
 		ExpiresAt: s.ExpiresAt,
+		Rotated:   s.RotatedAt.Valid,
+		Revoked:   s.RevokedAt.Valid,
+	}
+}
+
+// Session object representation of the active refresh token of a session family
+type Session struct {
+	FamilyID   string    `db:"family_id"`
+	UserAgent  string    `db:"user_agent"`
+	IP         string    `db:"ip"`
+	CreatedAt  time.Time `db:"created_at"`
+	LastUsedAt time.Time `db:"last_used_at"`
+	ExpiresAt  time.Time `db:"expires_at"`
+}
+
+// ToDomain converts Session to domain.Session
+func (s Session) ToDomain() domain.Session {
+	return domain.Session{
+		FamilyID:   s.FamilyID,
+		UserAgent:  s.UserAgent,
+		IP:         s.IP,
+		CreatedAt:  s.CreatedAt,
+		LastUsedAt: s.LastUsedAt,
+		ExpiresAt:  s.ExpiresAt,
 	}
 }
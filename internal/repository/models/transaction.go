@@ -2,24 +2,50 @@ package models
 
 import (
 	"database/sql"
+	"math/big"
 	"time"
 
 	"github.com/lukinairina90/banking_backend/internal/domain"
 )
 
-// Transaction object representation of the database table transaction
+// Transaction object representation of the database table transaction joined with the transaction currencies
 type Transaction struct {
-	ID          int           `db:"id"`
-	FromAccount sql.NullInt64 `db:"from_account"`
-	ToAccount   int           `db:"to_account"`
-	Amount      float64       `db:"amount"`
-	Status      string        `db:"status"`
-	DateCreated time.Time     `db:"date_created"`
-	DateUpdated sql.NullTime  `db:"date_updated"`
+	ID                  int            `db:"id"`
+	FromAccount         sql.NullInt64  `db:"from_account"`
+	ToAccount           sql.NullInt64  `db:"to_account"`
+	Amount              string         `db:"amount"`
+	Status              string         `db:"status"`
+	DateCreated         time.Time      `db:"date_created"`
+	DateUpdated         sql.NullTime   `db:"date_updated"`
+	CurrencyCode        string         `db:"currency_code"`
+	CurrencyPrecision   int            `db:"currency_precision"`
+	ToAmount            string         `db:"to_amount"`
+	ToCurrencyCode      string         `db:"to_currency_code"`
+	ToCurrencyPrecision int            `db:"to_currency_precision"`
+	ExchangeRate        sql.NullString `db:"exchange_rate"`
+	FailureReason       sql.NullString `db:"failure_reason"`
+	ReversalOf          sql.NullInt64  `db:"reversal_of"`
 }
 
 // ToDomain converts Transaction to domain.Transaction
-func (t Transaction) ToDomain() domain.Transaction {
+func (t Transaction) ToDomain() (domain.Transaction, error) {
+	amount, err := domain.ParseMoney(t.Amount, domain.Currency{Code: t.CurrencyCode, Precision: t.CurrencyPrecision})
+	if err != nil {
+		return domain.Transaction{}, err
+	}
+
+	toAmount, err := domain.ParseMoney(t.ToAmount, domain.Currency{Code: t.ToCurrencyCode, Precision: t.ToCurrencyPrecision})
+	if err != nil {
+		return domain.Transaction{}, err
+	}
+
+	var exchangeRate *big.Rat
+	if t.ExchangeRate.Valid {
+		if exchangeRate, err = domain.ParseRate(t.ExchangeRate.String); err != nil {
+			return domain.Transaction{}, err
+		}
+	}
+
 	var fromAccountID int
 	// if false, leave the default fromAccountID and do not enter to if
 	// if true means the value in the database is not null and enter if
@@ -28,6 +54,16 @@ func (t Transaction) ToDomain() domain.Transaction {
 		fromAccountID = int(t.FromAccount.Int64)
 	}
 
+	var toAccountID int
+	if t.ToAccount.Valid {
+		toAccountID = int(t.ToAccount.Int64)
+	}
+
+	var reversalOf int
+	if t.ReversalOf.Valid {
+		reversalOf = int(t.ReversalOf.Int64)
+	}
+
 	var dateUpdated time.Time
 	// if false, leave the default dateUpdated and do not enter to if
 	// if true means the value in the database is not null and enter if
@@ -37,12 +73,16 @@ func (t Transaction) ToDomain() domain.Transaction {
 	}
 
 	return domain.Transaction{
-		ID:          t.ID,
-		FromAccount: fromAccountID,
-		ToAccount:   t.ToAccount,
-		Amount:      t.Amount,
-		Status:      t.Status,
-		DateCreated: t.DateCreated,
-		DateUpdated: dateUpdated,
-	}
+		ID:            t.ID,
+		FromAccount:   fromAccountID,
+		ToAccount:     toAccountID,
+		Amount:        amount,
+		ToAmount:      toAmount,
+		ExchangeRate:  exchangeRate,
+		Status:        domain.TransactionStatus(t.Status),
+		FailureReason: t.FailureReason.String,
+		ReversalOf:    reversalOf,
+		DateCreated:   t.DateCreated,
+		DateUpdated:   dateUpdated,
+	}, nil
 }
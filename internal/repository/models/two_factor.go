@@ -0,0 +1,50 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+)
+
+// TwoFactor object representation of the TOTP columns of the database table users
+type TwoFactor struct {
+	UserID      int            `db:"id"`
+	Secret      sql.NullString `db:"totp_secret"`
+	Enabled     bool           `db:"totp_enabled"`
+	LastStep    int64          `db:"totp_last_step"`
+	Failures    int            `db:"totp_failures"`
+	LockedUntil sql.NullTime   `db:"totp_locked_until"`
+}
+
+// ToDomain converts TwoFactor to domain.TwoFactor
+func (t TwoFactor) ToDomain() domain.TwoFactor {
+	return domain.TwoFactor{
+		UserID:      t.UserID,
+		Secret:      t.Secret.String,
+		Enabled:     t.Enabled,
+		LastStep:    t.LastStep,
+		Failures:    t.Failures,
+		LockedUntil: t.LockedUntil.Time,
+	}
+}
+
+// TwoFactorChallenge object representation of the database table two_factor_challenges
+type TwoFactorChallenge struct {
+	ID        int       `db:"id"`
+	UserID    int       `db:"user_id"`
+	TokenHash string    `db:"token_hash"`
+	Attempts  int       `db:"attempts"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// ToDomain converts TwoFactorChallenge to domain.TwoFactorChallenge
+func (c TwoFactorChallenge) ToDomain() domain.TwoFactorChallenge {
+	return domain.TwoFactorChallenge{
+		ID:        c.ID,
+		UserID:    c.UserID,
+		TokenHash: c.TokenHash,
+		Attempts:  c.Attempts,
+		ExpiresAt: c.ExpiresAt,
+	}
+}
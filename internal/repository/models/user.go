@@ -3,19 +3,24 @@ package models
 import (
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/lukinairina90/banking_backend/internal/domain"
 )
 
 // User object representation of the database table users
 type User struct {
-	ID           int       `db:"id"`
-	Name         string    `db:"name"`
-	Surname      string    `db:"surname"`
-	Email        string    `db:"email"`
-	Password     string    `db:"password"`
-	RoleId       int       `db:"role_id"`
-	Blocked      bool      `db:"blocked"`
-	RegisteredAt time.Time `db:"registered_at"`
+	ID           int            `db:"id"`
+	Name         string         `db:"name"`
+	Surname      string         `db:"surname"`
+	Email        string         `db:"email"`
+	Password     string         `db:"password"`
+	Roles        pq.StringArray `db:"roles"`
+	Blocked      bool           `db:"blocked"`
+	RegisteredAt time.Time      `db:"registered_at"`
+	TokenVersion int            `db:"token_version"`
+
+	TwoFactorEnabled bool `db:"totp_enabled"`
+	EmailVerified    bool `db:"email_verified"`
 }
 
 // ToDomain converts User to domain.User
@@ -26,9 +31,13 @@ func (u User) ToDomain() domain.User {
 		Surname:      u.Surname,
 		Email:        u.Email,
 		Password:     u.Password,
-		RoleId:       u.RoleId,
+		Roles:        u.Roles,
 		Blocked:      u.Blocked,
 		RegisteredAt: u.RegisteredAt,
+		TokenVersion: u.TokenVersion,
+
+		TwoFactorEnabled: u.TwoFactorEnabled,
+		EmailVerified:    u.EmailVerified,
 	}
 }
 
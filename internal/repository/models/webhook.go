@@ -0,0 +1,136 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/pkg/errors"
+)
+
+// WebhookSubscription object representation of the database table webhook_subscriptions
+type WebhookSubscription struct {
+	ID         int            `db:"id"`
+	UserID     sql.NullInt64  `db:"user_id"`
+	URL        string         `db:"url"`
+	EventTypes pq.StringArray `db:"event_types"`
+	Secret     string         `db:"secret"`
+	CreatedAt  time.Time      `db:"created_at"`
+}
+
+// ToDomain converts WebhookSubscription to domain.WebhookSubscription
+func (s WebhookSubscription) ToDomain() (domain.WebhookSubscription, error) {
+	subscription := domain.WebhookSubscription{
+		ID:        s.ID,
+		UserID:    int(s.UserID.Int64),
+		URL:       s.URL,
+		Secret:    s.Secret,
+		CreatedAt: s.CreatedAt,
+	}
+
+	for _, et := range s.EventTypes {
+		eventType, err := domain.NewEventTypeFromString(et)
+		if err != nil {
+			return domain.WebhookSubscription{}, errors.Wrap(err, "converting event type error")
+		}
+
+		subscription.EventTypes = append(subscription.EventTypes, eventType)
+	}
+
+	return subscription, nil
+}
+
+// WebhookDelivery object representation of the database table webhook_deliveries with the type of the event
+type WebhookDelivery struct {
+	ID             int       `db:"id"`
+	SubscriptionID int       `db:"subscription_id"`
+	EventID        int       `db:"event_id"`
+	EventType      string    `db:"event_type"`
+	Status         string    `db:"status"`
+	Attempts       int       `db:"attempts"`
+	NextAttemptAt  time.Time `db:"next_attempt_at"`
+	LastError      string    `db:"last_error"`
+	CreatedAt      time.Time `db:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+// ToDomain converts WebhookDelivery to domain.WebhookDelivery
+func (d WebhookDelivery) ToDomain() (domain.WebhookDelivery, error) {
+	eventType, err := domain.NewEventTypeFromString(d.EventType)
+	if err != nil {
+		return domain.WebhookDelivery{}, errors.Wrap(err, "converting event type error")
+	}
+
+	return domain.WebhookDelivery{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      eventType,
+		Status:         domain.WebhookDeliveryStatus(d.Status),
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}, nil
+}
+
+// WebhookDeliveryAttempt object representation of the database table webhook_delivery_attempts
+type WebhookDeliveryAttempt struct {
+	ID          int       `db:"id"`
+	DeliveryID  int       `db:"delivery_id"`
+	StatusCode  int       `db:"status_code"`
+	Error       string    `db:"error"`
+	DurationMs  int64     `db:"duration_ms"`
+	AttemptedAt time.Time `db:"attempted_at"`
+}
+
+// ToDomain converts WebhookDeliveryAttempt to domain.WebhookDeliveryAttempt
+func (a WebhookDeliveryAttempt) ToDomain() domain.WebhookDeliveryAttempt {
+	return domain.WebhookDeliveryAttempt{
+		ID:          a.ID,
+		DeliveryID:  a.DeliveryID,
+		StatusCode:  a.StatusCode,
+		Error:       a.Error,
+		Duration:    time.Duration(a.DurationMs) * time.Millisecond,
+		AttemptedAt: a.AttemptedAt,
+	}
+}
+
+// WebhookDispatch object representation of the due delivery joined with its subscription and event
+type WebhookDispatch struct {
+	WebhookDelivery
+	URL           string        `db:"url"`
+	Secret        string        `db:"secret"`
+	EventUserID   sql.NullInt64 `db:"event_user_id"`
+	EventMetadata []byte        `db:"event_metadata"`
+	EventTime     sql.NullTime  `db:"event_time"`
+}
+
+// ToDomain converts WebhookDispatch to domain.WebhookDispatch
+func (d WebhookDispatch) ToDomain() (domain.WebhookDispatch, error) {
+	delivery, err := d.WebhookDelivery.ToDomain()
+	if err != nil {
+		return domain.WebhookDispatch{}, err
+	}
+
+	var eventMetadata map[string]any
+	if err := json.Unmarshal(d.EventMetadata, &eventMetadata); err != nil {
+		return domain.WebhookDispatch{}, errors.Wrap(err, "unmarshaling event metadata error")
+	}
+
+	return domain.WebhookDispatch{
+		Delivery: delivery,
+		URL:      d.URL,
+		Secret:   d.Secret,
+		Event: domain.Event{
+			ID:       d.EventID,
+			UserID:   int(d.EventUserID.Int64),
+			Type:     delivery.EventType,
+			Metadata: eventMetadata,
+			DateTime: d.EventTime.Time,
+		},
+	}, nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/lukinairina90/banking_backend/internal/repository/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// OneTimeTokens repository layer struct.
+type OneTimeTokens struct {
+	db *sqlx.DB
+}
+
+// NewOneTimeTokens constructor for OneTimeTokens repository layer.
+func NewOneTimeTokens(db *sqlx.DB) *OneTimeTokens {
+	return &OneTimeTokens{db: db}
+}
+
+// Create creates the one-time token. Unused tokens of the user with the same purpose are removed, so only the latest one works,
+// expired and used tokens are removed as well.
+func (r OneTimeTokens) Create(ctx context.Context, token domain.OneTimeToken) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "OneTimeTokens",
+		"method":     "Create",
+		"user_id":    token.UserID,
+		"purpose":    token.Purpose,
+	}
+
+	query := "DELETE FROM one_time_tokens WHERE (user_id = $1 AND purpose = $2) OR used_at IS NOT NULL OR expires_at < NOW()"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, token.UserID, token.Purpose); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution deleting replaced one-time tokens query error")
+
+		return errors.Wrap(err, "execution deleting replaced one-time tokens query error")
+	}
+
+	query = "INSERT INTO one_time_tokens (user_id, purpose, token_hash, expires_at) VALUES ($1, $2, $3, $4)"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution inserting into one_time_tokens query error")
+
+		return errors.Wrap(err, "execution inserting into one_time_tokens query error")
+	}
+
+	return nil
+}
+
+// Lock locks the unused token row until the end of the current database transaction and returns it as provided token hash and purpose.
+func (r OneTimeTokens) Lock(ctx context.Context, tokenHash string, purpose domain.OneTimeTokenPurpose) (domain.OneTimeToken, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "OneTimeTokens",
+		"method":     "Lock",
+		"purpose":    purpose,
+	}
+
+	var token models.OneTimeToken
+
+	query := "SELECT id, user_id, purpose, token_hash, expires_at FROM one_time_tokens WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL FOR UPDATE"
+
+	if err := conn(ctx, r.db).GetContext(ctx, &token, query, tokenHash, purpose); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.OneTimeToken{}, domain.ErrInvalidOneTimeToken
+		}
+
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution locking one-time token query error")
+
+		return domain.OneTimeToken{}, errors.Wrap(err, "execution locking one-time token query error")
+	}
+
+	return token.ToDomain(), nil
+}
+
+// MarkUsed marks the token as used, it can not be used again.
+func (r OneTimeTokens) MarkUsed(ctx context.Context, id int) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "OneTimeTokens",
+		"method":     "MarkUsed",
+		"id":         id,
+	}
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE one_time_tokens SET used_at = NOW() WHERE id = $1", id); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution marking one-time token used query error")
+
+		return errors.Wrap(err, "execution marking one-time token used query error")
+	}
+
+	return nil
+}
+
+// DeleteUnused removes the unused tokens of the user with the purpose, the links sent with them stop working.
+func (r OneTimeTokens) DeleteUnused(ctx context.Context, userID int, purpose domain.OneTimeTokenPurpose) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "OneTimeTokens",
+		"method":     "DeleteUnused",
+		"user_id":    userID,
+		"purpose":    purpose,
+	}
+
+	query := "DELETE FROM one_time_tokens WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID, purpose); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution deleting unused one-time tokens query error")
+
+		return errors.Wrap(err, "execution deleting unused one-time tokens query error")
+	}
+
+	return nil
+}
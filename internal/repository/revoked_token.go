@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// RevokedTokens repository layer struct.
+type RevokedTokens struct {
+	db *sqlx.DB
+}
+
+// NewRevokedTokens constructor for RevokedTokens repository layer.
+func NewRevokedTokens(db *sqlx.DB) *RevokedTokens {
+	return &RevokedTokens{db: db}
+}
+
+// Revoke adds the access token ID to the revocation list until the token expires.
+// Entries of already expired tokens are removed, they are rejected by the expiration check anyway.
+func (r RevokedTokens) Revoke(ctx context.Context, jti string, userID int, expiresAt time.Time) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "RevokedTokens",
+		"method":     "Revoke",
+		"jti":        jti,
+		"user_id":    userID,
+	}
+
+	query := "INSERT INTO revoked_tokens (jti, user_id, expires_at) VALUES ($1, $2, $3) ON CONFLICT (jti) DO NOTHING"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, jti, userID, expiresAt); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution inserting into revoked_tokens query error")
+
+		return errors.Wrap(err, "execution inserting into revoked_tokens query error")
+	}
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at < NOW()"); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution deleting expired revoked tokens query error")
+
+		return errors.Wrap(err, "execution deleting expired revoked tokens query error")
+	}
+
+	return nil
+}
+
+// IsRevoked checks if the access token ID is in the revocation list.
+func (r RevokedTokens) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "RevokedTokens",
+		"method":     "IsRevoked",
+		"jti":        jti,
+	}
+
+	var revoked bool
+
+	if err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)", jti).Scan(&revoked); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution checking revoked token query error")
+
+		return false, errors.Wrap(err, "execution checking revoked token query error")
+	}
+
+	return revoked, nil
+}
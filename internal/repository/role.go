@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lukinairina90/banking_backend/internal/domain"
@@ -33,7 +34,11 @@ func (r Roles) GetByName(ctx context.Context, name string) (domain.Role, error)
 
 	query := "SELECT * FROM roles WHERE name = $1"
 
-	if err := r.db.GetContext(ctx, &role, query, name); err != nil {
+	if err := conn(ctx, r.db).GetContext(ctx, &role, query, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Role{}, domain.ErrRoleNotFound
+		}
+
 		logrus.WithError(err).
 			WithFields(fields).
 			Error("execution getting name from roles query error")
@@ -57,7 +62,11 @@ func (r Roles) GetByID(ctx context.Context, id int) (domain.Role, error) {
 
 	query := "SELECT * FROM roles WHERE id = $1"
 
-	if err := r.db.GetContext(ctx, &role, query, id); err != nil {
+	if err := conn(ctx, r.db).GetContext(ctx, &role, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Role{}, domain.ErrRoleNotFound
+		}
+
 		logrus.WithError(err).
 			WithFields(fields).
 			Error("execution getting role by ID from roles query error")
@@ -67,3 +76,143 @@ func (r Roles) GetByID(ctx context.Context, id int) (domain.Role, error) {
 
 	return role.ToDomain(), nil
 }
+
+// GetRoles returns all roles.
+func (r Roles) GetRoles(ctx context.Context) ([]domain.Role, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Role",
+		"method":     "GetRoles",
+	}
+
+	var roles []models.Role
+
+	query := "SELECT id, name FROM roles ORDER BY id"
+
+	if err := conn(ctx, r.db).SelectContext(ctx, &roles, query); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting roles query error")
+
+		return nil, errors.Wrap(err, "execution getting roles query error")
+	}
+
+	return rolesToDomain(roles), nil
+}
+
+// Create creates the role with the provided name, the name must be unique.
+func (r Roles) Create(ctx context.Context, name string) (domain.Role, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Role",
+		"method":     "Create",
+		"name":       name,
+	}
+
+	var role models.Role
+
+	query := "INSERT INTO roles (name) VALUES ($1) ON CONFLICT (name) DO NOTHING RETURNING id, name"
+
+	if err := conn(ctx, r.db).GetContext(ctx, &role, query, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Role{}, domain.ErrRoleAlreadyExists
+		}
+
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution creating role query error")
+
+		return domain.Role{}, errors.Wrap(err, "execution creating role query error")
+	}
+
+	return role.ToDomain(), nil
+}
+
+// GetUserRoles returns the roles of the user.
+func (r Roles) GetUserRoles(ctx context.Context, userID int) ([]domain.Role, error) {
+	return r.userRoles(ctx, "GetUserRoles", "SELECT r.id, r.name FROM user_roles ur INNER JOIN roles r ON r.id = ur.role_id WHERE ur.user_id = $1 ORDER BY r.name", userID)
+}
+
+// LockUserRoles returns the roles of the user locking them until the end of the transaction,
+// so concurrent changes of the user roles are applied one after another.
+func (r Roles) LockUserRoles(ctx context.Context, userID int) ([]domain.Role, error) {
+	return r.userRoles(ctx, "LockUserRoles", "SELECT r.id, r.name FROM user_roles ur INNER JOIN roles r ON r.id = ur.role_id WHERE ur.user_id = $1 ORDER BY r.name FOR UPDATE OF ur", userID)
+}
+
+// userRoles returns the roles of the user selected by the query.
+func (r Roles) userRoles(ctx context.Context, method, query string, userID int) ([]domain.Role, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Role",
+		"method":     method,
+		"user_id":    userID,
+	}
+
+	var roles []models.Role
+
+	if err := conn(ctx, r.db).SelectContext(ctx, &roles, query, userID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting user roles query error")
+
+		return nil, errors.Wrap(err, "execution getting user roles query error")
+	}
+
+	return rolesToDomain(roles), nil
+}
+
+// AssignRole gives the role to the user, assigning a role the user already has changes nothing.
+func (r Roles) AssignRole(ctx context.Context, userID, roleID int) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Role",
+		"method":     "AssignRole",
+		"user_id":    userID,
+		"role_id":    roleID,
+	}
+
+	query := "INSERT INTO user_roles (user_id, role_id) VALUES ($1, $2) ON CONFLICT (user_id, role_id) DO NOTHING"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID, roleID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution assigning role query error")
+
+		return errors.Wrap(err, "execution assigning role query error")
+	}
+
+	return nil
+}
+
+// RevokeRole takes the role away from the user.
+func (r Roles) RevokeRole(ctx context.Context, userID, roleID int) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Role",
+		"method":     "RevokeRole",
+		"user_id":    userID,
+		"role_id":    roleID,
+	}
+
+	query := "DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID, roleID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution revoking role query error")
+
+		return errors.Wrap(err, "execution revoking role query error")
+	}
+
+	return nil
+}
+
+// rolesToDomain converts the role models to domain.Role.
+func rolesToDomain(roles []models.Role) []domain.Role {
+	domainRoles := make([]domain.Role, 0, len(roles))
+	for _, role := range roles {
+		domainRoles = append(domainRoles, role.ToDomain())
+	}
+
+	return domainRoles
+}
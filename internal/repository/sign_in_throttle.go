@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/lukinairina90/banking_backend/internal/repository/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const signInThrottleColumns = "scope, subject, failures, last_failure_at, locked_until"
+
+// SignInThrottles repository layer struct.
+type SignInThrottles struct {
+	db *sqlx.DB
+}
+
+// NewSignInThrottles constructor for SignInThrottles repository layer.
+func NewSignInThrottles(db *sqlx.DB) *SignInThrottles {
+	return &SignInThrottles{db: db}
+}
+
+// Get returns the failed sign in attempts of the subject, a subject without failures has an empty throttle.
+func (r SignInThrottles) Get(ctx context.Context, scope domain.SignInThrottleScope, subject string) (domain.SignInThrottle, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "SignInThrottles",
+		"method":     "Get",
+		"scope":      scope,
+		"subject":    subject,
+	}
+
+	var throttle models.SignInThrottle
+
+	query := "SELECT " + signInThrottleColumns + " FROM sign_in_throttles WHERE scope = $1 AND subject = $2"
+
+	if err := conn(ctx, r.db).GetContext(ctx, &throttle, query, scope, subject); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.SignInThrottle{Scope: scope, Subject: subject}, nil
+		}
+
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting sign in throttle query error")
+
+		return domain.SignInThrottle{}, errors.Wrap(err, "execution getting sign in throttle query error")
+	}
+
+	return throttle.ToDomain(), nil
+}
+
+// RegisterFailure counts a failed sign in attempt of the subject and returns the updated throttle.
+// Failures before since and failures followed by an expired lockout are forgotten, the count starts again.
+// Forgotten throttles of other subjects are removed.
+func (r SignInThrottles) RegisterFailure(ctx context.Context, scope domain.SignInThrottleScope, subject string, since time.Time) (domain.SignInThrottle, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "SignInThrottles",
+		"method":     "RegisterFailure",
+		"scope":      scope,
+		"subject":    subject,
+	}
+
+	var throttle models.SignInThrottle
+
+	query := "INSERT INTO sign_in_throttles (scope, subject, failures, last_failure_at) VALUES ($1, $2, 1, NOW()) " +
+		"ON CONFLICT (scope, subject) DO UPDATE SET " +
+		"failures = CASE WHEN sign_in_throttles.last_failure_at < $3 OR sign_in_throttles.locked_until <= NOW() THEN 1 ELSE sign_in_throttles.failures + 1 END, " +
+		"locked_until = CASE WHEN sign_in_throttles.locked_until <= NOW() THEN NULL ELSE sign_in_throttles.locked_until END, " +
+		"last_failure_at = NOW() " +
+		"RETURNING " + signInThrottleColumns
+
+	if err := conn(ctx, r.db).GetContext(ctx, &throttle, query, scope, subject, since); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution registering sign in failure query error")
+
+		return domain.SignInThrottle{}, errors.Wrap(err, "execution registering sign in failure query error")
+	}
+
+	query = "DELETE FROM sign_in_throttles WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until <= NOW())"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, since); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution deleting forgotten sign in throttles query error")
+
+		return domain.SignInThrottle{}, errors.Wrap(err, "execution deleting forgotten sign in throttles query error")
+	}
+
+	return throttle.ToDomain(), nil
+}
+
+// Lock locks sign in of the subject until the provided time.
+func (r SignInThrottles) Lock(ctx context.Context, scope domain.SignInThrottleScope, subject string, until time.Time) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "SignInThrottles",
+		"method":     "Lock",
+		"scope":      scope,
+		"subject":    subject,
+	}
+
+	query := "UPDATE sign_in_throttles SET locked_until = $3 WHERE scope = $1 AND subject = $2"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, scope, subject, until); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution locking sign in query error")
+
+		return errors.Wrap(err, "execution locking sign in query error")
+	}
+
+	return nil
+}
+
+// Delete removes the failed sign in attempts and the lockout of the subject.
+func (r SignInThrottles) Delete(ctx context.Context, scope domain.SignInThrottleScope, subject string) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "SignInThrottles",
+		"method":     "Delete",
+		"scope":      scope,
+		"subject":    subject,
+	}
+
+	query := "DELETE FROM sign_in_throttles WHERE scope = $1 AND subject = $2"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, scope, subject); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution deleting sign in throttle query error")
+
+		return errors.Wrap(err, "execution deleting sign in throttle query error")
+	}
+
+	return nil
+}
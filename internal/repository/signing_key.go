@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	signingKeyFileExtension = ".pem"
+	privateKeyPEMType       = "PRIVATE KEY"
+)
+
+// SigningKeys file backed access token signing keys repository.
+// Every key is stored as a PKCS #8 PEM file named by the key ID, the file modification time is the key creation time.
+type SigningKeys struct {
+	dir string
+}
+
+// NewSigningKeys constructor for SigningKeys repository layer, creates the keys directory if it does not exist.
+func NewSigningKeys(dir string) (*SigningKeys, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.Wrap(err, "creating signing keys directory error")
+	}
+
+	return &SigningKeys{dir: dir}, nil
+}
+
+// List returns all keys stored in the directory.
+func (r SigningKeys) List(_ context.Context) ([]domain.SigningKey, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "SigningKeys",
+		"method":     "List",
+		"dir":        r.dir,
+	}
+
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("reading signing keys directory error")
+
+		return nil, errors.Wrap(err, "reading signing keys directory error")
+	}
+
+	keys := make([]domain.SigningKey, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != signingKeyFileExtension {
+			continue
+		}
+
+		key, err := r.read(entry)
+		if err != nil {
+			logrus.WithError(err).
+				WithFields(fields).
+				WithField("file", entry.Name()).
+				Error("reading signing key error")
+
+			return nil, errors.Wrapf(err, "reading signing key %s error", entry.Name())
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// read parses the key file.
+func (r SigningKeys) read(entry os.DirEntry) (domain.SigningKey, error) {
+	info, err := entry.Info()
+	if err != nil {
+		return domain.SigningKey{}, errors.Wrap(err, "getting key file info error")
+	}
+
+	data, err := os.ReadFile(filepath.Join(r.dir, entry.Name()))
+	if err != nil {
+		return domain.SigningKey{}, errors.Wrap(err, "reading key file error")
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != privateKeyPEMType {
+		return domain.SigningKey{}, errors.New("key file does not contain a PKCS #8 private key")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return domain.SigningKey{}, errors.Wrap(err, "parsing private key error")
+	}
+
+	key := domain.SigningKey{
+		ID:        strings.TrimSuffix(entry.Name(), signingKeyFileExtension),
+		CreatedAt: info.ModTime(),
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Algorithm, key.PrivateKey = domain.SigningAlgorithmRS256, k
+	case ed25519.PrivateKey:
+		key.Algorithm, key.PrivateKey = domain.SigningAlgorithmEdDSA, k
+	default:
+		return domain.SigningKey{}, errors.Errorf("unsupported private key type %T", parsed)
+	}
+
+	return key, nil
+}
+
+// Create stores the key in the directory, the file is written under a temporary name and renamed, so other instances never read a partial key.
+func (r SigningKeys) Create(_ context.Context, key domain.SigningKey) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "SigningKeys",
+		"method":     "Create",
+		"kid":        key.ID,
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(crypto.PrivateKey(key.PrivateKey))
+	if err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("marshaling private key error")
+
+		return errors.Wrap(err, "marshaling private key error")
+	}
+
+	path := filepath.Join(r.dir, key.ID+signingKeyFileExtension)
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, pem.EncodeToMemory(&pem.Block{Type: privateKeyPEMType, Bytes: der}), 0o600); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("writing signing key file error")
+
+		return errors.Wrap(err, "writing signing key file error")
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("renaming signing key file error")
+
+		return errors.Wrap(err, "renaming signing key file error")
+	}
+
+	return nil
+}
+
+// Delete removes the key from the directory.
+func (r SigningKeys) Delete(_ context.Context, id string) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "SigningKeys",
+		"method":     "Delete",
+		"kid":        id,
+	}
+
+	if err := os.Remove(filepath.Join(r.dir, id+signingKeyFileExtension)); err != nil && !os.IsNotExist(err) {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("removing signing key file error")
+
+		return errors.Wrap(err, "removing signing key file error")
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lukinairina90/banking_backend/internal/domain"
@@ -10,6 +11,8 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+const refreshSessionColumns = "id, user_id, family_id, token_hash, user_agent, ip, created_at, expires_at, rotated_at, revoked_at"
+
 // Tokens repository layer struct.
 type Tokens struct {
 	db *sqlx.DB
@@ -26,10 +29,13 @@ func (r Tokens) Create(ctx context.Context, token domain.RefreshSession) error {
 		"layer":      "repository",
 		"repository": "Token",
 		"method":     "Create",
-		"token":      token,
+		"user_id":    token.UserID,
+		"family_id":  token.FamilyID,
 	}
 
-	_, err := r.db.ExecContext(ctx, "INSERT INTO refresh_tokens (user_id, token, expires_at) VALUES ($1, $2, $3)", token.UserID, token.Token, token.ExpiresAt)
+	query := "INSERT INTO refresh_tokens (user_id, family_id, token_hash, user_agent, ip, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, NOW(), $6)"
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, token.UserID, token.FamilyID, token.TokenHash, token.UserAgent, token.IP, token.ExpiresAt)
 	if err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
@@ -40,36 +46,136 @@ func (r Tokens) Create(ctx context.Context, token domain.RefreshSession) error {
 	return nil
 }
 
-// Get returns the token struct as provided token string.
-func (r Tokens) Get(ctx context.Context, token string) (domain.RefreshSession, error) {
+// Get returns the refresh session as provided token hash.
+func (r Tokens) Get(ctx context.Context, tokenHash string) (domain.RefreshSession, error) {
+	return r.get(ctx, "Get", "SELECT "+refreshSessionColumns+" FROM refresh_tokens WHERE token_hash = $1", tokenHash)
+}
+
+// Lock locks the refresh session row until the end of the current database transaction and returns it as provided token hash.
+func (r Tokens) Lock(ctx context.Context, tokenHash string) (domain.RefreshSession, error) {
+	return r.get(ctx, "Lock", "SELECT "+refreshSessionColumns+" FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE", tokenHash)
+}
+
+// get returns the refresh session selected by the query.
+func (r Tokens) get(ctx context.Context, method, query, tokenHash string) (domain.RefreshSession, error) {
 	fields := logrus.Fields{
 		"layer":      "repository",
 		"repository": "Token",
-		"method":     "Get",
-		"token":      token,
+		"method":     method,
 	}
 
 	var refreshSession models.RefreshSession
 
-	err := r.db.QueryRowContext(ctx, "SELECT id, user_id, token, expires_at FROM refresh_tokens WHERE token=$1", token).Scan(&refreshSession.ID, &refreshSession.UserID, &refreshSession.Token, &refreshSession.ExpiresAt)
-	if err != nil {
+	if err := conn(ctx, r.db).GetContext(ctx, &refreshSession, query, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.RefreshSession{}, domain.ErrInvalidRefreshToken
+		}
+
 		logrus.WithError(err).
 			WithFields(fields).
-			Error("scanning result into struct error")
+			Error("execution getting refresh session query error")
 
-		return domain.RefreshSession{}, errors.Wrap(err, "scanning result into struct error")
+		return domain.RefreshSession{}, errors.Wrap(err, "execution getting refresh session query error")
 	}
 
-	_, err = r.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE user_id=$1", refreshSession.UserID)
-	if err != nil {
+	return refreshSession.ToDomain(), nil
+}
+
+// MarkRotated marks the refresh token as used, it can not be used again.
+func (r Tokens) MarkRotated(ctx context.Context, id int) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Token",
+		"method":     "MarkRotated",
+		"id":         id,
+	}
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE refresh_tokens SET rotated_at = NOW() WHERE id = $1", id); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution marking refresh token rotated query error")
+
+		return errors.Wrap(err, "execution marking refresh token rotated query error")
+	}
+
+	return nil
+}
+
+// RevokeFamily revokes all refresh tokens of the session family.
+func (r Tokens) RevokeFamily(ctx context.Context, familyID string) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Token",
+		"method":     "RevokeFamily",
+		"family_id":  familyID,
+	}
+
+	query := "UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, familyID); err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
-			Error("execution deleting from refresh_tokens query error")
+			Error("execution revoking refresh session family query error")
 
-		return domain.RefreshSession{}, errors.Wrap(err, "execution deleting from refresh_tokens query error")
+		return errors.Wrap(err, "execution revoking refresh session family query error")
 	}
 
-	domainRefreshSession := refreshSession.ToDomain()
+	return nil
+}
+
+// RevokeAll revokes all refresh tokens of the user.
+func (r Tokens) RevokeAll(ctx context.Context, userID int) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Token",
+		"method":     "RevokeAll",
+		"user_id":    userID,
+	}
+
+	query := "UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution revoking user refresh sessions query error")
+
+		return errors.Wrap(err, "execution revoking user refresh sessions query error")
+	}
+
+	return nil
+}
+
+// GetActiveSessions returns the active session families of the user, newest first.
+// The session is described by its current refresh token, its start is the creation of the first token of the family.
+func (r Tokens) GetActiveSessions(ctx context.Context, userID int) ([]domain.Session, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Token",
+		"method":     "GetActiveSessions",
+		"user_id":    userID,
+	}
+
+	query := "SELECT t.family_id, t.user_agent, t.ip, " +
+		"(SELECT MIN(f.created_at) FROM refresh_tokens f WHERE f.family_id = t.family_id) AS created_at, " +
+		"t.created_at AS last_used_at, t.expires_at " +
+		"FROM refresh_tokens t " +
+		"WHERE t.user_id = $1 AND t.rotated_at IS NULL AND t.revoked_at IS NULL AND t.expires_at > NOW() " +
+		"ORDER BY t.created_at DESC"
+
+	var sessions []models.Session
+
+	if err := conn(ctx, r.db).SelectContext(ctx, &sessions, query, userID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting active sessions query error")
+
+		return nil, errors.Wrap(err, "execution getting active sessions query error")
+	}
+
+	domainSessions := make([]domain.Session, 0, len(sessions))
+	for _, session := range sessions {
+		domainSessions = append(domainSessions, session.ToDomain())
+	}
 
-	return domainRefreshSession, err
+	return domainSessions, nil
 }
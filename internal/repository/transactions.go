@@ -5,9 +5,11 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/lukinairina90/banking_backend/internal/domain"
 	"github.com/lukinairina90/banking_backend/internal/repository/models"
 	"github.com/pkg/errors"
@@ -15,13 +17,18 @@ import (
 )
 
 const (
-	transactionPreparedStatus = "PREPARED"
-	transactionSentStatus     = "SENT"
-
 	ingoingTransactionType  = "ingoing"
 	outgoingTransactionType = "outgoing"
 )
 
+// transactionColumns selects transaction columns together with the codes and precisions of the transaction currencies.
+const transactionColumns = "t.id, t.from_account, t.to_account, t.amount, t.status, t.date_created, t.date_updated, " +
+	"c.code AS currency_code, c.precision AS currency_precision, " +
+	"t.to_amount, tc.code AS to_currency_code, tc.precision AS to_currency_precision, t.exchange_rate, t.failure_reason, t.reversal_of"
+
+// transactionCurrencyJoins joins currencies of the amount and the received amount to the transactions table aliased as t.
+const transactionCurrencyJoins = "INNER JOIN currency c ON c.id = t.currency_id INNER JOIN currency tc ON tc.id = t.to_currency_id"
+
 // Transactions repository layer struct.
 type Transactions struct {
 	db *sqlx.DB
@@ -32,26 +39,51 @@ func NewTransactions(db *sqlx.DB) *Transactions {
 	return &Transactions{db: db}
 }
 
-// CreateTransaction creates a transaction in the database by provides from account ID, to account ID and amount.
-func (r Transactions) CreateTransaction(ctx context.Context, fromAccountID, toAccountID int, amount float64) (domain.Transaction, error) {
+// CreateTransaction creates a transaction in the database by provided from account ID, to account ID, amount,
+// received amount and the applied exchange rate, if the amounts currencies differ.
+// Compensating transactions are linked to the reversed transaction.
+func (r Transactions) CreateTransaction(ctx context.Context, transaction domain.Transaction) (domain.Transaction, error) {
 	fields := logrus.Fields{
 		"layer":           "repository",
 		"repository":      "Transaction",
 		"method":          "CreateTransaction",
-		"from_account_id": fromAccountID,
-		"to_account_id":   toAccountID,
-		"amount":          amount,
+		"from_account_id": transaction.FromAccount,
+		"to_account_id":   transaction.ToAccount,
+		"amount":          transaction.Amount.String(),
+		"to_amount":       transaction.ToAmount.String(),
 	}
 
 	nullableFromAccountID := sql.NullInt64{}
-	if fromAccountID != 0 {
-		nullableFromAccountID.Int64 = int64(fromAccountID)
+	if transaction.FromAccount != 0 {
+		nullableFromAccountID.Int64 = int64(transaction.FromAccount)
 		nullableFromAccountID.Valid = true
 	}
 
-	query := "INSERT INTO transactions (from_account, to_account, amount, status, date_created) VALUES ($1, $2, $3, $4, NOW()) RETURNING *"
+	nullableToAccountID := sql.NullInt64{}
+	if transaction.ToAccount != 0 {
+		nullableToAccountID.Int64 = int64(transaction.ToAccount)
+		nullableToAccountID.Valid = true
+	}
+
+	nullableReversalOf := sql.NullInt64{}
+	if transaction.ReversalOf != 0 {
+		nullableReversalOf.Int64 = int64(transaction.ReversalOf)
+		nullableReversalOf.Valid = true
+	}
+
+	nullableExchangeRate := sql.NullString{}
+	if transaction.ExchangeRate != nil {
+		nullableExchangeRate.String = domain.FormatRate(transaction.ExchangeRate)
+		nullableExchangeRate.Valid = true
+	}
+
+	query := "WITH t AS (INSERT INTO transactions (from_account, to_account, amount, currency_id, to_amount, to_currency_id, exchange_rate, status, reversal_of, date_created) " +
+		"VALUES ($1, $2, $3, (SELECT id FROM currency WHERE code = $4), $5, (SELECT id FROM currency WHERE code = $6), $7, $8, $9, NOW()) RETURNING *) " +
+		"SELECT " + transactionColumns + " FROM t " + transactionCurrencyJoins
 
-	row := r.db.QueryRowContext(ctx, query, nullableFromAccountID, toAccountID, amount, transactionPreparedStatus)
+	row := conn(ctx, r.db).QueryRowxContext(ctx, query, nullableFromAccountID, nullableToAccountID,
+		transaction.Amount.String(), transaction.Amount.Currency, transaction.ToAmount.String(), transaction.ToAmount.Currency,
+		nullableExchangeRate, domain.TransactionPrepared.String(), nullableReversalOf)
 	if row.Err() != nil {
 		logrus.WithError(row.Err()).
 			WithFields(fields).
@@ -60,16 +92,8 @@ func (r Transactions) CreateTransaction(ctx context.Context, fromAccountID, toAc
 		return domain.Transaction{}, errors.Wrap(row.Err(), "execution inserting into transactions query error")
 	}
 
-	transaction := models.Transaction{}
-	if err := row.Scan(
-		&transaction.ID,
-		&transaction.FromAccount,
-		&transaction.ToAccount,
-		&transaction.Amount,
-		&transaction.Status,
-		&transaction.DateCreated,
-		&transaction.DateUpdated,
-	); err != nil {
+	created := models.Transaction{}
+	if err := row.StructScan(&created); err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
 			Error("scanning row into struct error")
@@ -77,23 +101,116 @@ func (r Transactions) CreateTransaction(ctx context.Context, fromAccountID, toAc
 		return domain.Transaction{}, errors.Wrap(err, "scanning row into struct error")
 	}
 
-	domainTransaction := transaction.ToDomain()
+	domainTransaction, err := created.ToDomain()
+	if err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("converting transaction error")
+
+		return domain.Transaction{}, errors.Wrap(err, "converting transaction error")
+	}
+
+	return domainTransaction, nil
+}
+
+// LockTransaction locks the transaction row until the end of the database transaction and returns it.
+// domain.ErrTransactionNotFound is returned if the transaction does not exist.
+func (r Transactions) LockTransaction(ctx context.Context, transactionID int) (domain.Transaction, error) {
+	fields := logrus.Fields{
+		"layer":          "repository",
+		"repository":     "Transaction",
+		"method":         "LockTransaction",
+		"transaction_id": transactionID,
+	}
+
+	var transaction models.Transaction
+
+	query := "SELECT " + transactionColumns + " FROM transactions t " + transactionCurrencyJoins + " WHERE t.id = $1 FOR UPDATE OF t"
+
+	if err := conn(ctx, r.db).GetContext(ctx, &transaction, query, transactionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Transaction{}, domain.ErrTransactionNotFound
+		}
+
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution locking transaction query error")
+
+		return domain.Transaction{}, errors.Wrap(err, "execution locking transaction query error")
+	}
+
+	domainTransaction, err := transaction.ToDomain()
+	if err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("converting transaction error")
+
+		return domain.Transaction{}, errors.Wrap(err, "converting transaction error")
+	}
 
 	return domainTransaction, nil
 }
 
-// SetTransactionStatusToSent set transaction status to sent
-func (r Transactions) SetTransactionStatusToSent(ctx context.Context, transactionID int) error {
+// GetReversals returns the sent compensating transactions of the provided transaction.
+func (r Transactions) GetReversals(ctx context.Context, transactionID int) ([]domain.Transaction, error) {
+	fields := logrus.Fields{
+		"layer":          "repository",
+		"repository":     "Transaction",
+		"method":         "GetReversals",
+		"transaction_id": transactionID,
+	}
+
+	var transactions []models.Transaction
+
+	query := "SELECT " + transactionColumns + " FROM transactions t " + transactionCurrencyJoins +
+		" WHERE t.reversal_of = $1 AND t.status = $2 ORDER BY t.id"
+
+	if err := conn(ctx, r.db).SelectContext(ctx, &transactions, query, transactionID, domain.TransactionSent.String()); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting transaction reversals query error")
+
+		return nil, errors.Wrap(err, "execution getting transaction reversals query error")
+	}
+
+	domainTransactions := make([]domain.Transaction, 0, len(transactions))
+	for _, transaction := range transactions {
+		domainTransaction, err := transaction.ToDomain()
+		if err != nil {
+			logrus.WithError(err).
+				WithFields(fields).
+				Error("converting transaction error")
+
+			return nil, errors.Wrap(err, "converting transaction error")
+		}
+
+		domainTransactions = append(domainTransactions, domainTransaction)
+	}
+
+	return domainTransactions, nil
+}
+
+// UpdateTransactionStatus moves the transaction to the provided status, recording the failure reason if any.
+// The update is applied only if the transaction is in a status the new one can be reached from,
+// domain.ErrInvalidTransactionStatusTransition is returned otherwise.
+func (r Transactions) UpdateTransactionStatus(ctx context.Context, transactionID int, status domain.TransactionStatus, failureReason string) error {
 	fields := logrus.Fields{
 		"layer":          "repository",
 		"repository":     "Transaction",
-		"method":         "CreateTransaction",
+		"method":         "UpdateTransactionStatus",
 		"transaction_id": transactionID,
+		"status":         status,
 	}
 
-	query := "UPDATE transactions SET status=$1, date_updated=NOW() WHERE id = $2"
+	var fromStatuses []string
+	for _, s := range domain.TransactionStatusesBefore(status) {
+		fromStatuses = append(fromStatuses, s.String())
+	}
+
+	query := "UPDATE transactions SET status = $1, failure_reason = NULLIF($2, ''), date_updated = NOW() WHERE id = $3 AND status = ANY($4)"
 
-	if _, err := r.db.ExecContext(ctx, query, transactionSentStatus, transactionID); err != nil {
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, status.String(), failureReason, transactionID, pq.Array(fromStatuses))
+	if err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
 			Error("execution updating status into transactions query error")
@@ -101,9 +218,61 @@ func (r Transactions) SetTransactionStatusToSent(ctx context.Context, transactio
 		return errors.Wrap(err, "execution updating status into transactions query error")
 	}
 
+	affected, err := res.RowsAffected()
+	if err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("getting affected rows error")
+
+		return errors.Wrap(err, "getting affected rows error")
+	}
+
+	if affected == 0 {
+		return domain.ErrInvalidTransactionStatusTransition
+	}
+
 	return nil
 }
 
+// GetStalePreparedTransactions returns transactions which are in PREPARED status since before the provided time.
+func (r Transactions) GetStalePreparedTransactions(ctx context.Context, before time.Time, limit int) ([]domain.Transaction, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Transaction",
+		"method":     "GetStalePreparedTransactions",
+		"before":     before,
+	}
+
+	var transactions []models.Transaction
+
+	query := "SELECT " + transactionColumns + " FROM transactions t " + transactionCurrencyJoins +
+		" WHERE t.status = $1 AND t.date_created < $2 ORDER BY t.id LIMIT $3"
+
+	if err := conn(ctx, r.db).SelectContext(ctx, &transactions, query, domain.TransactionPrepared.String(), before, limit); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting stale prepared transactions query error")
+
+		return nil, errors.Wrap(err, "execution getting stale prepared transactions query error")
+	}
+
+	domainTransactions := make([]domain.Transaction, 0, len(transactions))
+	for _, transaction := range transactions {
+		domainTransaction, err := transaction.ToDomain()
+		if err != nil {
+			logrus.WithError(err).
+				WithFields(fields).
+				Error("converting transaction error")
+
+			return nil, errors.Wrap(err, "converting transaction error")
+		}
+
+		domainTransactions = append(domainTransactions, domainTransaction)
+	}
+
+	return domainTransactions, nil
+}
+
 // GetTransactionList provides transactions list.
 func (r Transactions) GetTransactionList(ctx context.Context, accountID int, ordering domain.Orderings, paginator domain.Paginator) ([]domain.Transaction, error) {
 	fields := logrus.Fields{
@@ -116,19 +285,21 @@ func (r Transactions) GetTransactionList(ctx context.Context, accountID int, ord
 	listTransaction := make([]models.Transaction, 0, paginator.PerPage)
 
 	//query := "SELECT * FROM transactions WHERE from_account = $1 OR to_account = $2"
-	qb := squirrel.Select("*").
-		From("transactions").
-		Where("from_account = ? OR to_account = ?", accountID, accountID)
+	qb := squirrel.Select(transactionColumns).
+		From("transactions t").
+		InnerJoin("currency c ON c.id = t.currency_id").
+		InnerJoin("currency tc ON tc.id = t.to_currency_id").
+		Where("t.from_account = ? OR t.to_account = ?", accountID, accountID)
 
 	if ordering != nil {
 		var parts []string
 		for field, direction := range ordering {
-			parts = append(parts, fmt.Sprintf("%s %s", field, strings.ToUpper(direction)))
+			parts = append(parts, fmt.Sprintf("t.%s %s", field, strings.ToUpper(direction)))
 		}
 
 		qb = qb.OrderBy(parts...)
 	} else {
-		qb = qb.OrderBy("id ASC")
+		qb = qb.OrderBy("t.id ASC")
 	}
 
 	qb = qb.Limit(uint64(paginator.PerPage)).
@@ -144,7 +315,7 @@ func (r Transactions) GetTransactionList(ctx context.Context, accountID int, ord
 		return nil, errors.Wrap(err, "building a ToSql query into a SQL string error")
 	}
 
-	rows, err := r.db.QueryxContext(ctx, query, params...)
+	rows, err := conn(ctx, r.db).QueryxContext(ctx, query, params...)
 	if err != nil && rows.Err() != nil {
 		logrus.WithError(err).
 			WithFields(fields).
@@ -167,7 +338,14 @@ func (r Transactions) GetTransactionList(ctx context.Context, accountID int, ord
 
 	domainListTransaction := make([]domain.Transaction, 0)
 	for _, transaction := range listTransaction {
-		domainTransaction := transaction.ToDomain()
+		domainTransaction, err := transaction.ToDomain()
+		if err != nil {
+			logrus.WithError(err).
+				WithFields(fields).
+				Error("converting transaction error")
+
+			return nil, errors.Wrap(err, "converting transaction error")
+		}
 
 		domainTransaction.Type = outgoingTransactionType
 		if domainTransaction.ToAccount == accountID {
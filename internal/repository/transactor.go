@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// txKey context key under which the active database transaction is stored.
+type txKey struct{}
+
+// queryer is implemented by both *sqlx.DB and *sqlx.Tx, so repositories can run queries on either of them.
+type queryer interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn returns the transaction carried by the context or the provided database connection if there is none.
+func conn(ctx context.Context, db *sqlx.DB) queryer {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+
+	return db
+}
+
+// Transactor unit of work, runs a business operation inside a single database transaction.
+type Transactor struct {
+	db *sqlx.DB
+}
+
+// NewTransactor constructor for Transactor.
+func NewTransactor(db *sqlx.DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+// WithinTransaction begins a transaction and passes a context carrying it to fn.
+// The transaction is committed if fn succeeds and rolled back otherwise.
+// Nested calls reuse the transaction that is already carried by the context.
+func (t Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Transactor",
+		"method":     "WithinTransaction",
+	}
+
+	tx, err := t.db.BeginTxx(ctx, nil)
+	if err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("begin transaction error")
+
+		return errors.Wrap(err, "begin transaction error")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			logrus.WithError(rbErr).
+				WithFields(fields).
+				Error("rollback transaction error")
+		}
+
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("commit transaction error")
+
+		return errors.Wrap(err, "commit transaction error")
+	}
+
+	return nil
+}
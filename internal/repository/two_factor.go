@@ -0,0 +1,370 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/lukinairina90/banking_backend/internal/repository/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// TwoFactor repository layer struct.
+type TwoFactor struct {
+	db *sqlx.DB
+}
+
+// NewTwoFactor constructor for TwoFactor repository layer.
+func NewTwoFactor(db *sqlx.DB) *TwoFactor {
+	return &TwoFactor{db: db}
+}
+
+// Lock locks the user row until the end of the current database transaction and returns the TOTP settings of the user.
+func (r TwoFactor) Lock(ctx context.Context, userID int) (domain.TwoFactor, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "TwoFactor",
+		"method":     "Lock",
+		"user_id":    userID,
+	}
+
+	var twoFactor models.TwoFactor
+
+	query := "SELECT id, totp_secret, totp_enabled, totp_last_step, totp_failures, totp_locked_until FROM users WHERE id = $1 FOR UPDATE"
+
+	if err := conn(ctx, r.db).GetContext(ctx, &twoFactor, query, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.TwoFactor{}, domain.ErrUserNotFound
+		}
+
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution locking user two-factor settings query error")
+
+		return domain.TwoFactor{}, errors.Wrap(err, "execution locking user two-factor settings query error")
+	}
+
+	return twoFactor.ToDomain(), nil
+}
+
+// SetSecret stores the new TOTP secret of the user, two-factor authentication stays disabled until it is confirmed.
+func (r TwoFactor) SetSecret(ctx context.Context, userID int, secret string) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "TwoFactor",
+		"method":     "SetSecret",
+		"user_id":    userID,
+	}
+
+	query := "UPDATE users SET totp_secret = $1, totp_enabled = FALSE, totp_last_step = 0 WHERE id = $2"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, secret, userID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution setting TOTP secret query error")
+
+		return errors.Wrap(err, "execution setting TOTP secret query error")
+	}
+
+	return nil
+}
+
+// Enable enables two-factor authentication of the user.
+func (r TwoFactor) Enable(ctx context.Context, userID int) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "TwoFactor",
+		"method":     "Enable",
+		"user_id":    userID,
+	}
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE users SET totp_enabled = TRUE WHERE id = $1", userID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution enabling two-factor authentication query error")
+
+		return errors.Wrap(err, "execution enabling two-factor authentication query error")
+	}
+
+	return nil
+}
+
+// Disable disables two-factor authentication of the user, removes the secret and the recovery codes.
+func (r TwoFactor) Disable(ctx context.Context, userID int) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "TwoFactor",
+		"method":     "Disable",
+		"user_id":    userID,
+	}
+
+	query := "UPDATE users SET totp_secret = NULL, totp_enabled = FALSE, totp_last_step = 0, totp_failures = 0, totp_locked_until = NULL WHERE id = $1"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution disabling two-factor authentication query error")
+
+		return errors.Wrap(err, "execution disabling two-factor authentication query error")
+	}
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution deleting recovery codes query error")
+
+		return errors.Wrap(err, "execution deleting recovery codes query error")
+	}
+
+	return nil
+}
+
+// UpdateLastStep stores the time step of the last accepted TOTP code.
+func (r TwoFactor) UpdateLastStep(ctx context.Context, userID int, step int64) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "TwoFactor",
+		"method":     "UpdateLastStep",
+		"user_id":    userID,
+	}
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE users SET totp_last_step = $1 WHERE id = $2", step, userID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution updating TOTP last step query error")
+
+		return errors.Wrap(err, "execution updating TOTP last step query error")
+	}
+
+	return nil
+}
+
+// RegisterCodeFailure counts a wrong two-factor code of the user and returns the number of wrong codes since the last accepted one.
+func (r TwoFactor) RegisterCodeFailure(ctx context.Context, userID int) (int, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "TwoFactor",
+		"method":     "RegisterCodeFailure",
+		"user_id":    userID,
+	}
+
+	var failures int
+
+	query := "UPDATE users SET totp_failures = totp_failures + 1 WHERE id = $1 RETURNING totp_failures"
+
+	if err := conn(ctx, r.db).GetContext(ctx, &failures, query, userID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution registering two-factor code failure query error")
+
+		return 0, errors.Wrap(err, "execution registering two-factor code failure query error")
+	}
+
+	return failures, nil
+}
+
+// LockCodes refuses the two-factor codes of the user until the provided time, the count of wrong codes starts again.
+func (r TwoFactor) LockCodes(ctx context.Context, userID int, until time.Time) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "TwoFactor",
+		"method":     "LockCodes",
+		"user_id":    userID,
+	}
+
+	query := "UPDATE users SET totp_failures = 0, totp_locked_until = $2 WHERE id = $1"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID, until); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution locking two-factor codes query error")
+
+		return errors.Wrap(err, "execution locking two-factor codes query error")
+	}
+
+	return nil
+}
+
+// ResetCodeFailures forgets the wrong two-factor codes of the user.
+func (r TwoFactor) ResetCodeFailures(ctx context.Context, userID int) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "TwoFactor",
+		"method":     "ResetCodeFailures",
+		"user_id":    userID,
+	}
+
+	query := "UPDATE users SET totp_failures = 0, totp_locked_until = NULL WHERE id = $1"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution resetting two-factor code failures query error")
+
+		return errors.Wrap(err, "execution resetting two-factor code failures query error")
+	}
+
+	return nil
+}
+
+// ReplaceRecoveryCodes replaces all recovery codes of the user with the provided code hashes.
+func (r TwoFactor) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "TwoFactor",
+		"method":     "ReplaceRecoveryCodes",
+		"user_id":    userID,
+	}
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution deleting recovery codes query error")
+
+		return errors.Wrap(err, "execution deleting recovery codes query error")
+	}
+
+	for _, codeHash := range codeHashes {
+		if _, err := conn(ctx, r.db).ExecContext(ctx, "INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)", userID, codeHash); err != nil {
+			logrus.WithError(err).
+				WithFields(fields).
+				Error("execution inserting into recovery_codes query error")
+
+			return errors.Wrap(err, "execution inserting into recovery_codes query error")
+		}
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks the unused recovery code of the user as used and reports whether such a code existed.
+func (r TwoFactor) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "TwoFactor",
+		"method":     "UseRecoveryCode",
+		"user_id":    userID,
+	}
+
+	query := "UPDATE recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL"
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution using recovery code query error")
+
+		return false, errors.Wrap(err, "execution using recovery code query error")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("getting affected rows error")
+
+		return false, errors.Wrap(err, "getting affected rows error")
+	}
+
+	return affected > 0, nil
+}
+
+// CreateChallenge creates the two-factor challenge of the sign in, expired challenges are removed.
+func (r TwoFactor) CreateChallenge(ctx context.Context, challenge domain.TwoFactorChallenge) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "TwoFactor",
+		"method":     "CreateChallenge",
+		"user_id":    challenge.UserID,
+	}
+
+	query := "INSERT INTO two_factor_challenges (user_id, token_hash, expires_at) VALUES ($1, $2, $3)"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, challenge.UserID, challenge.TokenHash, challenge.ExpiresAt); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution inserting into two_factor_challenges query error")
+
+		return errors.Wrap(err, "execution inserting into two_factor_challenges query error")
+	}
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM two_factor_challenges WHERE expires_at < NOW()"); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution deleting expired two-factor challenges query error")
+
+		return errors.Wrap(err, "execution deleting expired two-factor challenges query error")
+	}
+
+	return nil
+}
+
+// LockChallenge locks the challenge row until the end of the current database transaction and returns it as provided token hash.
+func (r TwoFactor) LockChallenge(ctx context.Context, tokenHash string) (domain.TwoFactorChallenge, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "TwoFactor",
+		"method":     "LockChallenge",
+	}
+
+	var challenge models.TwoFactorChallenge
+
+	query := "SELECT id, user_id, token_hash, attempts, expires_at FROM two_factor_challenges WHERE token_hash = $1 FOR UPDATE"
+
+	if err := conn(ctx, r.db).GetContext(ctx, &challenge, query, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.TwoFactorChallenge{}, domain.ErrInvalidTwoFactorChallenge
+		}
+
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution locking two-factor challenge query error")
+
+		return domain.TwoFactorChallenge{}, errors.Wrap(err, "execution locking two-factor challenge query error")
+	}
+
+	return challenge.ToDomain(), nil
+}
+
+// IncrementChallengeAttempts counts the failed verification of the challenge.
+func (r TwoFactor) IncrementChallengeAttempts(ctx context.Context, id int) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "TwoFactor",
+		"method":     "IncrementChallengeAttempts",
+		"id":         id,
+	}
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE two_factor_challenges SET attempts = attempts + 1 WHERE id = $1", id); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution incrementing two-factor challenge attempts query error")
+
+		return errors.Wrap(err, "execution incrementing two-factor challenge attempts query error")
+	}
+
+	return nil
+}
+
+// DeleteChallenge deletes the challenge, it can not be used again.
+func (r TwoFactor) DeleteChallenge(ctx context.Context, id int) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "TwoFactor",
+		"method":     "DeleteChallenge",
+		"id":         id,
+	}
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM two_factor_challenges WHERE id = $1", id); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution deleting two-factor challenge query error")
+
+		return errors.Wrap(err, "execution deleting two-factor challenge query error")
+	}
+
+	return nil
+}
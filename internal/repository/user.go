@@ -2,7 +2,12 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
 	"github.com/lukinairina90/banking_backend/internal/domain"
 	"github.com/lukinairina90/banking_backend/internal/repository/models"
@@ -10,6 +15,11 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// userColumns columns of the user selected from the users table aliased as u, including the names of the user roles.
+const userColumns = "u.id, u.name, u.surname, u.email, u.password, " +
+	"array(select r.name from user_roles ur inner join roles r on r.id = ur.role_id where ur.user_id = u.id order by r.name) as roles, " +
+	"u.blocked, u.registered_at, u.token_version, u.totp_enabled, u.email_verified"
+
 // Users repository layer struct.
 type Users struct {
 	db *sqlx.DB
@@ -33,7 +43,11 @@ func (r Users) CheckBlockUser(ctx context.Context, userID int) (bool, error) {
 
 	query := "SELECT blocked FROM users WHERE id = $1"
 
-	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&checkBlock); err != nil {
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(&checkBlock); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, domain.ErrUserNotFound
+		}
+
 		logrus.WithError(err).
 			WithFields(fields).
 			Error("execution getting blocked from users query error")
@@ -59,7 +73,7 @@ func (r Users) GetUserNameAndSurnameByID(ctx context.Context, userID int) (strin
 
 	query := "select name, surname from users where id = $1"
 
-	rows, err := r.db.QueryxContext(ctx, query, userID)
+	rows, err := conn(ctx, r.db).QueryxContext(ctx, query, userID)
 	if err != nil && rows.Err() != nil {
 		logrus.WithError(err).
 			WithFields(fields).
@@ -97,7 +111,7 @@ func (r Users) Exists(ctx context.Context, email string) (bool, error) {
 
 	query := "select exists(select id from users where email = $1)"
 
-	if err := r.db.QueryRowContext(ctx, query, email).Scan(&exists); err != nil {
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, email).Scan(&exists); err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
 			Error("execution checking exists user query error")
@@ -108,8 +122,8 @@ func (r Users) Exists(ctx context.Context, email string) (bool, error) {
 	return exists, nil
 }
 
-// Create creates a user in the database according to the data provided by the user.
-func (r Users) Create(ctx context.Context, user domain.User) error {
+// Create creates a user in the database according to the data provided by the user and returns its ID.
+func (r Users) Create(ctx context.Context, user domain.User) (int, error) {
 	fields := logrus.Fields{
 		"layer":      "repository",
 		"repository": "Users",
@@ -117,42 +131,46 @@ func (r Users) Create(ctx context.Context, user domain.User) error {
 		"user":       user,
 	}
 
-	query := "insert into users (name, surname, email, password, role_id, blocked, registered_at) values ($1, $2, $3, $4, $5, $6, now())"
+	query := "insert into users (name, surname, email, password, blocked, registered_at) values ($1, $2, $3, $4, $5, now()) returning id"
 
-	_, err := r.db.ExecContext(ctx, query, user.Name, user.Surname, user.Email, user.Password, user.RoleId, user.Blocked)
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, user.Name, user.Surname, user.Email, user.Password, user.Blocked).Scan(&id)
 	if err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
 			Error("execution creating user query error")
 
-		return errors.Wrap(err, "execution creating user query error")
+		return 0, errors.Wrap(err, "execution creating user query error")
 	}
 
-	return nil
+	return id, nil
 }
 
-// GetByCredentials returns the user according to the provided email and password.
-func (r Users) GetByCredentials(ctx context.Context, email, password string) (domain.User, error) {
+// GetByEmail returns the user according to the provided email.
+func (r Users) GetByEmail(ctx context.Context, email string) (domain.User, error) {
 	fields := logrus.Fields{
 		"layer":      "repository",
 		"repository": "Users",
-		"method":     "GetByCredentials",
+		"method":     "GetByEmail",
 		"email":      email,
-		"password":   password,
 	}
 
 	var user models.User
 
-	query := "select id, name, surname, email, password, role_id, blocked, registered_at from users where email = $1 and password = $2"
+	query := "select " + userColumns + " from users u where u.email = $1"
 
-	err := r.db.QueryRowContext(ctx, query, email, password).
-		Scan(&user.ID, &user.Name, &user.Surname, &user.Email, &user.Password, &user.RoleId, &user.Blocked, &user.RegisteredAt)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, email).
+		Scan(&user.ID, &user.Name, &user.Surname, &user.Email, &user.Password, &user.Roles, &user.Blocked, &user.RegisteredAt, &user.TokenVersion, &user.TwoFactorEnabled, &user.EmailVerified)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.User{}, domain.ErrUserNotFound
+		}
+
 		logrus.WithError(err).
 			WithFields(fields).
-			Error("execution selecting user by credentials query error")
+			Error("execution selecting user by email query error")
 
-		return domain.User{}, errors.Wrap(err, "execution selecting user by credentials query error")
+		return domain.User{}, errors.Wrap(err, "execution selecting user by email query error")
 	}
 
 	domainUser := user.ToDomain()
@@ -160,6 +178,28 @@ func (r Users) GetByCredentials(ctx context.Context, email, password string) (do
 	return domainUser, nil
 }
 
+// UpdatePassword replaces the password hash of the user according to the provided user id.
+func (r Users) UpdatePassword(ctx context.Context, userID int, password string) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Users",
+		"method":     "UpdatePassword",
+		"user_id":    userID,
+	}
+
+	query := "update users set password = $1 where id = $2"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, password, userID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution updating user password query error")
+
+		return errors.Wrap(err, "execution updating user password query error")
+	}
+
+	return nil
+}
+
 // GetByID returns the user according to the provided id.
 func (r Users) GetByID(ctx context.Context, id int) (domain.User, error) {
 	fields := logrus.Fields{
@@ -171,11 +211,15 @@ func (r Users) GetByID(ctx context.Context, id int) (domain.User, error) {
 
 	var user models.User
 
-	query := "select id, name, surname, email, password, role_id, blocked, registered_at from users where id = $1"
+	query := "select " + userColumns + " from users u where u.id = $1"
 
-	err := r.db.QueryRowContext(ctx, query, id).
-		Scan(&user.ID, &user.Name, &user.Surname, &user.Email, &user.Password, &user.RoleId, &user.Blocked, &user.RegisteredAt)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).
+		Scan(&user.ID, &user.Name, &user.Surname, &user.Email, &user.Password, &user.Roles, &user.Blocked, &user.RegisteredAt, &user.TokenVersion, &user.TwoFactorEnabled, &user.EmailVerified)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.User{}, domain.ErrUserNotFound
+		}
+
 		logrus.WithError(err).
 			WithFields(fields).
 			Error("execution getting user by ID query error")
@@ -189,7 +233,7 @@ func (r Users) GetByID(ctx context.Context, id int) (domain.User, error) {
 
 }
 
-// BlockUser blocks user according to the provided user id.
+// BlockUser blocks user according to the provided user id. The token version is bumped, so issued access tokens stop working.
 func (r Users) BlockUser(ctx context.Context, userID int) error {
 	fields := logrus.Fields{
 		"layer":      "repository",
@@ -198,9 +242,9 @@ func (r Users) BlockUser(ctx context.Context, userID int) error {
 		"user_id":    userID,
 	}
 
-	query := "update users set blocked = $1 where id = $2"
+	query := "update users set blocked = $1, token_version = token_version + 1 where id = $2"
 
-	if _, err := r.db.ExecContext(ctx, query, block, userID); err != nil {
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, block, userID); err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
 			Error("execution updating blocked from user by ID query error")
@@ -222,7 +266,7 @@ func (r Users) UnblockUser(ctx context.Context, userID int) error {
 
 	query := "update users set blocked= $1 where id = $2"
 
-	if _, err := r.db.ExecContext(ctx, query, unblock, userID); err != nil {
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, unblock, userID); err != nil {
 		logrus.WithError(err).
 			WithFields(fields).
 			Error("execution updating blocked from user by ID query error")
@@ -232,3 +276,182 @@ func (r Users) UnblockUser(ctx context.Context, userID int) error {
 
 	return nil
 }
+
+// GetTokenVersion returns the current access token version of the user.
+func (r Users) GetTokenVersion(ctx context.Context, userID int) (int, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Users",
+		"method":     "GetTokenVersion",
+		"user_id":    userID,
+	}
+
+	var version int
+
+	if err := conn(ctx, r.db).QueryRowContext(ctx, "select token_version from users where id = $1", userID).Scan(&version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domain.ErrUserNotFound
+		}
+
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting token version query error")
+
+		return 0, errors.Wrap(err, "execution getting token version query error")
+	}
+
+	return version, nil
+}
+
+// IncrementTokenVersion bumps the access token version of the user, access tokens issued before stop working.
+func (r Users) IncrementTokenVersion(ctx context.Context, userID int) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Users",
+		"method":     "IncrementTokenVersion",
+		"user_id":    userID,
+	}
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, "update users set token_version = token_version + 1 where id = $1", userID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution incrementing token version query error")
+
+		return errors.Wrap(err, "execution incrementing token version query error")
+	}
+
+	return nil
+}
+
+// MarkEmailVerified marks the email of the user as verified. The token version is bumped,
+// so access tokens issued to the unverified user are refreshed with the new state.
+func (r Users) MarkEmailVerified(ctx context.Context, userID int) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Users",
+		"method":     "MarkEmailVerified",
+		"user_id":    userID,
+	}
+
+	query := "update users set email_verified = true, token_version = token_version + 1 where id = $1"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution marking user email verified query error")
+
+		return errors.Wrap(err, "execution marking user email verified query error")
+	}
+
+	return nil
+}
+
+// UpdateProfile updates the name, surname, email and email verification state of the user.
+func (r Users) UpdateProfile(ctx context.Context, user domain.User) error {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Users",
+		"method":     "UpdateProfile",
+		"user_id":    user.ID,
+	}
+
+	query := "update users set name = $2, surname = $3, email = $4, email_verified = $5 where id = $1"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, user.ID, user.Name, user.Surname, user.Email, user.EmailVerified); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution updating user profile query error")
+
+		return errors.Wrap(err, "execution updating user profile query error")
+	}
+
+	return nil
+}
+
+// GetUsersList returns the users matching the filter.
+func (r Users) GetUsersList(ctx context.Context, filter domain.UserFilter, paginator domain.Paginator, ordering domain.Orderings) ([]domain.User, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Users",
+		"method":     "GetUsersList",
+		"filter":     filter,
+	}
+
+	qb := squirrel.Select(userColumns).From("users u")
+
+	if filter.Email != "" {
+		qb = qb.Where("u.email ILIKE ?", "%"+escapeLike(filter.Email)+"%")
+	}
+
+	if filter.Name != "" {
+		qb = qb.Where("(u.name || ' ' || u.surname) ILIKE ?", "%"+escapeLike(filter.Name)+"%")
+	}
+
+	if filter.Blocked != nil {
+		qb = qb.Where("u.blocked = ?", *filter.Blocked)
+	}
+
+	if filter.Role != "" {
+		qb = qb.Where("EXISTS (SELECT 1 FROM user_roles ur INNER JOIN roles r ON r.id = ur.role_id WHERE ur.user_id = u.id AND r.name = ?)", filter.Role)
+	}
+
+	if !filter.RegisteredFrom.IsZero() {
+		qb = qb.Where("u.registered_at >= ?", filter.RegisteredFrom)
+	}
+
+	if !filter.RegisteredTo.IsZero() {
+		qb = qb.Where("u.registered_at < ?", filter.RegisteredTo)
+	}
+
+	// the fields are sorted, so the order does not depend on the map iteration,
+	// and the id breaks the ties, so the pages neither repeat nor skip users
+	fieldNames := make([]string, 0, len(ordering))
+	for field := range ordering {
+		fieldNames = append(fieldNames, field)
+	}
+	sort.Strings(fieldNames)
+
+	parts := make([]string, 0, len(fieldNames)+1)
+	for _, field := range fieldNames {
+		parts = append(parts, fmt.Sprintf("u.%s %s", field, strings.ToUpper(ordering[field])))
+	}
+
+	if _, ok := ordering["id"]; !ok {
+		parts = append(parts, "u.id ASC")
+	}
+
+	qb = qb.OrderBy(parts...)
+
+	query, params, err := qb.Limit(uint64(paginator.PerPage)).
+		Offset(uint64((paginator.Page - 1) * paginator.PerPage)).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("building users list query error")
+
+		return nil, errors.Wrap(err, "building users list query error")
+	}
+
+	var users []models.User
+	if err := conn(ctx, r.db).SelectContext(ctx, &users, query, params...); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting users list query error")
+
+		return nil, errors.Wrap(err, "execution getting users list query error")
+	}
+
+	domainUsers := make([]domain.User, 0, len(users))
+	for _, user := range users {
+		domainUsers = append(domainUsers, user.ToDomain())
+	}
+
+	return domainUsers, nil
+}
+
+// escapeLike escapes the wildcards of the LIKE pattern, so the value is matched literally.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
@@ -0,0 +1,353 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/lukinairina90/banking_backend/internal/repository/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	webhookSubscriptionColumns = "id, user_id, url, event_types, secret, created_at"
+	webhookDeliveryColumns     = "d.id, d.subscription_id, d.event_id, e.type AS event_type, d.status, d.attempts, d.next_attempt_at, d.last_error, d.created_at, d.updated_at"
+)
+
+// Webhooks repository layer struct.
+type Webhooks struct {
+	db *sqlx.DB
+}
+
+// NewWebhooks constructor for Webhooks repository layer.
+func NewWebhooks(db *sqlx.DB) *Webhooks {
+	return &Webhooks{db: db}
+}
+
+// CreateSubscription creates the webhook subscription and returns it, a subscription without the user gets the events of all users.
+func (r Webhooks) CreateSubscription(ctx context.Context, subscription domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Webhooks",
+		"method":     "CreateSubscription",
+		"user_id":    subscription.UserID,
+		"url":        subscription.URL,
+	}
+
+	eventTypes := make([]string, 0, len(subscription.EventTypes))
+	for _, eventType := range subscription.EventTypes {
+		eventTypes = append(eventTypes, eventType.String())
+	}
+
+	var mSubscription models.WebhookSubscription
+
+	query := "INSERT INTO webhook_subscriptions (user_id, url, event_types, secret) VALUES (NULLIF($1, 0), $2, $3::event_type[], $4) " +
+		"RETURNING " + webhookSubscriptionColumns
+
+	if err := conn(ctx, r.db).GetContext(ctx, &mSubscription, query, subscription.UserID, subscription.URL, pq.Array(eventTypes), subscription.Secret); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution inserting webhook subscription query error")
+
+		return domain.WebhookSubscription{}, errors.Wrap(err, "execution inserting webhook subscription query error")
+	}
+
+	return mSubscription.ToDomain()
+}
+
+// GetSubscriptions returns the webhook subscriptions of the user, zero userID returns the subscriptions to the events of all users.
+func (r Webhooks) GetSubscriptions(ctx context.Context, userID int) ([]domain.WebhookSubscription, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Webhooks",
+		"method":     "GetSubscriptions",
+		"user_id":    userID,
+	}
+
+	var mSubscriptions []models.WebhookSubscription
+
+	query := "SELECT " + webhookSubscriptionColumns + " FROM webhook_subscriptions WHERE user_id IS NOT DISTINCT FROM NULLIF($1, 0) ORDER BY id"
+
+	if err := conn(ctx, r.db).SelectContext(ctx, &mSubscriptions, query, userID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting webhook subscriptions query error")
+
+		return nil, errors.Wrap(err, "execution getting webhook subscriptions query error")
+	}
+
+	subscriptions := make([]domain.WebhookSubscription, 0, len(mSubscriptions))
+	for _, mSubscription := range mSubscriptions {
+		subscription, err := mSubscription.ToDomain()
+		if err != nil {
+			logrus.WithError(err).
+				WithFields(fields).
+				Error("converting webhook subscription error")
+
+			return nil, errors.Wrap(err, "converting webhook subscription error")
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, nil
+}
+
+// GetSubscription returns the webhook subscription by ID.
+func (r Webhooks) GetSubscription(ctx context.Context, id int) (domain.WebhookSubscription, error) {
+	fields := logrus.Fields{
+		"layer":           "repository",
+		"repository":      "Webhooks",
+		"method":          "GetSubscription",
+		"subscription_id": id,
+	}
+
+	var mSubscription models.WebhookSubscription
+
+	query := "SELECT " + webhookSubscriptionColumns + " FROM webhook_subscriptions WHERE id = $1"
+
+	if err := conn(ctx, r.db).GetContext(ctx, &mSubscription, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.WebhookSubscription{}, domain.ErrWebhookNotFound
+		}
+
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting webhook subscription query error")
+
+		return domain.WebhookSubscription{}, errors.Wrap(err, "execution getting webhook subscription query error")
+	}
+
+	return mSubscription.ToDomain()
+}
+
+// DeleteSubscription deletes the webhook subscription with its deliveries.
+func (r Webhooks) DeleteSubscription(ctx context.Context, id int) error {
+	fields := logrus.Fields{
+		"layer":           "repository",
+		"repository":      "Webhooks",
+		"method":          "DeleteSubscription",
+		"subscription_id": id,
+	}
+
+	query := "DELETE FROM webhook_subscriptions WHERE id = $1"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution deleting webhook subscription query error")
+
+		return errors.Wrap(err, "execution deleting webhook subscription query error")
+	}
+
+	return nil
+}
+
+// GetDeliveries returns the latest deliveries of the subscription, at most limit of them.
+func (r Webhooks) GetDeliveries(ctx context.Context, subscriptionID, limit int) ([]domain.WebhookDelivery, error) {
+	fields := logrus.Fields{
+		"layer":           "repository",
+		"repository":      "Webhooks",
+		"method":          "GetDeliveries",
+		"subscription_id": subscriptionID,
+	}
+
+	var mDeliveries []models.WebhookDelivery
+
+	query := "SELECT " + webhookDeliveryColumns + " FROM webhook_deliveries d INNER JOIN event e ON e.id = d.event_id " +
+		"WHERE d.subscription_id = $1 ORDER BY d.id DESC LIMIT $2"
+
+	if err := conn(ctx, r.db).SelectContext(ctx, &mDeliveries, query, subscriptionID, limit); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting webhook deliveries query error")
+
+		return nil, errors.Wrap(err, "execution getting webhook deliveries query error")
+	}
+
+	deliveries := make([]domain.WebhookDelivery, 0, len(mDeliveries))
+	for _, mDelivery := range mDeliveries {
+		delivery, err := mDelivery.ToDomain()
+		if err != nil {
+			logrus.WithError(err).
+				WithFields(fields).
+				Error("converting webhook delivery error")
+
+			return nil, errors.Wrap(err, "converting webhook delivery error")
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+// GetDelivery returns the webhook delivery by ID.
+func (r Webhooks) GetDelivery(ctx context.Context, id int) (domain.WebhookDelivery, error) {
+	fields := logrus.Fields{
+		"layer":       "repository",
+		"repository":  "Webhooks",
+		"method":      "GetDelivery",
+		"delivery_id": id,
+	}
+
+	var mDelivery models.WebhookDelivery
+
+	query := "SELECT " + webhookDeliveryColumns + " FROM webhook_deliveries d INNER JOIN event e ON e.id = d.event_id WHERE d.id = $1"
+
+	if err := conn(ctx, r.db).GetContext(ctx, &mDelivery, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.WebhookDelivery{}, domain.ErrWebhookDeliveryNotFound
+		}
+
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting webhook delivery query error")
+
+		return domain.WebhookDelivery{}, errors.Wrap(err, "execution getting webhook delivery query error")
+	}
+
+	return mDelivery.ToDomain()
+}
+
+// GetDeliveryAttempts returns the attempts of the delivery in the order they were made.
+func (r Webhooks) GetDeliveryAttempts(ctx context.Context, deliveryID int) ([]domain.WebhookDeliveryAttempt, error) {
+	fields := logrus.Fields{
+		"layer":       "repository",
+		"repository":  "Webhooks",
+		"method":      "GetDeliveryAttempts",
+		"delivery_id": deliveryID,
+	}
+
+	var mAttempts []models.WebhookDeliveryAttempt
+
+	query := "SELECT id, delivery_id, status_code, error, duration_ms, attempted_at FROM webhook_delivery_attempts WHERE delivery_id = $1 ORDER BY id"
+
+	if err := conn(ctx, r.db).SelectContext(ctx, &mAttempts, query, deliveryID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution getting webhook delivery attempts query error")
+
+		return nil, errors.Wrap(err, "execution getting webhook delivery attempts query error")
+	}
+
+	attempts := make([]domain.WebhookDeliveryAttempt, 0, len(mAttempts))
+	for _, mAttempt := range mAttempts {
+		attempts = append(attempts, mAttempt.ToDomain())
+	}
+
+	return attempts, nil
+}
+
+// Redeliver queues the delivery again with a fresh attempts budget, it is attempted by the next dispatch.
+func (r Webhooks) Redeliver(ctx context.Context, id int) error {
+	fields := logrus.Fields{
+		"layer":       "repository",
+		"repository":  "Webhooks",
+		"method":      "Redeliver",
+		"delivery_id": id,
+	}
+
+	query := "UPDATE webhook_deliveries SET status = $1, attempts = 0, next_attempt_at = NOW(), updated_at = NOW() WHERE id = $2"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, domain.WebhookDeliveryPending.String(), id); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution requeuing webhook delivery query error")
+
+		return errors.Wrap(err, "execution requeuing webhook delivery query error")
+	}
+
+	return nil
+}
+
+// ClaimDueDeliveries returns at most limit pending deliveries which are due and postpones them by lease,
+// so other instances do not dispatch them at the same time. A delivery left unfinished is attempted again after the lease.
+func (r Webhooks) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDispatch, error) {
+	fields := logrus.Fields{
+		"layer":      "repository",
+		"repository": "Webhooks",
+		"method":     "ClaimDueDeliveries",
+	}
+
+	var mDispatches []models.WebhookDispatch
+
+	query := "WITH claimed AS (" +
+		"UPDATE webhook_deliveries SET next_attempt_at = NOW() + $3 * INTERVAL '1 millisecond' WHERE id IN (" +
+		"SELECT id FROM webhook_deliveries WHERE status = $1 AND next_attempt_at <= NOW() ORDER BY next_attempt_at LIMIT $2 FOR UPDATE SKIP LOCKED" +
+		") RETURNING *) " +
+		"SELECT " + webhookDeliveryColumns + ", s.url, s.secret, e.user_id AS event_user_id, COALESCE(e.metadata, '{}') AS event_metadata, e.time AS event_time " +
+		"FROM claimed d INNER JOIN webhook_subscriptions s ON s.id = d.subscription_id INNER JOIN event e ON e.id = d.event_id ORDER BY d.next_attempt_at, d.id"
+
+	if err := conn(ctx, r.db).SelectContext(ctx, &mDispatches, query, domain.WebhookDeliveryPending.String(), limit, lease.Milliseconds()); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution claiming due webhook deliveries query error")
+
+		return nil, errors.Wrap(err, "execution claiming due webhook deliveries query error")
+	}
+
+	dispatches := make([]domain.WebhookDispatch, 0, len(mDispatches))
+	for _, mDispatch := range mDispatches {
+		dispatch, err := mDispatch.ToDomain()
+		if err != nil {
+			logrus.WithError(err).
+				WithFields(fields).
+				Error("converting webhook dispatch error")
+
+			return nil, errors.Wrap(err, "converting webhook dispatch error")
+		}
+
+		dispatches = append(dispatches, dispatch)
+	}
+
+	return dispatches, nil
+}
+
+// CreateDeliveryAttempt records the delivery attempt.
+func (r Webhooks) CreateDeliveryAttempt(ctx context.Context, attempt domain.WebhookDeliveryAttempt) error {
+	fields := logrus.Fields{
+		"layer":       "repository",
+		"repository":  "Webhooks",
+		"method":      "CreateDeliveryAttempt",
+		"delivery_id": attempt.DeliveryID,
+	}
+
+	query := "INSERT INTO webhook_delivery_attempts (delivery_id, status_code, error, duration_ms) VALUES ($1, $2, $3, $4)"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, attempt.DeliveryID, attempt.StatusCode, attempt.Error, attempt.Duration.Milliseconds()); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution inserting webhook delivery attempt query error")
+
+		return errors.Wrap(err, "execution inserting webhook delivery attempt query error")
+	}
+
+	return nil
+}
+
+// UpdateDelivery saves the status, the attempts, the next attempt time and the last error of the delivery.
+func (r Webhooks) UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	fields := logrus.Fields{
+		"layer":       "repository",
+		"repository":  "Webhooks",
+		"method":      "UpdateDelivery",
+		"delivery_id": delivery.ID,
+	}
+
+	query := "UPDATE webhook_deliveries SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, updated_at = NOW() WHERE id = $5"
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, delivery.Status.String(), delivery.Attempts, delivery.NextAttemptAt, delivery.LastError, delivery.ID); err != nil {
+		logrus.WithError(err).
+			WithFields(fields).
+			Error("execution updating webhook delivery query error")
+
+		return errors.Wrap(err, "execution updating webhook delivery query error")
+	}
+
+	return nil
+}
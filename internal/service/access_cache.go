@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// names of the access caches, they prefix the cache keys
+const (
+	rolesCacheName        = "roles"
+	blockStatusCacheName  = "block_status"
+	revokedTokenCacheName = "revoked_token"
+	tokenVersionCacheName = "token_version"
+)
+
+// AccessCache business logic layer struct caching the roles, the block status and the access token version of the users
+// and the revocation of the access tokens, all checked on every request.
+// Lookups fall back to the database when the cache backend fails, so a broken cache only slows the requests down.
+// The entries are removed when the cached data change, entries of other instances sharing no backend expire after ttl.
+type AccessCache struct {
+	backend      CacheBackend
+	userRepo     UsersRepository
+	roleRepo     RolesRepository
+	revokedRepo  RevokedTokenRepository
+	ttl          time.Duration
+	roles        cacheCounters
+	blockStatus  cacheCounters
+	revokedToken cacheCounters
+	tokenVersion cacheCounters
+}
+
+// cacheCounters lookup counters of a cache.
+type cacheCounters struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+	errors atomic.Int64
+}
+
+// NewAccessCache constructor for AccessCache.
+func NewAccessCache(backend CacheBackend, userRepo UsersRepository, roleRepo RolesRepository, revokedRepo RevokedTokenRepository, ttl time.Duration) *AccessCache {
+	return &AccessCache{
+		backend:     backend,
+		userRepo:    userRepo,
+		roleRepo:    roleRepo,
+		revokedRepo: revokedRepo,
+		ttl:         ttl,
+	}
+}
+
+// GetUserRoles returns the roles of the user.
+func (c *AccessCache) GetUserRoles(ctx context.Context, userID int) ([]domain.Role, error) {
+	key := cacheKey(rolesCacheName, userID)
+
+	if value, ok := c.get(ctx, key, &c.roles); ok {
+		var roles []domain.Role
+		if err := json.Unmarshal(value, &roles); err == nil {
+			return roles, nil
+		}
+	}
+
+	roles, err := c.roleRepo.GetUserRoles(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting user roles error")
+	}
+
+	value, err := json.Marshal(roles)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshaling user roles error")
+	}
+
+	c.set(ctx, key, value, &c.roles)
+
+	return roles, nil
+}
+
+// CheckBlockUser checks if the user is blocked.
+func (c *AccessCache) CheckBlockUser(ctx context.Context, userID int) (bool, error) {
+	key := cacheKey(blockStatusCacheName, userID)
+
+	if value, ok := c.get(ctx, key, &c.blockStatus); ok {
+		if blocked, err := strconv.ParseBool(string(value)); err == nil {
+			return blocked, nil
+		}
+	}
+
+	blocked, err := c.userRepo.CheckBlockUser(ctx, userID)
+	if err != nil {
+		return false, errors.Wrap(err, "check block user error")
+	}
+
+	c.set(ctx, key, []byte(strconv.FormatBool(blocked)), &c.blockStatus)
+
+	return blocked, nil
+}
+
+// IsRevoked checks if the access token with the provided ID is revoked.
+func (c *AccessCache) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	key := revokedTokenCacheKey(jti)
+
+	if value, ok := c.get(ctx, key, &c.revokedToken); ok {
+		if revoked, err := strconv.ParseBool(string(value)); err == nil {
+			return revoked, nil
+		}
+	}
+
+	revoked, err := c.revokedRepo.IsRevoked(ctx, jti)
+	if err != nil {
+		return false, errors.Wrap(err, "checking token revocation error")
+	}
+
+	c.set(ctx, key, []byte(strconv.FormatBool(revoked)), &c.revokedToken)
+
+	return revoked, nil
+}
+
+// GetTokenVersion returns the access token version of the user.
+func (c *AccessCache) GetTokenVersion(ctx context.Context, userID int) (int, error) {
+	key := cacheKey(tokenVersionCacheName, userID)
+
+	if value, ok := c.get(ctx, key, &c.tokenVersion); ok {
+		if version, err := strconv.Atoi(string(value)); err == nil {
+			return version, nil
+		}
+	}
+
+	version, err := c.userRepo.GetTokenVersion(ctx, userID)
+	if err != nil {
+		return 0, errors.Wrap(err, "getting token version error")
+	}
+
+	c.set(ctx, key, []byte(strconv.Itoa(version)), &c.tokenVersion)
+
+	return version, nil
+}
+
+// InvalidateRoles removes the cached roles of the user.
+func (c *AccessCache) InvalidateRoles(ctx context.Context, userID int) {
+	c.delete(ctx, cacheKey(rolesCacheName, userID), &c.roles)
+}
+
+// InvalidateBlockStatus removes the cached block status of the user.
+func (c *AccessCache) InvalidateBlockStatus(ctx context.Context, userID int) {
+	c.delete(ctx, cacheKey(blockStatusCacheName, userID), &c.blockStatus)
+}
+
+// InvalidateRevokedToken removes the cached revocation of the access token.
+func (c *AccessCache) InvalidateRevokedToken(ctx context.Context, jti string) {
+	c.delete(ctx, revokedTokenCacheKey(jti), &c.revokedToken)
+}
+
+// InvalidateTokenVersion removes the cached access token version of the user.
+func (c *AccessCache) InvalidateTokenVersion(ctx context.Context, userID int) {
+	c.delete(ctx, cacheKey(tokenVersionCacheName, userID), &c.tokenVersion)
+}
+
+// GetStats returns the statistics of the access caches.
+func (c *AccessCache) GetStats(_ context.Context) []domain.CacheStats {
+	return []domain.CacheStats{
+		c.roles.stats(rolesCacheName),
+		c.blockStatus.stats(blockStatusCacheName),
+		c.revokedToken.stats(revokedTokenCacheName),
+		c.tokenVersion.stats(tokenVersionCacheName),
+	}
+}
+
+// get returns the cached value of the key and counts the lookup.
+func (c *AccessCache) get(ctx context.Context, key string, counters *cacheCounters) ([]byte, bool) {
+	value, ok, err := c.backend.Get(ctx, key)
+	if err != nil {
+		counters.errors.Add(1)
+		c.logError(err, "Get", key, "getting cache value error")
+	}
+
+	if !ok {
+		counters.misses.Add(1)
+		return nil, false
+	}
+
+	counters.hits.Add(1)
+
+	return value, true
+}
+
+// set caches the value of the key for ttl.
+func (c *AccessCache) set(ctx context.Context, key string, value []byte, counters *cacheCounters) {
+	if err := c.backend.Set(ctx, key, value, c.ttl); err != nil {
+		counters.errors.Add(1)
+		c.logError(err, "Set", key, "setting cache value error")
+	}
+}
+
+// delete removes the key, a failed removal leaves the stale value until it expires.
+func (c *AccessCache) delete(ctx context.Context, key string, counters *cacheCounters) {
+	if err := c.backend.Delete(ctx, key); err != nil {
+		counters.errors.Add(1)
+		c.logError(err, "Delete", key, "deleting cache value error")
+	}
+}
+
+// logError logs the failed cache backend call.
+func (c *AccessCache) logError(err error, method, key, message string) {
+	logrus.WithError(err).
+		WithFields(logrus.Fields{
+			"layer":   "service",
+			"service": "AccessCache",
+			"method":  method,
+			"key":     key,
+		}).
+		Error(message)
+}
+
+// stats returns the statistics of the cache.
+func (c *cacheCounters) stats(name string) domain.CacheStats {
+	stats := domain.CacheStats{
+		Name:   name,
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Errors: c.errors.Load(),
+	}
+
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(lookups)
+	}
+
+	return stats
+}
+
+// cacheKey returns the cache key of the user in the cache.
+func cacheKey(cache string, userID int) string {
+	return "access:" + cache + ":" + strconv.Itoa(userID)
+}
+
+// revokedTokenCacheKey returns the cache key of the access token in the revocation cache.
+func revokedTokenCacheKey(jti string) string {
+	return "access:" + revokedTokenCacheName + ":" + jti
+}
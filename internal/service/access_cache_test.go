@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessCache_GetUserRoles(t *testing.T) {
+	controller := gomock.NewController(t)
+	cacheBackendMock := NewMockCacheBackend(controller)
+	roleRepositoryMock := NewMockRolesRepository(controller)
+
+	ctx := context.Background()
+	userID := 1
+	key := "access:roles:1"
+	ttl := time.Minute
+	roles := []domain.Role{{ID: 2, Name: "user"}, {ID: 3, Name: "support"}}
+	cached := []byte(`[{"ID":2,"Name":"user"},{"ID":3,"Name":"support"}]`)
+	testError := errors.New("test error")
+
+	tests := []struct {
+		name          string
+		configureMock func()
+		want          []domain.Role
+		wantStats     domain.CacheStats
+		wantErr       error
+	}{
+		{
+			name: "hit",
+			configureMock: func() {
+				cacheBackendMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(key)).Return(cached, true, nil)
+			},
+			want:      roles,
+			wantStats: domain.CacheStats{Name: "roles", Hits: 1, HitRatio: 1},
+		},
+		{
+			name: "miss",
+			configureMock: func() {
+				cacheBackendMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(key)).Return(nil, false, nil)
+				roleRepositoryMock.EXPECT().GetUserRoles(gomock.Eq(ctx), gomock.Eq(userID)).Return(roles, nil)
+				cacheBackendMock.EXPECT().Set(gomock.Eq(ctx), gomock.Eq(key), gomock.Eq(cached), gomock.Eq(ttl)).Return(nil)
+			},
+			want:      roles,
+			wantStats: domain.CacheStats{Name: "roles", Misses: 1},
+		},
+		{
+			name: "backend_error_falls_back_to_repository",
+			configureMock: func() {
+				cacheBackendMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(key)).Return(nil, false, testError)
+				roleRepositoryMock.EXPECT().GetUserRoles(gomock.Eq(ctx), gomock.Eq(userID)).Return(roles, nil)
+				cacheBackendMock.EXPECT().Set(gomock.Eq(ctx), gomock.Eq(key), gomock.Eq(cached), gomock.Eq(ttl)).Return(testError)
+			},
+			want:      roles,
+			wantStats: domain.CacheStats{Name: "roles", Misses: 1, Errors: 2},
+		},
+		{
+			name: "repository_error",
+			configureMock: func() {
+				cacheBackendMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(key)).Return(nil, false, nil)
+				roleRepositoryMock.EXPECT().GetUserRoles(gomock.Eq(ctx), gomock.Eq(userID)).Return(nil, testError)
+			},
+			wantErr: testError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			c := NewAccessCache(cacheBackendMock, nil, roleRepositoryMock, nil, ttl)
+
+			got, err := c.GetUserRoles(ctx, userID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantStats, c.GetStats(ctx)[0])
+		})
+	}
+}
+
+func TestAccessCache_CheckBlockUser(t *testing.T) {
+	controller := gomock.NewController(t)
+	cacheBackendMock := NewMockCacheBackend(controller)
+	userRepositoryMock := NewMockUsersRepository(controller)
+
+	ctx := context.Background()
+	userID := 1
+	key := "access:block_status:1"
+	ttl := time.Minute
+
+	c := NewAccessCache(cacheBackendMock, userRepositoryMock, nil, nil, ttl)
+
+	// the first check reads the database, the second one is answered by the cache
+	cacheBackendMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(key)).Return(nil, false, nil)
+	userRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(userID)).Return(true, nil)
+	cacheBackendMock.EXPECT().Set(gomock.Eq(ctx), gomock.Eq(key), gomock.Eq([]byte("true")), gomock.Eq(ttl)).Return(nil)
+	cacheBackendMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(key)).Return([]byte("true"), true, nil)
+
+	for i := 0; i < 2; i++ {
+		blocked, err := c.CheckBlockUser(ctx, userID)
+		assert.NoError(t, err)
+		assert.True(t, blocked)
+	}
+
+	cacheBackendMock.EXPECT().Delete(gomock.Eq(ctx), gomock.Eq(key)).Return(nil)
+	c.InvalidateBlockStatus(ctx, userID)
+
+	assert.Equal(t, domain.CacheStats{Name: "block_status", Hits: 1, Misses: 1, HitRatio: 0.5}, c.GetStats(ctx)[1])
+}
+
+func TestAccessCache_IsRevoked(t *testing.T) {
+	controller := gomock.NewController(t)
+	cacheBackendMock := NewMockCacheBackend(controller)
+	revokedTokenRepositoryMock := NewMockRevokedTokenRepository(controller)
+
+	ctx := context.Background()
+	jti := "token-id"
+	key := "access:revoked_token:token-id"
+	ttl := time.Minute
+
+	c := NewAccessCache(cacheBackendMock, nil, nil, revokedTokenRepositoryMock, ttl)
+
+	// the first check reads the database, the second one is answered by the cache
+	cacheBackendMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(key)).Return(nil, false, nil)
+	revokedTokenRepositoryMock.EXPECT().IsRevoked(gomock.Eq(ctx), gomock.Eq(jti)).Return(false, nil)
+	cacheBackendMock.EXPECT().Set(gomock.Eq(ctx), gomock.Eq(key), gomock.Eq([]byte("false")), gomock.Eq(ttl)).Return(nil)
+	cacheBackendMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(key)).Return([]byte("false"), true, nil)
+
+	for i := 0; i < 2; i++ {
+		revoked, err := c.IsRevoked(ctx, jti)
+		assert.NoError(t, err)
+		assert.False(t, revoked)
+	}
+
+	// the revocation removes the cached state, the next check reads the database again
+	cacheBackendMock.EXPECT().Delete(gomock.Eq(ctx), gomock.Eq(key)).Return(nil)
+	c.InvalidateRevokedToken(ctx, jti)
+
+	cacheBackendMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(key)).Return(nil, false, nil)
+	revokedTokenRepositoryMock.EXPECT().IsRevoked(gomock.Eq(ctx), gomock.Eq(jti)).Return(true, nil)
+	cacheBackendMock.EXPECT().Set(gomock.Eq(ctx), gomock.Eq(key), gomock.Eq([]byte("true")), gomock.Eq(ttl)).Return(nil)
+
+	revoked, err := c.IsRevoked(ctx, jti)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	assert.Equal(t, domain.CacheStats{Name: "revoked_token", Hits: 1, Misses: 2, HitRatio: 1.0 / 3}, c.GetStats(ctx)[2])
+}
+
+func TestAccessCache_GetTokenVersion(t *testing.T) {
+	controller := gomock.NewController(t)
+	cacheBackendMock := NewMockCacheBackend(controller)
+	userRepositoryMock := NewMockUsersRepository(controller)
+
+	ctx := context.Background()
+	userID := 1
+	key := "access:token_version:1"
+	ttl := time.Minute
+	testError := errors.New("test error")
+
+	tests := []struct {
+		name          string
+		configureMock func()
+		want          int
+		wantErr       error
+	}{
+		{
+			name: "hit",
+			configureMock: func() {
+				cacheBackendMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(key)).Return([]byte("4"), true, nil)
+			},
+			want: 4,
+		},
+		{
+			name: "miss",
+			configureMock: func() {
+				cacheBackendMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(key)).Return(nil, false, nil)
+				userRepositoryMock.EXPECT().GetTokenVersion(gomock.Eq(ctx), gomock.Eq(userID)).Return(5, nil)
+				cacheBackendMock.EXPECT().Set(gomock.Eq(ctx), gomock.Eq(key), gomock.Eq([]byte("5")), gomock.Eq(ttl)).Return(nil)
+			},
+			want: 5,
+		},
+		{
+			name: "malformed_value_read_from_repository",
+			configureMock: func() {
+				cacheBackendMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(key)).Return([]byte("x"), true, nil)
+				userRepositoryMock.EXPECT().GetTokenVersion(gomock.Eq(ctx), gomock.Eq(userID)).Return(5, nil)
+				cacheBackendMock.EXPECT().Set(gomock.Eq(ctx), gomock.Eq(key), gomock.Eq([]byte("5")), gomock.Eq(ttl)).Return(nil)
+			},
+			want: 5,
+		},
+		{
+			name: "repository_error",
+			configureMock: func() {
+				cacheBackendMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(key)).Return(nil, false, nil)
+				userRepositoryMock.EXPECT().GetTokenVersion(gomock.Eq(ctx), gomock.Eq(userID)).Return(0, testError)
+			},
+			wantErr: testError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			c := NewAccessCache(cacheBackendMock, userRepositoryMock, nil, nil, ttl)
+
+			got, err := c.GetTokenVersion(ctx, userID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	c := NewAccessCache(cacheBackendMock, userRepositoryMock, nil, nil, ttl)
+	cacheBackendMock.EXPECT().Delete(gomock.Eq(ctx), gomock.Eq(key)).Return(nil)
+	c.InvalidateTokenVersion(ctx, userID)
+}
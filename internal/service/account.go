@@ -2,28 +2,51 @@ package service
 
 import (
 	"context"
+	"math/big"
 
 	"github.com/lukinairina90/banking_backend/internal/domain"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
-const fromAccountIDForDeposit = 0
+const (
+	fromAccountIDForDeposit  = 0
+	toAccountIDForWithdrawal = 0
+)
 
 // Account business logic layer struct.
 type Account struct {
+	transactor      Transactor
 	accountRepo     AccountRepository
 	transactionRepo TransactionRepository
+	ledgerRepo      LedgerRepository
+	currencyRepo    CurrencyRepository
+	exchanger       CurrencyExchanger
 	eventRepo       EventRepository
+	usersRepo       UsersRepository
+	stepUp          StepUpVerifier
 	ibanGenerator   RandomGenerator
+
+	// blockedReceiveOnly allows blocked accounts and accounts of blocked users to receive money.
+	blockedReceiveOnly bool
 }
 
 // NewAccount constructor for Account.
-func NewAccount(accountRepo AccountRepository, transactionRepo TransactionRepository, eventRepository EventRepository, ibanGenerator RandomGenerator) *Account {
+// Blocked accounts can not send money, they can not receive it either unless blockedReceiveOnly is set.
+func NewAccount(transactor Transactor, accountRepo AccountRepository, transactionRepo TransactionRepository, ledgerRepo LedgerRepository, currencyRepo CurrencyRepository, exchanger CurrencyExchanger, eventRepository EventRepository, usersRepo UsersRepository, stepUp StepUpVerifier, ibanGenerator RandomGenerator, blockedReceiveOnly bool) *Account {
 	return &Account{
+		transactor:      transactor,
 		accountRepo:     accountRepo,
 		transactionRepo: transactionRepo,
+		ledgerRepo:      ledgerRepo,
+		currencyRepo:    currencyRepo,
+		exchanger:       exchanger,
 		eventRepo:       eventRepository,
+		usersRepo:       usersRepo,
+		stepUp:          stepUp,
 		ibanGenerator:   ibanGenerator,
+
+		blockedReceiveOnly: blockedReceiveOnly,
 	}
 }
 
@@ -95,149 +118,462 @@ func (s Account) DeleteAccount(ctx context.Context, accountID int) error {
 }
 
 // DepositAccount replenishes the user's account by account id for the provided amount.
-func (s Account) DepositAccount(ctx context.Context, accountID int, amount float64) error {
-	exists, err := s.accountRepo.ExistsAccount(ctx, accountID)
+// The transaction is first recorded as PREPARED and then settled inside a separate database transaction, which locks the account
+// again and rechecks that neither it nor its owner is blocked. If settlement fails the transaction is marked FAILED with the reason.
+func (s Account) DepositAccount(ctx context.Context, accountID int, rawAmount string) error {
+	var transaction domain.Transaction
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		account, err := s.accountRepo.LockAccount(ctx, accountID)
+		if err != nil {
+			return errors.Wrap(err, "locking account error")
+		}
+
+		if err := s.checkCanReceive(ctx, account); err != nil {
+			return errors.Wrap(err, "checking account can receive money error")
+		}
+
+		amount, err := s.parseAmount(ctx, account.CurrencyID, rawAmount)
+		if err != nil {
+			return errors.Wrap(err, "parsing amount error")
+		}
+
+		transaction, err = s.transactionRepo.CreateTransaction(ctx, domain.Transaction{
+			FromAccount: fromAccountIDForDeposit,
+			ToAccount:   account.ID,
+			Amount:      amount,
+			ToAmount:    amount,
+		})
+		if err != nil {
+			return errors.Wrap(err, "transaction created error")
+		}
+
+		return nil
+	})
 	if err != nil {
-		return errors.Wrap(err, "account existence check error")
+		return err
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		// the account or its owner may have been blocked since the transaction was prepared
+		account, err := s.accountRepo.LockAccount(ctx, transaction.ToAccount)
+		if err != nil {
+			return errors.Wrap(err, "locking account error")
+		}
+
+		if err := s.checkCanReceive(ctx, account); err != nil {
+			return errors.Wrap(err, "checking account can receive money error")
+		}
+
+		if err := s.transactionRepo.UpdateTransactionStatus(ctx, transaction.ID, domain.TransactionSent, ""); err != nil {
+			return errors.Wrap(err, "set transaction status to sent error")
+		}
+
+		if _, err := s.ledgerRepo.CreateJournalEntry(ctx, domain.NewDepositJournalEntry(transaction.ID, transaction.ToAccount, transaction.Amount)); err != nil {
+			return errors.Wrap(err, "deposit journal entry creation error")
+		}
+
+		// the deposit is made by the admin, the event belongs to the owner of the account
+		event := domain.Event{
+			UserID:  account.UserID,
+			Type:    domain.DepositEvent,
+			Message: "deposit account successful",
+			Metadata: map[string]any{
+				"account_id":     transaction.ToAccount,
+				"transaction_id": transaction.ID,
+				"amount":         transaction.Amount.String(),
+				"currency":       transaction.Amount.Currency,
+			},
+		}
+
+		if err := s.eventRepo.CreateEvent(ctx, event); err != nil {
+			return errors.Wrap(err, "account deposit event deposit error")
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.failTransaction(ctx, transaction.ID, err)
+		return err
 	}
 
-	if !exists {
-		return errors.New("account does not exist error")
+	return nil
+}
+
+// WithdrawAccount takes the provided amount of cash out of the user's account.
+// The transaction has no recipient account, mirroring deposits, and is recorded as PREPARED first and then
+// settled inside a separate database transaction with the account row locked.
+// If settlement fails the transaction is marked FAILED with the reason.
+func (s Account) WithdrawAccount(ctx context.Context, accountID, userID int, rawAmount string) error {
+	var transaction domain.Transaction
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		account, err := s.accountRepo.LockAccount(ctx, accountID)
+		if err != nil {
+			return errors.Wrap(err, "locking account error")
+		}
+
+		if account.UserID != userID {
+			return errors.Wrap(domain.ErrNotAccountOwner, "userID matching check error")
+		}
+
+		if account.Blocked {
+			return errors.Wrap(domain.ErrAccountBlocked, "checking account is not blocked error")
+		}
+
+		amount, err := s.parseAmount(ctx, account.CurrencyID, rawAmount)
+		if err != nil {
+			return errors.Wrap(err, "parsing amount error")
+		}
+
+		transaction, err = s.transactionRepo.CreateTransaction(ctx, domain.Transaction{
+			FromAccount: account.ID,
+			ToAccount:   toAccountIDForWithdrawal,
+			Amount:      amount,
+			ToAmount:    amount,
+		})
+		if err != nil {
+			return errors.Wrap(err, "transaction created error")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		account, err := s.accountRepo.LockAccount(ctx, transaction.FromAccount)
+		if err != nil {
+			return errors.Wrap(err, "locking account error")
+		}
+
+		if account.Blocked {
+			return errors.Wrap(domain.ErrAccountBlocked, "checking account is not blocked error")
+		}
+
+		cmp, err := account.Amount.Cmp(transaction.Amount)
+		if err != nil {
+			return errors.Wrap(err, "comparing account amount error")
+		}
+
+		if cmp < 0 {
+			return errors.Wrap(domain.ErrInsufficientFunds, "checking enough money in the account for the withdrawal error")
+		}
+
+		if err := s.transactionRepo.UpdateTransactionStatus(ctx, transaction.ID, domain.TransactionSent, ""); err != nil {
+			return errors.Wrap(err, "set transaction status to sent error")
+		}
+
+		if _, err := s.ledgerRepo.CreateJournalEntry(ctx, domain.NewWithdrawalJournalEntry(transaction.ID, account.ID, transaction.Amount)); err != nil {
+			return errors.Wrap(err, "withdrawal journal entry creation error")
+		}
+
+		event := domain.Event{
+			UserID:  userID,
+			Type:    domain.WithdrawalEvent,
+			Message: "withdrawal from account successful",
+			Metadata: map[string]any{
+				"account_id":     account.ID,
+				"transaction_id": transaction.ID,
+				"amount":         transaction.Amount.String(),
+				"currency":       transaction.Amount.Currency,
+			},
+		}
+
+		if err := s.eventRepo.CreateEvent(ctx, event); err != nil {
+			return errors.Wrap(err, "account withdrawal event creation error")
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.failTransaction(ctx, transaction.ID, err)
+		return err
 	}
 
-	transaction, err := s.transactionRepo.CreateTransaction(ctx, fromAccountIDForDeposit, accountID, amount)
+	return nil
+}
+
+// TransferAccount transfer money from the user's account to another account.
+// The amount is taken in the sender currency and exchanged into the recipient currency if they differ,
+// by the rate locked with the quote if quoteID is provided or by the current rate otherwise.
+// The transaction is first recorded as PREPARED and then settled inside a separate database transaction,
+// both account rows are locked in a stable order, so concurrent transfers can not overdraw the account.
+// If settlement fails the transaction is marked FAILED with the reason.
+// Large transfers of users with two-factor authentication enabled require the two-factor code,
+// it is verified before the transfer transaction, so a wrong code stays counted although the transfer fails.
+func (s Account) TransferAccount(ctx context.Context, fromAccountID, userID int, rawAmount string, toAccountIban string, quoteID int, twoFactorCode string) error {
+	account, err := s.accountRepo.GetAccount(ctx, fromAccountID, userID)
 	if err != nil {
-		return errors.Wrap(err, "transaction created error")
+		return errors.Wrap(err, "getting account error")
 	}
 
-	err = s.accountRepo.DepositAccount(ctx, accountID, amount)
+	amount, err := s.parseAmount(ctx, account.CurrencyID, rawAmount)
+	if err != nil {
+		return errors.Wrap(err, "parsing amount error")
+	}
+
+	if err := s.stepUp.VerifyStepUp(ctx, userID, amount, twoFactorCode); err != nil {
+		return errors.Wrap(err, "step-up verification error")
+	}
+
+	var transaction domain.Transaction
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		toAccountID, err := s.accountRepo.GetAccountIDByIban(ctx, toAccountIban)
+		if err != nil {
+			return errors.Wrap(err, "getting accountID by iban error")
+		}
+
+		if toAccountID == fromAccountID {
+			return errors.Wrap(domain.ErrSameAccountTransfer, "transfer to the same account error")
+		}
+
+		fromAccount, toAccount, err := s.lockAccounts(ctx, fromAccountID, toAccountID)
+		if err != nil {
+			return errors.Wrap(err, "locking accounts error")
+		}
+
+		if fromAccount.UserID != userID {
+			return errors.Wrap(domain.ErrNotAccountOwner, "userID matching check error")
+		}
+
+		if fromAccount.Blocked {
+			return errors.Wrap(domain.ErrAccountBlocked, "checking account is not blocked error")
+		}
+
+		if err := s.checkCanReceive(ctx, toAccount); err != nil {
+			return errors.Wrap(err, "checking recipient account can receive money error")
+		}
+
+		toAmount, exchangeRate, err := s.exchange(ctx, userID, quoteID, amount, fromAccount.CurrencyID, toAccount.CurrencyID)
+		if err != nil {
+			return errors.Wrap(err, "currency exchange error")
+		}
+
+		transaction, err = s.transactionRepo.CreateTransaction(ctx, domain.Transaction{
+			FromAccount:  fromAccount.ID,
+			ToAccount:    toAccount.ID,
+			Amount:       amount,
+			ToAmount:     toAmount,
+			ExchangeRate: exchangeRate,
+		})
+		if err != nil {
+			return errors.Wrap(err, "transaction created error")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		fromAccount, toAccount, err := s.lockAccounts(ctx, transaction.FromAccount, transaction.ToAccount)
+		if err != nil {
+			return errors.Wrap(err, "locking accounts error")
+		}
+
+		// the accounts may have been blocked since the transaction was prepared
+		if fromAccount.Blocked {
+			return errors.Wrap(domain.ErrAccountBlocked, "checking account is not blocked error")
+		}
+
+		if toAccount.Blocked && !s.blockedReceiveOnly {
+			return errors.Wrap(domain.ErrRecipientAccountBlocked, "checking recipient account is not blocked error")
+		}
+
+		cmp, err := fromAccount.Amount.Cmp(transaction.Amount)
+		if err != nil {
+			return errors.Wrap(err, "comparing account amount error")
+		}
+
+		if cmp < 0 {
+			return errors.Wrap(domain.ErrInsufficientFunds, "checking enough money in the account for the transfer error")
+		}
+
+		if err := s.transactionRepo.UpdateTransactionStatus(ctx, transaction.ID, domain.TransactionSent, ""); err != nil {
+			return errors.Wrap(err, "set transaction status to sent error")
+		}
+
+		entry := domain.NewTransferJournalEntry(transaction.ID, transaction.FromAccount, transaction.ToAccount, transaction.Amount, transaction.ToAmount)
+		if _, err := s.ledgerRepo.CreateJournalEntry(ctx, entry); err != nil {
+			return errors.Wrap(err, "transfer journal entry creation error")
+		}
+
+		event := domain.Event{
+			UserID:  userID,
+			Type:    domain.TransferEvent,
+			Message: "money transfer successful",
+			Metadata: map[string]any{
+				"from_account_id": transaction.FromAccount,
+				"to_account_id":   transaction.ToAccount,
+				"transaction_id":  transaction.ID,
+				"amount":          transaction.Amount.String(),
+				"currency":        transaction.Amount.Currency,
+			},
+		}
+
+		if transaction.ExchangeRate != nil {
+			event.Metadata["to_amount"] = transaction.ToAmount.String()
+			event.Metadata["to_currency"] = transaction.ToAmount.Currency
+			event.Metadata["exchange_rate"] = domain.FormatRate(transaction.ExchangeRate)
+		}
+
+		if err := s.eventRepo.CreateEvent(ctx, event); err != nil {
+			return errors.Wrap(err, "account transfer event creation error")
+		}
+
+		return nil
+	})
 	if err != nil {
-		return errors.Wrap(err, "deposit account error")
+		s.failTransaction(ctx, transaction.ID, err)
+		return err
 	}
 
-	if err := s.transactionRepo.SetTransactionStatusToSent(ctx, transaction.ID); err != nil {
-		return errors.Wrap(err, "set transaction status to sent error")
+	return nil
+}
+
+// BlockAccount blocks the user account to the provided account ID and user ID.
+func (s Account) BlockAccount(ctx context.Context, accountID, userID int) error {
+	err := s.accountRepo.BlockAccount(ctx, accountID, userID)
+	if err != nil {
+		return errors.Wrap(err, "blocking account error")
 	}
 
 	event := domain.Event{
-		Type:    domain.DepositEvent,
-		Message: "deposit account successful",
+		UserID:  userID,
+		Type:    domain.AccountBlockedEvent,
+		Message: "account blocked successfully",
 		Metadata: map[string]any{
 			"account_id": accountID,
-			"amount":     amount,
 		},
 	}
 
-	if err := s.eventRepo.CreateEvent(ctx, event); err != nil {
-		return errors.Wrap(err, "account deposit event deposit error")
+	if err = s.eventRepo.CreateEvent(ctx, event); err != nil {
+		return errors.Wrap(err, "account blocking event blocking error")
 	}
 
 	return nil
 }
 
-// TransferAccount transfer money from the user's account to another account with the same currency ID.
-func (s Account) TransferAccount(ctx context.Context, fromAccountID, userID int, amount float64, toAccountIban string) error {
-	fromCurrency, err := s.accountRepo.GetAccountCurrencyIDByID(ctx, fromAccountID)
+// UnblockAccount unblocks the account with the provided ID on behalf of the admin, the account of any user is unblocked.
+// The event is recorded for the owner of the account.
+func (s Account) UnblockAccount(ctx context.Context, accountID, adminID int) error {
+	ownerID, err := s.accountRepo.UnblockAccount(ctx, accountID)
 	if err != nil {
-		return errors.Wrap(err, "getting account currencyID by ID error")
+		return errors.Wrap(err, "unblocking account error")
 	}
 
-	toCurrency, err := s.accountRepo.GetAccountCurrencyIDByIban(ctx, toAccountIban)
-	if err != nil {
-		return errors.Wrap(err, "getting account currencyID by iban error")
+	event := domain.Event{
+		UserID:  ownerID,
+		Type:    domain.AccountUnblockedEvent,
+		Message: "account unblocked successfully",
+		Metadata: map[string]any{
+			"account_id": accountID,
+			"admin_id":   adminID,
+		},
 	}
 
-	if fromCurrency != toCurrency {
-		return errors.New("currency matching check error")
+	if err := s.eventRepo.CreateEvent(ctx, event); err != nil {
+		return errors.Wrap(err, "account unblocking event unblocking error")
 	}
 
-	enough, err := s.accountRepo.GetAccountAmount(ctx, fromAccountID, userID)
-	if err != nil {
-		return errors.Wrap(err, "getting account amount error")
+	return nil
+}
+
+// checkCanReceive checks that the account and its owner are not blocked, unless blocked accounts may receive money.
+func (s Account) checkCanReceive(ctx context.Context, account domain.Account) error {
+	if s.blockedReceiveOnly {
+		return nil
 	}
 
-	if enough < amount {
-		return errors.New("checking enough money in the account for the transfer error")
+	if account.Blocked {
+		return domain.ErrRecipientAccountBlocked
 	}
 
-	toAccountID, err := s.accountRepo.GetAccountIDByIban(ctx, toAccountIban)
+	blocked, err := s.usersRepo.CheckBlockUser(ctx, account.UserID)
 	if err != nil {
-		return errors.Wrap(err, "getting accountID by iban error")
+		return errors.Wrap(err, "checking account owner is blocked error")
 	}
 
-	transaction, err := s.transactionRepo.CreateTransaction(ctx, fromAccountID, toAccountID, amount)
-	if err != nil {
-		return errors.Wrap(err, "transaction created error")
+	if blocked {
+		return domain.ErrRecipientUserBlocked
 	}
 
-	if err := s.accountRepo.TransferAccount(ctx, fromAccountID, userID, amount, toAccountIban); err != nil {
-		return errors.Wrap(err, "transfer to account error")
+	return nil
+}
+
+// lockAccounts locks both accounts in ascending ID order to avoid deadlocks between concurrent transfers.
+func (s Account) lockAccounts(ctx context.Context, fromAccountID, toAccountID int) (domain.Account, domain.Account, error) {
+	firstID, secondID := fromAccountID, toAccountID
+	if firstID > secondID {
+		firstID, secondID = secondID, firstID
 	}
 
-	if err := s.transactionRepo.SetTransactionStatusToSent(ctx, transaction.ID); err != nil {
-		return errors.Wrap(err, "set transaction status to sent error")
+	first, err := s.accountRepo.LockAccount(ctx, firstID)
+	if err != nil {
+		return domain.Account{}, domain.Account{}, err
 	}
 
-	event := domain.Event{
-		UserID:  userID,
-		Type:    domain.WithdrawalEvent,
-		Message: "money transfer successful",
-		Metadata: map[string]any{
-			"from_account_id": fromAccountID,
-			"to_account_id":   toAccountID,
-			"amount":          amount,
-		},
+	second, err := s.accountRepo.LockAccount(ctx, secondID)
+	if err != nil {
+		return domain.Account{}, domain.Account{}, err
 	}
 
-	if err := s.eventRepo.CreateEvent(ctx, event); err != nil {
-		return errors.Wrap(err, "account transfer event withdrawal error")
+	if first.ID == fromAccountID {
+		return first, second, nil
 	}
 
-	return nil
+	return second, first, nil
 }
 
-// BlockAccount blocks the user account to the provided account ID and user ID.
-func (s Account) BlockAccount(ctx context.Context, accountID, userID int) error {
-	err := s.accountRepo.BlockAccount(ctx, accountID, userID)
-	if err != nil {
-		return errors.Wrap(err, "blocking account error")
+// failTransaction marks the prepared transaction as FAILED with the reason of the settlement error.
+// It is best effort, a transaction left in PREPARED is resolved by the TransactionSweeper later.
+func (s Account) failTransaction(ctx context.Context, transactionID int, cause error) {
+	if err := s.transactionRepo.UpdateTransactionStatus(ctx, transactionID, domain.TransactionFailed, failureReason(cause)); err != nil {
+		logrus.WithError(err).
+			WithFields(logrus.Fields{
+				"layer":          "service",
+				"service":        "Account",
+				"method":         "failTransaction",
+				"transaction_id": transactionID,
+			}).
+			Error("marking transaction as failed error")
 	}
+}
 
-	event := domain.Event{
-		UserID:  userID,
-		Type:    domain.AccountBlockedEvent,
-		Message: "account blocked successfully",
-		Metadata: map[string]any{
-			"account_id": accountID,
-		},
+// exchange returns the amount the recipient account receives and the applied exchange rate, which is nil if no exchange is needed.
+func (s Account) exchange(ctx context.Context, userID, quoteID int, amount domain.Money, fromCurrencyID, toCurrencyID int) (domain.Money, *big.Rat, error) {
+	if fromCurrencyID == toCurrencyID {
+		if quoteID != 0 {
+			return domain.Money{}, nil, domain.ErrQuoteMismatch
+		}
+
+		return amount, nil, nil
 	}
 
-	if err = s.eventRepo.CreateEvent(ctx, event); err != nil {
-		return errors.Wrap(err, "account blocking event blocking error")
+	toCurrency, err := s.currencyRepo.GetByID(ctx, toCurrencyID)
+	if err != nil {
+		return domain.Money{}, nil, errors.Wrap(err, "getting recipient currency error")
 	}
 
-	return nil
+	return s.exchanger.Exchange(ctx, userID, quoteID, amount, toCurrency)
 }
 
-// UnblockAccount unblocks the user account to the provided account ID and user ID.
-func (s Account) UnblockAccount(ctx context.Context, accountID, userID int) error {
-	err := s.accountRepo.UnblockAccount(ctx, accountID, userID)
+// parseAmount parses the provided decimal amount in the currency with provided ID and checks that it is positive.
+func (s Account) parseAmount(ctx context.Context, currencyID int, rawAmount string) (domain.Money, error) {
+	currency, err := s.currencyRepo.GetByID(ctx, currencyID)
 	if err != nil {
-		return errors.Wrap(err, "unblocking account error")
+		return domain.Money{}, errors.Wrap(err, "getting currency by ID error")
 	}
 
-	event := domain.Event{
-		UserID:  userID,
-		Type:    domain.AccountUnblockedEvent,
-		Message: "account unblocked successfully",
-		Metadata: map[string]any{
-			"account_id": accountID,
-		},
+	amount, err := domain.ParseMoney(rawAmount, currency)
+	if err != nil {
+		return domain.Money{}, err
 	}
 
-	if err := s.eventRepo.CreateEvent(ctx, event); err != nil {
-		return errors.Wrap(err, "account unblocking event unblocking error")
+	if !amount.IsPositive() {
+		return domain.Money{}, domain.ErrNonPositiveAmount
 	}
 
-	return nil
+	return amount, nil
 }
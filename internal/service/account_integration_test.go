@@ -0,0 +1,53 @@
+//go:build integration
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/lukinairina90/banking_backend/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIntegration_AdminUnblocksAccountOfOtherUser runs against the migrated database from TEST_DB_DSN (see make test-integration).
+func TestIntegration_AdminUnblocksAccountOfOtherUser(t *testing.T) {
+	db := integrationDB(t)
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+
+	var ownerID int
+	err := db.GetContext(ctx, &ownerID, "INSERT INTO users (name, surname, email, password, blocked, registered_at, email_verified) "+
+		"VALUES ('blocked', 'owner', $1, 'password', false, NOW(), true) RETURNING id", fmt.Sprintf("unblock-%d@example.com", suffix))
+	require.NoError(t, err)
+
+	var currencyID int
+	require.NoError(t, db.GetContext(ctx, &currencyID, "SELECT id FROM currency WHERE code = 'USD'"))
+
+	var accountID int
+	err = db.GetContext(ctx, &accountID, "INSERT INTO accounts (iban, user_id, currency_id, blocked) VALUES ($1, $2, $3, true) RETURNING id",
+		fmt.Sprintf("UA%d", suffix), ownerID, currencyID)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM event WHERE user_id = $1", ownerID)
+		db.Exec("DELETE FROM accounts WHERE user_id = $1", ownerID)
+		db.Exec("DELETE FROM users WHERE id = $1", ownerID)
+	})
+
+	s := NewAccount(repository.NewTransactor(db), repository.NewAccount(db), nil, nil, nil, nil, repository.NewEvent(db), nil, nil, nil, false)
+
+	adminID := ownerID + 1
+	require.NoError(t, s.UnblockAccount(ctx, accountID, adminID))
+
+	var blocked bool
+	require.NoError(t, db.GetContext(ctx, &blocked, "SELECT blocked FROM accounts WHERE id = $1", accountID))
+	assert.False(t, blocked)
+
+	err = s.UnblockAccount(ctx, accountID+1000000, adminID)
+	assert.ErrorIs(t, err, domain.ErrAccountNotFound)
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"math/big"
 	"testing"
 	"time"
 
@@ -30,7 +31,7 @@ func TestAccount_Create(t *testing.T) {
 		UserID:     userID,
 		CurrencyID: currencyID,
 		Blocked:    false,
-		Amount:     0,
+		Amount:     domain.Money{Currency: "USD", Precision: 2},
 	}
 
 	event := domain.Event{
@@ -126,7 +127,7 @@ func TestAccount_Create(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.configureMock()
 
-			a := NewAccount(tt.fields.accountRepo, nil, tt.fields.eventRepo, tt.fields.ibanGenerator)
+			a := NewAccount(nil, tt.fields.accountRepo, nil, nil, nil, nil, tt.fields.eventRepo, nil, nil, tt.fields.ibanGenerator, false)
 			got, err := a.Create(tt.args.ctx, tt.args.userID, tt.args.currencyID)
 			assert.Equal(t, tt.wantErr, err != nil)
 			assert.Equal(t, tt.want, got)
@@ -151,7 +152,7 @@ func TestAccount_GetAccountsList(t *testing.T) {
 		UserID:     userID,
 		CurrencyID: currencyID,
 		Blocked:    false,
-		Amount:     0,
+		Amount:     domain.Money{Currency: "USD", Precision: 2},
 	}
 
 	account2 := domain.Account{
@@ -160,7 +161,7 @@ func TestAccount_GetAccountsList(t *testing.T) {
 		UserID:     userID,
 		CurrencyID: currencyID,
 		Blocked:    false,
-		Amount:     0,
+		Amount:     domain.Money{Currency: "USD", Precision: 2},
 	}
 
 	accountList := []domain.Account{account1, account2}
@@ -233,7 +234,7 @@ func TestAccount_GetAccountsList(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.configureMock()
 
-			a := NewAccount(tt.fields.accountRepo, nil, nil, nil)
+			a := NewAccount(nil, tt.fields.accountRepo, nil, nil, nil, nil, nil, nil, nil, nil, false)
 			got, err := a.GetAccountsList(tt.args.ctx, tt.args.userID, tt.args.paginator, tt.args.ordering)
 			assert.Equal(t, tt.wantErr, err != nil)
 			assert.Equal(t, tt.want, got)
@@ -243,302 +244,661 @@ func TestAccount_GetAccountsList(t *testing.T) {
 
 func TestAccount_TransferAccount(t *testing.T) {
 	controller := gomock.NewController(t)
+	transactorMock := NewMockTransactor(controller)
 	accountRepositoryMock := NewMockAccountRepository(controller)
 	eventRepositoryMock := NewMockEventRepository(controller)
 	transactionRepositoryMock := NewMockTransactionRepository(controller)
+	ledgerRepositoryMock := NewMockLedgerRepository(controller)
+	usersRepositoryMock := NewMockUsersRepository(controller)
+	currencyRepositoryMock := NewMockCurrencyRepository(controller)
+	exchangerMock := NewMockCurrencyExchanger(controller)
+	stepUpVerifierMock := NewMockStepUpVerifier(controller)
 
 	ctx := context.Background()
 	fromAccountID := 1
 	toAccountID := 2
 	userID := 1
-	amount := float64(60)
+	currency := domain.Currency{ID: 1, Name: "US Dollar", Code: "USD", Precision: 2}
+	rawAmount := "60.25"
+	amount := domain.NewMoney(6025, currency)
 	toAccountIban := "UA031234560000039096125330468"
 
 	testError := errors.New("test error")
-	fromAccountCurrencyID := 1
-	toAccountCurrencyID := 1
+
+	fromAccount := domain.Account{
+		ID:         fromAccountID,
+		Iban:       "UA031234560000039096125330467",
+		UserID:     userID,
+		CurrencyID: currency.ID,
+		Amount:     domain.NewMoney(10000, currency),
+	}
+	toAccount := domain.Account{
+		ID:         toAccountID,
+		Iban:       toAccountIban,
+		UserID:     2,
+		CurrencyID: currency.ID,
+		Amount:     domain.NewMoney(0, currency),
+	}
+
+	blockedFromAccount := fromAccount
+	blockedFromAccount.Blocked = true
+
+	blockedToAccount := toAccount
+	blockedToAccount.Blocked = true
+
+	poorAccount := fromAccount
+	poorAccount.Amount = domain.NewMoney(6024, currency)
+
+	uahCurrency := domain.Currency{ID: 2, Name: "Ukrainian hryvnia", Code: "UAH", Precision: 2}
+	uahAccount := toAccount
+	uahAccount.CurrencyID = uahCurrency.ID
+	uahAccount.Amount = domain.NewMoney(0, uahCurrency)
+	exchangeRate := big.NewRat(367155, 10000)
+	uahAmount := domain.NewMoney(221207, uahCurrency)
+
 	transaction := domain.Transaction{
 		ID:          1,
 		FromAccount: fromAccountID,
 		ToAccount:   toAccountID,
 		Amount:      amount,
-		Status:      "PREPARED",
+		ToAmount:    amount,
+		Status:      domain.TransactionPrepared,
 		DateCreated: time.Now(),
 	}
 
 	event := domain.Event{
 		UserID:  userID,
-		Type:    domain.WithdrawalEvent,
+		Type:    domain.TransferEvent,
 		Message: "money transfer successful",
 		Metadata: map[string]any{
 			"from_account_id": fromAccountID,
 			"to_account_id":   toAccountID,
-			"amount":          amount,
+			"transaction_id":  transaction.ID,
+			"amount":          "60.25",
+			"currency":        "USD",
 		},
 	}
 
-	type fields struct {
-		accountRepo     AccountRepository
-		transactionRepo TransactionRepository
-		eventRepo       EventRepository
+	newTransaction := domain.Transaction{
+		FromAccount: fromAccountID,
+		ToAccount:   toAccountID,
+		Amount:      amount,
+		ToAmount:    amount,
+	}
+
+	journalEntry := domain.NewTransferJournalEntry(transaction.ID, fromAccountID, toAccountID, amount, amount)
+
+	withinTransaction := func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
 	}
+
 	type args struct {
 		ctx           context.Context
 		fromAccountID int
 		userID        int
-		amount        float64
+		amount        string
 		toAccountIban string
+		quoteID       int
+		twoFactorCode string
 	}
 	tests := []struct {
-		name          string
-		fields        fields
-		args          args
-		configureMock func()
-		wantErr       bool
+		name               string
+		args               args
+		blockedReceiveOnly bool
+		configureMock      func()
+		wantErr            bool
 	}{
 		{
-			name: "getting_account_currency_by_id_error",
-			fields: fields{
-				accountRepo:     accountRepositoryMock,
-				transactionRepo: transactionRepositoryMock,
-				eventRepo:       eventRepositoryMock,
+			name: "getting_account_error",
+			args: args{
+				ctx:           ctx,
+				fromAccountID: fromAccountID,
+				userID:        userID,
+				amount:        rawAmount,
+				toAccountIban: toAccountIban,
+			},
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(domain.Account{}, testError)
 			},
+			wantErr: true,
+		},
+		{
+			name: "transaction_begin_error",
 			args: args{
 				ctx:           ctx,
 				fromAccountID: fromAccountID,
 				userID:        userID,
-				amount:        amount,
+				amount:        rawAmount,
 				toAccountIban: toAccountIban,
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByID(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(0, testError)
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).Return(testError)
 			},
 			wantErr: true,
 		},
 		{
-			name: "getting_account_currency_by_iban_error",
-			fields: fields{
-				accountRepo:     accountRepositoryMock,
-				transactionRepo: transactionRepositoryMock,
-				eventRepo:       eventRepositoryMock,
+			name: "getting_account_id_by_iban_error",
+			args: args{
+				ctx:           ctx,
+				fromAccountID: fromAccountID,
+				userID:        userID,
+				amount:        rawAmount,
+				toAccountIban: toAccountIban,
+			},
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(0, testError)
 			},
+			wantErr: true,
+		},
+		{
+			name: "transfer_to_the_same_account_error",
 			args: args{
 				ctx:           ctx,
 				fromAccountID: fromAccountID,
 				userID:        userID,
-				amount:        amount,
+				amount:        rawAmount,
 				toAccountIban: toAccountIban,
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByID(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccountCurrencyID, nil)
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(0, testError)
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(fromAccountID, nil)
 			},
 			wantErr: true,
 		},
 		{
-			name: "account_currencies_mismatching",
-			fields: fields{
-				accountRepo:     accountRepositoryMock,
-				transactionRepo: transactionRepositoryMock,
-				eventRepo:       eventRepositoryMock,
+			name: "locking_account_error",
+			args: args{
+				ctx:           ctx,
+				fromAccountID: fromAccountID,
+				userID:        userID,
+				amount:        rawAmount,
+				toAccountIban: toAccountIban,
+			},
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountID, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(domain.Account{}, testError)
+			},
+			wantErr: true,
+		},
+		{
+			name: "account_of_other_user_error",
+			args: args{
+				ctx:           ctx,
+				fromAccountID: fromAccountID,
+				userID:        3,
+				amount:        rawAmount,
+				toAccountIban: toAccountIban,
 			},
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(3)).Return(domain.Account{}, domain.ErrAccountNotFound)
+			},
+			wantErr: true,
+		},
+		{
+			name: "sender_account_blocked_error",
 			args: args{
 				ctx:           ctx,
 				fromAccountID: fromAccountID,
 				userID:        userID,
-				amount:        amount,
+				amount:        rawAmount,
 				toAccountIban: toAccountIban,
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByID(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccountCurrencyID, nil)
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(2, nil)
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountID, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(blockedFromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(toAccount, nil)
 			},
 			wantErr: true,
 		},
 		{
-			name: "getting_from_account_amount_error",
-			fields: fields{
-				accountRepo:     accountRepositoryMock,
-				transactionRepo: transactionRepositoryMock,
-				eventRepo:       eventRepositoryMock,
+			name: "recipient_account_blocked_error",
+			args: args{
+				ctx:           ctx,
+				fromAccountID: fromAccountID,
+				userID:        userID,
+				amount:        rawAmount,
+				toAccountIban: toAccountIban,
+			},
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountID, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(blockedToAccount, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "recipient_user_blocked_error",
+			args: args{
+				ctx:           ctx,
+				fromAccountID: fromAccountID,
+				userID:        userID,
+				amount:        rawAmount,
+				toAccountIban: toAccountIban,
+			},
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountID, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(toAccount, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(toAccount.UserID)).Return(true, nil)
 			},
+			wantErr: true,
+		},
+		{
+			name: "checking_recipient_user_blocked_error",
 			args: args{
 				ctx:           ctx,
 				fromAccountID: fromAccountID,
 				userID:        userID,
-				amount:        amount,
+				amount:        rawAmount,
 				toAccountIban: toAccountIban,
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByID(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccountCurrencyID, nil)
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountCurrencyID, nil)
-				accountRepositoryMock.EXPECT().GetAccountAmount(gomock.Eq(ctx), gomock.Eq(fromAccountCurrencyID), gomock.Eq(userID)).Return(float64(0), testError)
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountID, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(toAccount, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(toAccount.UserID)).Return(false, testError)
 			},
 			wantErr: true,
 		},
 		{
-			name: "not_enough_money_error",
-			fields: fields{
-				accountRepo:     accountRepositoryMock,
-				transactionRepo: transactionRepositoryMock,
-				eventRepo:       eventRepositoryMock,
+			name: "blocked_recipient_receive_only_success",
+			args: args{
+				ctx:           ctx,
+				fromAccountID: fromAccountID,
+				userID:        userID,
+				amount:        rawAmount,
+				toAccountIban: toAccountIban,
 			},
+			blockedReceiveOnly: true,
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountID, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(blockedToAccount, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(transaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(blockedToAccount, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionSent), gomock.Eq("")).Return(nil)
+				ledgerRepositoryMock.EXPECT().CreateJournalEntry(gomock.Eq(ctx), gomock.Eq(journalEntry)).Return(journalEntry, nil)
+				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Eq(event)).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "step_up_verification_error",
 			args: args{
 				ctx:           ctx,
 				fromAccountID: fromAccountID,
 				userID:        userID,
-				amount:        amount,
+				amount:        rawAmount,
 				toAccountIban: toAccountIban,
+				twoFactorCode: "123456",
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByID(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccountCurrencyID, nil)
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountCurrencyID, nil)
-				accountRepositoryMock.EXPECT().GetAccountAmount(gomock.Eq(ctx), gomock.Eq(fromAccountCurrencyID), gomock.Eq(userID)).Return(float64(50), nil)
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("123456")).Return(domain.ErrInvalidTwoFactorCode)
 			},
 			wantErr: true,
 		},
 		{
-			name: "getting_account_id_by_iban_error",
-			fields: fields{
-				accountRepo:     accountRepositoryMock,
-				transactionRepo: transactionRepositoryMock,
-				eventRepo:       eventRepositoryMock,
+			name: "amount_precision_error",
+			args: args{
+				ctx:           ctx,
+				fromAccountID: fromAccountID,
+				userID:        userID,
+				amount:        "60.251",
+				toAccountIban: toAccountIban,
+			},
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
 			},
+			wantErr: true,
+		},
+		{
+			name: "settlement_begin_error",
 			args: args{
 				ctx:           ctx,
 				fromAccountID: fromAccountID,
 				userID:        userID,
-				amount:        amount,
+				amount:        rawAmount,
 				toAccountIban: toAccountIban,
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByID(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccountCurrencyID, nil)
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountCurrencyID, nil)
-				accountRepositoryMock.EXPECT().GetAccountAmount(gomock.Eq(ctx), gomock.Eq(fromAccountCurrencyID), gomock.Eq(userID)).Return(float64(100), nil)
-				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(0, testError)
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountID, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(toAccount, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(toAccount.UserID)).Return(false, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(transaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).Return(testError)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionFailed), gomock.Any()).Return(nil)
 			},
 			wantErr: true,
 		},
 		{
-			name: "creating_transaction_error",
-			fields: fields{
-				accountRepo:     accountRepositoryMock,
-				transactionRepo: transactionRepositoryMock,
-				eventRepo:       eventRepositoryMock,
+			name: "settlement_locking_accounts_error",
+			args: args{
+				ctx:           ctx,
+				fromAccountID: fromAccountID,
+				userID:        userID,
+				amount:        rawAmount,
+				toAccountIban: toAccountIban,
+			},
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountID, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(toAccount, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(toAccount.UserID)).Return(false, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(transaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(domain.Account{}, testError)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionFailed), gomock.Any()).Return(nil)
 			},
+			wantErr: true,
+		},
+		{
+			name: "not_enough_money_error",
 			args: args{
 				ctx:           ctx,
 				fromAccountID: fromAccountID,
 				userID:        userID,
-				amount:        amount,
+				amount:        rawAmount,
 				toAccountIban: toAccountIban,
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByID(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccountCurrencyID, nil)
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountCurrencyID, nil)
-				accountRepositoryMock.EXPECT().GetAccountAmount(gomock.Eq(ctx), gomock.Eq(fromAccountCurrencyID), gomock.Eq(userID)).Return(float64(100), nil)
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
 				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountID, nil)
-				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(toAccountID), gomock.Eq(amount)).Return(domain.Transaction{}, testError)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(toAccount, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(toAccount.UserID)).Return(false, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(transaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(poorAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(toAccount, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionFailed), gomock.Any()).Return(nil)
 			},
 			wantErr: true,
 		},
 		{
 			name: "creating_transaction_error",
-			fields: fields{
-				accountRepo:     accountRepositoryMock,
-				transactionRepo: transactionRepositoryMock,
-				eventRepo:       eventRepositoryMock,
+			args: args{
+				ctx:           ctx,
+				fromAccountID: fromAccountID,
+				userID:        userID,
+				amount:        rawAmount,
+				toAccountIban: toAccountIban,
+			},
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountID, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(toAccount, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(toAccount.UserID)).Return(false, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(domain.Transaction{}, testError)
 			},
+			wantErr: true,
+		},
+		{
+			name: "creating_journal_entry_error",
 			args: args{
 				ctx:           ctx,
 				fromAccountID: fromAccountID,
 				userID:        userID,
-				amount:        amount,
+				amount:        rawAmount,
 				toAccountIban: toAccountIban,
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByID(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccountCurrencyID, nil)
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountCurrencyID, nil)
-				accountRepositoryMock.EXPECT().GetAccountAmount(gomock.Eq(ctx), gomock.Eq(fromAccountCurrencyID), gomock.Eq(userID)).Return(float64(100), nil)
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
 				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountID, nil)
-				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(toAccountID), gomock.Eq(amount)).Return(transaction, nil)
-				accountRepositoryMock.EXPECT().TransferAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq(toAccountIban)).Return(testError)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(toAccount, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(toAccount.UserID)).Return(false, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(transaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(toAccount, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionSent), gomock.Eq("")).Return(nil)
+				ledgerRepositoryMock.EXPECT().CreateJournalEntry(gomock.Eq(ctx), gomock.Eq(journalEntry)).Return(domain.JournalEntry{}, testError)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionFailed), gomock.Any()).Return(nil)
 			},
 			wantErr: true,
 		},
 		{
 			name: "updating_transaction_status_error",
-			fields: fields{
-				accountRepo:     accountRepositoryMock,
-				transactionRepo: transactionRepositoryMock,
-				eventRepo:       eventRepositoryMock,
-			},
 			args: args{
 				ctx:           ctx,
 				fromAccountID: fromAccountID,
 				userID:        userID,
-				amount:        amount,
+				amount:        rawAmount,
 				toAccountIban: toAccountIban,
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByID(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccountCurrencyID, nil)
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountCurrencyID, nil)
-				accountRepositoryMock.EXPECT().GetAccountAmount(gomock.Eq(ctx), gomock.Eq(fromAccountCurrencyID), gomock.Eq(userID)).Return(float64(100), nil)
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
 				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountID, nil)
-				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(toAccountID), gomock.Eq(amount)).Return(transaction, nil)
-				accountRepositoryMock.EXPECT().TransferAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq(toAccountIban)).Return(nil)
-				transactionRepositoryMock.EXPECT().SetTransactionStatusToSent(gomock.Eq(ctx), gomock.Eq(transaction.ID)).Return(testError)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(toAccount, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(toAccount.UserID)).Return(false, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(transaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(toAccount, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionSent), gomock.Eq("")).Return(domain.ErrInvalidTransactionStatusTransition)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionFailed), gomock.Any()).Return(nil)
 			},
 			wantErr: true,
 		},
 		{
 			name: "creating_event_error",
-			fields: fields{
-				accountRepo:     accountRepositoryMock,
-				transactionRepo: transactionRepositoryMock,
-				eventRepo:       eventRepositoryMock,
-			},
 			args: args{
 				ctx:           ctx,
 				fromAccountID: fromAccountID,
 				userID:        userID,
-				amount:        amount,
+				amount:        rawAmount,
 				toAccountIban: toAccountIban,
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByID(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccountCurrencyID, nil)
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountCurrencyID, nil)
-				accountRepositoryMock.EXPECT().GetAccountAmount(gomock.Eq(ctx), gomock.Eq(fromAccountCurrencyID), gomock.Eq(userID)).Return(float64(100), nil)
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
 				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountID, nil)
-				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(toAccountID), gomock.Eq(amount)).Return(transaction, nil)
-				accountRepositoryMock.EXPECT().TransferAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq(toAccountIban)).Return(nil)
-				transactionRepositoryMock.EXPECT().SetTransactionStatusToSent(gomock.Eq(ctx), gomock.Eq(transaction.ID)).Return(nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(toAccount, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(toAccount.UserID)).Return(false, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(transaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(toAccount, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionSent), gomock.Eq("")).Return(nil)
+				ledgerRepositoryMock.EXPECT().CreateJournalEntry(gomock.Eq(ctx), gomock.Eq(journalEntry)).Return(journalEntry, nil)
 				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Eq(event)).Return(testError)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionFailed), gomock.Any()).Return(nil)
 			},
 			wantErr: true,
 		},
 		{
-			name: "success",
-			fields: fields{
-				accountRepo:     accountRepositoryMock,
-				transactionRepo: transactionRepositoryMock,
-				eventRepo:       eventRepositoryMock,
+			name: "quote_for_same_currency_error",
+			args: args{
+				ctx:           ctx,
+				fromAccountID: fromAccountID,
+				userID:        userID,
+				amount:        rawAmount,
+				toAccountIban: toAccountIban,
+				quoteID:       1,
+			},
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountID, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(toAccount, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(toAccount.UserID)).Return(false, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "currency_exchange_error",
+			args: args{
+				ctx:           ctx,
+				fromAccountID: fromAccountID,
+				userID:        userID,
+				amount:        rawAmount,
+				toAccountIban: toAccountIban,
+				quoteID:       1,
+			},
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountID, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(uahAccount, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(toAccount.UserID)).Return(false, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(uahCurrency.ID)).Return(uahCurrency, nil)
+				exchangerMock.EXPECT().Exchange(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(1), gomock.Eq(amount), gomock.Eq(uahCurrency)).Return(domain.Money{}, nil, domain.ErrQuoteNotFound)
+			},
+			wantErr: true,
+		},
+		{
+			name: "cross_currency_success",
+			args: args{
+				ctx:           ctx,
+				fromAccountID: fromAccountID,
+				userID:        userID,
+				amount:        rawAmount,
+				toAccountIban: toAccountIban,
+			},
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				exchangeTransaction := domain.Transaction{
+					FromAccount:  fromAccountID,
+					ToAccount:    toAccountID,
+					Amount:       amount,
+					ToAmount:     uahAmount,
+					ExchangeRate: exchangeRate,
+				}
+				createdExchangeTransaction := exchangeTransaction
+				createdExchangeTransaction.ID = transaction.ID
+				createdExchangeTransaction.Status = domain.TransactionPrepared
+				exchangeEntry := domain.NewTransferJournalEntry(transaction.ID, fromAccountID, toAccountID, amount, uahAmount)
+				exchangeEvent := domain.Event{
+					UserID:  userID,
+					Type:    domain.TransferEvent,
+					Message: "money transfer successful",
+					Metadata: map[string]any{
+						"from_account_id": fromAccountID,
+						"to_account_id":   toAccountID,
+						"transaction_id":  transaction.ID,
+						"amount":          "60.25",
+						"currency":        "USD",
+						"to_amount":       "2212.07",
+						"to_currency":     "UAH",
+						"exchange_rate":   "36.7155",
+					},
+				}
+
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountID, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(uahAccount, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(toAccount.UserID)).Return(false, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(uahCurrency.ID)).Return(uahCurrency, nil)
+				exchangerMock.EXPECT().Exchange(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(0), gomock.Eq(amount), gomock.Eq(uahCurrency)).Return(uahAmount, exchangeRate, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(exchangeTransaction)).Return(createdExchangeTransaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(uahAccount, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionSent), gomock.Eq("")).Return(nil)
+				ledgerRepositoryMock.EXPECT().CreateJournalEntry(gomock.Eq(ctx), gomock.Eq(exchangeEntry)).Return(exchangeEntry, nil)
+				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Eq(exchangeEvent)).Return(nil)
 			},
+			wantErr: false,
+		},
+		{
+			name: "success",
 			args: args{
 				ctx:           ctx,
 				fromAccountID: fromAccountID,
 				userID:        userID,
-				amount:        amount,
+				amount:        rawAmount,
 				toAccountIban: toAccountIban,
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByID(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccountCurrencyID, nil)
-				accountRepositoryMock.EXPECT().GetAccountCurrencyIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountCurrencyID, nil)
-				accountRepositoryMock.EXPECT().GetAccountAmount(gomock.Eq(ctx), gomock.Eq(fromAccountCurrencyID), gomock.Eq(userID)).Return(float64(100), nil)
+				accountRepositoryMock.EXPECT().GetAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID)).Return(fromAccount, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				stepUpVerifierMock.EXPECT().VerifyStepUp(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq("")).Return(nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
 				accountRepositoryMock.EXPECT().GetAccountIDByIban(gomock.Eq(ctx), gomock.Eq(toAccountIban)).Return(toAccountID, nil)
-				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(toAccountID), gomock.Eq(amount)).Return(transaction, nil)
-				accountRepositoryMock.EXPECT().TransferAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID), gomock.Eq(userID), gomock.Eq(amount), gomock.Eq(toAccountIban)).Return(nil)
-				transactionRepositoryMock.EXPECT().SetTransactionStatusToSent(gomock.Eq(ctx), gomock.Eq(transaction.ID)).Return(nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(toAccount, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(toAccount.UserID)).Return(false, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(transaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(fromAccountID)).Return(fromAccount, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(toAccountID)).Return(toAccount, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionSent), gomock.Eq("")).Return(nil)
+				ledgerRepositoryMock.EXPECT().CreateJournalEntry(gomock.Eq(ctx), gomock.Eq(journalEntry)).Return(journalEntry, nil)
 				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Eq(event)).Return(nil)
 			},
 			wantErr: false,
@@ -548,8 +908,8 @@ func TestAccount_TransferAccount(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.configureMock()
 
-			a := NewAccount(accountRepositoryMock, transactionRepositoryMock, eventRepositoryMock, nil)
-			err := a.TransferAccount(tt.args.ctx, tt.args.fromAccountID, tt.args.userID, tt.args.amount, tt.args.toAccountIban)
+			a := NewAccount(transactorMock, accountRepositoryMock, transactionRepositoryMock, ledgerRepositoryMock, currencyRepositoryMock, exchangerMock, eventRepositoryMock, usersRepositoryMock, stepUpVerifierMock, nil, tt.blockedReceiveOnly)
+			err := a.TransferAccount(tt.args.ctx, tt.args.fromAccountID, tt.args.userID, tt.args.amount, tt.args.toAccountIban, tt.args.quoteID, tt.args.twoFactorCode)
 			assert.Equal(t, tt.wantErr, err != nil)
 		})
 	}
@@ -570,7 +930,7 @@ func TestAccount_GetAccount(t *testing.T) {
 		UserID:     1,
 		CurrencyID: 1,
 		Blocked:    false,
-		Amount:     0,
+		Amount:     domain.Money{Currency: "USD", Precision: 2},
 	}
 
 	testError := errors.New("test error")
@@ -629,7 +989,7 @@ func TestAccount_GetAccount(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.configureMock()
 
-			a := NewAccount(tt.fields.accountRepo, nil, nil, nil)
+			a := NewAccount(nil, tt.fields.accountRepo, nil, nil, nil, nil, nil, nil, nil, nil, false)
 			got, err := a.GetAccount(tt.args.ctx, tt.args.accountID, tt.args.userID)
 			assert.Equal(t, tt.wantErr, err != nil)
 			assert.Equal(t, tt.want, got)
@@ -722,7 +1082,7 @@ func TestAccount_DeleteAccount(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.configureMock()
 
-			a := NewAccount(tt.fields.accountRepo, nil, tt.fields.eventRepo, nil)
+			a := NewAccount(nil, tt.fields.accountRepo, nil, nil, nil, nil, tt.fields.eventRepo, nil, nil, nil, false)
 			err := a.DeleteAccount(tt.args.ctx, tt.args.accountID)
 			assert.Equal(t, tt.wantErr, err != nil)
 		})
@@ -731,14 +1091,20 @@ func TestAccount_DeleteAccount(t *testing.T) {
 
 func TestAccount_DepositAccount(t *testing.T) {
 	controller := gomock.NewController(t)
+	transactorMock := NewMockTransactor(controller)
 	accountRepositoryMock := NewMockAccountRepository(controller)
 	eventRepositoryMock := NewMockEventRepository(controller)
 	transactionRepositoryMock := NewMockTransactionRepository(controller)
+	ledgerRepositoryMock := NewMockLedgerRepository(controller)
+	usersRepositoryMock := NewMockUsersRepository(controller)
+	currencyRepositoryMock := NewMockCurrencyRepository(controller)
 
 	ctx := context.Background()
 
 	accountID := 1
-	amount := float64(100)
+	currency := domain.Currency{ID: 1, Name: "US Dollar", Code: "USD", Precision: 2}
+	rawAmount := "100.50"
+	amount := domain.NewMoney(10050, currency)
 	const fromAccountIDForDeposit = 0
 
 	transaction := domain.Transaction{
@@ -746,21 +1112,49 @@ func TestAccount_DepositAccount(t *testing.T) {
 		FromAccount: fromAccountIDForDeposit,
 		ToAccount:   accountID,
 		Amount:      amount,
-		Status:      "PREPARED",
+		ToAmount:    amount,
+		Status:      domain.TransactionPrepared,
 		DateCreated: time.Now(),
 	}
 
+	account := domain.Account{
+		ID:         accountID,
+		Iban:       "UA031234560000039096125330468",
+		UserID:     1,
+		CurrencyID: currency.ID,
+		Amount:     domain.NewMoney(0, currency),
+	}
+
+	blockedAccount := account
+	blockedAccount.Blocked = true
+
 	event := domain.Event{
+		UserID:  account.UserID,
 		Type:    domain.DepositEvent,
 		Message: "deposit account successful",
 		Metadata: map[string]any{
-			"account_id": accountID,
-			"amount":     amount,
+			"account_id":     accountID,
+			"transaction_id": transaction.ID,
+			"amount":         "100.50",
+			"currency":       "USD",
 		},
 	}
 
 	testError := errors.New("test error")
 
+	newTransaction := domain.Transaction{
+		FromAccount: fromAccountIDForDeposit,
+		ToAccount:   accountID,
+		Amount:      amount,
+		ToAmount:    amount,
+	}
+
+	journalEntry := domain.NewDepositJournalEntry(transaction.ID, accountID, amount)
+
+	withinTransaction := func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}
+
 	type fields struct {
 		accountRepo     AccountRepository
 		eventRepo       EventRepository
@@ -769,7 +1163,7 @@ func TestAccount_DepositAccount(t *testing.T) {
 	type args struct {
 		ctx       context.Context
 		accountID int
-		amount    float64
+		amount    string
 	}
 	tests := []struct {
 		name          string
@@ -779,7 +1173,7 @@ func TestAccount_DepositAccount(t *testing.T) {
 		wantErr       bool
 	}{
 		{
-			name: "checking_existence_account_repository_error",
+			name: "locking_account_repository_error",
 			fields: fields{
 				accountRepo:     accountRepositoryMock,
 				eventRepo:       eventRepositoryMock,
@@ -788,10 +1182,11 @@ func TestAccount_DepositAccount(t *testing.T) {
 			args: args{
 				ctx:       ctx,
 				accountID: accountID,
-				amount:    amount,
+				amount:    rawAmount,
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().ExistsAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(false, testError)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(domain.Account{}, testError)
 			},
 			wantErr: true,
 		},
@@ -805,15 +1200,16 @@ func TestAccount_DepositAccount(t *testing.T) {
 			args: args{
 				ctx:       ctx,
 				accountID: accountID,
-				amount:    amount,
+				amount:    rawAmount,
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().ExistsAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(false, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(domain.Account{}, domain.ErrAccountNotFound)
 			},
 			wantErr: true,
 		},
 		{
-			name: "create_transaction_repository_error",
+			name: "account_blocked_error",
 			fields: fields{
 				accountRepo:     accountRepositoryMock,
 				eventRepo:       eventRepositoryMock,
@@ -822,16 +1218,16 @@ func TestAccount_DepositAccount(t *testing.T) {
 			args: args{
 				ctx:       ctx,
 				accountID: accountID,
-				amount:    amount,
+				amount:    rawAmount,
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().ExistsAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(true, nil)
-				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(fromAccountIDForDeposit), gomock.Eq(accountID), gomock.Eq(amount)).Return(domain.Transaction{}, testError)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(blockedAccount, nil)
 			},
 			wantErr: true,
 		},
 		{
-			name: "deposit_account_repository_error",
+			name: "account_owner_blocked_error",
 			fields: fields{
 				accountRepo:     accountRepositoryMock,
 				eventRepo:       eventRepositoryMock,
@@ -840,17 +1236,17 @@ func TestAccount_DepositAccount(t *testing.T) {
 			args: args{
 				ctx:       ctx,
 				accountID: accountID,
-				amount:    amount,
+				amount:    rawAmount,
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().ExistsAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(true, nil)
-				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(fromAccountIDForDeposit), gomock.Eq(accountID), gomock.Eq(amount)).Return(transaction, nil)
-				accountRepositoryMock.EXPECT().DepositAccount(gomock.Eq(ctx), gomock.Eq(accountID), gomock.Eq(amount)).Return(testError)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(account.UserID)).Return(true, nil)
 			},
 			wantErr: true,
 		},
 		{
-			name: "setting_transaction_status_transaction_error",
+			name: "getting_currency_error",
 			fields: fields{
 				accountRepo:     accountRepositoryMock,
 				eventRepo:       eventRepositoryMock,
@@ -859,18 +1255,18 @@ func TestAccount_DepositAccount(t *testing.T) {
 			args: args{
 				ctx:       ctx,
 				accountID: accountID,
-				amount:    amount,
+				amount:    rawAmount,
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().ExistsAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(true, nil)
-				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(fromAccountIDForDeposit), gomock.Eq(accountID), gomock.Eq(amount)).Return(transaction, nil)
-				accountRepositoryMock.EXPECT().DepositAccount(gomock.Eq(ctx), gomock.Eq(accountID), gomock.Eq(amount)).Return(nil)
-				transactionRepositoryMock.EXPECT().SetTransactionStatusToSent(gomock.Eq(ctx), gomock.Eq(transaction.ID)).Return(testError)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(account.UserID)).Return(false, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(domain.Currency{}, testError)
 			},
 			wantErr: true,
 		},
 		{
-			name: "event_repository_error",
+			name: "amount_precision_error",
 			fields: fields{
 				accountRepo:     accountRepositoryMock,
 				eventRepo:       eventRepositoryMock,
@@ -879,20 +1275,18 @@ func TestAccount_DepositAccount(t *testing.T) {
 			args: args{
 				ctx:       ctx,
 				accountID: accountID,
-				amount:    amount,
+				amount:    "100.505",
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().ExistsAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(true, nil)
-				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(fromAccountIDForDeposit), gomock.Eq(accountID), gomock.Eq(amount)).Return(transaction, nil)
-				accountRepositoryMock.EXPECT().DepositAccount(gomock.Eq(ctx), gomock.Eq(accountID), gomock.Eq(amount)).Return(nil)
-				transactionRepositoryMock.EXPECT().SetTransactionStatusToSent(gomock.Eq(ctx), gomock.Eq(transaction.ID)).Return(nil)
-				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Eq(event)).Return(testError)
-
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(account.UserID)).Return(false, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
 			},
 			wantErr: true,
 		},
 		{
-			name: "success",
+			name: "non_positive_amount_error",
 			fields: fields{
 				accountRepo:     accountRepositoryMock,
 				eventRepo:       eventRepositoryMock,
@@ -901,30 +1295,483 @@ func TestAccount_DepositAccount(t *testing.T) {
 			args: args{
 				ctx:       ctx,
 				accountID: accountID,
-				amount:    amount,
+				amount:    "0.00",
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().ExistsAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(true, nil)
-				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(fromAccountIDForDeposit), gomock.Eq(accountID), gomock.Eq(amount)).Return(transaction, nil)
-				accountRepositoryMock.EXPECT().DepositAccount(gomock.Eq(ctx), gomock.Eq(accountID), gomock.Eq(amount)).Return(nil)
-				transactionRepositoryMock.EXPECT().SetTransactionStatusToSent(gomock.Eq(ctx), gomock.Eq(transaction.ID)).Return(nil)
-				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Eq(event)).Return(nil)
-
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(account.UserID)).Return(false, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
 			},
-			wantErr: false,
+			wantErr: true,
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			tt.configureMock()
+		{
+			name: "create_transaction_repository_error",
+			fields: fields{
+				accountRepo:     accountRepositoryMock,
+				eventRepo:       eventRepositoryMock,
+				transactionRepo: transactionRepositoryMock,
+			},
+			args: args{
+				ctx:       ctx,
+				accountID: accountID,
+				amount:    rawAmount,
+			},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(account.UserID)).Return(false, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(domain.Transaction{}, testError)
+			},
+			wantErr: true,
+		},
+		{
+			name: "settlement_begin_error",
+			fields: fields{
+				accountRepo:     accountRepositoryMock,
+				eventRepo:       eventRepositoryMock,
+				transactionRepo: transactionRepositoryMock,
+			},
+			args: args{
+				ctx:       ctx,
+				accountID: accountID,
+				amount:    rawAmount,
+			},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(account.UserID)).Return(false, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(transaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).Return(testError)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionFailed), gomock.Any()).Return(nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "settlement_account_blocked_error",
+			fields: fields{
+				accountRepo:     accountRepositoryMock,
+				eventRepo:       eventRepositoryMock,
+				transactionRepo: transactionRepositoryMock,
+			},
+			args: args{
+				ctx:       ctx,
+				accountID: accountID,
+				amount:    rawAmount,
+			},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(account.UserID)).Return(false, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(transaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(blockedAccount, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionFailed), gomock.Any()).Return(nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "settlement_account_owner_blocked_error",
+			fields: fields{
+				accountRepo:     accountRepositoryMock,
+				eventRepo:       eventRepositoryMock,
+				transactionRepo: transactionRepositoryMock,
+			},
+			args: args{
+				ctx:       ctx,
+				accountID: accountID,
+				amount:    rawAmount,
+			},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(account.UserID)).Return(false, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(transaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(account.UserID)).Return(true, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionFailed), gomock.Any()).Return(nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "creating_journal_entry_error",
+			fields: fields{
+				accountRepo:     accountRepositoryMock,
+				eventRepo:       eventRepositoryMock,
+				transactionRepo: transactionRepositoryMock,
+			},
+			args: args{
+				ctx:       ctx,
+				accountID: accountID,
+				amount:    rawAmount,
+			},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(account.UserID)).Return(false, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(transaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(account.UserID)).Return(false, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionSent), gomock.Eq("")).Return(nil)
+				ledgerRepositoryMock.EXPECT().CreateJournalEntry(gomock.Eq(ctx), gomock.Eq(journalEntry)).Return(domain.JournalEntry{}, testError)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionFailed), gomock.Any()).Return(nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "setting_transaction_status_transaction_error",
+			fields: fields{
+				accountRepo:     accountRepositoryMock,
+				eventRepo:       eventRepositoryMock,
+				transactionRepo: transactionRepositoryMock,
+			},
+			args: args{
+				ctx:       ctx,
+				accountID: accountID,
+				amount:    rawAmount,
+			},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(account.UserID)).Return(false, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(transaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(account.UserID)).Return(false, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionSent), gomock.Eq("")).Return(domain.ErrInvalidTransactionStatusTransition)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionFailed), gomock.Any()).Return(nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "event_repository_error",
+			fields: fields{
+				accountRepo:     accountRepositoryMock,
+				eventRepo:       eventRepositoryMock,
+				transactionRepo: transactionRepositoryMock,
+			},
+			args: args{
+				ctx:       ctx,
+				accountID: accountID,
+				amount:    rawAmount,
+			},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(account.UserID)).Return(false, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(transaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(account.UserID)).Return(false, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionSent), gomock.Eq("")).Return(nil)
+				ledgerRepositoryMock.EXPECT().CreateJournalEntry(gomock.Eq(ctx), gomock.Eq(journalEntry)).Return(journalEntry, nil)
+				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Eq(event)).Return(testError)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionFailed), gomock.Any()).Return(nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "success",
+			fields: fields{
+				accountRepo:     accountRepositoryMock,
+				eventRepo:       eventRepositoryMock,
+				transactionRepo: transactionRepositoryMock,
+			},
+			args: args{
+				ctx:       ctx,
+				accountID: accountID,
+				amount:    rawAmount,
+			},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(account.UserID)).Return(false, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(transaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				usersRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(account.UserID)).Return(false, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionSent), gomock.Eq("")).Return(nil)
+				ledgerRepositoryMock.EXPECT().CreateJournalEntry(gomock.Eq(ctx), gomock.Eq(journalEntry)).Return(journalEntry, nil)
+				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Eq(event)).Return(nil)
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
 
-			a := NewAccount(tt.fields.accountRepo, tt.fields.transactionRepo, tt.fields.eventRepo, nil)
+			a := NewAccount(transactorMock, tt.fields.accountRepo, tt.fields.transactionRepo, ledgerRepositoryMock, currencyRepositoryMock, nil, tt.fields.eventRepo, usersRepositoryMock, nil, nil, false)
 			err := a.DepositAccount(tt.args.ctx, tt.args.accountID, tt.args.amount)
 			assert.Equal(t, tt.wantErr, err != nil)
 		})
 	}
 }
 
+func TestAccount_WithdrawAccount(t *testing.T) {
+	controller := gomock.NewController(t)
+	transactorMock := NewMockTransactor(controller)
+	accountRepositoryMock := NewMockAccountRepository(controller)
+	eventRepositoryMock := NewMockEventRepository(controller)
+	transactionRepositoryMock := NewMockTransactionRepository(controller)
+	ledgerRepositoryMock := NewMockLedgerRepository(controller)
+	currencyRepositoryMock := NewMockCurrencyRepository(controller)
+
+	ctx := context.Background()
+
+	accountID := 1
+	userID := 1
+	currency := domain.Currency{ID: 1, Name: "US Dollar", Code: "USD", Precision: 2}
+	rawAmount := "60.25"
+	amount := domain.NewMoney(6025, currency)
+
+	testError := errors.New("test error")
+
+	account := domain.Account{
+		ID:         accountID,
+		Iban:       "UA031234560000039096125330467",
+		UserID:     userID,
+		CurrencyID: currency.ID,
+		Amount:     domain.NewMoney(10000, currency),
+	}
+
+	blockedAccount := account
+	blockedAccount.Blocked = true
+
+	poorAccount := account
+	poorAccount.Amount = domain.NewMoney(6024, currency)
+
+	newTransaction := domain.Transaction{
+		FromAccount: accountID,
+		Amount:      amount,
+		ToAmount:    amount,
+	}
+
+	transaction := newTransaction
+	transaction.ID = 1
+	transaction.Status = domain.TransactionPrepared
+
+	journalEntry := domain.NewWithdrawalJournalEntry(transaction.ID, accountID, amount)
+
+	event := domain.Event{
+		UserID:  userID,
+		Type:    domain.WithdrawalEvent,
+		Message: "withdrawal from account successful",
+		Metadata: map[string]any{
+			"account_id":     accountID,
+			"transaction_id": transaction.ID,
+			"amount":         "60.25",
+			"currency":       "USD",
+		},
+	}
+
+	withinTransaction := func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}
+
+	type args struct {
+		ctx       context.Context
+		accountID int
+		userID    int
+		amount    string
+	}
+	tests := []struct {
+		name          string
+		args          args
+		configureMock func()
+		wantErr       bool
+	}{
+		{
+			name: "transaction_begin_error",
+			args: args{
+				ctx:       ctx,
+				accountID: accountID,
+				userID:    userID,
+				amount:    rawAmount,
+			},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).Return(testError)
+			},
+			wantErr: true,
+		},
+		{
+			name: "account_not_exists_error",
+			args: args{
+				ctx:       ctx,
+				accountID: accountID,
+				userID:    userID,
+				amount:    rawAmount,
+			},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(domain.Account{}, domain.ErrAccountNotFound)
+			},
+			wantErr: true,
+		},
+		{
+			name: "user_id_mismatching",
+			args: args{
+				ctx:       ctx,
+				accountID: accountID,
+				userID:    2,
+				amount:    rawAmount,
+			},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "account_blocked_error",
+			args: args{
+				ctx:       ctx,
+				accountID: accountID,
+				userID:    userID,
+				amount:    rawAmount,
+			},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(blockedAccount, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "amount_precision_error",
+			args: args{
+				ctx:       ctx,
+				accountID: accountID,
+				userID:    userID,
+				amount:    "60.251",
+			},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "creating_transaction_error",
+			args: args{
+				ctx:       ctx,
+				accountID: accountID,
+				userID:    userID,
+				amount:    rawAmount,
+			},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(domain.Transaction{}, testError)
+			},
+			wantErr: true,
+		},
+		{
+			name: "not_enough_money_error",
+			args: args{
+				ctx:       ctx,
+				accountID: accountID,
+				userID:    userID,
+				amount:    rawAmount,
+			},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(transaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(poorAccount, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionFailed), gomock.Any()).Return(nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "creating_journal_entry_error",
+			args: args{
+				ctx:       ctx,
+				accountID: accountID,
+				userID:    userID,
+				amount:    rawAmount,
+			},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(transaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionSent), gomock.Eq("")).Return(nil)
+				ledgerRepositoryMock.EXPECT().CreateJournalEntry(gomock.Eq(ctx), gomock.Eq(journalEntry)).Return(domain.JournalEntry{}, testError)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionFailed), gomock.Any()).Return(nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "creating_event_error",
+			args: args{
+				ctx:       ctx,
+				accountID: accountID,
+				userID:    userID,
+				amount:    rawAmount,
+			},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(transaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionSent), gomock.Eq("")).Return(nil)
+				ledgerRepositoryMock.EXPECT().CreateJournalEntry(gomock.Eq(ctx), gomock.Eq(journalEntry)).Return(journalEntry, nil)
+				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Eq(event)).Return(testError)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionFailed), gomock.Any()).Return(nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "success",
+			args: args{
+				ctx:       ctx,
+				accountID: accountID,
+				userID:    userID,
+				amount:    rawAmount,
+			},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				currencyRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(currency.ID)).Return(currency, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(newTransaction)).Return(transaction, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(account, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(transaction.ID), gomock.Eq(domain.TransactionSent), gomock.Eq("")).Return(nil)
+				ledgerRepositoryMock.EXPECT().CreateJournalEntry(gomock.Eq(ctx), gomock.Eq(journalEntry)).Return(journalEntry, nil)
+				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Eq(event)).Return(nil)
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			a := NewAccount(transactorMock, accountRepositoryMock, transactionRepositoryMock, ledgerRepositoryMock, currencyRepositoryMock, nil, eventRepositoryMock, nil, nil, nil, false)
+			err := a.WithdrawAccount(tt.args.ctx, tt.args.accountID, tt.args.userID, tt.args.amount)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
 func TestAccount_BlockAccount(t *testing.T) {
 	controller := gomock.NewController(t)
 	accountRepositoryMock := NewMockAccountRepository(controller)
@@ -1016,7 +1863,7 @@ func TestAccount_BlockAccount(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.configureMock()
 
-			a := NewAccount(tt.fields.accountRepo, nil, tt.fields.eventRepo, nil)
+			a := NewAccount(nil, tt.fields.accountRepo, nil, nil, nil, nil, tt.fields.eventRepo, nil, nil, nil, false)
 			err := a.BlockAccount(tt.args.ctx, tt.args.accountID, tt.args.userID)
 			assert.Equal(t, tt.wantErr, err != nil)
 		})
@@ -1030,15 +1877,17 @@ func TestAccount_UnblockAccount(t *testing.T) {
 
 	ctx := context.Background()
 	accountID := 1
-	userID := 1
+	adminID := 1
+	ownerID := 2
 	testError := errors.New("test error")
 
 	event := domain.Event{
-		UserID:  userID,
+		UserID:  ownerID,
 		Type:    domain.AccountUnblockedEvent,
 		Message: "account unblocked successfully",
 		Metadata: map[string]any{
 			"account_id": accountID,
+			"admin_id":   adminID,
 		},
 	}
 
@@ -1050,7 +1899,7 @@ func TestAccount_UnblockAccount(t *testing.T) {
 	type args struct {
 		ctx       context.Context
 		accountID int
-		userID    int
+		adminID   int
 	}
 	tests := []struct {
 		name          string
@@ -1068,10 +1917,26 @@ func TestAccount_UnblockAccount(t *testing.T) {
 			args: args{
 				ctx:       ctx,
 				accountID: accountID,
-				userID:    userID,
+				adminID:   adminID,
+			},
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().UnblockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(0, testError)
+			},
+			wantErr: true,
+		},
+		{
+			name: "account_not_found_error",
+			fields: fields{
+				accountRepo: accountRepositoryMock,
+				eventRepo:   eventRepositoryMock,
+			},
+			args: args{
+				ctx:       ctx,
+				accountID: accountID,
+				adminID:   adminID,
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().UnblockAccount(gomock.Eq(ctx), gomock.Eq(accountID), gomock.Eq(userID)).Return(testError)
+				accountRepositoryMock.EXPECT().UnblockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(0, domain.ErrAccountNotFound)
 			},
 			wantErr: true,
 		},
@@ -1084,10 +1949,10 @@ func TestAccount_UnblockAccount(t *testing.T) {
 			args: args{
 				ctx:       ctx,
 				accountID: accountID,
-				userID:    userID,
+				adminID:   adminID,
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().UnblockAccount(gomock.Eq(ctx), gomock.Eq(accountID), gomock.Eq(userID)).Return(nil)
+				accountRepositoryMock.EXPECT().UnblockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(ownerID, nil)
 				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), event).Return(testError)
 			},
 			wantErr: true,
@@ -1101,10 +1966,10 @@ func TestAccount_UnblockAccount(t *testing.T) {
 			args: args{
 				ctx:       ctx,
 				accountID: accountID,
-				userID:    userID,
+				adminID:   adminID,
 			},
 			configureMock: func() {
-				accountRepositoryMock.EXPECT().UnblockAccount(gomock.Eq(ctx), gomock.Eq(accountID), gomock.Eq(userID)).Return(nil)
+				accountRepositoryMock.EXPECT().UnblockAccount(gomock.Eq(ctx), gomock.Eq(accountID)).Return(ownerID, nil)
 				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), event).Return(nil)
 			},
 			wantErr: false,
@@ -1114,8 +1979,8 @@ func TestAccount_UnblockAccount(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.configureMock()
 
-			a := NewAccount(tt.fields.accountRepo, nil, tt.fields.eventRepo, nil)
-			err := a.UnblockAccount(tt.args.ctx, tt.args.accountID, tt.args.userID)
+			a := NewAccount(nil, tt.fields.accountRepo, nil, nil, nil, nil, tt.fields.eventRepo, nil, nil, nil, false)
+			err := a.UnblockAccount(tt.args.ctx, tt.args.accountID, tt.args.adminID)
 			assert.Equal(t, tt.wantErr, err != nil)
 		})
 	}
@@ -98,7 +98,7 @@ func (s Card) GetCard(ctx context.Context, cardID, accountID, userID int) (domai
 	}
 
 	if checkUserID != userID {
-		return domain.Card{}, errors.New("userID matching check error")
+		return domain.Card{}, errors.Wrap(domain.ErrNotAccountOwner, "userID matching check error")
 	}
 
 	card, err := s.cardRepo.GetCard(ctx, cardID, accountID)
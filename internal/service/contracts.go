@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"math/big"
+	"time"
 
 	"github.com/lukinairina90/banking_backend/internal/domain"
 )
@@ -11,12 +13,23 @@ import (
 // RolesRepository contract for roles repository.
 type RolesRepository interface {
 	GetByName(ctx context.Context, name string) (domain.Role, error)
+	GetRoles(ctx context.Context) ([]domain.Role, error)
+	Create(ctx context.Context, name string) (domain.Role, error)
+	GetUserRoles(ctx context.Context, userID int) ([]domain.Role, error)
+	LockUserRoles(ctx context.Context, userID int) ([]domain.Role, error)
+	AssignRole(ctx context.Context, userID, roleID int) error
+	RevokeRole(ctx context.Context, userID, roleID int) error
 }
 
 // SessionRepository contract for refresh session repository.
 type SessionRepository interface {
 	Create(ctx context.Context, token domain.RefreshSession) error
-	Get(ctx context.Context, token string) (domain.RefreshSession, error)
+	Get(ctx context.Context, tokenHash string) (domain.RefreshSession, error)
+	Lock(ctx context.Context, tokenHash string) (domain.RefreshSession, error)
+	MarkRotated(ctx context.Context, id int) error
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAll(ctx context.Context, userID int) error
+	GetActiveSessions(ctx context.Context, userID int) ([]domain.Session, error)
 }
 
 // RandomGenerator contract for generator.
@@ -26,28 +39,43 @@ type RandomGenerator interface {
 	GenerateRandomCvv() string
 }
 
+// Transactor contract for unit of work running a business operation inside a single database transaction.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 // AccountRepository contract for account repository.
 type AccountRepository interface {
 	GetUserIDByAccountID(ctx context.Context, accountID int) (int, error)
 	GetAccountIDByIban(ctx context.Context, iban string) (int, error)
-	GetAccountCurrencyIDByID(ctx context.Context, accountID int) (int, error)
-	GetAccountCurrencyIDByIban(ctx context.Context, iban string) (int, error)
-	GetAccountAmount(ctx context.Context, accountID, userID int) (float64, error)
-	ExistsAccount(ctx context.Context, accountID int) (bool, error)
+	LockAccount(ctx context.Context, accountID int) (domain.Account, error)
 	Create(ctx context.Context, userID, currencyID int, iban string) (domain.Account, error)
 	GetAccountsList(ctx context.Context, userID int, paginator domain.Paginator, ordering domain.Orderings) ([]domain.Account, error)
 	GetAccount(ctx context.Context, accountID, userID int) (domain.Account, error)
 	DeleteAccount(ctx context.Context, accountID int) error
-	DepositAccount(ctx context.Context, accountID int, amount float64) error
-	TransferAccount(ctx context.Context, fromAccountID, userID int, amount float64, toAccountIban string) error
 	BlockAccount(ctx context.Context, accountID, userID int) error
-	UnblockAccount(ctx context.Context, accountID, userID int) error
+	UnblockAccount(ctx context.Context, accountID int) (int, error)
+}
+
+// LedgerRepository contract for ledger repository.
+type LedgerRepository interface {
+	CreateJournalEntry(ctx context.Context, entry domain.JournalEntry) (domain.JournalEntry, error)
+	HasJournalEntry(ctx context.Context, transactionID int) (bool, error)
+}
+
+// CurrencyRepository contract for currency repository.
+type CurrencyRepository interface {
+	GetByID(ctx context.Context, id int) (domain.Currency, error)
+	GetByCode(ctx context.Context, code string) (domain.Currency, error)
 }
 
 // TransactionRepository contract for transaction repository.
 type TransactionRepository interface {
-	CreateTransaction(ctx context.Context, fromAccountID, toAccountID int, amount float64) (domain.Transaction, error)
-	SetTransactionStatusToSent(ctx context.Context, transactionID int) error
+	CreateTransaction(ctx context.Context, transaction domain.Transaction) (domain.Transaction, error)
+	LockTransaction(ctx context.Context, transactionID int) (domain.Transaction, error)
+	GetReversals(ctx context.Context, transactionID int) ([]domain.Transaction, error)
+	UpdateTransactionStatus(ctx context.Context, transactionID int, status domain.TransactionStatus, failureReason string) error
+	GetStalePreparedTransactions(ctx context.Context, before time.Time, limit int) ([]domain.Transaction, error)
 	GetTransactionList(ctx context.Context, accountID int, ordering domain.Orderings, paginator domain.Paginator) ([]domain.Transaction, error)
 }
 
@@ -57,27 +85,213 @@ type CardRepository interface {
 	GetCardListUser(ctx context.Context, userID int) ([]domain.Card, error)
 	GetCardListByAccount(ctx context.Context, userID, accountID int) ([]domain.Card, error)
 	GetCard(ctx context.Context, id, accountID int) (domain.Card, error)
+	GetAccountIDByCardID(ctx context.Context, cardID int) (int, error)
+	UpdateCardholderName(ctx context.Context, userID int, cardholderName string) error
 }
 
 // EventRepository contract for event repository.
 type EventRepository interface {
 	CreateEvent(ctx context.Context, event domain.Event) error
 	GetEventsList(ctx context.Context, userID int) ([]domain.Event, error)
+	GetRecentEvents(ctx context.Context, userID, limit int) ([]domain.Event, error)
 }
 
 // PasswordHasher contract for hash.
 type PasswordHasher interface {
 	Hash(password string) (string, error)
+	Verify(password, encoded string) (ok bool, needsRehash bool, err error)
 }
 
 // UsersRepository contract for user repository.
 type UsersRepository interface {
 	GetUserNameAndSurnameByID(ctx context.Context, userID int) (string, error)
 	Exists(ctx context.Context, email string) (bool, error)
-	Create(ctx context.Context, user domain.User) error
-	GetByCredentials(ctx context.Context, email, password string) (domain.User, error)
+	Create(ctx context.Context, user domain.User) (int, error)
+	GetByEmail(ctx context.Context, email string) (domain.User, error)
+	UpdatePassword(ctx context.Context, userID int, password string) error
 	GetByID(ctx context.Context, id int) (domain.User, error)
 	BlockUser(ctx context.Context, userID int) error
 	UnblockUser(ctx context.Context, userID int) error
 	CheckBlockUser(ctx context.Context, userID int) (bool, error)
+	GetTokenVersion(ctx context.Context, userID int) (int, error)
+	IncrementTokenVersion(ctx context.Context, userID int) error
+	MarkEmailVerified(ctx context.Context, userID int) error
+	UpdateProfile(ctx context.Context, user domain.User) error
+	GetUsersList(ctx context.Context, filter domain.UserFilter, paginator domain.Paginator, ordering domain.Orderings) ([]domain.User, error)
+}
+
+// RevokedTokenRepository contract for access token revocation list repository.
+type RevokedTokenRepository interface {
+	Revoke(ctx context.Context, jti string, userID int, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// SigningKeyRepository contract for access token signing keys repository.
+type SigningKeyRepository interface {
+	List(ctx context.Context) ([]domain.SigningKey, error)
+	Create(ctx context.Context, key domain.SigningKey) error
+	Delete(ctx context.Context, id string) error
+}
+
+// TwoFactorRepository contract for two-factor authentication repository.
+type TwoFactorRepository interface {
+	Lock(ctx context.Context, userID int) (domain.TwoFactor, error)
+	SetSecret(ctx context.Context, userID int, secret string) error
+	Enable(ctx context.Context, userID int) error
+	Disable(ctx context.Context, userID int) error
+	UpdateLastStep(ctx context.Context, userID int, step int64) error
+	RegisterCodeFailure(ctx context.Context, userID int) (int, error)
+	LockCodes(ctx context.Context, userID int, until time.Time) error
+	ResetCodeFailures(ctx context.Context, userID int) error
+	ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	CreateChallenge(ctx context.Context, challenge domain.TwoFactorChallenge) error
+	LockChallenge(ctx context.Context, tokenHash string) (domain.TwoFactorChallenge, error)
+	IncrementChallengeAttempts(ctx context.Context, id int) error
+	DeleteChallenge(ctx context.Context, id int) error
+}
+
+// TwoFactorChallenger contract for the second step of the sign in.
+type TwoFactorChallenger interface {
+	CreateChallenge(ctx context.Context, userID int) (string, error)
+	CompleteChallenge(ctx context.Context, challengeToken, code string) (int, error)
+}
+
+// StepUpVerifier contract for two-factor verification of sensitive operations.
+type StepUpVerifier interface {
+	VerifyStepUp(ctx context.Context, userID int, amount domain.Money, code string) error
+}
+
+// OneTimeTokenRepository contract for one-time tokens repository.
+type OneTimeTokenRepository interface {
+	Create(ctx context.Context, token domain.OneTimeToken) error
+	Lock(ctx context.Context, tokenHash string, purpose domain.OneTimeTokenPurpose) (domain.OneTimeToken, error)
+	MarkUsed(ctx context.Context, id int) error
+	DeleteUnused(ctx context.Context, userID int, purpose domain.OneTimeTokenPurpose) error
+}
+
+// Mailer contract for sending emails to the users.
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// EmailVerifier contract for sending the email verification to a new user.
+type EmailVerifier interface {
+	RequestEmailVerification(ctx context.Context, userID int) error
+	CancelEmailVerification(ctx context.Context, userID int) error
+}
+
+// SignInThrottleRepository contract for failed sign in attempts repository.
+type SignInThrottleRepository interface {
+	Get(ctx context.Context, scope domain.SignInThrottleScope, subject string) (domain.SignInThrottle, error)
+	RegisterFailure(ctx context.Context, scope domain.SignInThrottleScope, subject string, since time.Time) (domain.SignInThrottle, error)
+	Lock(ctx context.Context, scope domain.SignInThrottleScope, subject string, until time.Time) error
+	Delete(ctx context.Context, scope domain.SignInThrottleScope, subject string) error
+}
+
+// SignInLimiter contract for brute-force protection of the sign in.
+type SignInLimiter interface {
+	Check(ctx context.Context, email, ip string) error
+	RegisterFailure(ctx context.Context, email, ip string, userID int) error
+	Reset(ctx context.Context, email string) error
+}
+
+// TokenKeys contract for access token signing keys provider.
+type TokenKeys interface {
+	SigningKey() (domain.SigningKey, error)
+	VerificationKey(ctx context.Context, id string) (domain.SigningKey, error)
+}
+
+// IdempotencyRepository contract for idempotency keys repository.
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, key domain.IdempotencyKey) (domain.IdempotencyKey, bool, error)
+	Complete(ctx context.Context, userID int, key, token string, statusCode int, responseBody []byte) error
+	Release(ctx context.Context, userID int, key, token string) error
+}
+
+// RateProvider contract for exchange rates source.
+type RateProvider interface {
+	GetRate(ctx context.Context, base, quote string) (*big.Rat, error)
+}
+
+// FXQuoteRepository contract for exchange quotes repository.
+type FXQuoteRepository interface {
+	CreateQuote(ctx context.Context, quote domain.FXQuote) (domain.FXQuote, error)
+	UseQuote(ctx context.Context, quoteID, userID int) (domain.FXQuote, error)
+}
+
+// CurrencyExchanger contract for converting money between currencies.
+type CurrencyExchanger interface {
+	Exchange(ctx context.Context, userID, quoteID int, amount domain.Money, to domain.Currency) (domain.Money, *big.Rat, error)
+}
+
+// PolicyEnforcer contract for RBAC enforcer keeping the policy.
+type PolicyEnforcer interface {
+	GetNamedPolicy(ptype string) [][]string
+	GetGroupingPolicy() [][]string
+	AddNamedPolicy(ptype string, params ...interface{}) (bool, error)
+	RemoveNamedPolicy(ptype string, params ...interface{}) (bool, error)
+	AddGroupingPolicy(params ...interface{}) (bool, error)
+	RemoveGroupingPolicy(params ...interface{}) (bool, error)
+	LoadPolicy() error
+}
+
+// PolicyRevisionRepository contract for repository of the RBAC policy revision.
+type PolicyRevisionRepository interface {
+	GetRevision(ctx context.Context) (int64, error)
+}
+
+// RouteRegistry contract for registry of the routes served by the application.
+type RouteRegistry interface {
+	Routes() []domain.Route
+}
+
+// CacheBackend contract for the key value cache storage.
+type CacheBackend interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// UserAccessCache contract for the cache of the user access data.
+type UserAccessCache interface {
+	CheckBlockUser(ctx context.Context, userID int) (bool, error)
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	GetTokenVersion(ctx context.Context, userID int) (int, error)
+	InvalidateBlockStatus(ctx context.Context, userID int)
+	InvalidateRoles(ctx context.Context, userID int)
+	InvalidateRevokedToken(ctx context.Context, jti string)
+	InvalidateTokenVersion(ctx context.Context, userID int)
+}
+
+// PolicyChecker contract for the RBAC policy evaluation.
+type PolicyChecker interface {
+	Enforce(rvals ...interface{}) (bool, error)
+	GetNamedPolicy(ptype string) [][]string
+	GetGroupingPolicy() [][]string
+}
+
+// WebhookRepository contract for webhook subscriptions and deliveries repository.
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription domain.WebhookSubscription) (domain.WebhookSubscription, error)
+	GetSubscriptions(ctx context.Context, userID int) ([]domain.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id int) (domain.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int) error
+	GetDeliveries(ctx context.Context, subscriptionID, limit int) ([]domain.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, id int) (domain.WebhookDelivery, error)
+	GetDeliveryAttempts(ctx context.Context, deliveryID int) ([]domain.WebhookDeliveryAttempt, error)
+	Redeliver(ctx context.Context, id int) error
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDispatch, error)
+	CreateDeliveryAttempt(ctx context.Context, attempt domain.WebhookDeliveryAttempt) error
+	UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error
+}
+
+// WebhookAddressChecker contract for checking the hosts of the webhook URLs.
+type WebhookAddressChecker interface {
+	CheckHost(ctx context.Context, host string) error
+}
+
+// WebhookSender contract for sending the webhook requests.
+type WebhookSender interface {
+	Send(ctx context.Context, url string, headers map[string]string, body []byte) (int, error)
 }
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/pkg/errors"
+)
+
+// FX business logic layer struct.
+type FX struct {
+	rateProvider RateProvider
+	quoteRepo    FXQuoteRepository
+	currencyRepo CurrencyRepository
+	spreadBps    int64
+	quoteTTL     time.Duration
+}
+
+// NewFX constructor for FX.
+// spreadBps is the margin in basis points the applied rate is reduced by, quoteTTL is how long a quoted rate stays locked.
+// Returns an error when the spread is negative or not below the whole rate.
+func NewFX(rateProvider RateProvider, quoteRepo FXQuoteRepository, currencyRepo CurrencyRepository, spreadBps int64, quoteTTL time.Duration) (*FX, error) {
+	if spreadBps < 0 || spreadBps >= domain.BasisPointsPerUnit {
+		return nil, errors.Errorf("spread %d bps is out of range [0, %d)", spreadBps, domain.BasisPointsPerUnit)
+	}
+
+	return &FX{
+		rateProvider: rateProvider,
+		quoteRepo:    quoteRepo,
+		currencyRepo: currencyRepo,
+		spreadBps:    spreadBps,
+		quoteTTL:     quoteTTL,
+	}, nil
+}
+
+// CreateQuote locks the current exchange rate for the user to convert the amount between the provided currencies.
+func (s FX) CreateQuote(ctx context.Context, userID int, fromCurrencyCode, toCurrencyCode, rawAmount string) (domain.FXQuote, error) {
+	fromCurrency, err := s.currencyRepo.GetByCode(ctx, fromCurrencyCode)
+	if err != nil {
+		return domain.FXQuote{}, errors.Wrap(err, "getting source currency error")
+	}
+
+	toCurrency, err := s.currencyRepo.GetByCode(ctx, toCurrencyCode)
+	if err != nil {
+		return domain.FXQuote{}, errors.Wrap(err, "getting target currency error")
+	}
+
+	if fromCurrency.ID == toCurrency.ID {
+		return domain.FXQuote{}, errors.Wrap(domain.ErrQuoteSameCurrency, "quote currencies must differ error")
+	}
+
+	amount, err := domain.ParseMoney(rawAmount, fromCurrency)
+	if err != nil {
+		return domain.FXQuote{}, errors.Wrap(err, "parsing amount error")
+	}
+
+	if !amount.IsPositive() {
+		return domain.FXQuote{}, domain.ErrNonPositiveAmount
+	}
+
+	rate, err := s.appliedRate(ctx, fromCurrency.Code, toCurrency.Code)
+	if err != nil {
+		return domain.FXQuote{}, errors.Wrap(err, "getting exchange rate error")
+	}
+
+	targetAmount, err := s.convert(amount, rate, toCurrency)
+	if err != nil {
+		return domain.FXQuote{}, errors.Wrap(err, "converting amount error")
+	}
+
+	quote := domain.FXQuote{
+		UserID:       userID,
+		Rate:         rate,
+		SourceAmount: amount,
+		TargetAmount: targetAmount,
+		ExpiresAt:    time.Now().Add(s.quoteTTL),
+	}
+
+	created, err := s.quoteRepo.CreateQuote(ctx, quote)
+	if err != nil {
+		return domain.FXQuote{}, errors.Wrap(err, "quote creation error")
+	}
+
+	return created, nil
+}
+
+// Exchange converts the amount into the provided currency and returns the converted amount with the applied rate.
+// The rate locked by the user's quote is used when quoteID is provided, the current rate otherwise.
+func (s FX) Exchange(ctx context.Context, userID, quoteID int, amount domain.Money, to domain.Currency) (domain.Money, *big.Rat, error) {
+	if quoteID != 0 {
+		quote, err := s.quoteRepo.UseQuote(ctx, quoteID, userID)
+		if err != nil {
+			return domain.Money{}, nil, errors.Wrap(err, "using quote error")
+		}
+
+		if quote.SourceAmount != amount || quote.TargetAmount.Currency != to.Code {
+			return domain.Money{}, nil, domain.ErrQuoteMismatch
+		}
+
+		return quote.TargetAmount, quote.Rate, nil
+	}
+
+	rate, err := s.appliedRate(ctx, amount.Currency, to.Code)
+	if err != nil {
+		return domain.Money{}, nil, errors.Wrap(err, "getting exchange rate error")
+	}
+
+	converted, err := s.convert(amount, rate, to)
+	if err != nil {
+		return domain.Money{}, nil, errors.Wrap(err, "converting amount error")
+	}
+
+	return converted, rate, nil
+}
+
+// appliedRate returns the provider's rate with the spread applied, the inverse rate is used if only the opposite pair is known.
+func (s FX) appliedRate(ctx context.Context, base, quote string) (*big.Rat, error) {
+	rate, err := s.rateProvider.GetRate(ctx, base, quote)
+	if errors.Is(err, domain.ErrExchangeRateNotFound) {
+		inverse, inverseErr := s.rateProvider.GetRate(ctx, quote, base)
+		if inverseErr != nil {
+			return nil, inverseErr
+		}
+
+		rate, err = new(big.Rat).Inv(inverse), nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.ApplySpread(rate, s.spreadBps), nil
+}
+
+// convert converts the amount by the rate and makes sure something is left after rounding.
+func (s FX) convert(amount domain.Money, rate *big.Rat, to domain.Currency) (domain.Money, error) {
+	converted, err := amount.Convert(rate, to)
+	if err != nil {
+		return domain.Money{}, err
+	}
+
+	if !converted.IsPositive() {
+		return domain.Money{}, domain.ErrNonPositiveAmount
+	}
+
+	return converted, nil
+}
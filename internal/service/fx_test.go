@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFX_CreateQuote(t *testing.T) {
+	controller := gomock.NewController(t)
+	rateProviderMock := NewMockRateProvider(controller)
+	fxQuoteRepositoryMock := NewMockFXQuoteRepository(controller)
+	currencyRepositoryMock := NewMockCurrencyRepository(controller)
+
+	ctx := context.Background()
+	userID := 1
+	usd := domain.Currency{ID: 1, Name: "US Dollar", Code: "USD", Precision: 2}
+	uah := domain.Currency{ID: 2, Name: "Ukrainian hryvnia", Code: "UAH", Precision: 2}
+
+	testError := errors.New("test error")
+
+	// 36.9 with 50 bps spread
+	appliedRate := big.NewRat(367155, 10000)
+	quote := domain.FXQuote{
+		ID:           1,
+		UserID:       userID,
+		Rate:         appliedRate,
+		SourceAmount: domain.NewMoney(10000, usd),
+		TargetAmount: domain.NewMoney(367155, uah),
+		ExpiresAt:    time.Now().Add(time.Minute),
+	}
+
+	type args struct {
+		ctx          context.Context
+		userID       int
+		fromCurrency string
+		toCurrency   string
+		amount       string
+	}
+	tests := []struct {
+		name          string
+		args          args
+		configureMock func()
+		want          domain.FXQuote
+		wantErr       bool
+	}{
+		{
+			name: "getting_source_currency_error",
+			args: args{
+				ctx:          ctx,
+				userID:       userID,
+				fromCurrency: "USD",
+				toCurrency:   "UAH",
+				amount:       "100",
+			},
+			configureMock: func() {
+				currencyRepositoryMock.EXPECT().GetByCode(gomock.Eq(ctx), gomock.Eq("USD")).Return(domain.Currency{}, testError)
+			},
+			want:    domain.FXQuote{},
+			wantErr: true,
+		},
+		{
+			name: "same_currencies_error",
+			args: args{
+				ctx:          ctx,
+				userID:       userID,
+				fromCurrency: "USD",
+				toCurrency:   "USD",
+				amount:       "100",
+			},
+			configureMock: func() {
+				currencyRepositoryMock.EXPECT().GetByCode(gomock.Eq(ctx), gomock.Eq("USD")).Return(usd, nil).Times(2)
+			},
+			want:    domain.FXQuote{},
+			wantErr: true,
+		},
+		{
+			name: "amount_precision_error",
+			args: args{
+				ctx:          ctx,
+				userID:       userID,
+				fromCurrency: "USD",
+				toCurrency:   "UAH",
+				amount:       "100.001",
+			},
+			configureMock: func() {
+				currencyRepositoryMock.EXPECT().GetByCode(gomock.Eq(ctx), gomock.Eq("USD")).Return(usd, nil)
+				currencyRepositoryMock.EXPECT().GetByCode(gomock.Eq(ctx), gomock.Eq("UAH")).Return(uah, nil)
+			},
+			want:    domain.FXQuote{},
+			wantErr: true,
+		},
+		{
+			name: "exchange_rate_not_found_error",
+			args: args{
+				ctx:          ctx,
+				userID:       userID,
+				fromCurrency: "USD",
+				toCurrency:   "UAH",
+				amount:       "100",
+			},
+			configureMock: func() {
+				currencyRepositoryMock.EXPECT().GetByCode(gomock.Eq(ctx), gomock.Eq("USD")).Return(usd, nil)
+				currencyRepositoryMock.EXPECT().GetByCode(gomock.Eq(ctx), gomock.Eq("UAH")).Return(uah, nil)
+				rateProviderMock.EXPECT().GetRate(gomock.Eq(ctx), gomock.Eq("USD"), gomock.Eq("UAH")).Return(nil, domain.ErrExchangeRateNotFound)
+				rateProviderMock.EXPECT().GetRate(gomock.Eq(ctx), gomock.Eq("UAH"), gomock.Eq("USD")).Return(nil, domain.ErrExchangeRateNotFound)
+			},
+			want:    domain.FXQuote{},
+			wantErr: true,
+		},
+		{
+			name: "quote_repository_error",
+			args: args{
+				ctx:          ctx,
+				userID:       userID,
+				fromCurrency: "USD",
+				toCurrency:   "UAH",
+				amount:       "100",
+			},
+			configureMock: func() {
+				currencyRepositoryMock.EXPECT().GetByCode(gomock.Eq(ctx), gomock.Eq("USD")).Return(usd, nil)
+				currencyRepositoryMock.EXPECT().GetByCode(gomock.Eq(ctx), gomock.Eq("UAH")).Return(uah, nil)
+				rateProviderMock.EXPECT().GetRate(gomock.Eq(ctx), gomock.Eq("USD"), gomock.Eq("UAH")).Return(big.NewRat(369, 10), nil)
+				fxQuoteRepositoryMock.EXPECT().CreateQuote(gomock.Eq(ctx), gomock.Any()).Return(domain.FXQuote{}, testError)
+			},
+			want:    domain.FXQuote{},
+			wantErr: true,
+		},
+		{
+			name: "success",
+			args: args{
+				ctx:          ctx,
+				userID:       userID,
+				fromCurrency: "USD",
+				toCurrency:   "UAH",
+				amount:       "100",
+			},
+			configureMock: func() {
+				currencyRepositoryMock.EXPECT().GetByCode(gomock.Eq(ctx), gomock.Eq("USD")).Return(usd, nil)
+				currencyRepositoryMock.EXPECT().GetByCode(gomock.Eq(ctx), gomock.Eq("UAH")).Return(uah, nil)
+				rateProviderMock.EXPECT().GetRate(gomock.Eq(ctx), gomock.Eq("USD"), gomock.Eq("UAH")).Return(big.NewRat(369, 10), nil)
+				fxQuoteRepositoryMock.EXPECT().CreateQuote(gomock.Eq(ctx), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, q domain.FXQuote) (domain.FXQuote, error) {
+						assert.Equal(t, userID, q.UserID)
+						assert.Equal(t, "36.7155", domain.FormatRate(q.Rate))
+						assert.Equal(t, domain.NewMoney(10000, usd), q.SourceAmount)
+						assert.Equal(t, domain.NewMoney(367155, uah), q.TargetAmount)
+
+						return quote, nil
+					})
+			},
+			want:    quote,
+			wantErr: false,
+		},
+		{
+			name: "inverse_rate_success",
+			args: args{
+				ctx:          ctx,
+				userID:       userID,
+				fromCurrency: "UAH",
+				toCurrency:   "USD",
+				amount:       "369",
+			},
+			configureMock: func() {
+				currencyRepositoryMock.EXPECT().GetByCode(gomock.Eq(ctx), gomock.Eq("UAH")).Return(uah, nil)
+				currencyRepositoryMock.EXPECT().GetByCode(gomock.Eq(ctx), gomock.Eq("USD")).Return(usd, nil)
+				rateProviderMock.EXPECT().GetRate(gomock.Eq(ctx), gomock.Eq("UAH"), gomock.Eq("USD")).Return(nil, domain.ErrExchangeRateNotFound)
+				rateProviderMock.EXPECT().GetRate(gomock.Eq(ctx), gomock.Eq("USD"), gomock.Eq("UAH")).Return(big.NewRat(369, 10), nil)
+				fxQuoteRepositoryMock.EXPECT().CreateQuote(gomock.Eq(ctx), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, q domain.FXQuote) (domain.FXQuote, error) {
+						assert.Equal(t, domain.NewMoney(995, usd), q.TargetAmount)
+
+						return quote, nil
+					})
+			},
+			want:    quote,
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s, err := NewFX(rateProviderMock, fxQuoteRepositoryMock, currencyRepositoryMock, 50, time.Minute)
+			assert.NoError(t, err)
+
+			got, err := s.CreateQuote(tt.args.ctx, tt.args.userID, tt.args.fromCurrency, tt.args.toCurrency, tt.args.amount)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFX_Exchange(t *testing.T) {
+	controller := gomock.NewController(t)
+	rateProviderMock := NewMockRateProvider(controller)
+	fxQuoteRepositoryMock := NewMockFXQuoteRepository(controller)
+
+	ctx := context.Background()
+	userID := 1
+	quoteID := 1
+	usd := domain.Currency{ID: 1, Name: "US Dollar", Code: "USD", Precision: 2}
+	uah := domain.Currency{ID: 2, Name: "Ukrainian hryvnia", Code: "UAH", Precision: 2}
+	amount := domain.NewMoney(10000, usd)
+
+	testError := errors.New("test error")
+
+	lockedRate := big.NewRat(365, 10)
+	quote := domain.FXQuote{
+		ID:           quoteID,
+		UserID:       userID,
+		Rate:         lockedRate,
+		SourceAmount: amount,
+		TargetAmount: domain.NewMoney(365000, uah),
+		ExpiresAt:    time.Now().Add(time.Minute),
+	}
+
+	otherQuote := quote
+	otherQuote.SourceAmount = domain.NewMoney(5000, usd)
+
+	type args struct {
+		ctx     context.Context
+		userID  int
+		quoteID int
+		amount  domain.Money
+		to      domain.Currency
+	}
+	tests := []struct {
+		name          string
+		args          args
+		configureMock func()
+		want          domain.Money
+		wantRate      *big.Rat
+		wantErr       bool
+	}{
+		{
+			name: "using_quote_error",
+			args: args{
+				ctx:     ctx,
+				userID:  userID,
+				quoteID: quoteID,
+				amount:  amount,
+				to:      uah,
+			},
+			configureMock: func() {
+				fxQuoteRepositoryMock.EXPECT().UseQuote(gomock.Eq(ctx), gomock.Eq(quoteID), gomock.Eq(userID)).Return(domain.FXQuote{}, domain.ErrQuoteNotFound)
+			},
+			want:    domain.Money{},
+			wantErr: true,
+		},
+		{
+			name: "quote_mismatch_error",
+			args: args{
+				ctx:     ctx,
+				userID:  userID,
+				quoteID: quoteID,
+				amount:  amount,
+				to:      uah,
+			},
+			configureMock: func() {
+				fxQuoteRepositoryMock.EXPECT().UseQuote(gomock.Eq(ctx), gomock.Eq(quoteID), gomock.Eq(userID)).Return(otherQuote, nil)
+			},
+			want:    domain.Money{},
+			wantErr: true,
+		},
+		{
+			name: "quote_success",
+			args: args{
+				ctx:     ctx,
+				userID:  userID,
+				quoteID: quoteID,
+				amount:  amount,
+				to:      uah,
+			},
+			configureMock: func() {
+				fxQuoteRepositoryMock.EXPECT().UseQuote(gomock.Eq(ctx), gomock.Eq(quoteID), gomock.Eq(userID)).Return(quote, nil)
+			},
+			want:     quote.TargetAmount,
+			wantRate: lockedRate,
+			wantErr:  false,
+		},
+		{
+			name: "rate_provider_error",
+			args: args{
+				ctx:    ctx,
+				userID: userID,
+				amount: amount,
+				to:     uah,
+			},
+			configureMock: func() {
+				rateProviderMock.EXPECT().GetRate(gomock.Eq(ctx), gomock.Eq("USD"), gomock.Eq("UAH")).Return(nil, testError)
+			},
+			want:    domain.Money{},
+			wantErr: true,
+		},
+		{
+			name: "current_rate_success",
+			args: args{
+				ctx:    ctx,
+				userID: userID,
+				amount: amount,
+				to:     uah,
+			},
+			configureMock: func() {
+				rateProviderMock.EXPECT().GetRate(gomock.Eq(ctx), gomock.Eq("USD"), gomock.Eq("UAH")).Return(big.NewRat(369, 10), nil)
+			},
+			want:     domain.NewMoney(367155, uah),
+			wantRate: big.NewRat(367155, 10000),
+			wantErr:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s, err := NewFX(rateProviderMock, fxQuoteRepositoryMock, nil, 50, time.Minute)
+			assert.NoError(t, err)
+
+			got, rate, err := s.Exchange(tt.args.ctx, tt.args.userID, tt.args.quoteID, tt.args.amount, tt.args.to)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+			if tt.wantRate != nil {
+				assert.Equal(t, 0, tt.wantRate.Cmp(rate))
+			}
+		})
+	}
+}
+
+func TestNewFX(t *testing.T) {
+	for _, spreadBps := range []int64{-1, 10000, 20000} {
+		_, err := NewFX(nil, nil, nil, spreadBps, time.Minute)
+		assert.Error(t, err)
+	}
+
+	for _, spreadBps := range []int64{0, 50, 9999} {
+		_, err := NewFX(nil, nil, nil, spreadBps, time.Minute)
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/pkg/errors"
+)
+
+// idempotencyTokenSize number of random bytes of the token identifying the reservation of the idempotency key.
+const idempotencyTokenSize = 16
+
+// Idempotency business logic layer struct.
+type Idempotency struct {
+	idempotencyRepo IdempotencyRepository
+	keyTTL          time.Duration
+	lockTimeout     time.Duration
+}
+
+// NewIdempotency constructor for Idempotency.
+// The key of the request not completed within lockTimeout can be taken over by a retry of the request.
+func NewIdempotency(idempotencyRepo IdempotencyRepository, keyTTL, lockTimeout time.Duration) *Idempotency {
+	return &Idempotency{
+		idempotencyRepo: idempotencyRepo,
+		keyTTL:          keyTTL,
+		lockTimeout:     lockTimeout,
+	}
+}
+
+// Begin reserves the idempotency key for the request with the provided fingerprint.
+// Returns the stored key and true when the request is a replay of an already completed one.
+func (s Idempotency) Begin(ctx context.Context, userID int, key, fingerprint string) (domain.IdempotencyKey, bool, error) {
+	token, err := newRandomToken(idempotencyTokenSize)
+	if err != nil {
+		return domain.IdempotencyKey{}, false, errors.Wrap(err, "generating idempotency key token error")
+	}
+
+	now := time.Now()
+	reservation := domain.IdempotencyKey{
+		Key:         key,
+		UserID:      userID,
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(s.keyTTL),
+		LockedUntil: now.Add(s.lockTimeout),
+		Token:       token,
+	}
+
+	stored, reserved, err := s.idempotencyRepo.Reserve(ctx, reservation)
+	if err != nil {
+		return domain.IdempotencyKey{}, false, errors.Wrap(err, "reserving idempotency key error")
+	}
+
+	if reserved {
+		return stored, false, nil
+	}
+
+	if stored.Fingerprint != fingerprint {
+		return domain.IdempotencyKey{}, false, domain.ErrIdempotencyKeyReused
+	}
+
+	if !stored.Completed() {
+		return domain.IdempotencyKey{}, false, domain.NewRetryError(domain.ErrIdempotencyRequestInProgress, stored.LockedUntil)
+	}
+
+	return stored, true, nil
+}
+
+// RequestTimeout returns how long the request reserving the key may be handled.
+// It is half of the lock timeout, so the request is cancelled and its transactions are rolled back well before a retry can take the key over.
+func (s Idempotency) RequestTimeout() time.Duration {
+	return s.lockTimeout / 2
+}
+
+// Complete stores the response of the request, so replays of the request return it.
+// token is the token of the reservation made by Begin, the key taken over by a retry is not changed.
+func (s Idempotency) Complete(ctx context.Context, userID int, key, token string, statusCode int, responseBody []byte) error {
+	if err := s.idempotencyRepo.Complete(ctx, userID, key, token, statusCode, responseBody); err != nil {
+		return errors.Wrap(err, "completing idempotency key error")
+	}
+
+	return nil
+}
+
+// Release frees the idempotency key of the request that has not been completed, so the client can retry it.
+// token is the token of the reservation made by Begin, the key taken over by a retry is not released.
+func (s Idempotency) Release(ctx context.Context, userID int, key, token string) error {
+	if err := s.idempotencyRepo.Release(ctx, userID, key, token); err != nil {
+		return errors.Wrap(err, "releasing idempotency key error")
+	}
+
+	return nil
+}
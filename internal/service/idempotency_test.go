@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotency_Begin(t *testing.T) {
+	controller := gomock.NewController(t)
+	idempotencyRepositoryMock := NewMockIdempotencyRepository(controller)
+
+	ctx := context.Background()
+	userID := 1
+	key := "3f1c9a52-1f8e-4d1e-9c1a-1b2c3d4e5f60"
+	fingerprint := "fingerprint"
+
+	testError := errors.New("test error")
+
+	reserved := domain.IdempotencyKey{
+		Key:         key,
+		UserID:      userID,
+		Fingerprint: fingerprint,
+		DateCreated: time.Now(),
+		ExpiresAt:   time.Now().Add(time.Hour),
+		LockedUntil: time.Now().Add(time.Minute),
+	}
+
+	completed := reserved
+	completed.StatusCode = 204
+
+	type args struct {
+		ctx         context.Context
+		userID      int
+		key         string
+		fingerprint string
+	}
+	tests := []struct {
+		name          string
+		args          args
+		configureMock func()
+		want          domain.IdempotencyKey
+		wantReplay    bool
+		wantErr       error
+	}{
+		{
+			name: "reserving_repository_error",
+			args: args{
+				ctx:         ctx,
+				userID:      userID,
+				key:         key,
+				fingerprint: fingerprint,
+			},
+			configureMock: func() {
+				idempotencyRepositoryMock.EXPECT().Reserve(gomock.Eq(ctx), gomock.Any()).Return(domain.IdempotencyKey{}, false, testError)
+			},
+			want:    domain.IdempotencyKey{},
+			wantErr: testError,
+		},
+		{
+			name: "key_reused_with_different_request",
+			args: args{
+				ctx:         ctx,
+				userID:      userID,
+				key:         key,
+				fingerprint: "other fingerprint",
+			},
+			configureMock: func() {
+				idempotencyRepositoryMock.EXPECT().Reserve(gomock.Eq(ctx), gomock.Any()).Return(completed, false, nil)
+			},
+			want:    domain.IdempotencyKey{},
+			wantErr: domain.ErrIdempotencyKeyReused,
+		},
+		{
+			name: "request_in_progress",
+			args: args{
+				ctx:         ctx,
+				userID:      userID,
+				key:         key,
+				fingerprint: fingerprint,
+			},
+			configureMock: func() {
+				idempotencyRepositoryMock.EXPECT().Reserve(gomock.Eq(ctx), gomock.Any()).Return(reserved, false, nil)
+			},
+			want:    domain.IdempotencyKey{},
+			wantErr: domain.ErrIdempotencyRequestInProgress,
+		},
+		{
+			name: "replay",
+			args: args{
+				ctx:         ctx,
+				userID:      userID,
+				key:         key,
+				fingerprint: fingerprint,
+			},
+			configureMock: func() {
+				idempotencyRepositoryMock.EXPECT().Reserve(gomock.Eq(ctx), gomock.Any()).Return(completed, false, nil)
+			},
+			want:       completed,
+			wantReplay: true,
+		},
+		{
+			name: "success",
+			args: args{
+				ctx:         ctx,
+				userID:      userID,
+				key:         key,
+				fingerprint: fingerprint,
+			},
+			configureMock: func() {
+				idempotencyRepositoryMock.EXPECT().Reserve(gomock.Eq(ctx), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, k domain.IdempotencyKey) (domain.IdempotencyKey, bool, error) {
+						assert.Equal(t, key, k.Key)
+						assert.Equal(t, userID, k.UserID)
+						assert.Equal(t, fingerprint, k.Fingerprint)
+						assert.True(t, k.ExpiresAt.After(time.Now()))
+						assert.True(t, k.LockedUntil.After(time.Now()))
+						assert.True(t, k.LockedUntil.Before(k.ExpiresAt))
+						assert.Len(t, k.Token, idempotencyTokenSize*2)
+
+						return reserved, true, nil
+					})
+			},
+			want: reserved,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s := NewIdempotency(idempotencyRepositoryMock, time.Hour, time.Minute)
+			got, replay, err := s.Begin(tt.args.ctx, tt.args.userID, tt.args.key, tt.args.fingerprint)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantReplay, replay)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIdempotency_Complete(t *testing.T) {
+	controller := gomock.NewController(t)
+	idempotencyRepositoryMock := NewMockIdempotencyRepository(controller)
+
+	ctx := context.Background()
+	body := []byte(`{"id":1}`)
+
+	idempotencyRepositoryMock.EXPECT().Complete(gomock.Eq(ctx), gomock.Eq(1), gomock.Eq("key"), gomock.Eq("token"), gomock.Eq(201), gomock.Eq(body)).
+		Return(domain.ErrIdempotencyReservationLost)
+
+	s := NewIdempotency(idempotencyRepositoryMock, time.Hour, time.Minute)
+	err := s.Complete(ctx, 1, "key", "token", 201, body)
+	assert.ErrorIs(t, err, domain.ErrIdempotencyReservationLost)
+}
+
+func TestIdempotency_RequestTimeout(t *testing.T) {
+	s := NewIdempotency(nil, time.Hour, time.Minute)
+	assert.Equal(t, time.Second*30, s.RequestTimeout())
+}
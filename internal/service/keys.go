@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	rsaKeyBits = 2048
+	keyIDSize  = 8
+)
+
+// Keys keeps the access token signing keys and rotates them.
+// The newest key signs new tokens, older keys stay available for verification until the overlap after their successor was created has passed,
+// so the overlap must be at least the access token TTL.
+type Keys struct {
+	keyRepo          SigningKeyRepository
+	algorithm        string
+	rotationInterval time.Duration
+	overlap          time.Duration
+	reloadInterval   time.Duration
+
+	mu       sync.RWMutex
+	keys     []domain.SigningKey
+	loadedAt time.Time
+
+	// reloadMu serializes the reloads of the keys caused by unknown key IDs
+	reloadMu sync.Mutex
+}
+
+// NewKeys constructor for Keys.
+// Unknown key IDs of the verified tokens reload the keys at most once per reloadInterval.
+func NewKeys(keyRepo SigningKeyRepository, algorithm string, rotationInterval, overlap, reloadInterval time.Duration) (*Keys, error) {
+	if algorithm != domain.SigningAlgorithmRS256 && algorithm != domain.SigningAlgorithmEdDSA {
+		return nil, errors.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+
+	return &Keys{
+		keyRepo:          keyRepo,
+		algorithm:        algorithm,
+		rotationInterval: rotationInterval,
+		overlap:          overlap,
+		reloadInterval:   reloadInterval,
+	}, nil
+}
+
+// Run rotates the keys every interval until the context is done.
+func (k *Keys) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := k.Rotate(ctx); err != nil {
+				logrus.WithError(err).
+					WithFields(logrus.Fields{
+						"layer":   "service",
+						"service": "Keys",
+						"method":  "Run",
+					}).
+					Error("rotating signing keys error")
+			}
+		}
+	}
+}
+
+// Rotate creates a new signing key when there is none or the newest one is older than the rotation interval,
+// removes the keys which are out of the overlap and reloads the keys.
+// Keys added to the directory by other instances or by hand are picked up as well.
+func (k *Keys) Rotate(ctx context.Context) error {
+	keys, err := k.list(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	if len(keys) == 0 || now.Sub(keys[0].CreatedAt) >= k.rotationInterval {
+		key, err := k.generate(now)
+		if err != nil {
+			return errors.Wrap(err, "generating signing key error")
+		}
+
+		if err := k.keyRepo.Create(ctx, key); err != nil {
+			return errors.Wrap(err, "creating signing key error")
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"layer":   "service",
+			"service": "Keys",
+			"method":  "Rotate",
+			"kid":     key.ID,
+		}).Info("signing key created")
+
+		keys = append([]domain.SigningKey{key}, keys...)
+	}
+
+	active, retired := k.split(keys, now)
+	for _, key := range retired {
+		if err := k.keyRepo.Delete(ctx, key.ID); err != nil {
+			return errors.Wrap(err, "deleting retired signing key error")
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"layer":   "service",
+			"service": "Keys",
+			"method":  "Rotate",
+			"kid":     key.ID,
+		}).Info("signing key retired")
+	}
+
+	k.mu.Lock()
+	k.keys = active
+	k.loadedAt = now
+	k.mu.Unlock()
+
+	return nil
+}
+
+// SigningKey returns the newest key, used to sign new access tokens.
+func (k *Keys) SigningKey() (domain.SigningKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if len(k.keys) == 0 {
+		return domain.SigningKey{}, errors.New("no signing keys loaded")
+	}
+
+	return k.keys[0], nil
+}
+
+// VerificationKey returns the key with the given ID.
+// An unknown ID reloads the keys, so tokens signed by a key just created by another instance are accepted.
+// The keys are reloaded at most once per reload interval, tokens with made up IDs can not make every request read the keys.
+func (k *Keys) VerificationKey(ctx context.Context, id string) (domain.SigningKey, error) {
+	if key, ok := k.find(id); ok {
+		return key, nil
+	}
+
+	k.reloadMu.Lock()
+	defer k.reloadMu.Unlock()
+
+	// the keys may have been reloaded by a concurrent request meanwhile
+	if key, ok := k.find(id); ok {
+		return key, nil
+	}
+
+	k.mu.RLock()
+	reloadAt := k.loadedAt.Add(k.reloadInterval)
+	k.mu.RUnlock()
+
+	now := time.Now()
+	if now.Before(reloadAt) {
+		return domain.SigningKey{}, errors.Wrap(domain.ErrSigningKeyNotFound, "signing key lookup error")
+	}
+
+	keys, err := k.list(ctx)
+	if err != nil {
+		return domain.SigningKey{}, err
+	}
+
+	k.mu.Lock()
+	if len(keys) != 0 {
+		k.keys, _ = k.split(keys, now)
+	}
+	k.loadedAt = now
+	k.mu.Unlock()
+
+	if key, ok := k.find(id); ok {
+		return key, nil
+	}
+
+	return domain.SigningKey{}, errors.Wrap(domain.ErrSigningKeyNotFound, "signing key lookup error")
+}
+
+// PublicKeys returns all keys which are valid for verification, newest first.
+func (k *Keys) PublicKeys() []domain.SigningKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return append([]domain.SigningKey(nil), k.keys...)
+}
+
+// find looks the key up among the loaded keys.
+func (k *Keys) find(id string) (domain.SigningKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for _, key := range k.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+
+	return domain.SigningKey{}, false
+}
+
+// split splits the keys sorted newest first into the keys valid for verification and the keys out of the overlap after their successor.
+func (k *Keys) split(keys []domain.SigningKey, now time.Time) ([]domain.SigningKey, []domain.SigningKey) {
+	active := keys[:1]
+	var retired []domain.SigningKey
+	for i := 1; i < len(keys); i++ {
+		if now.Sub(keys[i-1].CreatedAt) < k.overlap {
+			active = append(active, keys[i])
+			continue
+		}
+
+		retired = append(retired, keys[i])
+	}
+
+	return active, retired
+}
+
+// list returns the stored keys newest first.
+func (k *Keys) list(ctx context.Context) ([]domain.SigningKey, error) {
+	keys, err := k.keyRepo.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting signing keys error")
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+// generate creates a new key of the configured algorithm.
+func (k *Keys) generate(now time.Time) (domain.SigningKey, error) {
+	id, err := newRandomToken(keyIDSize)
+	if err != nil {
+		return domain.SigningKey{}, err
+	}
+
+	var signer crypto.Signer
+
+	switch k.algorithm {
+	case domain.SigningAlgorithmRS256:
+		signer, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case domain.SigningAlgorithmEdDSA:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	}
+
+	if err != nil {
+		return domain.SigningKey{}, err
+	}
+
+	return domain.SigningKey{
+		ID:         id,
+		Algorithm:  k.algorithm,
+		PrivateKey: signer,
+		CreatedAt:  now,
+	}, nil
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestKeys returns Keys with a single loaded Ed25519 key with the given ID.
+func newTestKeys(t *testing.T, keyRepo SigningKeyRepository, id string) *Keys {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	keys, err := NewKeys(keyRepo, domain.SigningAlgorithmEdDSA, time.Hour, time.Hour, time.Minute)
+	assert.NoError(t, err)
+
+	keys.keys = []domain.SigningKey{{ID: id, Algorithm: domain.SigningAlgorithmEdDSA, PrivateKey: private, CreatedAt: time.Now()}}
+
+	return keys
+}
+
+func TestKeys_Rotate(t *testing.T) {
+	controller := gomock.NewController(t)
+	signingKeyRepositoryMock := NewMockSigningKeyRepository(controller)
+
+	ctx := context.Background()
+	testError := errors.New("test error")
+	rotationInterval := 30 * 24 * time.Hour
+	overlap := 24 * time.Hour
+	now := time.Now()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	key := func(id string, age time.Duration) domain.SigningKey {
+		return domain.SigningKey{ID: id, Algorithm: domain.SigningAlgorithmEdDSA, PrivateKey: private, CreatedAt: now.Add(-age)}
+	}
+
+	tests := []struct {
+		name          string
+		configureMock func()
+		wantErr       bool
+		wantKeys      []string
+		wantNewKey    bool
+	}{
+		{
+			name: "listing_keys_error",
+			configureMock: func() {
+				signingKeyRepositoryMock.EXPECT().List(gomock.Eq(ctx)).Return(nil, testError)
+			},
+			wantErr: true,
+		},
+		{
+			name: "creating_first_key_error",
+			configureMock: func() {
+				signingKeyRepositoryMock.EXPECT().List(gomock.Eq(ctx)).Return(nil, nil)
+				signingKeyRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Any()).Return(testError)
+			},
+			wantErr: true,
+		},
+		{
+			name: "first_key_created",
+			configureMock: func() {
+				signingKeyRepositoryMock.EXPECT().List(gomock.Eq(ctx)).Return(nil, nil)
+				signingKeyRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Any()).DoAndReturn(func(_ context.Context, created domain.SigningKey) error {
+					assert.NotEmpty(t, created.ID)
+					assert.Equal(t, domain.SigningAlgorithmEdDSA, created.Algorithm)
+					return nil
+				})
+			},
+			wantNewKey: true,
+		},
+		{
+			name: "fresh_key_kept",
+			configureMock: func() {
+				signingKeyRepositoryMock.EXPECT().List(gomock.Eq(ctx)).Return([]domain.SigningKey{key("old", 2*time.Hour), key("new", time.Hour)}, nil)
+			},
+			wantKeys: []string{"new", "old"},
+		},
+		{
+			name: "expired_key_rotated_and_kept_for_overlap",
+			configureMock: func() {
+				signingKeyRepositoryMock.EXPECT().List(gomock.Eq(ctx)).Return([]domain.SigningKey{key("current", rotationInterval+time.Hour)}, nil)
+				signingKeyRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Any()).Return(nil)
+			},
+			wantKeys:   []string{"current"},
+			wantNewKey: true,
+		},
+		{
+			name: "key_out_of_overlap_deleted",
+			configureMock: func() {
+				signingKeyRepositoryMock.EXPECT().List(gomock.Eq(ctx)).Return([]domain.SigningKey{key("retired", 2*rotationInterval), key("previous", overlap+time.Hour), key("current", time.Hour)}, nil)
+				signingKeyRepositoryMock.EXPECT().Delete(gomock.Eq(ctx), gomock.Eq("retired")).Return(nil)
+			},
+			wantKeys: []string{"current", "previous"},
+		},
+		{
+			name: "deleting_key_error",
+			configureMock: func() {
+				signingKeyRepositoryMock.EXPECT().List(gomock.Eq(ctx)).Return([]domain.SigningKey{key("retired", 2*rotationInterval), key("current", overlap+time.Hour)}, nil)
+				signingKeyRepositoryMock.EXPECT().Delete(gomock.Eq(ctx), gomock.Eq("retired")).Return(testError)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			k, err := NewKeys(signingKeyRepositoryMock, domain.SigningAlgorithmEdDSA, rotationInterval, overlap, time.Minute)
+			assert.NoError(t, err)
+
+			err = k.Rotate(ctx)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantErr {
+				return
+			}
+
+			ids := make([]string, 0)
+			for _, key := range k.PublicKeys() {
+				ids = append(ids, key.ID)
+			}
+
+			if tt.wantNewKey {
+				assert.Len(t, ids, len(tt.wantKeys)+1)
+				ids = ids[1:]
+			}
+
+			assert.Equal(t, append([]string{}, tt.wantKeys...), ids)
+		})
+	}
+}
+
+func TestKeys_VerificationKey(t *testing.T) {
+	controller := gomock.NewController(t)
+	signingKeyRepositoryMock := NewMockSigningKeyRepository(controller)
+
+	ctx := context.Background()
+	k := newTestKeys(t, signingKeyRepositoryMock, "loaded")
+	stored := newTestKeys(t, nil, "stored").PublicKeys()[0]
+
+	key, err := k.VerificationKey(ctx, "loaded")
+	assert.NoError(t, err)
+	assert.Equal(t, "loaded", key.ID)
+
+	signingKeyRepositoryMock.EXPECT().List(gomock.Eq(ctx)).Return([]domain.SigningKey{stored}, nil)
+	key, err = k.VerificationKey(ctx, "stored")
+	assert.NoError(t, err)
+	assert.Equal(t, "stored", key.ID)
+
+	// the reloaded key is kept, unknown IDs do not reload the keys again within the reload interval
+	key, err = k.VerificationKey(ctx, "stored")
+	assert.NoError(t, err)
+	assert.Equal(t, "stored", key.ID)
+
+	_, err = k.VerificationKey(ctx, "unknown")
+	assert.ErrorIs(t, err, domain.ErrSigningKeyNotFound)
+
+	k.loadedAt = time.Now().Add(-time.Minute)
+	signingKeyRepositoryMock.EXPECT().List(gomock.Eq(ctx)).Return([]domain.SigningKey{stored}, nil)
+	_, err = k.VerificationKey(ctx, "unknown")
+	assert.ErrorIs(t, err, domain.ErrSigningKeyNotFound)
+}
+
+func TestNewKeys(t *testing.T) {
+	_, err := NewKeys(nil, "HS256", time.Hour, time.Hour, time.Minute)
+	assert.Error(t, err)
+}
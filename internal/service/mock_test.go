@@ -6,7 +6,9 @@ package service
 
 import (
 	context "context"
+	big "math/big"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	domain "github.com/lukinairina90/banking_backend/internal/domain"
@@ -35,6 +37,35 @@ func (m *MockRolesRepository) EXPECT() *MockRolesRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AssignRole mocks base method.
+func (m *MockRolesRepository) AssignRole(ctx context.Context, userID, roleID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignRole", ctx, userID, roleID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignRole indicates an expected call of AssignRole.
+func (mr *MockRolesRepositoryMockRecorder) AssignRole(ctx, userID, roleID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignRole", reflect.TypeOf((*MockRolesRepository)(nil).AssignRole), ctx, userID, roleID)
+}
+
+// Create mocks base method.
+func (m *MockRolesRepository) Create(ctx context.Context, name string) (domain.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, name)
+	ret0, _ := ret[0].(domain.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockRolesRepositoryMockRecorder) Create(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRolesRepository)(nil).Create), ctx, name)
+}
+
 // GetByName mocks base method.
 func (m *MockRolesRepository) GetByName(ctx context.Context, name string) (domain.Role, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +81,65 @@ func (mr *MockRolesRepositoryMockRecorder) GetByName(ctx, name interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByName", reflect.TypeOf((*MockRolesRepository)(nil).GetByName), ctx, name)
 }
 
+// GetRoles mocks base method.
+func (m *MockRolesRepository) GetRoles(ctx context.Context) ([]domain.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoles", ctx)
+	ret0, _ := ret[0].([]domain.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoles indicates an expected call of GetRoles.
+func (mr *MockRolesRepositoryMockRecorder) GetRoles(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoles", reflect.TypeOf((*MockRolesRepository)(nil).GetRoles), ctx)
+}
+
+// GetUserRoles mocks base method.
+func (m *MockRolesRepository) GetUserRoles(ctx context.Context, userID int) ([]domain.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRoles", ctx, userID)
+	ret0, _ := ret[0].([]domain.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRoles indicates an expected call of GetUserRoles.
+func (mr *MockRolesRepositoryMockRecorder) GetUserRoles(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRoles", reflect.TypeOf((*MockRolesRepository)(nil).GetUserRoles), ctx, userID)
+}
+
+// LockUserRoles mocks base method.
+func (m *MockRolesRepository) LockUserRoles(ctx context.Context, userID int) ([]domain.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockUserRoles", ctx, userID)
+	ret0, _ := ret[0].([]domain.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockUserRoles indicates an expected call of LockUserRoles.
+func (mr *MockRolesRepositoryMockRecorder) LockUserRoles(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockUserRoles", reflect.TypeOf((*MockRolesRepository)(nil).LockUserRoles), ctx, userID)
+}
+
+// RevokeRole mocks base method.
+func (m *MockRolesRepository) RevokeRole(ctx context.Context, userID, roleID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRole", ctx, userID, roleID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRole indicates an expected call of RevokeRole.
+func (mr *MockRolesRepositoryMockRecorder) RevokeRole(ctx, userID, roleID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRole", reflect.TypeOf((*MockRolesRepository)(nil).RevokeRole), ctx, userID, roleID)
+}
+
 // MockSessionRepository is a mock of SessionRepository interface.
 type MockSessionRepository struct {
 	ctrl     *gomock.Controller
@@ -88,18 +178,90 @@ func (mr *MockSessionRepositoryMockRecorder) Create(ctx, token interface{}) *gom
 }
 
 // Get mocks base method.
-func (m *MockSessionRepository) Get(ctx context.Context, token string) (domain.RefreshSession, error) {
+func (m *MockSessionRepository) Get(ctx context.Context, tokenHash string) (domain.RefreshSession, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", ctx, token)
+	ret := m.ctrl.Call(m, "Get", ctx, tokenHash)
 	ret0, _ := ret[0].(domain.RefreshSession)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get.
-func (mr *MockSessionRepositoryMockRecorder) Get(ctx, token interface{}) *gomock.Call {
+func (mr *MockSessionRepositoryMockRecorder) Get(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockSessionRepository)(nil).Get), ctx, tokenHash)
+}
+
+// GetActiveSessions mocks base method.
+func (m *MockSessionRepository) GetActiveSessions(ctx context.Context, userID int) ([]domain.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveSessions", ctx, userID)
+	ret0, _ := ret[0].([]domain.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveSessions indicates an expected call of GetActiveSessions.
+func (mr *MockSessionRepositoryMockRecorder) GetActiveSessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveSessions", reflect.TypeOf((*MockSessionRepository)(nil).GetActiveSessions), ctx, userID)
+}
+
+// Lock mocks base method.
+func (m *MockSessionRepository) Lock(ctx context.Context, tokenHash string) (domain.RefreshSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, tokenHash)
+	ret0, _ := ret[0].(domain.RefreshSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockSessionRepositoryMockRecorder) Lock(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockSessionRepository)(nil).Lock), ctx, tokenHash)
+}
+
+// MarkRotated mocks base method.
+func (m *MockSessionRepository) MarkRotated(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRotated", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRotated indicates an expected call of MarkRotated.
+func (mr *MockSessionRepositoryMockRecorder) MarkRotated(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRotated", reflect.TypeOf((*MockSessionRepository)(nil).MarkRotated), ctx, id)
+}
+
+// RevokeAll mocks base method.
+func (m *MockSessionRepository) RevokeAll(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAll", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAll indicates an expected call of RevokeAll.
+func (mr *MockSessionRepositoryMockRecorder) RevokeAll(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAll", reflect.TypeOf((*MockSessionRepository)(nil).RevokeAll), ctx, userID)
+}
+
+// RevokeFamily mocks base method.
+func (m *MockSessionRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeFamily", ctx, familyID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeFamily indicates an expected call of RevokeFamily.
+func (mr *MockSessionRepositoryMockRecorder) RevokeFamily(ctx, familyID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockSessionRepository)(nil).Get), ctx, token)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeFamily", reflect.TypeOf((*MockSessionRepository)(nil).RevokeFamily), ctx, familyID)
 }
 
 // MockRandomGenerator is a mock of RandomGenerator interface.
@@ -167,6 +329,43 @@ func (mr *MockRandomGeneratorMockRecorder) GenerateRandomIban() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateRandomIban", reflect.TypeOf((*MockRandomGenerator)(nil).GenerateRandomIban))
 }
 
+// MockTransactor is a mock of Transactor interface.
+type MockTransactor struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactorMockRecorder
+}
+
+// MockTransactorMockRecorder is the mock recorder for MockTransactor.
+type MockTransactorMockRecorder struct {
+	mock *MockTransactor
+}
+
+// NewMockTransactor creates a new mock instance.
+func NewMockTransactor(ctrl *gomock.Controller) *MockTransactor {
+	mock := &MockTransactor{ctrl: ctrl}
+	mock.recorder = &MockTransactorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactor) EXPECT() *MockTransactorMockRecorder {
+	return m.recorder
+}
+
+// WithinTransaction mocks base method.
+func (m *MockTransactor) WithinTransaction(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinTransaction indicates an expected call of WithinTransaction.
+func (mr *MockTransactorMockRecorder) WithinTransaction(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTransaction", reflect.TypeOf((*MockTransactor)(nil).WithinTransaction), ctx, fn)
+}
+
 // MockAccountRepository is a mock of AccountRepository interface.
 type MockAccountRepository struct {
 	ctrl     *gomock.Controller
@@ -233,166 +432,200 @@ func (mr *MockAccountRepositoryMockRecorder) DeleteAccount(ctx, accountID interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockAccountRepository)(nil).DeleteAccount), ctx, accountID)
 }
 
-// DepositAccount mocks base method.
-func (m *MockAccountRepository) DepositAccount(ctx context.Context, accountID int, amount float64) error {
+// GetAccount mocks base method.
+func (m *MockAccountRepository) GetAccount(ctx context.Context, accountID, userID int) (domain.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DepositAccount", ctx, accountID, amount)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetAccount", ctx, accountID, userID)
+	ret0, _ := ret[0].(domain.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DepositAccount indicates an expected call of DepositAccount.
-func (mr *MockAccountRepositoryMockRecorder) DepositAccount(ctx, accountID, amount interface{}) *gomock.Call {
+// GetAccount indicates an expected call of GetAccount.
+func (mr *MockAccountRepositoryMockRecorder) GetAccount(ctx, accountID, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DepositAccount", reflect.TypeOf((*MockAccountRepository)(nil).DepositAccount), ctx, accountID, amount)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockAccountRepository)(nil).GetAccount), ctx, accountID, userID)
 }
 
-// ExistsAccount mocks base method.
-func (m *MockAccountRepository) ExistsAccount(ctx context.Context, accountID int) (bool, error) {
+// GetAccountIDByIban mocks base method.
+func (m *MockAccountRepository) GetAccountIDByIban(ctx context.Context, iban string) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ExistsAccount", ctx, accountID)
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "GetAccountIDByIban", ctx, iban)
+	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ExistsAccount indicates an expected call of ExistsAccount.
-func (mr *MockAccountRepositoryMockRecorder) ExistsAccount(ctx, accountID interface{}) *gomock.Call {
+// GetAccountIDByIban indicates an expected call of GetAccountIDByIban.
+func (mr *MockAccountRepositoryMockRecorder) GetAccountIDByIban(ctx, iban interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExistsAccount", reflect.TypeOf((*MockAccountRepository)(nil).ExistsAccount), ctx, accountID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountIDByIban", reflect.TypeOf((*MockAccountRepository)(nil).GetAccountIDByIban), ctx, iban)
 }
 
-// GetAccount mocks base method.
-func (m *MockAccountRepository) GetAccount(ctx context.Context, accountID, userID int) (domain.Account, error) {
+// GetAccountsList mocks base method.
+func (m *MockAccountRepository) GetAccountsList(ctx context.Context, userID int, paginator domain.Paginator, ordering domain.Orderings) ([]domain.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccount", ctx, accountID, userID)
-	ret0, _ := ret[0].(domain.Account)
+	ret := m.ctrl.Call(m, "GetAccountsList", ctx, userID, paginator, ordering)
+	ret0, _ := ret[0].([]domain.Account)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAccount indicates an expected call of GetAccount.
-func (mr *MockAccountRepositoryMockRecorder) GetAccount(ctx, accountID, userID interface{}) *gomock.Call {
+// GetAccountsList indicates an expected call of GetAccountsList.
+func (mr *MockAccountRepositoryMockRecorder) GetAccountsList(ctx, userID, paginator, ordering interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockAccountRepository)(nil).GetAccount), ctx, accountID, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountsList", reflect.TypeOf((*MockAccountRepository)(nil).GetAccountsList), ctx, userID, paginator, ordering)
 }
 
-// GetAccountAmount mocks base method.
-func (m *MockAccountRepository) GetAccountAmount(ctx context.Context, accountID, userID int) (float64, error) {
+// GetUserIDByAccountID mocks base method.
+func (m *MockAccountRepository) GetUserIDByAccountID(ctx context.Context, accountID int) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccountAmount", ctx, accountID, userID)
-	ret0, _ := ret[0].(float64)
+	ret := m.ctrl.Call(m, "GetUserIDByAccountID", ctx, accountID)
+	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAccountAmount indicates an expected call of GetAccountAmount.
-func (mr *MockAccountRepositoryMockRecorder) GetAccountAmount(ctx, accountID, userID interface{}) *gomock.Call {
+// GetUserIDByAccountID indicates an expected call of GetUserIDByAccountID.
+func (mr *MockAccountRepositoryMockRecorder) GetUserIDByAccountID(ctx, accountID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountAmount", reflect.TypeOf((*MockAccountRepository)(nil).GetAccountAmount), ctx, accountID, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIDByAccountID", reflect.TypeOf((*MockAccountRepository)(nil).GetUserIDByAccountID), ctx, accountID)
 }
 
-// GetAccountCurrencyIDByID mocks base method.
-func (m *MockAccountRepository) GetAccountCurrencyIDByID(ctx context.Context, accountID int) (int, error) {
+// LockAccount mocks base method.
+func (m *MockAccountRepository) LockAccount(ctx context.Context, accountID int) (domain.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccountCurrencyIDByID", ctx, accountID)
-	ret0, _ := ret[0].(int)
+	ret := m.ctrl.Call(m, "LockAccount", ctx, accountID)
+	ret0, _ := ret[0].(domain.Account)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAccountCurrencyIDByID indicates an expected call of GetAccountCurrencyIDByID.
-func (mr *MockAccountRepositoryMockRecorder) GetAccountCurrencyIDByID(ctx, accountID interface{}) *gomock.Call {
+// LockAccount indicates an expected call of LockAccount.
+func (mr *MockAccountRepositoryMockRecorder) LockAccount(ctx, accountID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountCurrencyIDByID", reflect.TypeOf((*MockAccountRepository)(nil).GetAccountCurrencyIDByID), ctx, accountID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockAccount", reflect.TypeOf((*MockAccountRepository)(nil).LockAccount), ctx, accountID)
 }
 
-// GetAccountCurrencyIDByIban mocks base method.
-func (m *MockAccountRepository) GetAccountCurrencyIDByIban(ctx context.Context, iban string) (int, error) {
+// UnblockAccount mocks base method.
+func (m *MockAccountRepository) UnblockAccount(ctx context.Context, accountID int) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccountCurrencyIDByIban", ctx, iban)
+	ret := m.ctrl.Call(m, "UnblockAccount", ctx, accountID)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAccountCurrencyIDByIban indicates an expected call of GetAccountCurrencyIDByIban.
-func (mr *MockAccountRepositoryMockRecorder) GetAccountCurrencyIDByIban(ctx, iban interface{}) *gomock.Call {
+// UnblockAccount indicates an expected call of UnblockAccount.
+func (mr *MockAccountRepositoryMockRecorder) UnblockAccount(ctx, accountID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountCurrencyIDByIban", reflect.TypeOf((*MockAccountRepository)(nil).GetAccountCurrencyIDByIban), ctx, iban)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnblockAccount", reflect.TypeOf((*MockAccountRepository)(nil).UnblockAccount), ctx, accountID)
 }
 
-// GetAccountIDByIban mocks base method.
-func (m *MockAccountRepository) GetAccountIDByIban(ctx context.Context, iban string) (int, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccountIDByIban", ctx, iban)
-	ret0, _ := ret[0].(int)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+// MockLedgerRepository is a mock of LedgerRepository interface.
+type MockLedgerRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockLedgerRepositoryMockRecorder
 }
 
-// GetAccountIDByIban indicates an expected call of GetAccountIDByIban.
-func (mr *MockAccountRepositoryMockRecorder) GetAccountIDByIban(ctx, iban interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountIDByIban", reflect.TypeOf((*MockAccountRepository)(nil).GetAccountIDByIban), ctx, iban)
+// MockLedgerRepositoryMockRecorder is the mock recorder for MockLedgerRepository.
+type MockLedgerRepositoryMockRecorder struct {
+	mock *MockLedgerRepository
 }
 
-// GetAccountsList mocks base method.
-func (m *MockAccountRepository) GetAccountsList(ctx context.Context, userID int, paginator domain.Paginator, ordering domain.Orderings) ([]domain.Account, error) {
+// NewMockLedgerRepository creates a new mock instance.
+func NewMockLedgerRepository(ctrl *gomock.Controller) *MockLedgerRepository {
+	mock := &MockLedgerRepository{ctrl: ctrl}
+	mock.recorder = &MockLedgerRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLedgerRepository) EXPECT() *MockLedgerRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateJournalEntry mocks base method.
+func (m *MockLedgerRepository) CreateJournalEntry(ctx context.Context, entry domain.JournalEntry) (domain.JournalEntry, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccountsList", ctx, userID, paginator, ordering)
-	ret0, _ := ret[0].([]domain.Account)
+	ret := m.ctrl.Call(m, "CreateJournalEntry", ctx, entry)
+	ret0, _ := ret[0].(domain.JournalEntry)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAccountsList indicates an expected call of GetAccountsList.
-func (mr *MockAccountRepositoryMockRecorder) GetAccountsList(ctx, userID, paginator, ordering interface{}) *gomock.Call {
+// CreateJournalEntry indicates an expected call of CreateJournalEntry.
+func (mr *MockLedgerRepositoryMockRecorder) CreateJournalEntry(ctx, entry interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountsList", reflect.TypeOf((*MockAccountRepository)(nil).GetAccountsList), ctx, userID, paginator, ordering)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJournalEntry", reflect.TypeOf((*MockLedgerRepository)(nil).CreateJournalEntry), ctx, entry)
 }
 
-// GetUserIDByAccountID mocks base method.
-func (m *MockAccountRepository) GetUserIDByAccountID(ctx context.Context, accountID int) (int, error) {
+// HasJournalEntry mocks base method.
+func (m *MockLedgerRepository) HasJournalEntry(ctx context.Context, transactionID int) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserIDByAccountID", ctx, accountID)
-	ret0, _ := ret[0].(int)
+	ret := m.ctrl.Call(m, "HasJournalEntry", ctx, transactionID)
+	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserIDByAccountID indicates an expected call of GetUserIDByAccountID.
-func (mr *MockAccountRepositoryMockRecorder) GetUserIDByAccountID(ctx, accountID interface{}) *gomock.Call {
+// HasJournalEntry indicates an expected call of HasJournalEntry.
+func (mr *MockLedgerRepositoryMockRecorder) HasJournalEntry(ctx, transactionID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIDByAccountID", reflect.TypeOf((*MockAccountRepository)(nil).GetUserIDByAccountID), ctx, accountID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasJournalEntry", reflect.TypeOf((*MockLedgerRepository)(nil).HasJournalEntry), ctx, transactionID)
+}
+
+// MockCurrencyRepository is a mock of CurrencyRepository interface.
+type MockCurrencyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCurrencyRepositoryMockRecorder
+}
+
+// MockCurrencyRepositoryMockRecorder is the mock recorder for MockCurrencyRepository.
+type MockCurrencyRepositoryMockRecorder struct {
+	mock *MockCurrencyRepository
+}
+
+// NewMockCurrencyRepository creates a new mock instance.
+func NewMockCurrencyRepository(ctrl *gomock.Controller) *MockCurrencyRepository {
+	mock := &MockCurrencyRepository{ctrl: ctrl}
+	mock.recorder = &MockCurrencyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCurrencyRepository) EXPECT() *MockCurrencyRepositoryMockRecorder {
+	return m.recorder
 }
 
-// TransferAccount mocks base method.
-func (m *MockAccountRepository) TransferAccount(ctx context.Context, fromAccountID, userID int, amount float64, toAccountIban string) error {
+// GetByCode mocks base method.
+func (m *MockCurrencyRepository) GetByCode(ctx context.Context, code string) (domain.Currency, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TransferAccount", ctx, fromAccountID, userID, amount, toAccountIban)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetByCode", ctx, code)
+	ret0, _ := ret[0].(domain.Currency)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// TransferAccount indicates an expected call of TransferAccount.
-func (mr *MockAccountRepositoryMockRecorder) TransferAccount(ctx, fromAccountID, userID, amount, toAccountIban interface{}) *gomock.Call {
+// GetByCode indicates an expected call of GetByCode.
+func (mr *MockCurrencyRepositoryMockRecorder) GetByCode(ctx, code interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferAccount", reflect.TypeOf((*MockAccountRepository)(nil).TransferAccount), ctx, fromAccountID, userID, amount, toAccountIban)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByCode", reflect.TypeOf((*MockCurrencyRepository)(nil).GetByCode), ctx, code)
 }
 
-// UnblockAccount mocks base method.
-func (m *MockAccountRepository) UnblockAccount(ctx context.Context, accountID, userID int) error {
+// GetByID mocks base method.
+func (m *MockCurrencyRepository) GetByID(ctx context.Context, id int) (domain.Currency, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UnblockAccount", ctx, accountID, userID)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(domain.Currency)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// UnblockAccount indicates an expected call of UnblockAccount.
-func (mr *MockAccountRepositoryMockRecorder) UnblockAccount(ctx, accountID, userID interface{}) *gomock.Call {
+// GetByID indicates an expected call of GetByID.
+func (mr *MockCurrencyRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnblockAccount", reflect.TypeOf((*MockAccountRepository)(nil).UnblockAccount), ctx, accountID, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockCurrencyRepository)(nil).GetByID), ctx, id)
 }
 
 // MockTransactionRepository is a mock of TransactionRepository interface.
@@ -419,18 +652,48 @@ func (m *MockTransactionRepository) EXPECT() *MockTransactionRepositoryMockRecor
 }
 
 // CreateTransaction mocks base method.
-func (m *MockTransactionRepository) CreateTransaction(ctx context.Context, fromAccountID, toAccountID int, amount float64) (domain.Transaction, error) {
+func (m *MockTransactionRepository) CreateTransaction(ctx context.Context, transaction domain.Transaction) (domain.Transaction, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateTransaction", ctx, fromAccountID, toAccountID, amount)
+	ret := m.ctrl.Call(m, "CreateTransaction", ctx, transaction)
 	ret0, _ := ret[0].(domain.Transaction)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateTransaction indicates an expected call of CreateTransaction.
-func (mr *MockTransactionRepositoryMockRecorder) CreateTransaction(ctx, fromAccountID, toAccountID, amount interface{}) *gomock.Call {
+func (mr *MockTransactionRepositoryMockRecorder) CreateTransaction(ctx, transaction interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransaction", reflect.TypeOf((*MockTransactionRepository)(nil).CreateTransaction), ctx, transaction)
+}
+
+// GetReversals mocks base method.
+func (m *MockTransactionRepository) GetReversals(ctx context.Context, transactionID int) ([]domain.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReversals", ctx, transactionID)
+	ret0, _ := ret[0].([]domain.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReversals indicates an expected call of GetReversals.
+func (mr *MockTransactionRepositoryMockRecorder) GetReversals(ctx, transactionID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransaction", reflect.TypeOf((*MockTransactionRepository)(nil).CreateTransaction), ctx, fromAccountID, toAccountID, amount)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReversals", reflect.TypeOf((*MockTransactionRepository)(nil).GetReversals), ctx, transactionID)
+}
+
+// GetStalePreparedTransactions mocks base method.
+func (m *MockTransactionRepository) GetStalePreparedTransactions(ctx context.Context, before time.Time, limit int) ([]domain.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStalePreparedTransactions", ctx, before, limit)
+	ret0, _ := ret[0].([]domain.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStalePreparedTransactions indicates an expected call of GetStalePreparedTransactions.
+func (mr *MockTransactionRepositoryMockRecorder) GetStalePreparedTransactions(ctx, before, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStalePreparedTransactions", reflect.TypeOf((*MockTransactionRepository)(nil).GetStalePreparedTransactions), ctx, before, limit)
 }
 
 // GetTransactionList mocks base method.
@@ -448,18 +711,33 @@ func (mr *MockTransactionRepositoryMockRecorder) GetTransactionList(ctx, account
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionList", reflect.TypeOf((*MockTransactionRepository)(nil).GetTransactionList), ctx, accountID, ordering, paginator)
 }
 
-// SetTransactionStatusToSent mocks base method.
-func (m *MockTransactionRepository) SetTransactionStatusToSent(ctx context.Context, transactionID int) error {
+// LockTransaction mocks base method.
+func (m *MockTransactionRepository) LockTransaction(ctx context.Context, transactionID int) (domain.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockTransaction", ctx, transactionID)
+	ret0, _ := ret[0].(domain.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockTransaction indicates an expected call of LockTransaction.
+func (mr *MockTransactionRepositoryMockRecorder) LockTransaction(ctx, transactionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockTransaction", reflect.TypeOf((*MockTransactionRepository)(nil).LockTransaction), ctx, transactionID)
+}
+
+// UpdateTransactionStatus mocks base method.
+func (m *MockTransactionRepository) UpdateTransactionStatus(ctx context.Context, transactionID int, status domain.TransactionStatus, failureReason string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetTransactionStatusToSent", ctx, transactionID)
+	ret := m.ctrl.Call(m, "UpdateTransactionStatus", ctx, transactionID, status, failureReason)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SetTransactionStatusToSent indicates an expected call of SetTransactionStatusToSent.
-func (mr *MockTransactionRepositoryMockRecorder) SetTransactionStatusToSent(ctx, transactionID interface{}) *gomock.Call {
+// UpdateTransactionStatus indicates an expected call of UpdateTransactionStatus.
+func (mr *MockTransactionRepositoryMockRecorder) UpdateTransactionStatus(ctx, transactionID, status, failureReason interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTransactionStatusToSent", reflect.TypeOf((*MockTransactionRepository)(nil).SetTransactionStatusToSent), ctx, transactionID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTransactionStatus", reflect.TypeOf((*MockTransactionRepository)(nil).UpdateTransactionStatus), ctx, transactionID, status, failureReason)
 }
 
 // MockCardRepository is a mock of CardRepository interface.
@@ -500,6 +778,21 @@ func (mr *MockCardRepositoryMockRecorder) CreateCard(ctx, accountID, cardNumber,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCard", reflect.TypeOf((*MockCardRepository)(nil).CreateCard), ctx, accountID, cardNumber, cardholderName, cvvCode)
 }
 
+// GetAccountIDByCardID mocks base method.
+func (m *MockCardRepository) GetAccountIDByCardID(ctx context.Context, cardID int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountIDByCardID", ctx, cardID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountIDByCardID indicates an expected call of GetAccountIDByCardID.
+func (mr *MockCardRepositoryMockRecorder) GetAccountIDByCardID(ctx, cardID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountIDByCardID", reflect.TypeOf((*MockCardRepository)(nil).GetAccountIDByCardID), ctx, cardID)
+}
+
 // GetCard mocks base method.
 func (m *MockCardRepository) GetCard(ctx context.Context, id, accountID int) (domain.Card, error) {
 	m.ctrl.T.Helper()
@@ -545,11 +838,25 @@ func (mr *MockCardRepositoryMockRecorder) GetCardListUser(ctx, userID interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCardListUser", reflect.TypeOf((*MockCardRepository)(nil).GetCardListUser), ctx, userID)
 }
 
-// MockEventRepository is a mock of EventRepository interface.
-type MockEventRepository struct {
-	ctrl     *gomock.Controller
-	recorder *MockEventRepositoryMockRecorder
-}
+// UpdateCardholderName mocks base method.
+func (m *MockCardRepository) UpdateCardholderName(ctx context.Context, userID int, cardholderName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCardholderName", ctx, userID, cardholderName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCardholderName indicates an expected call of UpdateCardholderName.
+func (mr *MockCardRepositoryMockRecorder) UpdateCardholderName(ctx, userID, cardholderName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCardholderName", reflect.TypeOf((*MockCardRepository)(nil).UpdateCardholderName), ctx, userID, cardholderName)
+}
+
+// MockEventRepository is a mock of EventRepository interface.
+type MockEventRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventRepositoryMockRecorder
+}
 
 // MockEventRepositoryMockRecorder is the mock recorder for MockEventRepository.
 type MockEventRepositoryMockRecorder struct {
@@ -597,6 +904,21 @@ func (mr *MockEventRepositoryMockRecorder) GetEventsList(ctx, userID interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEventsList", reflect.TypeOf((*MockEventRepository)(nil).GetEventsList), ctx, userID)
 }
 
+// GetRecentEvents mocks base method.
+func (m *MockEventRepository) GetRecentEvents(ctx context.Context, userID, limit int) ([]domain.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecentEvents", ctx, userID, limit)
+	ret0, _ := ret[0].([]domain.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecentEvents indicates an expected call of GetRecentEvents.
+func (mr *MockEventRepositoryMockRecorder) GetRecentEvents(ctx, userID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecentEvents", reflect.TypeOf((*MockEventRepository)(nil).GetRecentEvents), ctx, userID, limit)
+}
+
 // MockPasswordHasher is a mock of PasswordHasher interface.
 type MockPasswordHasher struct {
 	ctrl     *gomock.Controller
@@ -635,6 +957,22 @@ func (mr *MockPasswordHasherMockRecorder) Hash(password interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Hash", reflect.TypeOf((*MockPasswordHasher)(nil).Hash), password)
 }
 
+// Verify mocks base method.
+func (m *MockPasswordHasher) Verify(password, encoded string) (bool, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", password, encoded)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockPasswordHasherMockRecorder) Verify(password, encoded interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockPasswordHasher)(nil).Verify), password, encoded)
+}
+
 // MockUsersRepository is a mock of UsersRepository interface.
 type MockUsersRepository struct {
 	ctrl     *gomock.Controller
@@ -688,11 +1026,12 @@ func (mr *MockUsersRepositoryMockRecorder) CheckBlockUser(ctx, userID interface{
 }
 
 // Create mocks base method.
-func (m *MockUsersRepository) Create(ctx context.Context, user domain.User) error {
+func (m *MockUsersRepository) Create(ctx context.Context, user domain.User) (int, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Create", ctx, user)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
@@ -716,19 +1055,19 @@ func (mr *MockUsersRepositoryMockRecorder) Exists(ctx, email interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exists", reflect.TypeOf((*MockUsersRepository)(nil).Exists), ctx, email)
 }
 
-// GetByCredentials mocks base method.
-func (m *MockUsersRepository) GetByCredentials(ctx context.Context, email, password string) (domain.User, error) {
+// GetByEmail mocks base method.
+func (m *MockUsersRepository) GetByEmail(ctx context.Context, email string) (domain.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByCredentials", ctx, email, password)
+	ret := m.ctrl.Call(m, "GetByEmail", ctx, email)
 	ret0, _ := ret[0].(domain.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetByCredentials indicates an expected call of GetByCredentials.
-func (mr *MockUsersRepositoryMockRecorder) GetByCredentials(ctx, email, password interface{}) *gomock.Call {
+// GetByEmail indicates an expected call of GetByEmail.
+func (mr *MockUsersRepositoryMockRecorder) GetByEmail(ctx, email interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByCredentials", reflect.TypeOf((*MockUsersRepository)(nil).GetByCredentials), ctx, email, password)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmail", reflect.TypeOf((*MockUsersRepository)(nil).GetByEmail), ctx, email)
 }
 
 // GetByID mocks base method.
@@ -746,6 +1085,21 @@ func (mr *MockUsersRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUsersRepository)(nil).GetByID), ctx, id)
 }
 
+// GetTokenVersion mocks base method.
+func (m *MockUsersRepository) GetTokenVersion(ctx context.Context, userID int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenVersion", ctx, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenVersion indicates an expected call of GetTokenVersion.
+func (mr *MockUsersRepositoryMockRecorder) GetTokenVersion(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenVersion", reflect.TypeOf((*MockUsersRepository)(nil).GetTokenVersion), ctx, userID)
+}
+
 // GetUserNameAndSurnameByID mocks base method.
 func (m *MockUsersRepository) GetUserNameAndSurnameByID(ctx context.Context, userID int) (string, error) {
 	m.ctrl.T.Helper()
@@ -761,6 +1115,49 @@ func (mr *MockUsersRepositoryMockRecorder) GetUserNameAndSurnameByID(ctx, userID
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserNameAndSurnameByID", reflect.TypeOf((*MockUsersRepository)(nil).GetUserNameAndSurnameByID), ctx, userID)
 }
 
+// GetUsersList mocks base method.
+func (m *MockUsersRepository) GetUsersList(ctx context.Context, filter domain.UserFilter, paginator domain.Paginator, ordering domain.Orderings) ([]domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersList", ctx, filter, paginator, ordering)
+	ret0, _ := ret[0].([]domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersList indicates an expected call of GetUsersList.
+func (mr *MockUsersRepositoryMockRecorder) GetUsersList(ctx, filter, paginator, ordering interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersList", reflect.TypeOf((*MockUsersRepository)(nil).GetUsersList), ctx, filter, paginator, ordering)
+}
+
+// IncrementTokenVersion mocks base method.
+func (m *MockUsersRepository) IncrementTokenVersion(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementTokenVersion", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrementTokenVersion indicates an expected call of IncrementTokenVersion.
+func (mr *MockUsersRepositoryMockRecorder) IncrementTokenVersion(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementTokenVersion", reflect.TypeOf((*MockUsersRepository)(nil).IncrementTokenVersion), ctx, userID)
+}
+
+// MarkEmailVerified mocks base method.
+func (m *MockUsersRepository) MarkEmailVerified(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEmailVerified", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEmailVerified indicates an expected call of MarkEmailVerified.
+func (mr *MockUsersRepositoryMockRecorder) MarkEmailVerified(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEmailVerified", reflect.TypeOf((*MockUsersRepository)(nil).MarkEmailVerified), ctx, userID)
+}
+
 // UnblockUser mocks base method.
 func (m *MockUsersRepository) UnblockUser(ctx context.Context, userID int) error {
 	m.ctrl.T.Helper()
@@ -774,3 +1171,1761 @@ func (mr *MockUsersRepositoryMockRecorder) UnblockUser(ctx, userID interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnblockUser", reflect.TypeOf((*MockUsersRepository)(nil).UnblockUser), ctx, userID)
 }
+
+// UpdatePassword mocks base method.
+func (m *MockUsersRepository) UpdatePassword(ctx context.Context, userID int, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, userID, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUsersRepositoryMockRecorder) UpdatePassword(ctx, userID, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUsersRepository)(nil).UpdatePassword), ctx, userID, password)
+}
+
+// UpdateProfile mocks base method.
+func (m *MockUsersRepository) UpdateProfile(ctx context.Context, user domain.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockUsersRepositoryMockRecorder) UpdateProfile(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockUsersRepository)(nil).UpdateProfile), ctx, user)
+}
+
+// MockRevokedTokenRepository is a mock of RevokedTokenRepository interface.
+type MockRevokedTokenRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRevokedTokenRepositoryMockRecorder
+}
+
+// MockRevokedTokenRepositoryMockRecorder is the mock recorder for MockRevokedTokenRepository.
+type MockRevokedTokenRepositoryMockRecorder struct {
+	mock *MockRevokedTokenRepository
+}
+
+// NewMockRevokedTokenRepository creates a new mock instance.
+func NewMockRevokedTokenRepository(ctrl *gomock.Controller) *MockRevokedTokenRepository {
+	mock := &MockRevokedTokenRepository{ctrl: ctrl}
+	mock.recorder = &MockRevokedTokenRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRevokedTokenRepository) EXPECT() *MockRevokedTokenRepositoryMockRecorder {
+	return m.recorder
+}
+
+// IsRevoked mocks base method.
+func (m *MockRevokedTokenRepository) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsRevoked", ctx, jti)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsRevoked indicates an expected call of IsRevoked.
+func (mr *MockRevokedTokenRepositoryMockRecorder) IsRevoked(ctx, jti interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRevoked", reflect.TypeOf((*MockRevokedTokenRepository)(nil).IsRevoked), ctx, jti)
+}
+
+// Revoke mocks base method.
+func (m *MockRevokedTokenRepository) Revoke(ctx context.Context, jti string, userID int, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, jti, userID, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockRevokedTokenRepositoryMockRecorder) Revoke(ctx, jti, userID, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockRevokedTokenRepository)(nil).Revoke), ctx, jti, userID, expiresAt)
+}
+
+// MockSigningKeyRepository is a mock of SigningKeyRepository interface.
+type MockSigningKeyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSigningKeyRepositoryMockRecorder
+}
+
+// MockSigningKeyRepositoryMockRecorder is the mock recorder for MockSigningKeyRepository.
+type MockSigningKeyRepositoryMockRecorder struct {
+	mock *MockSigningKeyRepository
+}
+
+// NewMockSigningKeyRepository creates a new mock instance.
+func NewMockSigningKeyRepository(ctrl *gomock.Controller) *MockSigningKeyRepository {
+	mock := &MockSigningKeyRepository{ctrl: ctrl}
+	mock.recorder = &MockSigningKeyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSigningKeyRepository) EXPECT() *MockSigningKeyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockSigningKeyRepository) Create(ctx context.Context, key domain.SigningKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockSigningKeyRepositoryMockRecorder) Create(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSigningKeyRepository)(nil).Create), ctx, key)
+}
+
+// Delete mocks base method.
+func (m *MockSigningKeyRepository) Delete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockSigningKeyRepositoryMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSigningKeyRepository)(nil).Delete), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockSigningKeyRepository) List(ctx context.Context) ([]domain.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]domain.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockSigningKeyRepositoryMockRecorder) List(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockSigningKeyRepository)(nil).List), ctx)
+}
+
+// MockTwoFactorRepository is a mock of TwoFactorRepository interface.
+type MockTwoFactorRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockTwoFactorRepositoryMockRecorder
+}
+
+// MockTwoFactorRepositoryMockRecorder is the mock recorder for MockTwoFactorRepository.
+type MockTwoFactorRepositoryMockRecorder struct {
+	mock *MockTwoFactorRepository
+}
+
+// NewMockTwoFactorRepository creates a new mock instance.
+func NewMockTwoFactorRepository(ctrl *gomock.Controller) *MockTwoFactorRepository {
+	mock := &MockTwoFactorRepository{ctrl: ctrl}
+	mock.recorder = &MockTwoFactorRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTwoFactorRepository) EXPECT() *MockTwoFactorRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateChallenge mocks base method.
+func (m *MockTwoFactorRepository) CreateChallenge(ctx context.Context, challenge domain.TwoFactorChallenge) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateChallenge", ctx, challenge)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateChallenge indicates an expected call of CreateChallenge.
+func (mr *MockTwoFactorRepositoryMockRecorder) CreateChallenge(ctx, challenge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChallenge", reflect.TypeOf((*MockTwoFactorRepository)(nil).CreateChallenge), ctx, challenge)
+}
+
+// DeleteChallenge mocks base method.
+func (m *MockTwoFactorRepository) DeleteChallenge(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteChallenge", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteChallenge indicates an expected call of DeleteChallenge.
+func (mr *MockTwoFactorRepositoryMockRecorder) DeleteChallenge(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChallenge", reflect.TypeOf((*MockTwoFactorRepository)(nil).DeleteChallenge), ctx, id)
+}
+
+// Disable mocks base method.
+func (m *MockTwoFactorRepository) Disable(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Disable", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Disable indicates an expected call of Disable.
+func (mr *MockTwoFactorRepositoryMockRecorder) Disable(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Disable", reflect.TypeOf((*MockTwoFactorRepository)(nil).Disable), ctx, userID)
+}
+
+// Enable mocks base method.
+func (m *MockTwoFactorRepository) Enable(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enable", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Enable indicates an expected call of Enable.
+func (mr *MockTwoFactorRepositoryMockRecorder) Enable(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enable", reflect.TypeOf((*MockTwoFactorRepository)(nil).Enable), ctx, userID)
+}
+
+// IncrementChallengeAttempts mocks base method.
+func (m *MockTwoFactorRepository) IncrementChallengeAttempts(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementChallengeAttempts", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrementChallengeAttempts indicates an expected call of IncrementChallengeAttempts.
+func (mr *MockTwoFactorRepositoryMockRecorder) IncrementChallengeAttempts(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementChallengeAttempts", reflect.TypeOf((*MockTwoFactorRepository)(nil).IncrementChallengeAttempts), ctx, id)
+}
+
+// Lock mocks base method.
+func (m *MockTwoFactorRepository) Lock(ctx context.Context, userID int) (domain.TwoFactor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, userID)
+	ret0, _ := ret[0].(domain.TwoFactor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockTwoFactorRepositoryMockRecorder) Lock(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockTwoFactorRepository)(nil).Lock), ctx, userID)
+}
+
+// LockChallenge mocks base method.
+func (m *MockTwoFactorRepository) LockChallenge(ctx context.Context, tokenHash string) (domain.TwoFactorChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockChallenge", ctx, tokenHash)
+	ret0, _ := ret[0].(domain.TwoFactorChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockChallenge indicates an expected call of LockChallenge.
+func (mr *MockTwoFactorRepositoryMockRecorder) LockChallenge(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockChallenge", reflect.TypeOf((*MockTwoFactorRepository)(nil).LockChallenge), ctx, tokenHash)
+}
+
+// LockCodes mocks base method.
+func (m *MockTwoFactorRepository) LockCodes(ctx context.Context, userID int, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockCodes", ctx, userID, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockCodes indicates an expected call of LockCodes.
+func (mr *MockTwoFactorRepositoryMockRecorder) LockCodes(ctx, userID, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockCodes", reflect.TypeOf((*MockTwoFactorRepository)(nil).LockCodes), ctx, userID, until)
+}
+
+// RegisterCodeFailure mocks base method.
+func (m *MockTwoFactorRepository) RegisterCodeFailure(ctx context.Context, userID int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterCodeFailure", ctx, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterCodeFailure indicates an expected call of RegisterCodeFailure.
+func (mr *MockTwoFactorRepositoryMockRecorder) RegisterCodeFailure(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterCodeFailure", reflect.TypeOf((*MockTwoFactorRepository)(nil).RegisterCodeFailure), ctx, userID)
+}
+
+// ReplaceRecoveryCodes mocks base method.
+func (m *MockTwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceRecoveryCodes", ctx, userID, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceRecoveryCodes indicates an expected call of ReplaceRecoveryCodes.
+func (mr *MockTwoFactorRepositoryMockRecorder) ReplaceRecoveryCodes(ctx, userID, codeHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceRecoveryCodes", reflect.TypeOf((*MockTwoFactorRepository)(nil).ReplaceRecoveryCodes), ctx, userID, codeHashes)
+}
+
+// ResetCodeFailures mocks base method.
+func (m *MockTwoFactorRepository) ResetCodeFailures(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetCodeFailures", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetCodeFailures indicates an expected call of ResetCodeFailures.
+func (mr *MockTwoFactorRepositoryMockRecorder) ResetCodeFailures(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetCodeFailures", reflect.TypeOf((*MockTwoFactorRepository)(nil).ResetCodeFailures), ctx, userID)
+}
+
+// SetSecret mocks base method.
+func (m *MockTwoFactorRepository) SetSecret(ctx context.Context, userID int, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSecret", ctx, userID, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSecret indicates an expected call of SetSecret.
+func (mr *MockTwoFactorRepositoryMockRecorder) SetSecret(ctx, userID, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSecret", reflect.TypeOf((*MockTwoFactorRepository)(nil).SetSecret), ctx, userID, secret)
+}
+
+// UpdateLastStep mocks base method.
+func (m *MockTwoFactorRepository) UpdateLastStep(ctx context.Context, userID int, step int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLastStep", ctx, userID, step)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLastStep indicates an expected call of UpdateLastStep.
+func (mr *MockTwoFactorRepositoryMockRecorder) UpdateLastStep(ctx, userID, step interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLastStep", reflect.TypeOf((*MockTwoFactorRepository)(nil).UpdateLastStep), ctx, userID, step)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, userID, codeHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockTwoFactorRepositoryMockRecorder) UseRecoveryCode(ctx, userID, codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockTwoFactorRepository)(nil).UseRecoveryCode), ctx, userID, codeHash)
+}
+
+// MockTwoFactorChallenger is a mock of TwoFactorChallenger interface.
+type MockTwoFactorChallenger struct {
+	ctrl     *gomock.Controller
+	recorder *MockTwoFactorChallengerMockRecorder
+}
+
+// MockTwoFactorChallengerMockRecorder is the mock recorder for MockTwoFactorChallenger.
+type MockTwoFactorChallengerMockRecorder struct {
+	mock *MockTwoFactorChallenger
+}
+
+// NewMockTwoFactorChallenger creates a new mock instance.
+func NewMockTwoFactorChallenger(ctrl *gomock.Controller) *MockTwoFactorChallenger {
+	mock := &MockTwoFactorChallenger{ctrl: ctrl}
+	mock.recorder = &MockTwoFactorChallengerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTwoFactorChallenger) EXPECT() *MockTwoFactorChallengerMockRecorder {
+	return m.recorder
+}
+
+// CompleteChallenge mocks base method.
+func (m *MockTwoFactorChallenger) CompleteChallenge(ctx context.Context, challengeToken, code string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteChallenge", ctx, challengeToken, code)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteChallenge indicates an expected call of CompleteChallenge.
+func (mr *MockTwoFactorChallengerMockRecorder) CompleteChallenge(ctx, challengeToken, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteChallenge", reflect.TypeOf((*MockTwoFactorChallenger)(nil).CompleteChallenge), ctx, challengeToken, code)
+}
+
+// CreateChallenge mocks base method.
+func (m *MockTwoFactorChallenger) CreateChallenge(ctx context.Context, userID int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateChallenge", ctx, userID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateChallenge indicates an expected call of CreateChallenge.
+func (mr *MockTwoFactorChallengerMockRecorder) CreateChallenge(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChallenge", reflect.TypeOf((*MockTwoFactorChallenger)(nil).CreateChallenge), ctx, userID)
+}
+
+// MockStepUpVerifier is a mock of StepUpVerifier interface.
+type MockStepUpVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockStepUpVerifierMockRecorder
+}
+
+// MockStepUpVerifierMockRecorder is the mock recorder for MockStepUpVerifier.
+type MockStepUpVerifierMockRecorder struct {
+	mock *MockStepUpVerifier
+}
+
+// NewMockStepUpVerifier creates a new mock instance.
+func NewMockStepUpVerifier(ctrl *gomock.Controller) *MockStepUpVerifier {
+	mock := &MockStepUpVerifier{ctrl: ctrl}
+	mock.recorder = &MockStepUpVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStepUpVerifier) EXPECT() *MockStepUpVerifierMockRecorder {
+	return m.recorder
+}
+
+// VerifyStepUp mocks base method.
+func (m *MockStepUpVerifier) VerifyStepUp(ctx context.Context, userID int, amount domain.Money, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyStepUp", ctx, userID, amount, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyStepUp indicates an expected call of VerifyStepUp.
+func (mr *MockStepUpVerifierMockRecorder) VerifyStepUp(ctx, userID, amount, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyStepUp", reflect.TypeOf((*MockStepUpVerifier)(nil).VerifyStepUp), ctx, userID, amount, code)
+}
+
+// MockOneTimeTokenRepository is a mock of OneTimeTokenRepository interface.
+type MockOneTimeTokenRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOneTimeTokenRepositoryMockRecorder
+}
+
+// MockOneTimeTokenRepositoryMockRecorder is the mock recorder for MockOneTimeTokenRepository.
+type MockOneTimeTokenRepositoryMockRecorder struct {
+	mock *MockOneTimeTokenRepository
+}
+
+// NewMockOneTimeTokenRepository creates a new mock instance.
+func NewMockOneTimeTokenRepository(ctrl *gomock.Controller) *MockOneTimeTokenRepository {
+	mock := &MockOneTimeTokenRepository{ctrl: ctrl}
+	mock.recorder = &MockOneTimeTokenRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOneTimeTokenRepository) EXPECT() *MockOneTimeTokenRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockOneTimeTokenRepository) Create(ctx context.Context, token domain.OneTimeToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockOneTimeTokenRepositoryMockRecorder) Create(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOneTimeTokenRepository)(nil).Create), ctx, token)
+}
+
+// DeleteUnused mocks base method.
+func (m *MockOneTimeTokenRepository) DeleteUnused(ctx context.Context, userID int, purpose domain.OneTimeTokenPurpose) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUnused", ctx, userID, purpose)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUnused indicates an expected call of DeleteUnused.
+func (mr *MockOneTimeTokenRepositoryMockRecorder) DeleteUnused(ctx, userID, purpose interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUnused", reflect.TypeOf((*MockOneTimeTokenRepository)(nil).DeleteUnused), ctx, userID, purpose)
+}
+
+// Lock mocks base method.
+func (m *MockOneTimeTokenRepository) Lock(ctx context.Context, tokenHash string, purpose domain.OneTimeTokenPurpose) (domain.OneTimeToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, tokenHash, purpose)
+	ret0, _ := ret[0].(domain.OneTimeToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockOneTimeTokenRepositoryMockRecorder) Lock(ctx, tokenHash, purpose interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockOneTimeTokenRepository)(nil).Lock), ctx, tokenHash, purpose)
+}
+
+// MarkUsed mocks base method.
+func (m *MockOneTimeTokenRepository) MarkUsed(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkUsed", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkUsed indicates an expected call of MarkUsed.
+func (mr *MockOneTimeTokenRepositoryMockRecorder) MarkUsed(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkUsed", reflect.TypeOf((*MockOneTimeTokenRepository)(nil).MarkUsed), ctx, id)
+}
+
+// MockMailer is a mock of Mailer interface.
+type MockMailer struct {
+	ctrl     *gomock.Controller
+	recorder *MockMailerMockRecorder
+}
+
+// MockMailerMockRecorder is the mock recorder for MockMailer.
+type MockMailerMockRecorder struct {
+	mock *MockMailer
+}
+
+// NewMockMailer creates a new mock instance.
+func NewMockMailer(ctrl *gomock.Controller) *MockMailer {
+	mock := &MockMailer{ctrl: ctrl}
+	mock.recorder = &MockMailerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMailer) EXPECT() *MockMailerMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method.
+func (m *MockMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", ctx, to, subject, body)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockMailerMockRecorder) Send(ctx, to, subject, body interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockMailer)(nil).Send), ctx, to, subject, body)
+}
+
+// MockEmailVerifier is a mock of EmailVerifier interface.
+type MockEmailVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmailVerifierMockRecorder
+}
+
+// MockEmailVerifierMockRecorder is the mock recorder for MockEmailVerifier.
+type MockEmailVerifierMockRecorder struct {
+	mock *MockEmailVerifier
+}
+
+// NewMockEmailVerifier creates a new mock instance.
+func NewMockEmailVerifier(ctrl *gomock.Controller) *MockEmailVerifier {
+	mock := &MockEmailVerifier{ctrl: ctrl}
+	mock.recorder = &MockEmailVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmailVerifier) EXPECT() *MockEmailVerifierMockRecorder {
+	return m.recorder
+}
+
+// CancelEmailVerification mocks base method.
+func (m *MockEmailVerifier) CancelEmailVerification(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelEmailVerification", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelEmailVerification indicates an expected call of CancelEmailVerification.
+func (mr *MockEmailVerifierMockRecorder) CancelEmailVerification(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelEmailVerification", reflect.TypeOf((*MockEmailVerifier)(nil).CancelEmailVerification), ctx, userID)
+}
+
+// RequestEmailVerification mocks base method.
+func (m *MockEmailVerifier) RequestEmailVerification(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestEmailVerification", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestEmailVerification indicates an expected call of RequestEmailVerification.
+func (mr *MockEmailVerifierMockRecorder) RequestEmailVerification(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestEmailVerification", reflect.TypeOf((*MockEmailVerifier)(nil).RequestEmailVerification), ctx, userID)
+}
+
+// MockSignInThrottleRepository is a mock of SignInThrottleRepository interface.
+type MockSignInThrottleRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSignInThrottleRepositoryMockRecorder
+}
+
+// MockSignInThrottleRepositoryMockRecorder is the mock recorder for MockSignInThrottleRepository.
+type MockSignInThrottleRepositoryMockRecorder struct {
+	mock *MockSignInThrottleRepository
+}
+
+// NewMockSignInThrottleRepository creates a new mock instance.
+func NewMockSignInThrottleRepository(ctrl *gomock.Controller) *MockSignInThrottleRepository {
+	mock := &MockSignInThrottleRepository{ctrl: ctrl}
+	mock.recorder = &MockSignInThrottleRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSignInThrottleRepository) EXPECT() *MockSignInThrottleRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockSignInThrottleRepository) Delete(ctx context.Context, scope domain.SignInThrottleScope, subject string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, scope, subject)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockSignInThrottleRepositoryMockRecorder) Delete(ctx, scope, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSignInThrottleRepository)(nil).Delete), ctx, scope, subject)
+}
+
+// Get mocks base method.
+func (m *MockSignInThrottleRepository) Get(ctx context.Context, scope domain.SignInThrottleScope, subject string) (domain.SignInThrottle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, scope, subject)
+	ret0, _ := ret[0].(domain.SignInThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockSignInThrottleRepositoryMockRecorder) Get(ctx, scope, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockSignInThrottleRepository)(nil).Get), ctx, scope, subject)
+}
+
+// Lock mocks base method.
+func (m *MockSignInThrottleRepository) Lock(ctx context.Context, scope domain.SignInThrottleScope, subject string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, scope, subject, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockSignInThrottleRepositoryMockRecorder) Lock(ctx, scope, subject, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockSignInThrottleRepository)(nil).Lock), ctx, scope, subject, until)
+}
+
+// RegisterFailure mocks base method.
+func (m *MockSignInThrottleRepository) RegisterFailure(ctx context.Context, scope domain.SignInThrottleScope, subject string, since time.Time) (domain.SignInThrottle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterFailure", ctx, scope, subject, since)
+	ret0, _ := ret[0].(domain.SignInThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterFailure indicates an expected call of RegisterFailure.
+func (mr *MockSignInThrottleRepositoryMockRecorder) RegisterFailure(ctx, scope, subject, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterFailure", reflect.TypeOf((*MockSignInThrottleRepository)(nil).RegisterFailure), ctx, scope, subject, since)
+}
+
+// MockSignInLimiter is a mock of SignInLimiter interface.
+type MockSignInLimiter struct {
+	ctrl     *gomock.Controller
+	recorder *MockSignInLimiterMockRecorder
+}
+
+// MockSignInLimiterMockRecorder is the mock recorder for MockSignInLimiter.
+type MockSignInLimiterMockRecorder struct {
+	mock *MockSignInLimiter
+}
+
+// NewMockSignInLimiter creates a new mock instance.
+func NewMockSignInLimiter(ctrl *gomock.Controller) *MockSignInLimiter {
+	mock := &MockSignInLimiter{ctrl: ctrl}
+	mock.recorder = &MockSignInLimiterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSignInLimiter) EXPECT() *MockSignInLimiterMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockSignInLimiter) Check(ctx context.Context, email, ip string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx, email, ip)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockSignInLimiterMockRecorder) Check(ctx, email, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockSignInLimiter)(nil).Check), ctx, email, ip)
+}
+
+// RegisterFailure mocks base method.
+func (m *MockSignInLimiter) RegisterFailure(ctx context.Context, email, ip string, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterFailure", ctx, email, ip, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterFailure indicates an expected call of RegisterFailure.
+func (mr *MockSignInLimiterMockRecorder) RegisterFailure(ctx, email, ip, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterFailure", reflect.TypeOf((*MockSignInLimiter)(nil).RegisterFailure), ctx, email, ip, userID)
+}
+
+// Reset mocks base method.
+func (m *MockSignInLimiter) Reset(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockSignInLimiterMockRecorder) Reset(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockSignInLimiter)(nil).Reset), ctx, email)
+}
+
+// MockTokenKeys is a mock of TokenKeys interface.
+type MockTokenKeys struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenKeysMockRecorder
+}
+
+// MockTokenKeysMockRecorder is the mock recorder for MockTokenKeys.
+type MockTokenKeysMockRecorder struct {
+	mock *MockTokenKeys
+}
+
+// NewMockTokenKeys creates a new mock instance.
+func NewMockTokenKeys(ctrl *gomock.Controller) *MockTokenKeys {
+	mock := &MockTokenKeys{ctrl: ctrl}
+	mock.recorder = &MockTokenKeysMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenKeys) EXPECT() *MockTokenKeysMockRecorder {
+	return m.recorder
+}
+
+// SigningKey mocks base method.
+func (m *MockTokenKeys) SigningKey() (domain.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SigningKey")
+	ret0, _ := ret[0].(domain.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SigningKey indicates an expected call of SigningKey.
+func (mr *MockTokenKeysMockRecorder) SigningKey() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SigningKey", reflect.TypeOf((*MockTokenKeys)(nil).SigningKey))
+}
+
+// VerificationKey mocks base method.
+func (m *MockTokenKeys) VerificationKey(ctx context.Context, id string) (domain.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerificationKey", ctx, id)
+	ret0, _ := ret[0].(domain.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerificationKey indicates an expected call of VerificationKey.
+func (mr *MockTokenKeysMockRecorder) VerificationKey(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerificationKey", reflect.TypeOf((*MockTokenKeys)(nil).VerificationKey), ctx, id)
+}
+
+// MockIdempotencyRepository is a mock of IdempotencyRepository interface.
+type MockIdempotencyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyRepositoryMockRecorder
+}
+
+// MockIdempotencyRepositoryMockRecorder is the mock recorder for MockIdempotencyRepository.
+type MockIdempotencyRepositoryMockRecorder struct {
+	mock *MockIdempotencyRepository
+}
+
+// NewMockIdempotencyRepository creates a new mock instance.
+func NewMockIdempotencyRepository(ctrl *gomock.Controller) *MockIdempotencyRepository {
+	mock := &MockIdempotencyRepository{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyRepository) EXPECT() *MockIdempotencyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Complete mocks base method.
+func (m *MockIdempotencyRepository) Complete(ctx context.Context, userID int, key, token string, statusCode int, responseBody []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", ctx, userID, key, token, statusCode, responseBody)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockIdempotencyRepositoryMockRecorder) Complete(ctx, userID, key, token, statusCode, responseBody interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockIdempotencyRepository)(nil).Complete), ctx, userID, key, token, statusCode, responseBody)
+}
+
+// Release mocks base method.
+func (m *MockIdempotencyRepository) Release(ctx context.Context, userID int, key, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, userID, key, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockIdempotencyRepositoryMockRecorder) Release(ctx, userID, key, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockIdempotencyRepository)(nil).Release), ctx, userID, key, token)
+}
+
+// Reserve mocks base method.
+func (m *MockIdempotencyRepository) Reserve(ctx context.Context, key domain.IdempotencyKey) (domain.IdempotencyKey, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reserve", ctx, key)
+	ret0, _ := ret[0].(domain.IdempotencyKey)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Reserve indicates an expected call of Reserve.
+func (mr *MockIdempotencyRepositoryMockRecorder) Reserve(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*MockIdempotencyRepository)(nil).Reserve), ctx, key)
+}
+
+// MockRateProvider is a mock of RateProvider interface.
+type MockRateProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockRateProviderMockRecorder
+}
+
+// MockRateProviderMockRecorder is the mock recorder for MockRateProvider.
+type MockRateProviderMockRecorder struct {
+	mock *MockRateProvider
+}
+
+// NewMockRateProvider creates a new mock instance.
+func NewMockRateProvider(ctrl *gomock.Controller) *MockRateProvider {
+	mock := &MockRateProvider{ctrl: ctrl}
+	mock.recorder = &MockRateProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRateProvider) EXPECT() *MockRateProviderMockRecorder {
+	return m.recorder
+}
+
+// GetRate mocks base method.
+func (m *MockRateProvider) GetRate(ctx context.Context, base, quote string) (*big.Rat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRate", ctx, base, quote)
+	ret0, _ := ret[0].(*big.Rat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRate indicates an expected call of GetRate.
+func (mr *MockRateProviderMockRecorder) GetRate(ctx, base, quote interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRate", reflect.TypeOf((*MockRateProvider)(nil).GetRate), ctx, base, quote)
+}
+
+// MockFXQuoteRepository is a mock of FXQuoteRepository interface.
+type MockFXQuoteRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockFXQuoteRepositoryMockRecorder
+}
+
+// MockFXQuoteRepositoryMockRecorder is the mock recorder for MockFXQuoteRepository.
+type MockFXQuoteRepositoryMockRecorder struct {
+	mock *MockFXQuoteRepository
+}
+
+// NewMockFXQuoteRepository creates a new mock instance.
+func NewMockFXQuoteRepository(ctrl *gomock.Controller) *MockFXQuoteRepository {
+	mock := &MockFXQuoteRepository{ctrl: ctrl}
+	mock.recorder = &MockFXQuoteRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFXQuoteRepository) EXPECT() *MockFXQuoteRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateQuote mocks base method.
+func (m *MockFXQuoteRepository) CreateQuote(ctx context.Context, quote domain.FXQuote) (domain.FXQuote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateQuote", ctx, quote)
+	ret0, _ := ret[0].(domain.FXQuote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateQuote indicates an expected call of CreateQuote.
+func (mr *MockFXQuoteRepositoryMockRecorder) CreateQuote(ctx, quote interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateQuote", reflect.TypeOf((*MockFXQuoteRepository)(nil).CreateQuote), ctx, quote)
+}
+
+// UseQuote mocks base method.
+func (m *MockFXQuoteRepository) UseQuote(ctx context.Context, quoteID, userID int) (domain.FXQuote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseQuote", ctx, quoteID, userID)
+	ret0, _ := ret[0].(domain.FXQuote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseQuote indicates an expected call of UseQuote.
+func (mr *MockFXQuoteRepositoryMockRecorder) UseQuote(ctx, quoteID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseQuote", reflect.TypeOf((*MockFXQuoteRepository)(nil).UseQuote), ctx, quoteID, userID)
+}
+
+// MockCurrencyExchanger is a mock of CurrencyExchanger interface.
+type MockCurrencyExchanger struct {
+	ctrl     *gomock.Controller
+	recorder *MockCurrencyExchangerMockRecorder
+}
+
+// MockCurrencyExchangerMockRecorder is the mock recorder for MockCurrencyExchanger.
+type MockCurrencyExchangerMockRecorder struct {
+	mock *MockCurrencyExchanger
+}
+
+// NewMockCurrencyExchanger creates a new mock instance.
+func NewMockCurrencyExchanger(ctrl *gomock.Controller) *MockCurrencyExchanger {
+	mock := &MockCurrencyExchanger{ctrl: ctrl}
+	mock.recorder = &MockCurrencyExchangerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCurrencyExchanger) EXPECT() *MockCurrencyExchangerMockRecorder {
+	return m.recorder
+}
+
+// Exchange mocks base method.
+func (m *MockCurrencyExchanger) Exchange(ctx context.Context, userID, quoteID int, amount domain.Money, to domain.Currency) (domain.Money, *big.Rat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exchange", ctx, userID, quoteID, amount, to)
+	ret0, _ := ret[0].(domain.Money)
+	ret1, _ := ret[1].(*big.Rat)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Exchange indicates an expected call of Exchange.
+func (mr *MockCurrencyExchangerMockRecorder) Exchange(ctx, userID, quoteID, amount, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exchange", reflect.TypeOf((*MockCurrencyExchanger)(nil).Exchange), ctx, userID, quoteID, amount, to)
+}
+
+// MockPolicyEnforcer is a mock of PolicyEnforcer interface.
+type MockPolicyEnforcer struct {
+	ctrl     *gomock.Controller
+	recorder *MockPolicyEnforcerMockRecorder
+}
+
+// MockPolicyEnforcerMockRecorder is the mock recorder for MockPolicyEnforcer.
+type MockPolicyEnforcerMockRecorder struct {
+	mock *MockPolicyEnforcer
+}
+
+// NewMockPolicyEnforcer creates a new mock instance.
+func NewMockPolicyEnforcer(ctrl *gomock.Controller) *MockPolicyEnforcer {
+	mock := &MockPolicyEnforcer{ctrl: ctrl}
+	mock.recorder = &MockPolicyEnforcerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPolicyEnforcer) EXPECT() *MockPolicyEnforcerMockRecorder {
+	return m.recorder
+}
+
+// AddGroupingPolicy mocks base method.
+func (m *MockPolicyEnforcer) AddGroupingPolicy(params ...interface{}) (bool, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range params {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "AddGroupingPolicy", varargs...)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddGroupingPolicy indicates an expected call of AddGroupingPolicy.
+func (mr *MockPolicyEnforcerMockRecorder) AddGroupingPolicy(params ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGroupingPolicy", reflect.TypeOf((*MockPolicyEnforcer)(nil).AddGroupingPolicy), params...)
+}
+
+// AddNamedPolicy mocks base method.
+func (m *MockPolicyEnforcer) AddNamedPolicy(ptype string, params ...interface{}) (bool, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ptype}
+	for _, a := range params {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "AddNamedPolicy", varargs...)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddNamedPolicy indicates an expected call of AddNamedPolicy.
+func (mr *MockPolicyEnforcerMockRecorder) AddNamedPolicy(ptype interface{}, params ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ptype}, params...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddNamedPolicy", reflect.TypeOf((*MockPolicyEnforcer)(nil).AddNamedPolicy), varargs...)
+}
+
+// GetGroupingPolicy mocks base method.
+func (m *MockPolicyEnforcer) GetGroupingPolicy() [][]string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupingPolicy")
+	ret0, _ := ret[0].([][]string)
+	return ret0
+}
+
+// GetGroupingPolicy indicates an expected call of GetGroupingPolicy.
+func (mr *MockPolicyEnforcerMockRecorder) GetGroupingPolicy() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupingPolicy", reflect.TypeOf((*MockPolicyEnforcer)(nil).GetGroupingPolicy))
+}
+
+// GetNamedPolicy mocks base method.
+func (m *MockPolicyEnforcer) GetNamedPolicy(ptype string) [][]string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNamedPolicy", ptype)
+	ret0, _ := ret[0].([][]string)
+	return ret0
+}
+
+// GetNamedPolicy indicates an expected call of GetNamedPolicy.
+func (mr *MockPolicyEnforcerMockRecorder) GetNamedPolicy(ptype interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNamedPolicy", reflect.TypeOf((*MockPolicyEnforcer)(nil).GetNamedPolicy), ptype)
+}
+
+// LoadPolicy mocks base method.
+func (m *MockPolicyEnforcer) LoadPolicy() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadPolicy")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LoadPolicy indicates an expected call of LoadPolicy.
+func (mr *MockPolicyEnforcerMockRecorder) LoadPolicy() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadPolicy", reflect.TypeOf((*MockPolicyEnforcer)(nil).LoadPolicy))
+}
+
+// RemoveGroupingPolicy mocks base method.
+func (m *MockPolicyEnforcer) RemoveGroupingPolicy(params ...interface{}) (bool, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range params {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RemoveGroupingPolicy", varargs...)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveGroupingPolicy indicates an expected call of RemoveGroupingPolicy.
+func (mr *MockPolicyEnforcerMockRecorder) RemoveGroupingPolicy(params ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveGroupingPolicy", reflect.TypeOf((*MockPolicyEnforcer)(nil).RemoveGroupingPolicy), params...)
+}
+
+// RemoveNamedPolicy mocks base method.
+func (m *MockPolicyEnforcer) RemoveNamedPolicy(ptype string, params ...interface{}) (bool, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ptype}
+	for _, a := range params {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RemoveNamedPolicy", varargs...)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveNamedPolicy indicates an expected call of RemoveNamedPolicy.
+func (mr *MockPolicyEnforcerMockRecorder) RemoveNamedPolicy(ptype interface{}, params ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ptype}, params...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveNamedPolicy", reflect.TypeOf((*MockPolicyEnforcer)(nil).RemoveNamedPolicy), varargs...)
+}
+
+// MockPolicyRevisionRepository is a mock of PolicyRevisionRepository interface.
+type MockPolicyRevisionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPolicyRevisionRepositoryMockRecorder
+}
+
+// MockPolicyRevisionRepositoryMockRecorder is the mock recorder for MockPolicyRevisionRepository.
+type MockPolicyRevisionRepositoryMockRecorder struct {
+	mock *MockPolicyRevisionRepository
+}
+
+// NewMockPolicyRevisionRepository creates a new mock instance.
+func NewMockPolicyRevisionRepository(ctrl *gomock.Controller) *MockPolicyRevisionRepository {
+	mock := &MockPolicyRevisionRepository{ctrl: ctrl}
+	mock.recorder = &MockPolicyRevisionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPolicyRevisionRepository) EXPECT() *MockPolicyRevisionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetRevision mocks base method.
+func (m *MockPolicyRevisionRepository) GetRevision(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevision", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevision indicates an expected call of GetRevision.
+func (mr *MockPolicyRevisionRepositoryMockRecorder) GetRevision(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevision", reflect.TypeOf((*MockPolicyRevisionRepository)(nil).GetRevision), ctx)
+}
+
+// MockRouteRegistry is a mock of RouteRegistry interface.
+type MockRouteRegistry struct {
+	ctrl     *gomock.Controller
+	recorder *MockRouteRegistryMockRecorder
+}
+
+// MockRouteRegistryMockRecorder is the mock recorder for MockRouteRegistry.
+type MockRouteRegistryMockRecorder struct {
+	mock *MockRouteRegistry
+}
+
+// NewMockRouteRegistry creates a new mock instance.
+func NewMockRouteRegistry(ctrl *gomock.Controller) *MockRouteRegistry {
+	mock := &MockRouteRegistry{ctrl: ctrl}
+	mock.recorder = &MockRouteRegistryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRouteRegistry) EXPECT() *MockRouteRegistryMockRecorder {
+	return m.recorder
+}
+
+// Routes mocks base method.
+func (m *MockRouteRegistry) Routes() []domain.Route {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Routes")
+	ret0, _ := ret[0].([]domain.Route)
+	return ret0
+}
+
+// Routes indicates an expected call of Routes.
+func (mr *MockRouteRegistryMockRecorder) Routes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Routes", reflect.TypeOf((*MockRouteRegistry)(nil).Routes))
+}
+
+// MockCacheBackend is a mock of CacheBackend interface.
+type MockCacheBackend struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheBackendMockRecorder
+}
+
+// MockCacheBackendMockRecorder is the mock recorder for MockCacheBackend.
+type MockCacheBackendMockRecorder struct {
+	mock *MockCacheBackend
+}
+
+// NewMockCacheBackend creates a new mock instance.
+func NewMockCacheBackend(ctrl *gomock.Controller) *MockCacheBackend {
+	mock := &MockCacheBackend{ctrl: ctrl}
+	mock.recorder = &MockCacheBackendMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCacheBackend) EXPECT() *MockCacheBackendMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockCacheBackend) Delete(ctx context.Context, keys ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range keys {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Delete", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockCacheBackendMockRecorder) Delete(ctx interface{}, keys ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, keys...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCacheBackend)(nil).Delete), varargs...)
+}
+
+// Get mocks base method.
+func (m *MockCacheBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockCacheBackendMockRecorder) Get(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCacheBackend)(nil).Get), ctx, key)
+}
+
+// Set mocks base method.
+func (m *MockCacheBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, key, value, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockCacheBackendMockRecorder) Set(ctx, key, value, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockCacheBackend)(nil).Set), ctx, key, value, ttl)
+}
+
+// MockUserAccessCache is a mock of UserAccessCache interface.
+type MockUserAccessCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserAccessCacheMockRecorder
+}
+
+// MockUserAccessCacheMockRecorder is the mock recorder for MockUserAccessCache.
+type MockUserAccessCacheMockRecorder struct {
+	mock *MockUserAccessCache
+}
+
+// NewMockUserAccessCache creates a new mock instance.
+func NewMockUserAccessCache(ctrl *gomock.Controller) *MockUserAccessCache {
+	mock := &MockUserAccessCache{ctrl: ctrl}
+	mock.recorder = &MockUserAccessCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserAccessCache) EXPECT() *MockUserAccessCacheMockRecorder {
+	return m.recorder
+}
+
+// CheckBlockUser mocks base method.
+func (m *MockUserAccessCache) CheckBlockUser(ctx context.Context, userID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckBlockUser", ctx, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckBlockUser indicates an expected call of CheckBlockUser.
+func (mr *MockUserAccessCacheMockRecorder) CheckBlockUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckBlockUser", reflect.TypeOf((*MockUserAccessCache)(nil).CheckBlockUser), ctx, userID)
+}
+
+// GetTokenVersion mocks base method.
+func (m *MockUserAccessCache) GetTokenVersion(ctx context.Context, userID int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenVersion", ctx, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenVersion indicates an expected call of GetTokenVersion.
+func (mr *MockUserAccessCacheMockRecorder) GetTokenVersion(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenVersion", reflect.TypeOf((*MockUserAccessCache)(nil).GetTokenVersion), ctx, userID)
+}
+
+// InvalidateBlockStatus mocks base method.
+func (m *MockUserAccessCache) InvalidateBlockStatus(ctx context.Context, userID int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "InvalidateBlockStatus", ctx, userID)
+}
+
+// InvalidateBlockStatus indicates an expected call of InvalidateBlockStatus.
+func (mr *MockUserAccessCacheMockRecorder) InvalidateBlockStatus(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateBlockStatus", reflect.TypeOf((*MockUserAccessCache)(nil).InvalidateBlockStatus), ctx, userID)
+}
+
+// InvalidateRevokedToken mocks base method.
+func (m *MockUserAccessCache) InvalidateRevokedToken(ctx context.Context, jti string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "InvalidateRevokedToken", ctx, jti)
+}
+
+// InvalidateRevokedToken indicates an expected call of InvalidateRevokedToken.
+func (mr *MockUserAccessCacheMockRecorder) InvalidateRevokedToken(ctx, jti interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateRevokedToken", reflect.TypeOf((*MockUserAccessCache)(nil).InvalidateRevokedToken), ctx, jti)
+}
+
+// InvalidateRoles mocks base method.
+func (m *MockUserAccessCache) InvalidateRoles(ctx context.Context, userID int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "InvalidateRoles", ctx, userID)
+}
+
+// InvalidateRoles indicates an expected call of InvalidateRoles.
+func (mr *MockUserAccessCacheMockRecorder) InvalidateRoles(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateRoles", reflect.TypeOf((*MockUserAccessCache)(nil).InvalidateRoles), ctx, userID)
+}
+
+// InvalidateTokenVersion mocks base method.
+func (m *MockUserAccessCache) InvalidateTokenVersion(ctx context.Context, userID int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "InvalidateTokenVersion", ctx, userID)
+}
+
+// InvalidateTokenVersion indicates an expected call of InvalidateTokenVersion.
+func (mr *MockUserAccessCacheMockRecorder) InvalidateTokenVersion(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateTokenVersion", reflect.TypeOf((*MockUserAccessCache)(nil).InvalidateTokenVersion), ctx, userID)
+}
+
+// IsRevoked mocks base method.
+func (m *MockUserAccessCache) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsRevoked", ctx, jti)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsRevoked indicates an expected call of IsRevoked.
+func (mr *MockUserAccessCacheMockRecorder) IsRevoked(ctx, jti interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRevoked", reflect.TypeOf((*MockUserAccessCache)(nil).IsRevoked), ctx, jti)
+}
+
+// MockPolicyChecker is a mock of PolicyChecker interface.
+type MockPolicyChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockPolicyCheckerMockRecorder
+}
+
+// MockPolicyCheckerMockRecorder is the mock recorder for MockPolicyChecker.
+type MockPolicyCheckerMockRecorder struct {
+	mock *MockPolicyChecker
+}
+
+// NewMockPolicyChecker creates a new mock instance.
+func NewMockPolicyChecker(ctrl *gomock.Controller) *MockPolicyChecker {
+	mock := &MockPolicyChecker{ctrl: ctrl}
+	mock.recorder = &MockPolicyCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPolicyChecker) EXPECT() *MockPolicyCheckerMockRecorder {
+	return m.recorder
+}
+
+// Enforce mocks base method.
+func (m *MockPolicyChecker) Enforce(rvals ...interface{}) (bool, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range rvals {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Enforce", varargs...)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Enforce indicates an expected call of Enforce.
+func (mr *MockPolicyCheckerMockRecorder) Enforce(rvals ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enforce", reflect.TypeOf((*MockPolicyChecker)(nil).Enforce), rvals...)
+}
+
+// GetGroupingPolicy mocks base method.
+func (m *MockPolicyChecker) GetGroupingPolicy() [][]string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupingPolicy")
+	ret0, _ := ret[0].([][]string)
+	return ret0
+}
+
+// GetGroupingPolicy indicates an expected call of GetGroupingPolicy.
+func (mr *MockPolicyCheckerMockRecorder) GetGroupingPolicy() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupingPolicy", reflect.TypeOf((*MockPolicyChecker)(nil).GetGroupingPolicy))
+}
+
+// GetNamedPolicy mocks base method.
+func (m *MockPolicyChecker) GetNamedPolicy(ptype string) [][]string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNamedPolicy", ptype)
+	ret0, _ := ret[0].([][]string)
+	return ret0
+}
+
+// GetNamedPolicy indicates an expected call of GetNamedPolicy.
+func (mr *MockPolicyCheckerMockRecorder) GetNamedPolicy(ptype interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNamedPolicy", reflect.TypeOf((*MockPolicyChecker)(nil).GetNamedPolicy), ptype)
+}
+
+// MockWebhookRepository is a mock of WebhookRepository interface.
+type MockWebhookRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookRepositoryMockRecorder
+}
+
+// MockWebhookRepositoryMockRecorder is the mock recorder for MockWebhookRepository.
+type MockWebhookRepositoryMockRecorder struct {
+	mock *MockWebhookRepository
+}
+
+// NewMockWebhookRepository creates a new mock instance.
+func NewMockWebhookRepository(ctrl *gomock.Controller) *MockWebhookRepository {
+	mock := &MockWebhookRepository{ctrl: ctrl}
+	mock.recorder = &MockWebhookRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookRepository) EXPECT() *MockWebhookRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimDueDeliveries mocks base method.
+func (m *MockWebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDispatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDueDeliveries", ctx, limit, lease)
+	ret0, _ := ret[0].([]domain.WebhookDispatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDueDeliveries indicates an expected call of ClaimDueDeliveries.
+func (mr *MockWebhookRepositoryMockRecorder) ClaimDueDeliveries(ctx, limit, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDueDeliveries", reflect.TypeOf((*MockWebhookRepository)(nil).ClaimDueDeliveries), ctx, limit, lease)
+}
+
+// CreateDeliveryAttempt mocks base method.
+func (m *MockWebhookRepository) CreateDeliveryAttempt(ctx context.Context, attempt domain.WebhookDeliveryAttempt) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDeliveryAttempt", ctx, attempt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateDeliveryAttempt indicates an expected call of CreateDeliveryAttempt.
+func (mr *MockWebhookRepositoryMockRecorder) CreateDeliveryAttempt(ctx, attempt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDeliveryAttempt", reflect.TypeOf((*MockWebhookRepository)(nil).CreateDeliveryAttempt), ctx, attempt)
+}
+
+// CreateSubscription mocks base method.
+func (m *MockWebhookRepository) CreateSubscription(ctx context.Context, subscription domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSubscription", ctx, subscription)
+	ret0, _ := ret[0].(domain.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSubscription indicates an expected call of CreateSubscription.
+func (mr *MockWebhookRepositoryMockRecorder) CreateSubscription(ctx, subscription interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSubscription", reflect.TypeOf((*MockWebhookRepository)(nil).CreateSubscription), ctx, subscription)
+}
+
+// DeleteSubscription mocks base method.
+func (m *MockWebhookRepository) DeleteSubscription(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSubscription", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSubscription indicates an expected call of DeleteSubscription.
+func (mr *MockWebhookRepositoryMockRecorder) DeleteSubscription(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSubscription", reflect.TypeOf((*MockWebhookRepository)(nil).DeleteSubscription), ctx, id)
+}
+
+// GetDeliveries mocks base method.
+func (m *MockWebhookRepository) GetDeliveries(ctx context.Context, subscriptionID, limit int) ([]domain.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeliveries", ctx, subscriptionID, limit)
+	ret0, _ := ret[0].([]domain.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeliveries indicates an expected call of GetDeliveries.
+func (mr *MockWebhookRepositoryMockRecorder) GetDeliveries(ctx, subscriptionID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeliveries", reflect.TypeOf((*MockWebhookRepository)(nil).GetDeliveries), ctx, subscriptionID, limit)
+}
+
+// GetDelivery mocks base method.
+func (m *MockWebhookRepository) GetDelivery(ctx context.Context, id int) (domain.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDelivery", ctx, id)
+	ret0, _ := ret[0].(domain.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDelivery indicates an expected call of GetDelivery.
+func (mr *MockWebhookRepositoryMockRecorder) GetDelivery(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDelivery", reflect.TypeOf((*MockWebhookRepository)(nil).GetDelivery), ctx, id)
+}
+
+// GetDeliveryAttempts mocks base method.
+func (m *MockWebhookRepository) GetDeliveryAttempts(ctx context.Context, deliveryID int) ([]domain.WebhookDeliveryAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeliveryAttempts", ctx, deliveryID)
+	ret0, _ := ret[0].([]domain.WebhookDeliveryAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeliveryAttempts indicates an expected call of GetDeliveryAttempts.
+func (mr *MockWebhookRepositoryMockRecorder) GetDeliveryAttempts(ctx, deliveryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeliveryAttempts", reflect.TypeOf((*MockWebhookRepository)(nil).GetDeliveryAttempts), ctx, deliveryID)
+}
+
+// GetSubscription mocks base method.
+func (m *MockWebhookRepository) GetSubscription(ctx context.Context, id int) (domain.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscription", ctx, id)
+	ret0, _ := ret[0].(domain.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscription indicates an expected call of GetSubscription.
+func (mr *MockWebhookRepositoryMockRecorder) GetSubscription(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscription", reflect.TypeOf((*MockWebhookRepository)(nil).GetSubscription), ctx, id)
+}
+
+// GetSubscriptions mocks base method.
+func (m *MockWebhookRepository) GetSubscriptions(ctx context.Context, userID int) ([]domain.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscriptions", ctx, userID)
+	ret0, _ := ret[0].([]domain.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscriptions indicates an expected call of GetSubscriptions.
+func (mr *MockWebhookRepositoryMockRecorder) GetSubscriptions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptions", reflect.TypeOf((*MockWebhookRepository)(nil).GetSubscriptions), ctx, userID)
+}
+
+// Redeliver mocks base method.
+func (m *MockWebhookRepository) Redeliver(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Redeliver", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Redeliver indicates an expected call of Redeliver.
+func (mr *MockWebhookRepositoryMockRecorder) Redeliver(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redeliver", reflect.TypeOf((*MockWebhookRepository)(nil).Redeliver), ctx, id)
+}
+
+// UpdateDelivery mocks base method.
+func (m *MockWebhookRepository) UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDelivery indicates an expected call of UpdateDelivery.
+func (mr *MockWebhookRepositoryMockRecorder) UpdateDelivery(ctx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDelivery", reflect.TypeOf((*MockWebhookRepository)(nil).UpdateDelivery), ctx, delivery)
+}
+
+// MockWebhookAddressChecker is a mock of WebhookAddressChecker interface.
+type MockWebhookAddressChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookAddressCheckerMockRecorder
+}
+
+// MockWebhookAddressCheckerMockRecorder is the mock recorder for MockWebhookAddressChecker.
+type MockWebhookAddressCheckerMockRecorder struct {
+	mock *MockWebhookAddressChecker
+}
+
+// NewMockWebhookAddressChecker creates a new mock instance.
+func NewMockWebhookAddressChecker(ctrl *gomock.Controller) *MockWebhookAddressChecker {
+	mock := &MockWebhookAddressChecker{ctrl: ctrl}
+	mock.recorder = &MockWebhookAddressCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookAddressChecker) EXPECT() *MockWebhookAddressCheckerMockRecorder {
+	return m.recorder
+}
+
+// CheckHost mocks base method.
+func (m *MockWebhookAddressChecker) CheckHost(ctx context.Context, host string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckHost", ctx, host)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckHost indicates an expected call of CheckHost.
+func (mr *MockWebhookAddressCheckerMockRecorder) CheckHost(ctx, host interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckHost", reflect.TypeOf((*MockWebhookAddressChecker)(nil).CheckHost), ctx, host)
+}
+
+// MockWebhookSender is a mock of WebhookSender interface.
+type MockWebhookSender struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookSenderMockRecorder
+}
+
+// MockWebhookSenderMockRecorder is the mock recorder for MockWebhookSender.
+type MockWebhookSenderMockRecorder struct {
+	mock *MockWebhookSender
+}
+
+// NewMockWebhookSender creates a new mock instance.
+func NewMockWebhookSender(ctrl *gomock.Controller) *MockWebhookSender {
+	mock := &MockWebhookSender{ctrl: ctrl}
+	mock.recorder = &MockWebhookSenderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookSender) EXPECT() *MockWebhookSenderMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method.
+func (m *MockWebhookSender) Send(ctx context.Context, url string, headers map[string]string, body []byte) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", ctx, url, headers, body)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockWebhookSenderMockRecorder) Send(ctx, url, headers, body interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockWebhookSender)(nil).Send), ctx, url, headers, body)
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/pkg/errors"
+)
+
+// Ownership business logic layer struct resolving the owners of the resources referred to by request paths.
+type Ownership struct {
+	accountRepo AccountRepository
+	cardRepo    CardRepository
+}
+
+// NewOwnership constructor for Ownership.
+func NewOwnership(accountRepo AccountRepository, cardRepo CardRepository) *Ownership {
+	return &Ownership{
+		accountRepo: accountRepo,
+		cardRepo:    cardRepo,
+	}
+}
+
+// CheckOwnership checks that the user owns all resources, the resources are listed in the order of the path.
+// A card must belong to the account preceding it in the path, without one the card account must be owned by the user.
+// Resources of unsupported kinds are refused, so a misconfigured owner scoped policy does not grant access.
+func (s *Ownership) CheckOwnership(ctx context.Context, userID int, resources []domain.ResourceRef) error {
+	var accountID int
+	for _, resource := range resources {
+		switch resource.Kind {
+		case domain.AccountResource:
+			if err := s.checkAccountOwner(ctx, userID, resource.ID); err != nil {
+				return err
+			}
+
+			accountID = resource.ID
+		case domain.CardResource:
+			cardAccountID, err := s.cardRepo.GetAccountIDByCardID(ctx, resource.ID)
+			if err != nil {
+				return errors.Wrap(err, "getting account ID by card ID error")
+			}
+
+			if accountID == 0 {
+				if err := s.checkAccountOwner(ctx, userID, cardAccountID); err != nil {
+					return err
+				}
+			} else if cardAccountID != accountID {
+				return errors.Wrap(domain.ErrCardNotFound, "card account matching error")
+			}
+		default:
+			return errors.Errorf("ownership of %q resources can not be checked", resource.Kind)
+		}
+	}
+
+	return nil
+}
+
+// checkAccountOwner checks that the account belongs to the user.
+func (s *Ownership) checkAccountOwner(ctx context.Context, userID, accountID int) error {
+	ownerID, err := s.accountRepo.GetUserIDByAccountID(ctx, accountID)
+	if err != nil {
+		return errors.Wrap(err, "getting userID by accountID error")
+	}
+
+	if ownerID != userID {
+		return errors.Wrap(domain.ErrNotAccountOwner, "userID matching check error")
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwnership_CheckOwnership(t *testing.T) {
+	controller := gomock.NewController(t)
+	accountRepositoryMock := NewMockAccountRepository(controller)
+	cardRepositoryMock := NewMockCardRepository(controller)
+
+	ctx := context.Background()
+	userID := 1
+	accountID := 10
+	cardID := 100
+	testError := errors.New("test error")
+
+	account := domain.ResourceRef{Kind: domain.AccountResource, ID: accountID}
+	card := domain.ResourceRef{Kind: domain.CardResource, ID: cardID}
+
+	tests := []struct {
+		name          string
+		resources     []domain.ResourceRef
+		configureMock func()
+		wantErr       error
+		wantErrMsg    string
+	}{
+		{
+			name:      "account_repository_error",
+			resources: []domain.ResourceRef{account},
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().GetUserIDByAccountID(gomock.Eq(ctx), gomock.Eq(accountID)).Return(0, testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name:      "not_account_owner_error",
+			resources: []domain.ResourceRef{account},
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().GetUserIDByAccountID(gomock.Eq(ctx), gomock.Eq(accountID)).Return(2, nil)
+			},
+			wantErr: domain.ErrNotAccountOwner,
+		},
+		{
+			name:      "account_owner",
+			resources: []domain.ResourceRef{account},
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().GetUserIDByAccountID(gomock.Eq(ctx), gomock.Eq(accountID)).Return(userID, nil)
+			},
+		},
+		{
+			name:      "card_not_found_error",
+			resources: []domain.ResourceRef{account, card},
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().GetUserIDByAccountID(gomock.Eq(ctx), gomock.Eq(accountID)).Return(userID, nil)
+				cardRepositoryMock.EXPECT().GetAccountIDByCardID(gomock.Eq(ctx), gomock.Eq(cardID)).Return(0, domain.ErrCardNotFound)
+			},
+			wantErr: domain.ErrCardNotFound,
+		},
+		{
+			name:      "card_of_other_account_error",
+			resources: []domain.ResourceRef{account, card},
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().GetUserIDByAccountID(gomock.Eq(ctx), gomock.Eq(accountID)).Return(userID, nil)
+				cardRepositoryMock.EXPECT().GetAccountIDByCardID(gomock.Eq(ctx), gomock.Eq(cardID)).Return(11, nil)
+			},
+			wantErr: domain.ErrCardNotFound,
+		},
+		{
+			name:      "card_of_account",
+			resources: []domain.ResourceRef{account, card},
+			configureMock: func() {
+				accountRepositoryMock.EXPECT().GetUserIDByAccountID(gomock.Eq(ctx), gomock.Eq(accountID)).Return(userID, nil)
+				cardRepositoryMock.EXPECT().GetAccountIDByCardID(gomock.Eq(ctx), gomock.Eq(cardID)).Return(accountID, nil)
+			},
+		},
+		{
+			name:      "card_without_account_not_owner_error",
+			resources: []domain.ResourceRef{card},
+			configureMock: func() {
+				cardRepositoryMock.EXPECT().GetAccountIDByCardID(gomock.Eq(ctx), gomock.Eq(cardID)).Return(accountID, nil)
+				accountRepositoryMock.EXPECT().GetUserIDByAccountID(gomock.Eq(ctx), gomock.Eq(accountID)).Return(2, nil)
+			},
+			wantErr: domain.ErrNotAccountOwner,
+		},
+		{
+			name:          "unknown_resource_error",
+			resources:     []domain.ResourceRef{{Kind: "user", ID: userID}},
+			configureMock: func() {},
+			wantErrMsg:    `ownership of "user" resources can not be checked`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s := NewOwnership(accountRepositoryMock, cardRepositoryMock)
+
+			err := s.CheckOwnership(ctx, userID, tt.resources)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErrMsg != "" {
+				assert.EqualError(t, err, tt.wantErrMsg)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
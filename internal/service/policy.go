@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/casbin/casbin/v2/util"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// anyAction policy action allowing all methods.
+const anyAction = "*"
+
+// policy types of the RBAC model, owner scoped rules are checked by the second matcher.
+const (
+	rolePolicyType  = "p"
+	ownerPolicyType = "p2"
+)
+
+// Policies business logic layer struct of the RBAC policy management.
+// The policy is kept in the database, changes made by any instance are picked up by the others on reload.
+type Policies struct {
+	enforcer PolicyEnforcer
+	repo     PolicyRevisionRepository
+	roleRepo RolesRepository
+	routes   RouteRegistry
+	revision int64
+}
+
+// NewPolicies constructor for Policies.
+func NewPolicies(enforcer PolicyEnforcer, repo PolicyRevisionRepository, roleRepo RolesRepository, routes RouteRegistry) *Policies {
+	return &Policies{
+		enforcer: enforcer,
+		repo:     repo,
+		roleRepo: roleRepo,
+		routes:   routes,
+	}
+}
+
+// Run reloads the policy every interval until the context is done.
+func (s *Policies) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reload(ctx); err != nil {
+				logrus.WithError(err).
+					WithFields(logrus.Fields{
+						"layer":   "service",
+						"service": "Policies",
+						"method":  "Run",
+					}).
+					Error("reloading policy error")
+			}
+		}
+	}
+}
+
+// Reload loads the policy into the enforcer when it has changed since the last load.
+func (s *Policies) Reload(ctx context.Context) error {
+	revision, err := s.repo.GetRevision(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting policy revision error")
+	}
+
+	if revision == s.revision {
+		return nil
+	}
+
+	if err := s.enforcer.LoadPolicy(); err != nil {
+		return errors.Wrap(err, "loading policy error")
+	}
+
+	s.revision = revision
+
+	return nil
+}
+
+// GetPolicies returns all policy rules, the owner only rules follow the others.
+func (s *Policies) GetPolicies(_ context.Context) []domain.Policy {
+	var policies []domain.Policy
+	for _, ptype := range []string{rolePolicyType, ownerPolicyType} {
+		for _, rule := range s.enforcer.GetNamedPolicy(ptype) {
+			if len(rule) < 3 {
+				continue
+			}
+
+			policies = append(policies, domain.Policy{Subject: rule[0], Object: rule[1], Action: rule[2], OwnerOnly: ptype == ownerPolicyType})
+		}
+	}
+
+	return policies
+}
+
+// AddPolicy adds the policy rule. The subject must be a role or a reserved group and at least one route must match the object and the action.
+// The path parameters of an owner only rule must be account or card IDs, so the owner can be resolved.
+func (s *Policies) AddPolicy(ctx context.Context, policy domain.Policy) error {
+	if err := s.checkRole(ctx, policy.Subject); err != nil {
+		return errors.Wrap(err, "policy subject check error")
+	}
+
+	if err := s.checkRoute(policy.Object, policy.Action); err != nil {
+		return errors.Wrap(err, "policy route check error")
+	}
+
+	if policy.OwnerOnly {
+		if err := checkOwnerObject(policy.Object); err != nil {
+			return errors.Wrap(err, "owner only policy check error")
+		}
+	}
+
+	added, err := s.enforcer.AddNamedPolicy(policyType(policy), policy.Subject, policy.Object, policy.Action)
+	if err != nil {
+		return errors.Wrap(err, "adding policy error")
+	}
+
+	if !added {
+		return errors.Wrap(domain.ErrPolicyAlreadyExists, "adding policy error")
+	}
+
+	return nil
+}
+
+// RemovePolicy removes the policy rule.
+func (s *Policies) RemovePolicy(_ context.Context, policy domain.Policy) error {
+	removed, err := s.enforcer.RemoveNamedPolicy(policyType(policy), policy.Subject, policy.Object, policy.Action)
+	if err != nil {
+		return errors.Wrap(err, "removing policy error")
+	}
+
+	if !removed {
+		return errors.Wrap(domain.ErrPolicyNotFound, "removing policy error")
+	}
+
+	return nil
+}
+
+// GetRoleInheritances returns all role inheritance rules.
+func (s *Policies) GetRoleInheritances(_ context.Context) []domain.RoleInheritance {
+	rules := s.enforcer.GetGroupingPolicy()
+
+	inheritances := make([]domain.RoleInheritance, 0, len(rules))
+	for _, rule := range rules {
+		if len(rule) < 2 {
+			continue
+		}
+
+		inheritances = append(inheritances, domain.RoleInheritance{Role: rule[0], Parent: rule[1]})
+	}
+
+	return inheritances
+}
+
+// AddRoleInheritance lets the role inherit the permissions of the parent role, both must be roles or reserved groups.
+func (s *Policies) AddRoleInheritance(ctx context.Context, inheritance domain.RoleInheritance) error {
+	if inheritance.Role == inheritance.Parent {
+		return errors.Wrap(domain.ErrInvalidRoleInheritance, "role inheritance check error")
+	}
+
+	if err := s.checkRole(ctx, inheritance.Role); err != nil {
+		return errors.Wrap(err, "role check error")
+	}
+
+	if err := s.checkRole(ctx, inheritance.Parent); err != nil {
+		return errors.Wrap(err, "parent role check error")
+	}
+
+	added, err := s.enforcer.AddGroupingPolicy(inheritance.Role, inheritance.Parent)
+	if err != nil {
+		return errors.Wrap(err, "adding role inheritance error")
+	}
+
+	if !added {
+		return errors.Wrap(domain.ErrPolicyAlreadyExists, "adding role inheritance error")
+	}
+
+	return nil
+}
+
+// RemoveRoleInheritance removes the role inheritance rule.
+func (s *Policies) RemoveRoleInheritance(_ context.Context, inheritance domain.RoleInheritance) error {
+	removed, err := s.enforcer.RemoveGroupingPolicy(inheritance.Role, inheritance.Parent)
+	if err != nil {
+		return errors.Wrap(err, "removing role inheritance error")
+	}
+
+	if !removed {
+		return errors.Wrap(domain.ErrPolicyNotFound, "removing role inheritance error")
+	}
+
+	return nil
+}
+
+// checkRole checks that the name is a role or a reserved group.
+func (s *Policies) checkRole(ctx context.Context, name string) error {
+	if name == domain.AnonymousRoleName || name == domain.UnverifiedRoleName {
+		return nil
+	}
+
+	if _, err := s.roleRepo.GetByName(ctx, name); err != nil {
+		return errors.Wrap(err, "getting role by name error")
+	}
+
+	return nil
+}
+
+// checkRoute checks that the action is valid and some route matches the path and the action the same way the RBAC model does.
+func (s *Policies) checkRoute(path, action string) error {
+	var methods *regexp.Regexp
+	if action != anyAction {
+		var err error
+		methods, err = regexp.Compile(action)
+		if err != nil {
+			return errors.Wrap(domain.ErrInvalidPolicyAction, err.Error())
+		}
+	}
+
+	for _, route := range s.routes.Routes() {
+		if !util.KeyMatch4(route.Path, path) {
+			continue
+		}
+
+		if methods == nil || methods.MatchString(route.Method) {
+			return nil
+		}
+	}
+
+	return errors.Wrapf(domain.ErrUnknownRoute, "checking route %s %s error", action, path)
+}
+
+// policyType returns the type of the policy rule in the RBAC model.
+func policyType(policy domain.Policy) string {
+	if policy.OwnerOnly {
+		return ownerPolicyType
+	}
+
+	return rolePolicyType
+}
+
+// checkOwnerObject checks that the path template has parameters and all of them refer to the resources with known owners.
+func checkOwnerObject(path string) error {
+	segments := strings.Split(path, "/")
+
+	var params int
+	for i := 1; i < len(segments); i++ {
+		if !strings.HasPrefix(segments[i], "{") {
+			continue
+		}
+
+		if kind := domain.ResourceKind(segments[i-1]); kind != domain.AccountResource && kind != domain.CardResource {
+			return errors.Wrapf(domain.ErrInvalidOwnerPolicy, "checking %q path param error", segments[i])
+		}
+
+		params++
+	}
+
+	if params == 0 {
+		return errors.Wrap(domain.ErrInvalidOwnerPolicy, "path has no params")
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/pkg/errors"
+)
+
+// PolicyCoverage business logic layer struct reporting which RBAC groups have access to the routes.
+type PolicyCoverage struct {
+	enforcer PolicyChecker
+	routes   RouteRegistry
+}
+
+// NewPolicyCoverage constructor for PolicyCoverage.
+func NewPolicyCoverage(enforcer PolicyChecker, routes RouteRegistry) *PolicyCoverage {
+	return &PolicyCoverage{
+		enforcer: enforcer,
+		routes:   routes,
+	}
+}
+
+// GetCoverage evaluates every route for every group the way the RBAC middleware does.
+// The groups are the reserved ones followed by all roles named in the policy, the routes are sorted by the path and the method.
+func (s *PolicyCoverage) GetCoverage(_ context.Context) (domain.CoverageReport, error) {
+	routes := s.routes.Routes()
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+
+		return routes[i].Method < routes[j].Method
+	})
+
+	report := domain.CoverageReport{Groups: s.groups()}
+	for _, route := range routes {
+		coverage := domain.RouteCoverage{Route: route, Access: make([]domain.Access, 0, len(report.Groups))}
+		for _, group := range report.Groups {
+			access, err := s.access(group, route)
+			if err != nil {
+				return domain.CoverageReport{}, errors.Wrapf(err, "checking %s access to %s %s error", group, route.Method, route.Path)
+			}
+
+			coverage.Access = append(coverage.Access, access)
+		}
+
+		report.Routes = append(report.Routes, coverage)
+	}
+
+	return report, nil
+}
+
+// access returns the access of the group to the route, owner scoped rules do not apply to anonymous requests.
+func (s *PolicyCoverage) access(group string, route domain.Route) (domain.Access, error) {
+	ok, err := s.enforcer.Enforce(group, route.Path, route.Method)
+	if err != nil {
+		return "", err
+	}
+
+	if ok {
+		return domain.AccessAllowed, nil
+	}
+
+	if group == domain.AnonymousRoleName {
+		return domain.AccessDenied, nil
+	}
+
+	ok, err = s.enforcer.Enforce(casbin.NewEnforceContext("2"), group, route.Path, route.Method)
+	if err != nil {
+		return "", err
+	}
+
+	if ok {
+		return domain.AccessOwnerOnly, nil
+	}
+
+	return domain.AccessDenied, nil
+}
+
+// groups returns the reserved groups and the sorted roles named in the policy rules and the role inheritance rules.
+func (s *PolicyCoverage) groups() []string {
+	names := make(map[string]struct{})
+	for _, ptype := range []string{rolePolicyType, ownerPolicyType} {
+		for _, rule := range s.enforcer.GetNamedPolicy(ptype) {
+			names[rule[0]] = struct{}{}
+		}
+	}
+
+	for _, rule := range s.enforcer.GetGroupingPolicy() {
+		names[rule[0]] = struct{}{}
+		names[rule[1]] = struct{}{}
+	}
+
+	var roles []string
+	for name := range names {
+		if name != domain.AnonymousRoleName && name != domain.UnverifiedRoleName {
+			roles = append(roles, name)
+		}
+	}
+
+	sort.Strings(roles)
+
+	return append([]string{domain.AnonymousRoleName, domain.UnverifiedRoleName}, roles...)
+}
+
+// WriteCoverage writes the coverage report as a matrix of the routes and the groups followed by the unreachable routes.
+func WriteCoverage(w io.Writer, report domain.CoverageReport) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "METHOD\tPATH\t%s\n", strings.Join(report.Groups, "\t"))
+	for _, route := range report.Routes {
+		access := make([]string, 0, len(route.Access))
+		for _, a := range route.Access {
+			access = append(access, string(a))
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", route.Route.Method, route.Route.Path, strings.Join(access, "\t"))
+	}
+
+	if err := tw.Flush(); err != nil {
+		return errors.Wrap(err, "writing coverage matrix error")
+	}
+
+	unreachable := report.Unreachable()
+	if len(unreachable) == 0 {
+		_, err := fmt.Fprintln(w, "\nall routes are reachable")
+		return errors.Wrap(err, "writing unreachable routes error")
+	}
+
+	fmt.Fprintf(w, "\nroutes unreachable by any group (%d):\n", len(unreachable))
+	for _, route := range unreachable {
+		if _, err := fmt.Fprintf(w, "%s %s\n", route.Method, route.Path); err != nil {
+			return errors.Wrap(err, "writing unreachable routes error")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyCoverage_GetCoverage(t *testing.T) {
+	controller := gomock.NewController(t)
+	policyCheckerMock := NewMockPolicyChecker(controller)
+	routeRegistryMock := NewMockRouteRegistry(controller)
+
+	ctx := context.Background()
+	account := domain.Route{Method: "GET", Path: "/account/{id}"}
+	unblock := domain.Route{Method: "POST", Path: "/user/{id}/unblock"}
+
+	routeRegistryMock.EXPECT().Routes().Return([]domain.Route{unblock, account})
+	policyCheckerMock.EXPECT().GetNamedPolicy("p").Return(nil)
+	policyCheckerMock.EXPECT().GetNamedPolicy("p2").Return([][]string{{"user", account.Path, "GET"}})
+	policyCheckerMock.EXPECT().GetGroupingPolicy().Return([][]string{{"user", domain.AnonymousRoleName}})
+	policyCheckerMock.EXPECT().Enforce(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	policyCheckerMock.EXPECT().Enforce(gomock.Any(), "user", account.Path, account.Method).Return(true, nil)
+	policyCheckerMock.EXPECT().Enforce(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+
+	report, err := NewPolicyCoverage(policyCheckerMock, routeRegistryMock).GetCoverage(ctx)
+	assert.NoError(t, err)
+
+	assert.Equal(t, domain.CoverageReport{
+		Groups: []string{domain.AnonymousRoleName, domain.UnverifiedRoleName, "user"},
+		Routes: []domain.RouteCoverage{
+			{Route: account, Access: []domain.Access{domain.AccessDenied, domain.AccessDenied, domain.AccessOwnerOnly}},
+			{Route: unblock, Access: []domain.Access{domain.AccessDenied, domain.AccessDenied, domain.AccessDenied}},
+		},
+	}, report)
+
+	var b bytes.Buffer
+	assert.NoError(t, WriteCoverage(&b, report))
+	assert.Contains(t, b.String(), "routes unreachable by any group (1):\nPOST /user/{id}/unblock\n")
+}
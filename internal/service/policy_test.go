@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicies_AddPolicy(t *testing.T) {
+	controller := gomock.NewController(t)
+	policyEnforcerMock := NewMockPolicyEnforcer(controller)
+	roleRepositoryMock := NewMockRolesRepository(controller)
+	routeRegistryMock := NewMockRouteRegistry(controller)
+
+	ctx := context.Background()
+	routes := []domain.Route{
+		{Method: "GET", Path: "/account/{id}"},
+		{Method: "DELETE", Path: "/account/{id}"},
+		{Method: "POST", Path: "/account/{id}/card/"},
+		{Method: "POST", Path: "/user/{id}/block"},
+	}
+	support := domain.Role{ID: 3, Name: "support"}
+	testError := errors.New("test error")
+
+	tests := []struct {
+		name          string
+		policy        domain.Policy
+		configureMock func()
+		wantErr       error
+	}{
+		{
+			name:   "unknown_role_error",
+			policy: domain.Policy{Subject: "auditor", Object: "/account/{id}", Action: "GET"},
+			configureMock: func() {
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq("auditor")).Return(domain.Role{}, domain.ErrRoleNotFound)
+			},
+			wantErr: domain.ErrRoleNotFound,
+		},
+		{
+			name:   "invalid_action_error",
+			policy: domain.Policy{Subject: "support", Object: "/account/{id}", Action: "(GET"},
+			configureMock: func() {
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq("support")).Return(support, nil)
+			},
+			wantErr: domain.ErrInvalidPolicyAction,
+		},
+		{
+			name:   "unknown_path_error",
+			policy: domain.Policy{Subject: "support", Object: "/accounts/{id}", Action: "GET"},
+			configureMock: func() {
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq("support")).Return(support, nil)
+				routeRegistryMock.EXPECT().Routes().Return(routes)
+			},
+			wantErr: domain.ErrUnknownRoute,
+		},
+		{
+			name:   "unknown_method_error",
+			policy: domain.Policy{Subject: "support", Object: "/account/{id}/card/", Action: "GET"},
+			configureMock: func() {
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq("support")).Return(support, nil)
+				routeRegistryMock.EXPECT().Routes().Return(routes)
+			},
+			wantErr: domain.ErrUnknownRoute,
+		},
+		{
+			name:   "policy_already_exists_error",
+			policy: domain.Policy{Subject: "support", Object: "/account/{id}", Action: "(GET)|(DELETE)"},
+			configureMock: func() {
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq("support")).Return(support, nil)
+				routeRegistryMock.EXPECT().Routes().Return(routes)
+				policyEnforcerMock.EXPECT().AddNamedPolicy("p", "support", "/account/{id}", "(GET)|(DELETE)").Return(false, nil)
+			},
+			wantErr: domain.ErrPolicyAlreadyExists,
+		},
+		{
+			name:   "enforcer_error",
+			policy: domain.Policy{Subject: "support", Object: "/account/{id}", Action: "*"},
+			configureMock: func() {
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq("support")).Return(support, nil)
+				routeRegistryMock.EXPECT().Routes().Return(routes)
+				policyEnforcerMock.EXPECT().AddNamedPolicy("p", "support", "/account/{id}", "*").Return(false, testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name:   "owner_only_unknown_resource_error",
+			policy: domain.Policy{Subject: "support", Object: "/user/{id}/block", Action: "POST", OwnerOnly: true},
+			configureMock: func() {
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq("support")).Return(support, nil)
+				routeRegistryMock.EXPECT().Routes().Return(routes)
+			},
+			wantErr: domain.ErrInvalidOwnerPolicy,
+		},
+		{
+			name:   "owner_only",
+			policy: domain.Policy{Subject: "support", Object: "/account/{id}", Action: "GET", OwnerOnly: true},
+			configureMock: func() {
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq("support")).Return(support, nil)
+				routeRegistryMock.EXPECT().Routes().Return(routes)
+				policyEnforcerMock.EXPECT().AddNamedPolicy("p2", "support", "/account/{id}", "GET").Return(true, nil)
+			},
+		},
+		{
+			name:   "reserved_group",
+			policy: domain.Policy{Subject: domain.UnverifiedRoleName, Object: "/account/{id}", Action: "GET"},
+			configureMock: func() {
+				routeRegistryMock.EXPECT().Routes().Return(routes)
+				policyEnforcerMock.EXPECT().AddNamedPolicy("p", domain.UnverifiedRoleName, "/account/{id}", "GET").Return(true, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s := NewPolicies(policyEnforcerMock, nil, roleRepositoryMock, routeRegistryMock)
+
+			err := s.AddPolicy(ctx, tt.policy)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPolicies_AddRoleInheritance(t *testing.T) {
+	controller := gomock.NewController(t)
+	policyEnforcerMock := NewMockPolicyEnforcer(controller)
+	roleRepositoryMock := NewMockRolesRepository(controller)
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		inheritance   domain.RoleInheritance
+		configureMock func()
+		wantErr       error
+	}{
+		{
+			name:          "self_inheritance_error",
+			inheritance:   domain.RoleInheritance{Role: "support", Parent: "support"},
+			configureMock: func() {},
+			wantErr:       domain.ErrInvalidRoleInheritance,
+		},
+		{
+			name:        "unknown_parent_error",
+			inheritance: domain.RoleInheritance{Role: "support", Parent: "auditor"},
+			configureMock: func() {
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq("support")).Return(domain.Role{ID: 3, Name: "support"}, nil)
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq("auditor")).Return(domain.Role{}, domain.ErrRoleNotFound)
+			},
+			wantErr: domain.ErrRoleNotFound,
+		},
+		{
+			name:        "success",
+			inheritance: domain.RoleInheritance{Role: "support", Parent: "user"},
+			configureMock: func() {
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq("support")).Return(domain.Role{ID: 3, Name: "support"}, nil)
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq("user")).Return(domain.Role{ID: 2, Name: "user"}, nil)
+				policyEnforcerMock.EXPECT().AddGroupingPolicy("support", "user").Return(true, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s := NewPolicies(policyEnforcerMock, nil, roleRepositoryMock, nil)
+
+			err := s.AddRoleInheritance(ctx, tt.inheritance)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPolicies_Reload(t *testing.T) {
+	controller := gomock.NewController(t)
+	policyEnforcerMock := NewMockPolicyEnforcer(controller)
+	policyRevisionRepositoryMock := NewMockPolicyRevisionRepository(controller)
+
+	ctx := context.Background()
+	testError := errors.New("test error")
+
+	s := NewPolicies(policyEnforcerMock, policyRevisionRepositoryMock, nil, nil)
+
+	// the first reload loads the policy
+	policyRevisionRepositoryMock.EXPECT().GetRevision(gomock.Eq(ctx)).Return(int64(4), nil)
+	policyEnforcerMock.EXPECT().LoadPolicy().Return(nil)
+	assert.NoError(t, s.Reload(ctx))
+
+	// the unchanged policy is not loaded again
+	policyRevisionRepositoryMock.EXPECT().GetRevision(gomock.Eq(ctx)).Return(int64(4), nil)
+	assert.NoError(t, s.Reload(ctx))
+
+	// a failed load is retried on the next reload
+	policyRevisionRepositoryMock.EXPECT().GetRevision(gomock.Eq(ctx)).Return(int64(5), nil)
+	policyEnforcerMock.EXPECT().LoadPolicy().Return(testError)
+	assert.ErrorIs(t, s.Reload(ctx), testError)
+
+	policyRevisionRepositoryMock.EXPECT().GetRevision(gomock.Eq(ctx)).Return(int64(5), nil)
+	policyEnforcerMock.EXPECT().LoadPolicy().Return(nil)
+	assert.NoError(t, s.Reload(ctx))
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Profile business logic layer struct of the user managing their own profile.
+type Profile struct {
+	transactor  Transactor
+	userRepo    UsersRepository
+	cardRepo    CardRepository
+	sessionRepo SessionRepository
+	hasher      PasswordHasher
+	verifier    EmailVerifier
+	access      UserAccessCache
+}
+
+// NewProfile constructor for Profile.
+func NewProfile(transactor Transactor, userRepo UsersRepository, cardRepo CardRepository, sessionRepo SessionRepository, hasher PasswordHasher, verifier EmailVerifier, access UserAccessCache) *Profile {
+	return &Profile{
+		transactor:  transactor,
+		userRepo:    userRepo,
+		cardRepo:    cardRepo,
+		sessionRepo: sessionRepo,
+		hasher:      hasher,
+		verifier:    verifier,
+		access:      access,
+	}
+}
+
+// GetProfile returns the user.
+func (s *Profile) GetProfile(ctx context.Context, userID int) (domain.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return domain.User{}, errors.Wrap(err, "getting user by ID error")
+	}
+
+	return user, nil
+}
+
+// UpdateProfile changes the provided fields of the user profile and returns the updated user.
+// A new email makes the user unverified until it is verified by the link sent to it, the links sent before stop working and issued access tokens are invalidated,
+// so the next refresh carries the new state. On request the cardholder name of the existing cards is changed as well.
+func (s *Profile) UpdateProfile(ctx context.Context, userID int, inp domain.UpdateProfileInput) (domain.User, error) {
+	var (
+		user         domain.User
+		emailChanged bool
+	)
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return errors.Wrap(err, "getting user by ID error")
+		}
+
+		if inp.Name != nil {
+			user.Name = *inp.Name
+		}
+
+		if inp.Surname != nil {
+			user.Surname = *inp.Surname
+		}
+
+		if inp.Email != nil && *inp.Email != user.Email {
+			exists, err := s.userRepo.Exists(ctx, *inp.Email)
+			if err != nil {
+				return errors.Wrap(err, "user existence check error")
+			}
+
+			if exists {
+				return errors.Wrap(domain.ErrUserAlreadyExists, "user already exists error")
+			}
+
+			user.Email = *inp.Email
+			user.EmailVerified = false
+			emailChanged = true
+		}
+
+		if err := s.userRepo.UpdateProfile(ctx, user); err != nil {
+			return errors.Wrap(err, "updating user profile error")
+		}
+
+		if emailChanged {
+			if err := s.verifier.CancelEmailVerification(ctx, userID); err != nil {
+				return errors.Wrap(err, "cancelling email verification error")
+			}
+
+			if err := s.userRepo.IncrementTokenVersion(ctx, userID); err != nil {
+				return errors.Wrap(err, "incrementing token version error")
+			}
+		}
+
+		if inp.UpdateCardholderName {
+			if err := s.cardRepo.UpdateCardholderName(ctx, userID, user.Name+" "+user.Surname); err != nil {
+				return errors.Wrap(err, "updating cardholder name error")
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.User{}, errors.Wrap(err, "updating profile error")
+	}
+
+	if emailChanged {
+		s.access.InvalidateTokenVersion(ctx, userID)
+
+		if err := s.verifier.RequestEmailVerification(ctx, userID); err != nil {
+			// the email is already changed and the user can request the verification email again
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"layer":   "service",
+				"service": "Profile",
+				"method":  "UpdateProfile",
+				"user_id": userID,
+			}).Error("sending email verification error")
+		}
+	}
+
+	return user, nil
+}
+
+// ChangePassword sets the new password of the user after verifying the current one.
+// All sessions of the user are ended and issued access tokens are invalidated.
+func (s *Profile) ChangePassword(ctx context.Context, userID int, inp domain.ChangePasswordInput) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return errors.Wrap(err, "getting user by ID error")
+	}
+
+	ok, _, err := s.hasher.Verify(inp.CurrentPassword, user.Password)
+	if err != nil {
+		return errors.Wrap(err, "password verification error")
+	}
+
+	if !ok {
+		return errors.Wrap(domain.ErrInvalidCurrentPassword, "password verification error")
+	}
+
+	hash, err := s.hasher.Hash(inp.NewPassword)
+	if err != nil {
+		return errors.Wrap(err, "password hash error")
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.UpdatePassword(ctx, userID, hash); err != nil {
+			return errors.Wrap(err, "updating password error")
+		}
+
+		if err := s.sessionRepo.RevokeAll(ctx, userID); err != nil {
+			return errors.Wrap(err, "revoking user refresh sessions error")
+		}
+
+		if err := s.userRepo.IncrementTokenVersion(ctx, userID); err != nil {
+			return errors.Wrap(err, "incrementing token version error")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "changing password error")
+	}
+
+	s.access.InvalidateTokenVersion(ctx, userID)
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfile_UpdateProfile(t *testing.T) {
+	controller := gomock.NewController(t)
+	transactorMock := NewMockTransactor(controller)
+	userRepositoryMock := NewMockUsersRepository(controller)
+	cardRepositoryMock := NewMockCardRepository(controller)
+	emailVerifierMock := NewMockEmailVerifier(controller)
+	userAccessCacheMock := NewMockUserAccessCache(controller)
+
+	ctx := context.Background()
+	user := domain.User{ID: 1, Name: "Bob", Surname: "Marly", Email: "bob@example.com", EmailVerified: true}
+	name := "Robert"
+	email := "robert@example.com"
+	testError := errors.New("test error")
+
+	withinTransaction := func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}
+
+	tests := []struct {
+		name          string
+		inp           domain.UpdateProfileInput
+		configureMock func()
+		want          domain.User
+		wantErr       error
+	}{
+		{
+			name: "user_repository_error",
+			inp:  domain.UpdateProfileInput{Name: &name},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(domain.User{}, testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "email_taken_error",
+			inp:  domain.UpdateProfileInput{Email: &email},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(user, nil)
+				userRepositoryMock.EXPECT().Exists(gomock.Eq(ctx), gomock.Eq(email)).Return(true, nil)
+			},
+			wantErr: domain.ErrUserAlreadyExists,
+		},
+		{
+			name: "name_and_cardholder_name",
+			inp:  domain.UpdateProfileInput{Name: &name, UpdateCardholderName: true},
+			configureMock: func() {
+				updated := user
+				updated.Name = name
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(user, nil)
+				userRepositoryMock.EXPECT().UpdateProfile(gomock.Eq(ctx), gomock.Eq(updated)).Return(nil)
+				cardRepositoryMock.EXPECT().UpdateCardholderName(gomock.Eq(ctx), gomock.Eq(user.ID), gomock.Eq("Robert Marly")).Return(nil)
+			},
+			want: domain.User{ID: 1, Name: name, Surname: "Marly", Email: "bob@example.com", EmailVerified: true},
+		},
+		{
+			name: "same_email_is_not_changed",
+			inp:  domain.UpdateProfileInput{Email: &user.Email},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(user, nil)
+				userRepositoryMock.EXPECT().UpdateProfile(gomock.Eq(ctx), gomock.Eq(user)).Return(nil)
+			},
+			want: user,
+		},
+		{
+			name: "cancelling_email_verification_error",
+			inp:  domain.UpdateProfileInput{Email: &email},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(user, nil)
+				userRepositoryMock.EXPECT().Exists(gomock.Eq(ctx), gomock.Eq(email)).Return(false, nil)
+				userRepositoryMock.EXPECT().UpdateProfile(gomock.Eq(ctx), gomock.Any()).Return(nil)
+				emailVerifierMock.EXPECT().CancelEmailVerification(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "email_requires_verification",
+			inp:  domain.UpdateProfileInput{Email: &email},
+			configureMock: func() {
+				updated := user
+				updated.Email = email
+				updated.EmailVerified = false
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(user, nil)
+				userRepositoryMock.EXPECT().Exists(gomock.Eq(ctx), gomock.Eq(email)).Return(false, nil)
+				userRepositoryMock.EXPECT().UpdateProfile(gomock.Eq(ctx), gomock.Eq(updated)).Return(nil)
+				emailVerifierMock.EXPECT().CancelEmailVerification(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(nil)
+				userRepositoryMock.EXPECT().IncrementTokenVersion(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(nil)
+				userAccessCacheMock.EXPECT().InvalidateTokenVersion(gomock.Eq(ctx), gomock.Eq(user.ID))
+				// a failed verification email does not fail the update
+				emailVerifierMock.EXPECT().RequestEmailVerification(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(testError)
+			},
+			want: domain.User{ID: 1, Name: "Bob", Surname: "Marly", Email: email},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s := NewProfile(transactorMock, userRepositoryMock, cardRepositoryMock, nil, nil, emailVerifierMock, userAccessCacheMock)
+
+			got, err := s.UpdateProfile(ctx, user.ID, tt.inp)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestProfile_ChangePassword(t *testing.T) {
+	controller := gomock.NewController(t)
+	transactorMock := NewMockTransactor(controller)
+	userRepositoryMock := NewMockUsersRepository(controller)
+	sessionRepositoryMock := NewMockSessionRepository(controller)
+	passwordHasherMock := NewMockPasswordHasher(controller)
+	userAccessCacheMock := NewMockUserAccessCache(controller)
+
+	ctx := context.Background()
+	user := domain.User{ID: 1, Password: "$argon2id$old"}
+	inp := domain.ChangePasswordInput{CurrentPassword: "old-password", NewPassword: "new-password"}
+	hash := "$argon2id$new"
+	testError := errors.New("test error")
+
+	withinTransaction := func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}
+
+	tests := []struct {
+		name          string
+		configureMock func()
+		wantErr       error
+	}{
+		{
+			name: "wrong_current_password_error",
+			configureMock: func() {
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(user, nil)
+				passwordHasherMock.EXPECT().Verify(gomock.Eq(inp.CurrentPassword), gomock.Eq(user.Password)).Return(false, false, nil)
+			},
+			wantErr: domain.ErrInvalidCurrentPassword,
+		},
+		{
+			name: "revoking_sessions_error",
+			configureMock: func() {
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(user, nil)
+				passwordHasherMock.EXPECT().Verify(gomock.Eq(inp.CurrentPassword), gomock.Eq(user.Password)).Return(true, false, nil)
+				passwordHasherMock.EXPECT().Hash(gomock.Eq(inp.NewPassword)).Return(hash, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				userRepositoryMock.EXPECT().UpdatePassword(gomock.Eq(ctx), gomock.Eq(user.ID), gomock.Eq(hash)).Return(nil)
+				sessionRepositoryMock.EXPECT().RevokeAll(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "success",
+			configureMock: func() {
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(user, nil)
+				passwordHasherMock.EXPECT().Verify(gomock.Eq(inp.CurrentPassword), gomock.Eq(user.Password)).Return(true, false, nil)
+				passwordHasherMock.EXPECT().Hash(gomock.Eq(inp.NewPassword)).Return(hash, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				userRepositoryMock.EXPECT().UpdatePassword(gomock.Eq(ctx), gomock.Eq(user.ID), gomock.Eq(hash)).Return(nil)
+				sessionRepositoryMock.EXPECT().RevokeAll(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(nil)
+				userRepositoryMock.EXPECT().IncrementTokenVersion(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(nil)
+				userAccessCacheMock.EXPECT().InvalidateTokenVersion(gomock.Eq(ctx), gomock.Eq(user.ID))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s := NewProfile(transactorMock, userRepositoryMock, nil, sessionRepositoryMock, passwordHasherMock, nil, userAccessCacheMock)
+
+			err := s.ChangePassword(ctx, user.ID, inp)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/pkg/errors"
+)
+
+// Roles business logic layer struct of the role management.
+type Roles struct {
+	transactor Transactor
+	roleRepo   RolesRepository
+	userRepo   UsersRepository
+	access     UserAccessCache
+}
+
+// NewRoles constructor for Roles.
+func NewRoles(transactor Transactor, roleRepo RolesRepository, userRepo UsersRepository, access UserAccessCache) *Roles {
+	return &Roles{
+		transactor: transactor,
+		roleRepo:   roleRepo,
+		userRepo:   userRepo,
+		access:     access,
+	}
+}
+
+// GetRoles returns all roles.
+func (s *Roles) GetRoles(ctx context.Context) ([]domain.Role, error) {
+	roles, err := s.roleRepo.GetRoles(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting roles error")
+	}
+
+	return roles, nil
+}
+
+// CreateRole creates the role with the provided name. Names of the RBAC groups of anonymous and unverified users are reserved.
+// Permissions of the new role are granted by the RBAC policy.
+func (s *Roles) CreateRole(ctx context.Context, name string) (domain.Role, error) {
+	if name == domain.AnonymousRoleName || name == domain.UnverifiedRoleName {
+		return domain.Role{}, errors.Wrap(domain.ErrReservedRoleName, "role name check error")
+	}
+
+	role, err := s.roleRepo.Create(ctx, name)
+	if err != nil {
+		return domain.Role{}, errors.Wrap(err, "role creation error")
+	}
+
+	return role, nil
+}
+
+// GetUserRoles returns the roles of the user.
+func (s *Roles) GetUserRoles(ctx context.Context, userID int) ([]domain.Role, error) {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, errors.Wrap(err, "getting user by ID error")
+	}
+
+	roles, err := s.roleRepo.GetUserRoles(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting user roles error")
+	}
+
+	return roles, nil
+}
+
+// AssignRole gives the role to the user and returns the roles of the user. The new role applies to the next request of the user,
+// access tokens issued before are revoked by bumping the token version of the user in the same transaction.
+func (s *Roles) AssignRole(ctx context.Context, userID int, roleName string) ([]domain.Role, error) {
+	var roles []domain.Role
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+			return errors.Wrap(err, "getting user by ID error")
+		}
+
+		role, err := s.roleRepo.GetByName(ctx, roleName)
+		if err != nil {
+			return errors.Wrap(err, "getting role by name error")
+		}
+
+		if err := s.roleRepo.AssignRole(ctx, userID, role.ID); err != nil {
+			return errors.Wrap(err, "role assigning error")
+		}
+
+		if err := s.userRepo.IncrementTokenVersion(ctx, userID); err != nil {
+			return errors.Wrap(err, "incrementing token version error")
+		}
+
+		roles, err = s.roleRepo.GetUserRoles(ctx, userID)
+		if err != nil {
+			return errors.Wrap(err, "getting user roles error")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "assigning role error")
+	}
+
+	s.access.InvalidateRoles(ctx, userID)
+	s.access.InvalidateTokenVersion(ctx, userID)
+
+	return roles, nil
+}
+
+// RevokeRole takes the role away from the user and returns the remaining roles of the user.
+// The last role can not be revoked, a user without roles would not be allowed to do anything.
+// Access tokens issued before are revoked by bumping the token version of the user in the same transaction.
+func (s *Roles) RevokeRole(ctx context.Context, userID int, roleName string) ([]domain.Role, error) {
+	var roles []domain.Role
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+			return errors.Wrap(err, "getting user by ID error")
+		}
+
+		role, err := s.roleRepo.GetByName(ctx, roleName)
+		if err != nil {
+			return errors.Wrap(err, "getting role by name error")
+		}
+
+		userRoles, err := s.roleRepo.LockUserRoles(ctx, userID)
+		if err != nil {
+			return errors.Wrap(err, "locking user roles error")
+		}
+
+		roles = make([]domain.Role, 0, len(userRoles))
+		for _, userRole := range userRoles {
+			if userRole.ID != role.ID {
+				roles = append(roles, userRole)
+			}
+		}
+
+		if len(roles) == len(userRoles) {
+			// the user does not have the role
+			return nil
+		}
+
+		if len(roles) == 0 {
+			return errors.Wrap(domain.ErrLastUserRole, "user roles check error")
+		}
+
+		if err := s.roleRepo.RevokeRole(ctx, userID, role.ID); err != nil {
+			return errors.Wrap(err, "role revoking error")
+		}
+
+		if err := s.userRepo.IncrementTokenVersion(ctx, userID); err != nil {
+			return errors.Wrap(err, "incrementing token version error")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "revoking role error")
+	}
+
+	s.access.InvalidateRoles(ctx, userID)
+	s.access.InvalidateTokenVersion(ctx, userID)
+
+	return roles, nil
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoles_CreateRole(t *testing.T) {
+	controller := gomock.NewController(t)
+	roleRepositoryMock := NewMockRolesRepository(controller)
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		roleName      string
+		configureMock func()
+		want          domain.Role
+		wantErr       error
+	}{
+		{
+			name:          "reserved_name_error",
+			roleName:      domain.UnverifiedRoleName,
+			configureMock: func() {},
+			wantErr:       domain.ErrReservedRoleName,
+		},
+		{
+			name:     "role_already_exists_error",
+			roleName: "support",
+			configureMock: func() {
+				roleRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Eq("support")).Return(domain.Role{}, domain.ErrRoleAlreadyExists)
+			},
+			wantErr: domain.ErrRoleAlreadyExists,
+		},
+		{
+			name:     "success",
+			roleName: "support",
+			configureMock: func() {
+				roleRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Eq("support")).Return(domain.Role{ID: 3, Name: "support"}, nil)
+			},
+			want: domain.Role{ID: 3, Name: "support"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s := NewRoles(nil, roleRepositoryMock, nil, nil)
+
+			got, err := s.CreateRole(ctx, tt.roleName)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRoles_AssignRole(t *testing.T) {
+	controller := gomock.NewController(t)
+	transactorMock := NewMockTransactor(controller)
+	roleRepositoryMock := NewMockRolesRepository(controller)
+	userRepositoryMock := NewMockUsersRepository(controller)
+	userAccessCacheMock := NewMockUserAccessCache(controller)
+
+	ctx := context.Background()
+	userID := 5
+	userRole := domain.Role{ID: 2, Name: "user"}
+	supportRole := domain.Role{ID: 3, Name: "support"}
+	testError := errors.New("test error")
+
+	withinTransaction := func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}
+
+	tests := []struct {
+		name          string
+		configureMock func()
+		want          []domain.Role
+		wantErr       error
+	}{
+		{
+			name: "user_not_found_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(userID)).Return(domain.User{}, domain.ErrUserNotFound)
+			},
+			wantErr: domain.ErrUserNotFound,
+		},
+		{
+			name: "role_not_found_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(userID)).Return(domain.User{ID: userID}, nil)
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq(supportRole.Name)).Return(domain.Role{}, domain.ErrRoleNotFound)
+			},
+			wantErr: domain.ErrRoleNotFound,
+		},
+		{
+			name: "token_version_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(userID)).Return(domain.User{ID: userID}, nil)
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq(supportRole.Name)).Return(supportRole, nil)
+				roleRepositoryMock.EXPECT().AssignRole(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(supportRole.ID)).Return(nil)
+				userRepositoryMock.EXPECT().IncrementTokenVersion(gomock.Eq(ctx), gomock.Eq(userID)).Return(testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "success",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(userID)).Return(domain.User{ID: userID}, nil)
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq(supportRole.Name)).Return(supportRole, nil)
+				roleRepositoryMock.EXPECT().AssignRole(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(supportRole.ID)).Return(nil)
+				userRepositoryMock.EXPECT().IncrementTokenVersion(gomock.Eq(ctx), gomock.Eq(userID)).Return(nil)
+				roleRepositoryMock.EXPECT().GetUserRoles(gomock.Eq(ctx), gomock.Eq(userID)).Return([]domain.Role{supportRole, userRole}, nil)
+				userAccessCacheMock.EXPECT().InvalidateRoles(gomock.Eq(ctx), gomock.Eq(userID))
+				userAccessCacheMock.EXPECT().InvalidateTokenVersion(gomock.Eq(ctx), gomock.Eq(userID))
+			},
+			want: []domain.Role{supportRole, userRole},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s := NewRoles(transactorMock, roleRepositoryMock, userRepositoryMock, userAccessCacheMock)
+
+			got, err := s.AssignRole(ctx, userID, supportRole.Name)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRoles_RevokeRole(t *testing.T) {
+	controller := gomock.NewController(t)
+	transactorMock := NewMockTransactor(controller)
+	roleRepositoryMock := NewMockRolesRepository(controller)
+	userRepositoryMock := NewMockUsersRepository(controller)
+	userAccessCacheMock := NewMockUserAccessCache(controller)
+
+	ctx := context.Background()
+	userID := 5
+	userRole := domain.Role{ID: 2, Name: "user"}
+	supportRole := domain.Role{ID: 3, Name: "support"}
+
+	withinTransaction := func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}
+
+	tests := []struct {
+		name          string
+		configureMock func()
+		want          []domain.Role
+		wantErr       error
+	}{
+		{
+			name: "last_role_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(userID)).Return(domain.User{ID: userID}, nil)
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq(supportRole.Name)).Return(supportRole, nil)
+				roleRepositoryMock.EXPECT().LockUserRoles(gomock.Eq(ctx), gomock.Eq(userID)).Return([]domain.Role{supportRole}, nil)
+			},
+			wantErr: domain.ErrLastUserRole,
+		},
+		{
+			name: "role_not_assigned",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(userID)).Return(domain.User{ID: userID}, nil)
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq(supportRole.Name)).Return(supportRole, nil)
+				roleRepositoryMock.EXPECT().LockUserRoles(gomock.Eq(ctx), gomock.Eq(userID)).Return([]domain.Role{userRole}, nil)
+				userAccessCacheMock.EXPECT().InvalidateRoles(gomock.Eq(ctx), gomock.Eq(userID))
+				userAccessCacheMock.EXPECT().InvalidateTokenVersion(gomock.Eq(ctx), gomock.Eq(userID))
+			},
+			want: []domain.Role{userRole},
+		},
+		{
+			name: "success",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(userID)).Return(domain.User{ID: userID}, nil)
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq(supportRole.Name)).Return(supportRole, nil)
+				roleRepositoryMock.EXPECT().LockUserRoles(gomock.Eq(ctx), gomock.Eq(userID)).Return([]domain.Role{supportRole, userRole}, nil)
+				roleRepositoryMock.EXPECT().RevokeRole(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(supportRole.ID)).Return(nil)
+				userRepositoryMock.EXPECT().IncrementTokenVersion(gomock.Eq(ctx), gomock.Eq(userID)).Return(nil)
+				userAccessCacheMock.EXPECT().InvalidateRoles(gomock.Eq(ctx), gomock.Eq(userID))
+				userAccessCacheMock.EXPECT().InvalidateTokenVersion(gomock.Eq(ctx), gomock.Eq(userID))
+			},
+			want: []domain.Role{userRole},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s := NewRoles(transactorMock, roleRepositoryMock, userRepositoryMock, userAccessCacheMock)
+
+			got, err := s.RevokeRole(ctx, userID, supportRole.Name)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
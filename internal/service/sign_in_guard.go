@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// SignInLimits limits of failed sign in attempts.
+// Every failure within Window delays the next attempt of the email and of the client IP address by BaseDelay doubled per failure
+// up to MaxDelay, reaching MaxEmailFailures or MaxIPFailures locks the sign in for Lockout.
+type SignInLimits struct {
+	MaxEmailFailures int
+	MaxIPFailures    int
+	Window           time.Duration
+	Lockout          time.Duration
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+}
+
+// SignInGuard business logic layer struct of brute-force protection of the sign in.
+type SignInGuard struct {
+	repo      SignInThrottleRepository
+	eventRepo EventRepository
+	limits    SignInLimits
+}
+
+// NewSignInGuard constructor for SignInGuard.
+func NewSignInGuard(repo SignInThrottleRepository, eventRepo EventRepository, limits SignInLimits) *SignInGuard {
+	return &SignInGuard{repo: repo, eventRepo: eventRepo, limits: limits}
+}
+
+// Check refuses the sign in attempt while the email or the client IP address is locked or its delay has not passed yet.
+func (g *SignInGuard) Check(ctx context.Context, email, ip string) error {
+	now := time.Now()
+
+	for _, subject := range g.subjects(email, ip) {
+		throttle, err := g.repo.Get(ctx, subject.scope, subject.subject)
+		if err != nil {
+			return errors.Wrap(err, "getting sign in throttle error")
+		}
+
+		if !throttle.LockedUntil.IsZero() {
+			if now.Before(throttle.LockedUntil) {
+				return domain.NewRetryError(domain.ErrSignInLocked, throttle.LockedUntil)
+			}
+
+			// the lockout is over, the next failure starts the count again
+			continue
+		}
+
+		if throttle.Failures == 0 || throttle.LastFailureAt.Before(now.Add(-g.limits.Window)) {
+			continue
+		}
+
+		if retryAt := throttle.LastFailureAt.Add(g.delay(throttle.Failures)); now.Before(retryAt) {
+			return domain.NewRetryError(domain.ErrSignInThrottled, retryAt)
+		}
+	}
+
+	return nil
+}
+
+// RegisterFailure counts the failed sign in attempt of the email and the client IP address and locks them on reaching the limit.
+// userID is the ID of the user with the email, zero for an unknown email. Locking the email of a user creates an event.
+// The lockout error is returned when this attempt locked the sign in.
+func (g *SignInGuard) RegisterFailure(ctx context.Context, email, ip string, userID int) error {
+	var lockErr error
+	for _, subject := range g.subjects(email, ip) {
+		throttle, err := g.repo.RegisterFailure(ctx, subject.scope, subject.subject, time.Now().Add(-g.limits.Window))
+		if err != nil {
+			return errors.Wrap(err, "registering sign in failure error")
+		}
+
+		if throttle.Failures < subject.maxFailures || !throttle.LockedUntil.IsZero() {
+			continue
+		}
+
+		lockedUntil := time.Now().Add(g.limits.Lockout)
+		if err := g.repo.Lock(ctx, subject.scope, subject.subject, lockedUntil); err != nil {
+			return errors.Wrap(err, "locking sign in error")
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"layer":        "service",
+			"service":      "SignInGuard",
+			"method":       "RegisterFailure",
+			"scope":        subject.scope,
+			"subject":      subject.subject,
+			"failures":     throttle.Failures,
+			"locked_until": lockedUntil,
+		}).Warn("too many failed sign in attempts, sign in locked")
+
+		if subject.scope == domain.EmailThrottleScope && userID != 0 {
+			event := domain.Event{
+				UserID:  userID,
+				Type:    domain.UserLockedEvent,
+				Message: "user sign in locked after too many failed attempts",
+				Metadata: map[string]any{
+					"failures":     throttle.Failures,
+					"locked_until": lockedUntil,
+				},
+			}
+
+			if err := g.eventRepo.CreateEvent(ctx, event); err != nil {
+				return errors.Wrap(err, "user locking event creation error")
+			}
+		}
+
+		lockErr = domain.NewRetryError(domain.ErrSignInLocked, lockedUntil)
+	}
+
+	return lockErr
+}
+
+// Reset forgets the failed sign in attempts and the lockout of the email.
+func (g *SignInGuard) Reset(ctx context.Context, email string) error {
+	if err := g.repo.Delete(ctx, domain.EmailThrottleScope, normalizeEmail(email)); err != nil {
+		return errors.Wrap(err, "deleting sign in throttle error")
+	}
+
+	return nil
+}
+
+// throttleSubject subject failed sign in attempts are counted for.
+type throttleSubject struct {
+	scope       domain.SignInThrottleScope
+	subject     string
+	maxFailures int
+}
+
+// subjects returns the subjects of the sign in attempt, the IP address is skipped when it is unknown.
+func (g *SignInGuard) subjects(email, ip string) []throttleSubject {
+	subjects := []throttleSubject{{scope: domain.EmailThrottleScope, subject: normalizeEmail(email), maxFailures: g.limits.MaxEmailFailures}}
+	if ip != "" {
+		subjects = append(subjects, throttleSubject{scope: domain.IPThrottleScope, subject: ip, maxFailures: g.limits.MaxIPFailures})
+	}
+
+	return subjects
+}
+
+// delay returns the delay of the next attempt after the number of failures.
+func (g *SignInGuard) delay(failures int) time.Duration {
+	delay := g.limits.BaseDelay
+	for i := 1; i < failures && delay < g.limits.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > g.limits.MaxDelay {
+		return g.limits.MaxDelay
+	}
+
+	return delay
+}
+
+// normalizeEmail returns the email the attempts are counted by, so the case of the address does not matter.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+var testSignInLimits = SignInLimits{
+	MaxEmailFailures: 3,
+	MaxIPFailures:    10,
+	Window:           time.Minute * 15,
+	Lockout:          time.Minute * 15,
+	BaseDelay:        time.Second,
+	MaxDelay:         time.Second * 30,
+}
+
+func TestSignInGuard_Check(t *testing.T) {
+	controller := gomock.NewController(t)
+	signInThrottleRepositoryMock := NewMockSignInThrottleRepository(controller)
+
+	ctx := context.Background()
+	email := "Bob@Example.com"
+	ip := "10.0.0.1"
+	now := time.Now()
+	testError := errors.New("test error")
+
+	emailThrottle := func(throttle domain.SignInThrottle) {
+		signInThrottleRepositoryMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(domain.EmailThrottleScope), gomock.Eq("bob@example.com")).Return(throttle, nil)
+	}
+	ipThrottle := func(throttle domain.SignInThrottle) {
+		signInThrottleRepositoryMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(domain.IPThrottleScope), gomock.Eq(ip)).Return(throttle, nil)
+	}
+
+	tests := []struct {
+		name          string
+		configureMock func()
+		wantErr       error
+	}{
+		{
+			name: "repository_error",
+			configureMock: func() {
+				signInThrottleRepositoryMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(domain.EmailThrottleScope), gomock.Any()).Return(domain.SignInThrottle{}, testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "no_failures",
+			configureMock: func() {
+				emailThrottle(domain.SignInThrottle{})
+				ipThrottle(domain.SignInThrottle{})
+			},
+		},
+		{
+			name: "email_locked_error",
+			configureMock: func() {
+				emailThrottle(domain.SignInThrottle{Failures: 3, LastFailureAt: now, LockedUntil: now.Add(time.Minute)})
+			},
+			wantErr: domain.ErrSignInLocked,
+		},
+		{
+			name: "lockout_expired",
+			configureMock: func() {
+				emailThrottle(domain.SignInThrottle{Failures: 3, LastFailureAt: now.Add(-time.Second), LockedUntil: now.Add(-time.Millisecond)})
+				ipThrottle(domain.SignInThrottle{})
+			},
+		},
+		{
+			name: "email_delay_error",
+			configureMock: func() {
+				// the third failure delays the next attempt by 4 seconds
+				emailThrottle(domain.SignInThrottle{Failures: 3, LastFailureAt: now.Add(-time.Second * 3)})
+			},
+			wantErr: domain.ErrSignInThrottled,
+		},
+		{
+			name: "email_delay_passed",
+			configureMock: func() {
+				emailThrottle(domain.SignInThrottle{Failures: 3, LastFailureAt: now.Add(-time.Second * 5)})
+				ipThrottle(domain.SignInThrottle{})
+			},
+		},
+		{
+			name: "ip_delay_capped_error",
+			configureMock: func() {
+				emailThrottle(domain.SignInThrottle{})
+				ipThrottle(domain.SignInThrottle{Failures: 9, LastFailureAt: now.Add(-time.Second * 29)})
+			},
+			wantErr: domain.ErrSignInThrottled,
+		},
+		{
+			name: "failures_outside_window",
+			configureMock: func() {
+				emailThrottle(domain.SignInThrottle{Failures: 2, LastFailureAt: now.Add(-time.Hour)})
+				ipThrottle(domain.SignInThrottle{Failures: 9, LastFailureAt: now.Add(-time.Hour)})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			g := NewSignInGuard(signInThrottleRepositoryMock, nil, testSignInLimits)
+
+			err := g.Check(ctx, email, ip)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSignInGuard_RegisterFailure(t *testing.T) {
+	controller := gomock.NewController(t)
+	signInThrottleRepositoryMock := NewMockSignInThrottleRepository(controller)
+	eventRepositoryMock := NewMockEventRepository(controller)
+
+	ctx := context.Background()
+	email := "bob@example.com"
+	ip := "10.0.0.1"
+	userID := 1
+	testError := errors.New("test error")
+
+	emailFailure := func(failures int) {
+		signInThrottleRepositoryMock.EXPECT().RegisterFailure(gomock.Eq(ctx), gomock.Eq(domain.EmailThrottleScope), gomock.Eq(email), gomock.Any()).
+			Return(domain.SignInThrottle{Scope: domain.EmailThrottleScope, Subject: email, Failures: failures, LastFailureAt: time.Now()}, nil)
+	}
+	ipFailure := func(failures int) {
+		signInThrottleRepositoryMock.EXPECT().RegisterFailure(gomock.Eq(ctx), gomock.Eq(domain.IPThrottleScope), gomock.Eq(ip), gomock.Any()).
+			Return(domain.SignInThrottle{Scope: domain.IPThrottleScope, Subject: ip, Failures: failures, LastFailureAt: time.Now()}, nil)
+	}
+
+	tests := []struct {
+		name          string
+		userID        int
+		configureMock func()
+		wantErr       error
+	}{
+		{
+			name:   "below_limit",
+			userID: userID,
+			configureMock: func() {
+				emailFailure(2)
+				ipFailure(2)
+			},
+		},
+		{
+			name:   "email_lock_event_error",
+			userID: userID,
+			configureMock: func() {
+				emailFailure(3)
+				signInThrottleRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(domain.EmailThrottleScope), gomock.Eq(email), gomock.Any()).Return(nil)
+				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Any()).Return(testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name:   "email_locked",
+			userID: userID,
+			configureMock: func() {
+				emailFailure(3)
+				signInThrottleRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(domain.EmailThrottleScope), gomock.Eq(email), gomock.Any()).DoAndReturn(func(_ context.Context, _ domain.SignInThrottleScope, _ string, until time.Time) error {
+					assert.WithinDuration(t, time.Now().Add(testSignInLimits.Lockout), until, time.Minute)
+					return nil
+				})
+				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Any()).DoAndReturn(func(_ context.Context, event domain.Event) error {
+					assert.Equal(t, userID, event.UserID)
+					assert.Equal(t, domain.UserLockedEvent, event.Type)
+					return nil
+				})
+				ipFailure(3)
+			},
+			wantErr: domain.ErrSignInLocked,
+		},
+		{
+			name: "unknown_email_locked_without_event",
+			configureMock: func() {
+				emailFailure(3)
+				signInThrottleRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(domain.EmailThrottleScope), gomock.Eq(email), gomock.Any()).Return(nil)
+				ipFailure(3)
+			},
+			wantErr: domain.ErrSignInLocked,
+		},
+		{
+			name:   "ip_locked",
+			userID: userID,
+			configureMock: func() {
+				emailFailure(1)
+				ipFailure(10)
+				signInThrottleRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(domain.IPThrottleScope), gomock.Eq(ip), gomock.Any()).Return(nil)
+			},
+			wantErr: domain.ErrSignInLocked,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			g := NewSignInGuard(signInThrottleRepositoryMock, eventRepositoryMock, testSignInLimits)
+
+			err := g.RegisterFailure(ctx, email, ip, tt.userID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
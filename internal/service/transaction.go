@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"math/big"
 
 	"github.com/lukinairina90/banking_backend/internal/domain"
 	"github.com/pkg/errors"
@@ -9,15 +10,21 @@ import (
 
 // Transaction business logic layer struct.
 type Transaction struct {
+	Transactor      Transactor
 	TransactionRepo TransactionRepository
 	AccountRepo     AccountRepository
+	LedgerRepo      LedgerRepository
+	EventRepo       EventRepository
 }
 
 // NewTransaction constructor for transaction.
-func NewTransaction(transactionRepo TransactionRepository, accountRepo AccountRepository) *Transaction {
+func NewTransaction(transactor Transactor, transactionRepo TransactionRepository, accountRepo AccountRepository, ledgerRepo LedgerRepository, eventRepo EventRepository) *Transaction {
 	return &Transaction{
+		Transactor:      transactor,
 		TransactionRepo: transactionRepo,
 		AccountRepo:     accountRepo,
+		LedgerRepo:      ledgerRepo,
+		EventRepo:       eventRepo,
 	}
 }
 
@@ -29,7 +36,7 @@ func (s Transaction) GetTransactionList(ctx context.Context, accountID, userID i
 	}
 
 	if checkUserID != userID {
-		return nil, errors.New("userID matching check error")
+		return nil, errors.Wrap(domain.ErrNotAccountOwner, "userID matching check error")
 	}
 
 	listTransaction, err := s.TransactionRepo.GetTransactionList(ctx, accountID, ordering, paginator)
@@ -39,3 +46,226 @@ func (s Transaction) GetTransactionList(ctx context.Context, accountID, userID i
 
 	return listTransaction, nil
 }
+
+// ReverseTransaction reverses the sent transaction by a compensating transaction linked to it, which moves the money back.
+// The amount is taken in the currency of the original amount, the whole amount left to reverse is used if it is empty,
+// so a transaction can be refunded partially in several steps and becomes REVERSED once nothing is left to reverse.
+// The reversal is refused if the recipient account balance would become negative, unless force is set.
+// A reversed withdrawal returns the cash to the account. The event is recorded for the owner of the account the money
+// is returned to, or of the deposited account for a deposit, with the admin in its metadata.
+func (s Transaction) ReverseTransaction(ctx context.Context, transactionID, adminID int, rawAmount string, force bool) (domain.Transaction, error) {
+	var reversal domain.Transaction
+	err := s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		original, err := s.TransactionRepo.LockTransaction(ctx, transactionID)
+		if err != nil {
+			return errors.Wrap(err, "locking transaction error")
+		}
+
+		if original.ReversalOf != 0 {
+			return errors.Wrap(domain.ErrTransactionNotReversible, "reversing compensating transaction error")
+		}
+
+		if !original.Status.CanTransitionTo(domain.TransactionReversed) {
+			return errors.Wrap(domain.ErrInvalidTransactionStatusTransition, "checking transaction status error")
+		}
+
+		leftAmount, leftToAmount, err := s.leftToReverse(ctx, original)
+		if err != nil {
+			return errors.Wrap(err, "calculating amount left to reverse error")
+		}
+
+		amount, toAmount, err := reversalAmounts(original, leftAmount, leftToAmount, rawAmount)
+		if err != nil {
+			return errors.Wrap(err, "calculating reversal amount error")
+		}
+
+		recipient, ownerID, err := s.lockTransactionAccounts(ctx, original)
+		if err != nil {
+			return errors.Wrap(err, "locking accounts error")
+		}
+
+		// the withdrawn cash has no balance to check
+		if original.ToAccount != toAccountIDForWithdrawal {
+			cmp, err := recipient.Amount.Cmp(toAmount)
+			if err != nil {
+				return errors.Wrap(err, "comparing account amount error")
+			}
+
+			if cmp < 0 && !force {
+				return errors.Wrap(domain.ErrReversalNegativeBalance, "checking recipient account balance error")
+			}
+		}
+
+		var exchangeRate *big.Rat
+		if original.ExchangeRate != nil {
+			exchangeRate = new(big.Rat).Inv(original.ExchangeRate)
+		}
+
+		reversal, err = s.TransactionRepo.CreateTransaction(ctx, domain.Transaction{
+			FromAccount:  original.ToAccount,
+			ToAccount:    original.FromAccount,
+			Amount:       toAmount,
+			ToAmount:     amount,
+			ExchangeRate: exchangeRate,
+			ReversalOf:   original.ID,
+		})
+		if err != nil {
+			return errors.Wrap(err, "compensating transaction creation error")
+		}
+
+		entry := domain.NewTransferJournalEntry(reversal.ID, original.ToAccount, original.FromAccount, toAmount, amount)
+		switch {
+		case original.FromAccount == fromAccountIDForDeposit:
+			entry = domain.NewDepositReversalJournalEntry(reversal.ID, original.ToAccount, toAmount)
+		case original.ToAccount == toAccountIDForWithdrawal:
+			entry = domain.NewWithdrawalReversalJournalEntry(reversal.ID, original.FromAccount, amount)
+		}
+
+		if _, err := s.LedgerRepo.CreateJournalEntry(ctx, entry); err != nil {
+			return errors.Wrap(err, "reversal journal entry creation error")
+		}
+
+		if err := s.TransactionRepo.UpdateTransactionStatus(ctx, reversal.ID, domain.TransactionSent, ""); err != nil {
+			return errors.Wrap(err, "set compensating transaction status to sent error")
+		}
+
+		reversal.Status = domain.TransactionSent
+
+		event := domain.Event{
+			UserID:  ownerID,
+			Type:    domain.TransactionRefundedEvent,
+			Message: "transaction refunded successfully",
+			Metadata: map[string]any{
+				"transaction_id":          original.ID,
+				"reversal_transaction_id": reversal.ID,
+				"amount":                  amount.String(),
+				"currency":                amount.Currency,
+				"forced":                  force,
+				"admin_id":                adminID,
+			},
+		}
+
+		if cmp, _ := amount.Cmp(leftAmount); cmp == 0 {
+			if err := s.TransactionRepo.UpdateTransactionStatus(ctx, original.ID, domain.TransactionReversed, ""); err != nil {
+				return errors.Wrap(err, "set transaction status to reversed error")
+			}
+
+			event.Type = domain.TransactionReversedEvent
+			event.Message = "transaction reversed successfully"
+		}
+
+		if err := s.EventRepo.CreateEvent(ctx, event); err != nil {
+			return errors.Wrap(err, "transaction reversal event creation error")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Transaction{}, err
+	}
+
+	return reversal, nil
+}
+
+// leftToReverse returns the amount of the transaction which is not reversed yet in the sender and the recipient currencies.
+func (s Transaction) leftToReverse(ctx context.Context, original domain.Transaction) (domain.Money, domain.Money, error) {
+	reversals, err := s.TransactionRepo.GetReversals(ctx, original.ID)
+	if err != nil {
+		return domain.Money{}, domain.Money{}, errors.Wrap(err, "getting transaction reversals error")
+	}
+
+	leftAmount, leftToAmount := original.Amount, original.ToAmount
+	for _, reversal := range reversals {
+		// compensating transactions move the money in the opposite direction
+		if leftAmount, err = leftAmount.Sub(reversal.ToAmount); err != nil {
+			return domain.Money{}, domain.Money{}, err
+		}
+
+		if leftToAmount, err = leftToAmount.Sub(reversal.Amount); err != nil {
+			return domain.Money{}, domain.Money{}, err
+		}
+	}
+
+	return leftAmount, leftToAmount, nil
+}
+
+// lockTransactionAccounts locks the customer accounts of the transaction in ascending ID order and returns the recipient account
+// and the owner of the account affected by the reversal: the sender, or the recipient of a deposit.
+// The cash side of deposits and withdrawals is not a customer account, the recipient of a withdrawal is empty.
+func (s Transaction) lockTransactionAccounts(ctx context.Context, original domain.Transaction) (domain.Account, int, error) {
+	var accountIDs []int
+	if original.FromAccount != fromAccountIDForDeposit {
+		accountIDs = append(accountIDs, original.FromAccount)
+	}
+
+	if original.ToAccount != toAccountIDForWithdrawal {
+		accountIDs = append(accountIDs, original.ToAccount)
+	}
+
+	if len(accountIDs) == 2 && accountIDs[0] > accountIDs[1] {
+		accountIDs[0], accountIDs[1] = accountIDs[1], accountIDs[0]
+	}
+
+	var (
+		recipient domain.Account
+		ownerID   int
+	)
+	for _, accountID := range accountIDs {
+		account, err := s.AccountRepo.LockAccount(ctx, accountID)
+		if err != nil {
+			return domain.Account{}, 0, err
+		}
+
+		if account.ID == original.ToAccount {
+			recipient = account
+		}
+
+		if account.ID == original.FromAccount || original.FromAccount == fromAccountIDForDeposit {
+			ownerID = account.UserID
+		}
+	}
+
+	return recipient, ownerID, nil
+}
+
+// reversalAmounts returns the amount returned to the sender and the amount taken from the recipient.
+// The whole amount left to reverse is used if rawAmount is empty, a partial amount is converted by the original exchange rate.
+func reversalAmounts(original domain.Transaction, leftAmount, leftToAmount domain.Money, rawAmount string) (domain.Money, domain.Money, error) {
+	if !leftAmount.IsPositive() {
+		return domain.Money{}, domain.Money{}, domain.ErrReversalAmountExceeded
+	}
+
+	if rawAmount == "" {
+		return leftAmount, leftToAmount, nil
+	}
+
+	amount, err := domain.ParseMoney(rawAmount, domain.Currency{Code: leftAmount.Currency, Precision: leftAmount.Precision})
+	if err != nil {
+		return domain.Money{}, domain.Money{}, err
+	}
+
+	if !amount.IsPositive() {
+		return domain.Money{}, domain.Money{}, domain.ErrNonPositiveAmount
+	}
+
+	cmp, err := amount.Cmp(leftAmount)
+	if err != nil {
+		return domain.Money{}, domain.Money{}, err
+	}
+
+	switch {
+	case cmp > 0:
+		return domain.Money{}, domain.Money{}, domain.ErrReversalAmountExceeded
+	case cmp == 0:
+		return leftAmount, leftToAmount, nil
+	case original.ExchangeRate == nil:
+		return amount, amount, nil
+	}
+
+	toAmount, err := amount.Convert(original.ExchangeRate, domain.Currency{Code: leftToAmount.Currency, Precision: leftToAmount.Precision})
+	if err != nil {
+		return domain.Money{}, domain.Money{}, err
+	}
+
+	return amount, toAmount, nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxFailureReasonLength length of the transactions failure_reason column.
+	maxFailureReasonLength = 500
+	// sweepBatchSize number of stale transactions resolved by a single sweep.
+	sweepBatchSize = 100
+	// preparedTimeoutFailureReason failure reason of transactions which were never settled.
+	preparedTimeoutFailureReason = "timed out in PREPARED state"
+)
+
+// TransactionSweeper resolves transactions stuck in PREPARED status,
+// e.g. when the process stopped between preparing and settling the transaction.
+type TransactionSweeper struct {
+	transactor      Transactor
+	transactionRepo TransactionRepository
+	ledgerRepo      LedgerRepository
+	timeout         time.Duration
+}
+
+// NewTransactionSweeper constructor for TransactionSweeper.
+// Transactions which are in PREPARED status for longer than timeout are considered stale.
+func NewTransactionSweeper(transactor Transactor, transactionRepo TransactionRepository, ledgerRepo LedgerRepository, timeout time.Duration) *TransactionSweeper {
+	return &TransactionSweeper{
+		transactor:      transactor,
+		transactionRepo: transactionRepo,
+		ledgerRepo:      ledgerRepo,
+		timeout:         timeout,
+	}
+}
+
+// Run sweeps stale transactions every interval until the context is done.
+func (s TransactionSweeper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Sweep(ctx); err != nil {
+				logrus.WithError(err).
+					WithFields(logrus.Fields{
+						"layer":   "service",
+						"service": "TransactionSweeper",
+						"method":  "Run",
+					}).
+					Error("sweeping stale transactions error")
+			}
+		}
+	}
+}
+
+// Sweep resolves stale PREPARED transactions and returns the number of resolved ones.
+// A transaction which already has a journal entry was settled, so it is moved to SENT, otherwise it is moved to FAILED.
+// Transactions concurrently resolved by their own settlement are skipped.
+func (s TransactionSweeper) Sweep(ctx context.Context) (int, error) {
+	transactions, err := s.transactionRepo.GetStalePreparedTransactions(ctx, time.Now().Add(-s.timeout), sweepBatchSize)
+	if err != nil {
+		return 0, errors.Wrap(err, "getting stale prepared transactions error")
+	}
+
+	resolved := 0
+	for _, transaction := range transactions {
+		err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			settled, err := s.ledgerRepo.HasJournalEntry(ctx, transaction.ID)
+			if err != nil {
+				return errors.Wrap(err, "checking transaction journal entry error")
+			}
+
+			if settled {
+				return s.transactionRepo.UpdateTransactionStatus(ctx, transaction.ID, domain.TransactionSent, "")
+			}
+
+			return s.transactionRepo.UpdateTransactionStatus(ctx, transaction.ID, domain.TransactionFailed, preparedTimeoutFailureReason)
+		})
+		if errors.Is(err, domain.ErrInvalidTransactionStatusTransition) {
+			continue
+		}
+
+		if err != nil {
+			return resolved, errors.Wrap(err, "resolving stale transaction error")
+		}
+
+		resolved++
+	}
+
+	return resolved, nil
+}
+
+// failureReason returns the error message cut to fit the failure_reason column.
+func failureReason(err error) string {
+	return truncate(err.Error(), maxFailureReasonLength)
+}
+
+// truncate cuts the string to the provided number of runes.
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) > max {
+		runes = runes[:max]
+	}
+
+	return string(runes)
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionSweeper_Sweep(t *testing.T) {
+	controller := gomock.NewController(t)
+	transactorMock := NewMockTransactor(controller)
+	transactionRepositoryMock := NewMockTransactionRepository(controller)
+	ledgerRepositoryMock := NewMockLedgerRepository(controller)
+
+	ctx := context.Background()
+
+	testError := errors.New("test error")
+
+	settled := domain.Transaction{ID: 1, Status: domain.TransactionPrepared}
+	abandoned := domain.Transaction{ID: 2, Status: domain.TransactionPrepared}
+
+	withinTransaction := func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}
+
+	tests := []struct {
+		name          string
+		configureMock func()
+		want          int
+		wantErr       bool
+	}{
+		{
+			name: "getting_stale_transactions_error",
+			configureMock: func() {
+				transactionRepositoryMock.EXPECT().GetStalePreparedTransactions(gomock.Eq(ctx), gomock.Any(), gomock.Eq(sweepBatchSize)).Return(nil, testError)
+			},
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name: "checking_journal_entry_error",
+			configureMock: func() {
+				transactionRepositoryMock.EXPECT().GetStalePreparedTransactions(gomock.Eq(ctx), gomock.Any(), gomock.Eq(sweepBatchSize)).Return([]domain.Transaction{settled}, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				ledgerRepositoryMock.EXPECT().HasJournalEntry(gomock.Eq(ctx), gomock.Eq(settled.ID)).Return(false, testError)
+			},
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name: "concurrently_resolved_transaction_skipped",
+			configureMock: func() {
+				transactionRepositoryMock.EXPECT().GetStalePreparedTransactions(gomock.Eq(ctx), gomock.Any(), gomock.Eq(sweepBatchSize)).Return([]domain.Transaction{abandoned}, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				ledgerRepositoryMock.EXPECT().HasJournalEntry(gomock.Eq(ctx), gomock.Eq(abandoned.ID)).Return(false, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(abandoned.ID), gomock.Eq(domain.TransactionFailed), gomock.Eq(preparedTimeoutFailureReason)).
+					Return(domain.ErrInvalidTransactionStatusTransition)
+			},
+			want:    0,
+			wantErr: false,
+		},
+		{
+			name: "success",
+			configureMock: func() {
+				transactionRepositoryMock.EXPECT().GetStalePreparedTransactions(gomock.Eq(ctx), gomock.Any(), gomock.Eq(sweepBatchSize)).Return([]domain.Transaction{settled, abandoned}, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction).Times(2)
+				ledgerRepositoryMock.EXPECT().HasJournalEntry(gomock.Eq(ctx), gomock.Eq(settled.ID)).Return(true, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(settled.ID), gomock.Eq(domain.TransactionSent), gomock.Eq("")).Return(nil)
+				ledgerRepositoryMock.EXPECT().HasJournalEntry(gomock.Eq(ctx), gomock.Eq(abandoned.ID)).Return(false, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(abandoned.ID), gomock.Eq(domain.TransactionFailed), gomock.Eq(preparedTimeoutFailureReason)).Return(nil)
+			},
+			want:    2,
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s := NewTransactionSweeper(transactorMock, transactionRepositoryMock, ledgerRepositoryMock, time.Minute)
+			got, err := s.Sweep(ctx)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"math/big"
 	"testing"
 	"time"
 
@@ -33,7 +34,7 @@ func TestTransaction_GetTransactionList(t *testing.T) {
 		ID:          1,
 		FromAccount: fromAccountIDForDeposit,
 		ToAccount:   accountID,
-		Amount:      float64(100),
+		Amount:      domain.Money{Units: 10000, Currency: "USD", Precision: 2},
 		Status:      "PREPARED",
 		DateCreated: time.Now(),
 	}
@@ -41,7 +42,7 @@ func TestTransaction_GetTransactionList(t *testing.T) {
 		ID:          1,
 		FromAccount: 1,
 		ToAccount:   accountID,
-		Amount:      float64(60),
+		Amount:      domain.Money{Units: 6000, Currency: "USD", Precision: 2},
 		Status:      "PREPARED",
 		DateCreated: time.Now(),
 	}
@@ -150,10 +151,362 @@ func TestTransaction_GetTransactionList(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.configureMock()
 
-			tr := NewTransaction(tt.fields.TransactionRepo, tt.fields.AccountRepo)
+			tr := NewTransaction(nil, tt.fields.TransactionRepo, tt.fields.AccountRepo, nil, nil)
 			got, err := tr.GetTransactionList(tt.args.ctx, tt.args.accountID, tt.args.userID, tt.args.ordering, tt.args.paginator)
 			assert.Equal(t, tt.wantErr, err != nil)
 			assert.Equal(t, tt.want, got)
 		})
 	}
 }
+
+func TestTransaction_ReverseTransaction(t *testing.T) {
+	controller := gomock.NewController(t)
+	transactorMock := NewMockTransactor(controller)
+	transactionRepositoryMock := NewMockTransactionRepository(controller)
+	accountRepositoryMock := NewMockAccountRepository(controller)
+	ledgerRepositoryMock := NewMockLedgerRepository(controller)
+	eventRepositoryMock := NewMockEventRepository(controller)
+
+	ctx := context.Background()
+	adminID := 5
+	testError := errors.New("test error")
+
+	usd := domain.Currency{ID: 1, Code: "USD", Precision: 2}
+	uah := domain.Currency{ID: 2, Code: "UAH", Precision: 2}
+
+	sender := domain.Account{ID: 1, UserID: 1, CurrencyID: usd.ID, Amount: domain.NewMoney(1000, usd)}
+	recipient := domain.Account{ID: 2, UserID: 2, CurrencyID: usd.ID, Amount: domain.NewMoney(10000, usd)}
+	poorRecipient := recipient
+	poorRecipient.Amount = domain.NewMoney(1000, usd)
+	uahRecipient := domain.Account{ID: 2, UserID: 2, CurrencyID: uah.ID, Amount: domain.NewMoney(1000000, uah)}
+
+	original := domain.Transaction{
+		ID:          10,
+		FromAccount: sender.ID,
+		ToAccount:   recipient.ID,
+		Amount:      domain.NewMoney(6025, usd),
+		ToAmount:    domain.NewMoney(6025, usd),
+		Status:      domain.TransactionSent,
+	}
+
+	failed := original
+	failed.Status = domain.TransactionFailed
+
+	compensating := original
+	compensating.ReversalOf = 3
+
+	deposit := original
+	deposit.FromAccount = 0
+
+	withdrawal := original
+	withdrawal.ToAccount = 0
+
+	exchangeRate := big.NewRat(367155, 10000)
+	crossCurrency := original
+	crossCurrency.ToAmount = domain.NewMoney(221207, uah)
+	crossCurrency.ExchangeRate = exchangeRate
+
+	previousReversal := domain.Transaction{
+		ID:          11,
+		FromAccount: recipient.ID,
+		ToAccount:   sender.ID,
+		Amount:      domain.NewMoney(2000, usd),
+		ToAmount:    domain.NewMoney(2000, usd),
+		Status:      domain.TransactionSent,
+		ReversalOf:  original.ID,
+	}
+
+	fullReversal := domain.Transaction{
+		FromAccount: recipient.ID,
+		ToAccount:   sender.ID,
+		Amount:      domain.NewMoney(6025, usd),
+		ToAmount:    domain.NewMoney(6025, usd),
+		ReversalOf:  original.ID,
+	}
+	createdFullReversal := fullReversal
+	createdFullReversal.ID = 12
+	createdFullReversal.Status = domain.TransactionPrepared
+	sentFullReversal := createdFullReversal
+	sentFullReversal.Status = domain.TransactionSent
+	fullReversalEntry := domain.NewTransferJournalEntry(createdFullReversal.ID, recipient.ID, sender.ID, fullReversal.Amount, fullReversal.ToAmount)
+
+	withinTransaction := func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}
+
+	reversalEvent := func(eventType string, ownerID int, amount string, forced bool) domain.Event {
+		et, _ := domain.NewEventTypeFromString(eventType)
+		message := "transaction refunded successfully"
+		if et == domain.TransactionReversedEvent {
+			message = "transaction reversed successfully"
+		}
+
+		return domain.Event{
+			UserID:  ownerID,
+			Type:    et,
+			Message: message,
+			Metadata: map[string]any{
+				"transaction_id":          original.ID,
+				"reversal_transaction_id": createdFullReversal.ID,
+				"amount":                  amount,
+				"currency":                "USD",
+				"forced":                  forced,
+				"admin_id":                adminID,
+			},
+		}
+	}
+
+	type args struct {
+		amount string
+		force  bool
+	}
+	tests := []struct {
+		name          string
+		args          args
+		configureMock func()
+		want          domain.Transaction
+		wantErr       error
+	}{
+		{
+			name: "transaction_begin_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).Return(testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "transaction_not_found_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				transactionRepositoryMock.EXPECT().LockTransaction(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(domain.Transaction{}, domain.ErrTransactionNotFound)
+			},
+			wantErr: domain.ErrTransactionNotFound,
+		},
+		{
+			name: "reversing_compensating_transaction_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				transactionRepositoryMock.EXPECT().LockTransaction(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(compensating, nil)
+			},
+			wantErr: domain.ErrTransactionNotReversible,
+		},
+		{
+			name: "reversing_failed_transaction_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				transactionRepositoryMock.EXPECT().LockTransaction(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(failed, nil)
+			},
+			wantErr: domain.ErrInvalidTransactionStatusTransition,
+		},
+		{
+			name: "getting_reversals_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				transactionRepositoryMock.EXPECT().LockTransaction(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(original, nil)
+				transactionRepositoryMock.EXPECT().GetReversals(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(nil, testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "amount_exceeds_left_to_reverse_error",
+			args: args{amount: "40.26"},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				transactionRepositoryMock.EXPECT().LockTransaction(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(original, nil)
+				transactionRepositoryMock.EXPECT().GetReversals(gomock.Eq(ctx), gomock.Eq(original.ID)).Return([]domain.Transaction{previousReversal}, nil)
+			},
+			wantErr: domain.ErrReversalAmountExceeded,
+		},
+		{
+			name: "amount_precision_error",
+			args: args{amount: "10.001"},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				transactionRepositoryMock.EXPECT().LockTransaction(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(original, nil)
+				transactionRepositoryMock.EXPECT().GetReversals(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(nil, nil)
+			},
+			wantErr: domain.ErrAmountPrecision,
+		},
+		{
+			name: "recipient_negative_balance_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				transactionRepositoryMock.EXPECT().LockTransaction(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(original, nil)
+				transactionRepositoryMock.EXPECT().GetReversals(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(nil, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(sender.ID)).Return(sender, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(recipient.ID)).Return(poorRecipient, nil)
+			},
+			wantErr: domain.ErrReversalNegativeBalance,
+		},
+		{
+			name: "creating_journal_entry_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				transactionRepositoryMock.EXPECT().LockTransaction(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(original, nil)
+				transactionRepositoryMock.EXPECT().GetReversals(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(nil, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(sender.ID)).Return(sender, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(recipient.ID)).Return(recipient, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(fullReversal)).Return(createdFullReversal, nil)
+				ledgerRepositoryMock.EXPECT().CreateJournalEntry(gomock.Eq(ctx), gomock.Eq(fullReversalEntry)).Return(domain.JournalEntry{}, testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "forced_full_reversal_success",
+			args: args{force: true},
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				transactionRepositoryMock.EXPECT().LockTransaction(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(original, nil)
+				transactionRepositoryMock.EXPECT().GetReversals(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(nil, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(sender.ID)).Return(sender, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(recipient.ID)).Return(poorRecipient, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(fullReversal)).Return(createdFullReversal, nil)
+				ledgerRepositoryMock.EXPECT().CreateJournalEntry(gomock.Eq(ctx), gomock.Eq(fullReversalEntry)).Return(fullReversalEntry, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(createdFullReversal.ID), gomock.Eq(domain.TransactionSent), gomock.Eq("")).Return(nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(original.ID), gomock.Eq(domain.TransactionReversed), gomock.Eq("")).Return(nil)
+				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Eq(reversalEvent("TRANSACTION_REVERSED", sender.UserID, "60.25", true))).Return(nil)
+			},
+			want: sentFullReversal,
+		},
+		{
+			name: "rest_of_partially_refunded_transaction_success",
+			configureMock: func() {
+				rest := fullReversal
+				rest.Amount = domain.NewMoney(4025, usd)
+				rest.ToAmount = domain.NewMoney(4025, usd)
+				createdRest := rest
+				createdRest.ID = createdFullReversal.ID
+				restEntry := domain.NewTransferJournalEntry(createdRest.ID, recipient.ID, sender.ID, rest.Amount, rest.ToAmount)
+
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				transactionRepositoryMock.EXPECT().LockTransaction(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(original, nil)
+				transactionRepositoryMock.EXPECT().GetReversals(gomock.Eq(ctx), gomock.Eq(original.ID)).Return([]domain.Transaction{previousReversal}, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(sender.ID)).Return(sender, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(recipient.ID)).Return(recipient, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(rest)).Return(createdRest, nil)
+				ledgerRepositoryMock.EXPECT().CreateJournalEntry(gomock.Eq(ctx), gomock.Eq(restEntry)).Return(restEntry, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(createdRest.ID), gomock.Eq(domain.TransactionSent), gomock.Eq("")).Return(nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(original.ID), gomock.Eq(domain.TransactionReversed), gomock.Eq("")).Return(nil)
+				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Eq(reversalEvent("TRANSACTION_REVERSED", sender.UserID, "40.25", false))).Return(nil)
+			},
+			want: domain.Transaction{
+				ID:          createdFullReversal.ID,
+				FromAccount: recipient.ID,
+				ToAccount:   sender.ID,
+				Amount:      domain.NewMoney(4025, usd),
+				ToAmount:    domain.NewMoney(4025, usd),
+				Status:      domain.TransactionSent,
+				ReversalOf:  original.ID,
+			},
+		},
+		{
+			name: "cross_currency_partial_refund_success",
+			args: args{amount: "10.00"},
+			configureMock: func() {
+				refund := domain.Transaction{
+					FromAccount:  recipient.ID,
+					ToAccount:    sender.ID,
+					Amount:       domain.NewMoney(36716, uah),
+					ToAmount:     domain.NewMoney(1000, usd),
+					ExchangeRate: new(big.Rat).Inv(exchangeRate),
+					ReversalOf:   original.ID,
+				}
+				createdRefund := refund
+				createdRefund.ID = createdFullReversal.ID
+				refundEntry := domain.NewTransferJournalEntry(createdRefund.ID, recipient.ID, sender.ID, refund.Amount, refund.ToAmount)
+
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				transactionRepositoryMock.EXPECT().LockTransaction(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(crossCurrency, nil)
+				transactionRepositoryMock.EXPECT().GetReversals(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(nil, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(sender.ID)).Return(sender, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(recipient.ID)).Return(uahRecipient, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(refund)).Return(createdRefund, nil)
+				ledgerRepositoryMock.EXPECT().CreateJournalEntry(gomock.Eq(ctx), gomock.Eq(refundEntry)).Return(refundEntry, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(createdRefund.ID), gomock.Eq(domain.TransactionSent), gomock.Eq("")).Return(nil)
+				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Eq(reversalEvent("TRANSACTION_REFUNDED", sender.UserID, "10.00", false))).Return(nil)
+			},
+			want: domain.Transaction{
+				ID:           createdFullReversal.ID,
+				FromAccount:  recipient.ID,
+				ToAccount:    sender.ID,
+				Amount:       domain.NewMoney(36716, uah),
+				ToAmount:     domain.NewMoney(1000, usd),
+				ExchangeRate: new(big.Rat).Inv(exchangeRate),
+				Status:       domain.TransactionSent,
+				ReversalOf:   original.ID,
+			},
+		},
+		{
+			name: "withdrawal_reversal_success",
+			configureMock: func() {
+				withdrawalReversal := fullReversal
+				withdrawalReversal.FromAccount = 0
+				createdWithdrawalReversal := withdrawalReversal
+				createdWithdrawalReversal.ID = createdFullReversal.ID
+				withdrawalReversalEntry := domain.NewWithdrawalReversalJournalEntry(createdWithdrawalReversal.ID, sender.ID, withdrawalReversal.ToAmount)
+
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				transactionRepositoryMock.EXPECT().LockTransaction(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(withdrawal, nil)
+				transactionRepositoryMock.EXPECT().GetReversals(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(nil, nil)
+				// only the customer account is locked, the withdrawn cash has no balance to check
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(sender.ID)).Return(sender, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(withdrawalReversal)).Return(createdWithdrawalReversal, nil)
+				ledgerRepositoryMock.EXPECT().CreateJournalEntry(gomock.Eq(ctx), gomock.Eq(withdrawalReversalEntry)).Return(withdrawalReversalEntry, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(createdWithdrawalReversal.ID), gomock.Eq(domain.TransactionSent), gomock.Eq("")).Return(nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(original.ID), gomock.Eq(domain.TransactionReversed), gomock.Eq("")).Return(nil)
+				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Eq(reversalEvent("TRANSACTION_REVERSED", sender.UserID, "60.25", false))).Return(nil)
+			},
+			want: domain.Transaction{
+				ID:         createdFullReversal.ID,
+				ToAccount:  sender.ID,
+				Amount:     domain.NewMoney(6025, usd),
+				ToAmount:   domain.NewMoney(6025, usd),
+				Status:     domain.TransactionSent,
+				ReversalOf: original.ID,
+			},
+		},
+		{
+			name: "deposit_reversal_success",
+			configureMock: func() {
+				depositReversal := fullReversal
+				depositReversal.ToAccount = 0
+				createdDepositReversal := depositReversal
+				createdDepositReversal.ID = createdFullReversal.ID
+				depositReversalEntry := domain.NewDepositReversalJournalEntry(createdDepositReversal.ID, recipient.ID, depositReversal.Amount)
+
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				transactionRepositoryMock.EXPECT().LockTransaction(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(deposit, nil)
+				transactionRepositoryMock.EXPECT().GetReversals(gomock.Eq(ctx), gomock.Eq(original.ID)).Return(nil, nil)
+				accountRepositoryMock.EXPECT().LockAccount(gomock.Eq(ctx), gomock.Eq(recipient.ID)).Return(recipient, nil)
+				transactionRepositoryMock.EXPECT().CreateTransaction(gomock.Eq(ctx), gomock.Eq(depositReversal)).Return(createdDepositReversal, nil)
+				ledgerRepositoryMock.EXPECT().CreateJournalEntry(gomock.Eq(ctx), gomock.Eq(depositReversalEntry)).Return(depositReversalEntry, nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(createdDepositReversal.ID), gomock.Eq(domain.TransactionSent), gomock.Eq("")).Return(nil)
+				transactionRepositoryMock.EXPECT().UpdateTransactionStatus(gomock.Eq(ctx), gomock.Eq(original.ID), gomock.Eq(domain.TransactionReversed), gomock.Eq("")).Return(nil)
+				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Eq(reversalEvent("TRANSACTION_REVERSED", recipient.UserID, "60.25", false))).Return(nil)
+			},
+			want: domain.Transaction{
+				ID:          createdFullReversal.ID,
+				FromAccount: recipient.ID,
+				Amount:      domain.NewMoney(6025, usd),
+				ToAmount:    domain.NewMoney(6025, usd),
+				Status:      domain.TransactionSent,
+				ReversalOf:  original.ID,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			tr := NewTransaction(transactorMock, transactionRepositoryMock, accountRepositoryMock, ledgerRepositoryMock, eventRepositoryMock)
+			got, err := tr.ReverseTransaction(ctx, original.ID, adminID, tt.args.amount, tt.args.force)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
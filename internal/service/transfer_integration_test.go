@@ -0,0 +1,97 @@
+//go:build integration
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/lukinairina90/banking_backend/internal/repository"
+	"github.com/lukinairina90/banking_backend/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIntegration_TransferWrongStepUpCodeIsCounted runs against the migrated database from TEST_DB_DSN (see make test-integration).
+func TestIntegration_TransferWrongStepUpCodeIsCounted(t *testing.T) {
+	db := integrationDB(t)
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	var userID int
+	err = db.GetContext(ctx, &userID, "INSERT INTO users (name, surname, email, password, blocked, registered_at, email_verified, totp_secret, totp_enabled) "+
+		"VALUES ('step', 'up', $1, 'password', false, NOW(), true, $2, true) RETURNING id", fmt.Sprintf("step-up-%d@example.com", suffix), secret)
+	require.NoError(t, err)
+
+	var currencyID int
+	require.NoError(t, db.GetContext(ctx, &currencyID, "SELECT id FROM currency WHERE code = 'USD'"))
+
+	toIban := fmt.Sprintf("UA%d", suffix)
+	var fromAccountID int
+	err = db.GetContext(ctx, &fromAccountID, "INSERT INTO accounts (iban, user_id, currency_id, blocked) VALUES ($1, $2, $3, false) RETURNING id",
+		fmt.Sprintf("UA%d", suffix+1), userID, currencyID)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "INSERT INTO accounts (iban, user_id, currency_id, blocked) VALUES ($1, $2, $3, false)", toIban, userID, currencyID)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM accounts WHERE user_id = $1", userID)
+		db.Exec("DELETE FROM users WHERE id = $1", userID)
+	})
+
+	transactor := repository.NewTransactor(db)
+	twoFactorRepository := repository.NewTwoFactor(db)
+	twoFactor, err := NewTwoFactor(transactor, twoFactorRepository, repository.NewUsers(db), "banking", "100", time.Minute, 5, 5, time.Minute*15)
+	require.NoError(t, err)
+
+	s := NewAccount(transactor, repository.NewAccount(db), repository.NewTransactions(db), repository.NewLedger(db), repository.NewCurrency(db),
+		nil, repository.NewEvent(db), repository.NewUsers(db), twoFactor, nil, false)
+
+	err = s.TransferAccount(ctx, fromAccountID, userID, "1000", toIban, 0, wrongTOTPCode(t, secret))
+	assert.ErrorIs(t, err, domain.ErrInvalidTwoFactorCode)
+
+	settings, err := twoFactorRepository.Lock(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, settings.Failures)
+}
+
+// integrationDB connects to the migrated database from TEST_DB_DSN and skips the test when it is not set.
+func integrationDB(t *testing.T) *sqlx.DB {
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DB_DSN is not set")
+	}
+
+	db, err := sqlx.Connect("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// wrongTOTPCode returns a code which is not valid for the secret within the allowed skew.
+func wrongTOTPCode(t *testing.T, secret string) string {
+	valid := make(map[string]bool)
+	current := totp.Step(time.Now())
+	for step := current - totpSkew - 1; step <= current+totpSkew+1; step++ {
+		code, err := totp.Code(secret, step)
+		require.NoError(t, err)
+		valid[code] = true
+	}
+
+	for i := 0; ; i++ {
+		code := fmt.Sprintf("%06d", i)
+		if !valid[code] {
+			return code
+		}
+	}
+}
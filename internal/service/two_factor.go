@@ -0,0 +1,440 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/lukinairina90/banking_backend/pkg/totp"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// totpSkew number of time steps a code may be off to tolerate clock drift of the device.
+	totpSkew           = 1
+	recoveryCodesCount = 10
+	// recoveryCodeSize number of random bytes of a recovery code, shown as two groups of 5 hex digits.
+	recoveryCodeSize    = 5
+	challengeTokenSize  = 32
+	recoveryCodeDivider = "-"
+)
+
+// TwoFactor business logic layer struct of TOTP two-factor authentication.
+type TwoFactor struct {
+	transactor    Transactor
+	twoFactorRepo TwoFactorRepository
+	userRepo      UsersRepository
+
+	issuer               string
+	stepUpAmount         *big.Rat
+	challengeTTL         time.Duration
+	maxChallengeAttempts int
+	maxCodeFailures      int
+	codeLockout          time.Duration
+}
+
+// NewTwoFactor constructor for TwoFactor.
+// Transfers of at least stepUpAmount, a decimal in units of the sending account currency, require a code of the users with two-factor authentication enabled.
+// After maxCodeFailures wrong codes of a user in a row, no code of the user is accepted for codeLockout.
+func NewTwoFactor(transactor Transactor, twoFactorRepo TwoFactorRepository, userRepo UsersRepository, issuer, stepUpAmount string, challengeTTL time.Duration, maxChallengeAttempts, maxCodeFailures int, codeLockout time.Duration) (*TwoFactor, error) {
+	amount, ok := new(big.Rat).SetString(stepUpAmount)
+	if !ok || amount.Sign() < 0 {
+		return nil, errors.Errorf("invalid step-up amount %q", stepUpAmount)
+	}
+
+	return &TwoFactor{
+		transactor:           transactor,
+		twoFactorRepo:        twoFactorRepo,
+		userRepo:             userRepo,
+		issuer:               issuer,
+		stepUpAmount:         amount,
+		challengeTTL:         challengeTTL,
+		maxChallengeAttempts: maxChallengeAttempts,
+		maxCodeFailures:      maxCodeFailures,
+		codeLockout:          codeLockout,
+	}, nil
+}
+
+// Enroll generates a new TOTP secret of the user and returns it with the otpauth URI for authenticator apps.
+// Two-factor authentication is enabled only after the first code is confirmed.
+func (s *TwoFactor) Enroll(ctx context.Context, userID int) (domain.TOTPEnrollment, error) {
+	var enrollment domain.TOTPEnrollment
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		twoFactor, err := s.twoFactorRepo.Lock(ctx, userID)
+		if err != nil {
+			return errors.Wrap(err, "getting two-factor settings error")
+		}
+
+		if twoFactor.Enabled {
+			return errors.Wrap(domain.ErrTwoFactorAlreadyEnabled, "checking two-factor authentication is disabled error")
+		}
+
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return errors.Wrap(err, "getting user error")
+		}
+
+		secret, err := totp.GenerateSecret()
+		if err != nil {
+			return errors.Wrap(err, "generating TOTP secret error")
+		}
+
+		if err := s.twoFactorRepo.SetSecret(ctx, userID, secret); err != nil {
+			return errors.Wrap(err, "setting TOTP secret error")
+		}
+
+		enrollment = domain.TOTPEnrollment{
+			Secret: secret,
+			URI:    totp.URI(s.issuer, user.Email, secret),
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+
+	return enrollment, nil
+}
+
+// Confirm checks the first TOTP code of the enrolled secret, enables two-factor authentication and returns new recovery codes.
+// The recovery codes are shown only once, only their hashes are stored.
+func (s *TwoFactor) Confirm(ctx context.Context, userID int, code string) ([]string, error) {
+	var recoveryCodes []string
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		twoFactor, err := s.twoFactorRepo.Lock(ctx, userID)
+		if err != nil {
+			return errors.Wrap(err, "getting two-factor settings error")
+		}
+
+		if twoFactor.Enabled {
+			return errors.Wrap(domain.ErrTwoFactorAlreadyEnabled, "checking two-factor authentication is disabled error")
+		}
+
+		if twoFactor.Secret == "" {
+			return errors.Wrap(domain.ErrTwoFactorNotEnrolled, "checking TOTP secret is enrolled error")
+		}
+
+		ok, err := s.verifyTOTP(ctx, twoFactor, code)
+		if err != nil {
+			return errors.Wrap(err, "verifying TOTP code error")
+		}
+
+		if !ok {
+			return errors.Wrap(domain.ErrInvalidTwoFactorCode, "verifying TOTP code error")
+		}
+
+		if err := s.twoFactorRepo.Enable(ctx, userID); err != nil {
+			return errors.Wrap(err, "enabling two-factor authentication error")
+		}
+
+		recoveryCodes, err = s.replaceRecoveryCodes(ctx, userID)
+		if err != nil {
+			return errors.Wrap(err, "creating recovery codes error")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// Disable disables two-factor authentication of the user after verifying a TOTP or recovery code.
+func (s *TwoFactor) Disable(ctx context.Context, userID int, code string) error {
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.verify(ctx, userID, code); err != nil {
+			return err
+		}
+
+		if err := s.twoFactorRepo.Disable(ctx, userID); err != nil {
+			return errors.Wrap(err, "disabling two-factor authentication error")
+		}
+
+		return nil
+	})
+
+	return s.countCodeFailure(ctx, userID, err)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user after verifying a TOTP or recovery code.
+func (s *TwoFactor) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error) {
+	var recoveryCodes []string
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.verify(ctx, userID, code); err != nil {
+			return err
+		}
+
+		var err error
+		recoveryCodes, err = s.replaceRecoveryCodes(ctx, userID)
+		if err != nil {
+			return errors.Wrap(err, "creating recovery codes error")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, s.countCodeFailure(ctx, userID, err)
+	}
+
+	return recoveryCodes, nil
+}
+
+// CreateChallenge creates the challenge of the sign in of the user with two-factor authentication enabled
+// and returns its token, which is exchanged for the access tokens together with a code.
+func (s *TwoFactor) CreateChallenge(ctx context.Context, userID int) (string, error) {
+	token, err := newRandomToken(challengeTokenSize)
+	if err != nil {
+		return "", errors.Wrap(err, "creating challenge token error")
+	}
+
+	challenge := domain.TwoFactorChallenge{
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.challengeTTL),
+	}
+
+	if err := s.twoFactorRepo.CreateChallenge(ctx, challenge); err != nil {
+		return "", errors.Wrap(err, "creating two-factor challenge error")
+	}
+
+	return token, nil
+}
+
+// CompleteChallenge verifies the code against the challenge and returns the ID of the signing in user.
+// The challenge can be completed once, it is dropped after maxChallengeAttempts wrong codes.
+func (s *TwoFactor) CompleteChallenge(ctx context.Context, challengeToken, code string) (int, error) {
+	var (
+		userID int
+		failed bool
+	)
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		challenge, err := s.twoFactorRepo.LockChallenge(ctx, hashToken(challengeToken))
+		if err != nil {
+			return errors.Wrap(err, "getting two-factor challenge error")
+		}
+
+		if time.Now().After(challenge.ExpiresAt) || challenge.Attempts >= s.maxChallengeAttempts {
+			if err := s.twoFactorRepo.DeleteChallenge(ctx, challenge.ID); err != nil {
+				return errors.Wrap(err, "deleting two-factor challenge error")
+			}
+
+			return errors.Wrap(domain.ErrInvalidTwoFactorChallenge, "checking two-factor challenge error")
+		}
+
+		userID = challenge.UserID
+
+		if err := s.verify(ctx, challenge.UserID, code); err != nil {
+			if !errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+				return err
+			}
+
+			// the failed attempt is counted in the committed transaction, the error is returned afterwards
+			failed = true
+
+			if err := s.twoFactorRepo.IncrementChallengeAttempts(ctx, challenge.ID); err != nil {
+				return errors.Wrap(err, "counting two-factor challenge attempt error")
+			}
+
+			return nil
+		}
+
+		if err := s.twoFactorRepo.DeleteChallenge(ctx, challenge.ID); err != nil {
+			return errors.Wrap(err, "deleting two-factor challenge error")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if failed {
+		return 0, s.countCodeFailure(ctx, userID, errors.Wrap(domain.ErrInvalidTwoFactorCode, "verifying two-factor code error"))
+	}
+
+	return userID, nil
+}
+
+// VerifyStepUp requires a TOTP or recovery code for amounts of at least the step-up amount when the user has two-factor authentication enabled.
+// It must not be called inside a database transaction, the counted wrong code would be rolled back with it.
+func (s *TwoFactor) VerifyStepUp(ctx context.Context, userID int, amount domain.Money, code string) error {
+	if amountRat(amount).Cmp(s.stepUpAmount) < 0 {
+		return nil
+	}
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		twoFactor, err := s.twoFactorRepo.Lock(ctx, userID)
+		if err != nil {
+			return errors.Wrap(err, "getting two-factor settings error")
+		}
+
+		if !twoFactor.Enabled {
+			return nil
+		}
+
+		if code == "" {
+			return errors.Wrap(domain.ErrStepUpRequired, "checking step-up code is provided error")
+		}
+
+		return s.verifyCode(ctx, twoFactor, code)
+	})
+
+	return s.countCodeFailure(ctx, userID, err)
+}
+
+// verify checks the TOTP or recovery code of the user with two-factor authentication enabled.
+// It must be called inside a database transaction.
+func (s *TwoFactor) verify(ctx context.Context, userID int, code string) error {
+	twoFactor, err := s.twoFactorRepo.Lock(ctx, userID)
+	if err != nil {
+		return errors.Wrap(err, "getting two-factor settings error")
+	}
+
+	if !twoFactor.Enabled {
+		return errors.Wrap(domain.ErrTwoFactorNotEnabled, "checking two-factor authentication is enabled error")
+	}
+
+	return s.verifyCode(ctx, twoFactor, code)
+}
+
+// verifyCode accepts a TOTP code which was not used yet or an unused recovery code, which is used up.
+// No code is accepted while the codes of the user are locked, an accepted code forgets the wrong ones.
+func (s *TwoFactor) verifyCode(ctx context.Context, twoFactor domain.TwoFactor, code string) error {
+	if time.Now().Before(twoFactor.LockedUntil) {
+		return domain.NewRetryError(domain.ErrTwoFactorLocked, twoFactor.LockedUntil)
+	}
+
+	ok, err := s.verifyTOTP(ctx, twoFactor, code)
+	if err != nil {
+		return errors.Wrap(err, "verifying TOTP code error")
+	}
+
+	if ok {
+		return s.resetCodeFailures(ctx, twoFactor)
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if len(normalized) != recoveryCodeSize*2 {
+		return errors.Wrap(domain.ErrInvalidTwoFactorCode, "verifying two-factor code error")
+	}
+
+	used, err := s.twoFactorRepo.UseRecoveryCode(ctx, twoFactor.UserID, hashToken(normalized))
+	if err != nil {
+		return errors.Wrap(err, "using recovery code error")
+	}
+
+	if !used {
+		return errors.Wrap(domain.ErrInvalidTwoFactorCode, "verifying two-factor code error")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"layer":   "service",
+		"service": "TwoFactor",
+		"method":  "verifyCode",
+		"user_id": twoFactor.UserID,
+	}).Info("recovery code used")
+
+	return s.resetCodeFailures(ctx, twoFactor)
+}
+
+// resetCodeFailures forgets the wrong codes of the user after an accepted code.
+func (s *TwoFactor) resetCodeFailures(ctx context.Context, twoFactor domain.TwoFactor) error {
+	if twoFactor.Failures == 0 {
+		return nil
+	}
+
+	if err := s.twoFactorRepo.ResetCodeFailures(ctx, twoFactor.UserID); err != nil {
+		return errors.Wrap(err, "resetting two-factor code failures error")
+	}
+
+	return nil
+}
+
+// countCodeFailure counts the wrong code of the user when err is caused by it and locks the codes of the user on reaching the limit.
+// It is called after the transaction of the check, which is rolled back by the error, and returns the error to report.
+// The check must run in its own transaction, a wrong code counted inside a transaction of the caller is rolled back with it.
+func (s *TwoFactor) countCodeFailure(ctx context.Context, userID int, err error) error {
+	if !errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+		return err
+	}
+
+	failures, regErr := s.twoFactorRepo.RegisterCodeFailure(ctx, userID)
+	if regErr != nil {
+		return errors.Wrap(regErr, "registering two-factor code failure error")
+	}
+
+	if failures < s.maxCodeFailures {
+		return err
+	}
+
+	lockedUntil := time.Now().Add(s.codeLockout)
+	if err := s.twoFactorRepo.LockCodes(ctx, userID, lockedUntil); err != nil {
+		return errors.Wrap(err, "locking two-factor codes error")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"layer":        "service",
+		"service":      "TwoFactor",
+		"method":       "countCodeFailure",
+		"user_id":      userID,
+		"failures":     failures,
+		"locked_until": lockedUntil,
+	}).Warn("too many wrong two-factor codes, codes locked")
+
+	return domain.NewRetryError(domain.ErrTwoFactorLocked, lockedUntil)
+}
+
+// verifyTOTP checks the TOTP code and remembers its time step, so the same code can not be replayed.
+func (s *TwoFactor) verifyTOTP(ctx context.Context, twoFactor domain.TwoFactor, code string) (bool, error) {
+	step, ok, err := totp.Validate(twoFactor.Secret, strings.TrimSpace(code), time.Now(), totpSkew)
+	if err != nil {
+		return false, err
+	}
+
+	if !ok || step <= twoFactor.LastStep {
+		return false, nil
+	}
+
+	if err := s.twoFactorRepo.UpdateLastStep(ctx, twoFactor.UserID, step); err != nil {
+		return false, errors.Wrap(err, "updating TOTP last step error")
+	}
+
+	return true, nil
+}
+
+// replaceRecoveryCodes generates new recovery codes of the user and stores their hashes instead of the previous ones.
+func (s *TwoFactor) replaceRecoveryCodes(ctx context.Context, userID int) ([]string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		code, err := newRandomToken(recoveryCodeSize)
+		if err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, code[:recoveryCodeSize]+recoveryCodeDivider+code[recoveryCodeSize:])
+		hashes = append(hashes, hashToken(code))
+	}
+
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// normalizeRecoveryCode strips the divider and the case of the recovery code as typed by the user.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), recoveryCodeDivider, ""))
+}
+
+// amountRat returns the amount in units of its currency.
+func amountRat(amount domain.Money) *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(amount.Units), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(amount.Precision)), nil))
+}
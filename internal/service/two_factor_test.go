@@ -0,0 +1,356 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/lukinairina90/banking_backend/pkg/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTwoFactor_Confirm(t *testing.T) {
+	controller := gomock.NewController(t)
+	transactorMock := NewMockTransactor(controller)
+	twoFactorRepositoryMock := NewMockTwoFactorRepository(controller)
+
+	ctx := context.Background()
+	userID := 1
+	testError := errors.New("test error")
+
+	secret, err := totp.GenerateSecret()
+	assert.NoError(t, err)
+
+	step := totp.Step(time.Now())
+	code, err := totp.Code(secret, step)
+	assert.NoError(t, err)
+
+	enrolled := domain.TwoFactor{UserID: userID, Secret: secret}
+
+	withinTransaction := func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}
+
+	tests := []struct {
+		name          string
+		code          string
+		configureMock func()
+		wantErr       error
+	}{
+		{
+			name: "not_enrolled_error",
+			code: code,
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				twoFactorRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(userID)).Return(domain.TwoFactor{UserID: userID}, nil)
+			},
+			wantErr: domain.ErrTwoFactorNotEnrolled,
+		},
+		{
+			name: "already_enabled_error",
+			code: code,
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				twoFactorRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(userID)).Return(domain.TwoFactor{UserID: userID, Secret: secret, Enabled: true}, nil)
+			},
+			wantErr: domain.ErrTwoFactorAlreadyEnabled,
+		},
+		{
+			name: "invalid_code_error",
+			code: "000000x",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				twoFactorRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(userID)).Return(enrolled, nil)
+			},
+			wantErr: domain.ErrInvalidTwoFactorCode,
+		},
+		{
+			name: "replayed_code_error",
+			code: code,
+			configureMock: func() {
+				replayed := enrolled
+				replayed.LastStep = step
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				twoFactorRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(userID)).Return(replayed, nil)
+			},
+			wantErr: domain.ErrInvalidTwoFactorCode,
+		},
+		{
+			name: "storing_recovery_codes_error",
+			code: code,
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				twoFactorRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(userID)).Return(enrolled, nil)
+				twoFactorRepositoryMock.EXPECT().UpdateLastStep(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(step)).Return(nil)
+				twoFactorRepositoryMock.EXPECT().Enable(gomock.Eq(ctx), gomock.Eq(userID)).Return(nil)
+				twoFactorRepositoryMock.EXPECT().ReplaceRecoveryCodes(gomock.Eq(ctx), gomock.Eq(userID), gomock.Any()).Return(testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "success",
+			code: code,
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				twoFactorRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(userID)).Return(enrolled, nil)
+				twoFactorRepositoryMock.EXPECT().UpdateLastStep(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(step)).Return(nil)
+				twoFactorRepositoryMock.EXPECT().Enable(gomock.Eq(ctx), gomock.Eq(userID)).Return(nil)
+				twoFactorRepositoryMock.EXPECT().ReplaceRecoveryCodes(gomock.Eq(ctx), gomock.Eq(userID), gomock.Any()).DoAndReturn(func(_ context.Context, _ int, hashes []string) error {
+					assert.Len(t, hashes, recoveryCodesCount)
+					return nil
+				})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s, err := NewTwoFactor(transactorMock, twoFactorRepositoryMock, nil, "Banking", "1000", time.Minute, 5, 3, time.Minute*15)
+			assert.NoError(t, err)
+
+			codes, err := s.Confirm(ctx, userID, tt.code)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, codes, recoveryCodesCount)
+		})
+	}
+}
+
+func TestTwoFactor_CompleteChallenge(t *testing.T) {
+	controller := gomock.NewController(t)
+	transactorMock := NewMockTransactor(controller)
+	twoFactorRepositoryMock := NewMockTwoFactorRepository(controller)
+
+	ctx := context.Background()
+	userID := 1
+	token := "challenge-token"
+	recoveryCode := "a1b2c-3d4e5"
+
+	challenge := domain.TwoFactorChallenge{ID: 7, UserID: userID, TokenHash: hashToken(token), ExpiresAt: time.Now().Add(time.Minute)}
+	expired := challenge
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	exhausted := challenge
+	exhausted.Attempts = 5
+
+	enabled := domain.TwoFactor{UserID: userID, Secret: "JBSWY3DPEHPK3PXP", Enabled: true}
+
+	withinTransaction := func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}
+
+	tests := []struct {
+		name          string
+		code          string
+		configureMock func()
+		wantErr       error
+	}{
+		{
+			name: "unknown_challenge_error",
+			code: recoveryCode,
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				twoFactorRepositoryMock.EXPECT().LockChallenge(gomock.Eq(ctx), gomock.Eq(hashToken(token))).Return(domain.TwoFactorChallenge{}, domain.ErrInvalidTwoFactorChallenge)
+			},
+			wantErr: domain.ErrInvalidTwoFactorChallenge,
+		},
+		{
+			name: "expired_challenge_error",
+			code: recoveryCode,
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				twoFactorRepositoryMock.EXPECT().LockChallenge(gomock.Eq(ctx), gomock.Eq(hashToken(token))).Return(expired, nil)
+				twoFactorRepositoryMock.EXPECT().DeleteChallenge(gomock.Eq(ctx), gomock.Eq(challenge.ID)).Return(nil)
+			},
+			wantErr: domain.ErrInvalidTwoFactorChallenge,
+		},
+		{
+			name: "attempts_exhausted_error",
+			code: recoveryCode,
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				twoFactorRepositoryMock.EXPECT().LockChallenge(gomock.Eq(ctx), gomock.Eq(hashToken(token))).Return(exhausted, nil)
+				twoFactorRepositoryMock.EXPECT().DeleteChallenge(gomock.Eq(ctx), gomock.Eq(challenge.ID)).Return(nil)
+			},
+			wantErr: domain.ErrInvalidTwoFactorChallenge,
+		},
+		{
+			name: "wrong_code_counted",
+			code: recoveryCode,
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				twoFactorRepositoryMock.EXPECT().LockChallenge(gomock.Eq(ctx), gomock.Eq(hashToken(token))).Return(challenge, nil)
+				twoFactorRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(userID)).Return(enabled, nil)
+				twoFactorRepositoryMock.EXPECT().UseRecoveryCode(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(hashToken("a1b2c3d4e5"))).Return(false, nil)
+				twoFactorRepositoryMock.EXPECT().IncrementChallengeAttempts(gomock.Eq(ctx), gomock.Eq(challenge.ID)).Return(nil)
+				twoFactorRepositoryMock.EXPECT().RegisterCodeFailure(gomock.Eq(ctx), gomock.Eq(userID)).Return(1, nil)
+			},
+			wantErr: domain.ErrInvalidTwoFactorCode,
+		},
+		{
+			name: "recovery_code_success",
+			code: " A1B2C-3D4E5 ",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				twoFactorRepositoryMock.EXPECT().LockChallenge(gomock.Eq(ctx), gomock.Eq(hashToken(token))).Return(challenge, nil)
+				twoFactorRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(userID)).Return(enabled, nil)
+				twoFactorRepositoryMock.EXPECT().UseRecoveryCode(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(hashToken("a1b2c3d4e5"))).Return(true, nil)
+				twoFactorRepositoryMock.EXPECT().DeleteChallenge(gomock.Eq(ctx), gomock.Eq(challenge.ID)).Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s, err := NewTwoFactor(transactorMock, twoFactorRepositoryMock, nil, "Banking", "1000", time.Minute, 5, 3, time.Minute*15)
+			assert.NoError(t, err)
+
+			gotUserID, err := s.CompleteChallenge(ctx, token, tt.code)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, userID, gotUserID)
+		})
+	}
+}
+
+func TestTwoFactor_VerifyStepUp(t *testing.T) {
+	controller := gomock.NewController(t)
+	transactorMock := NewMockTransactor(controller)
+	twoFactorRepositoryMock := NewMockTwoFactorRepository(controller)
+
+	ctx := context.Background()
+	userID := 1
+	currency := domain.Currency{ID: 1, Code: "USD", Precision: 2}
+	small := domain.NewMoney(99999, currency)
+	large := domain.NewMoney(100000, currency)
+	recoveryCode := "a1b2c-3d4e5"
+	testError := errors.New("test error")
+
+	enabled := domain.TwoFactor{UserID: userID, Secret: "JBSWY3DPEHPK3PXP", Enabled: true, Failures: 1}
+	locked := enabled
+	locked.LockedUntil = time.Now().Add(time.Minute)
+
+	withinTransaction := func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}
+
+	tests := []struct {
+		name          string
+		amount        domain.Money
+		code          string
+		configureMock func()
+		wantErr       error
+	}{
+		{
+			name:          "below_step_up_amount",
+			amount:        small,
+			configureMock: func() {},
+		},
+		{
+			name:   "two_factor_disabled",
+			amount: large,
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				twoFactorRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(userID)).Return(domain.TwoFactor{UserID: userID}, nil)
+			},
+		},
+		{
+			name:   "code_required_error",
+			amount: large,
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				twoFactorRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(userID)).Return(domain.TwoFactor{UserID: userID, Secret: "JBSWY3DPEHPK3PXP", Enabled: true}, nil)
+			},
+			wantErr: domain.ErrStepUpRequired,
+		},
+		{
+			name:   "codes_locked_error",
+			amount: large,
+			code:   recoveryCode,
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				twoFactorRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(userID)).Return(locked, nil)
+			},
+			wantErr: domain.ErrTwoFactorLocked,
+		},
+		{
+			name:   "wrong_code_counted",
+			amount: large,
+			code:   recoveryCode,
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				twoFactorRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(userID)).Return(enabled, nil)
+				twoFactorRepositoryMock.EXPECT().UseRecoveryCode(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(hashToken("a1b2c3d4e5"))).Return(false, nil)
+				twoFactorRepositoryMock.EXPECT().RegisterCodeFailure(gomock.Eq(ctx), gomock.Eq(userID)).Return(2, nil)
+			},
+			wantErr: domain.ErrInvalidTwoFactorCode,
+		},
+		{
+			name:   "counting_wrong_code_error",
+			amount: large,
+			code:   recoveryCode,
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				twoFactorRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(userID)).Return(enabled, nil)
+				twoFactorRepositoryMock.EXPECT().UseRecoveryCode(gomock.Eq(ctx), gomock.Eq(userID), gomock.Any()).Return(false, nil)
+				twoFactorRepositoryMock.EXPECT().RegisterCodeFailure(gomock.Eq(ctx), gomock.Eq(userID)).Return(0, testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name:   "last_wrong_code_locks_codes",
+			amount: large,
+			code:   recoveryCode,
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				twoFactorRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(userID)).Return(enabled, nil)
+				twoFactorRepositoryMock.EXPECT().UseRecoveryCode(gomock.Eq(ctx), gomock.Eq(userID), gomock.Any()).Return(false, nil)
+				twoFactorRepositoryMock.EXPECT().RegisterCodeFailure(gomock.Eq(ctx), gomock.Eq(userID)).Return(3, nil)
+				twoFactorRepositoryMock.EXPECT().LockCodes(gomock.Eq(ctx), gomock.Eq(userID), gomock.Any()).DoAndReturn(func(_ context.Context, _ int, until time.Time) error {
+					assert.WithinDuration(t, time.Now().Add(time.Minute*15), until, time.Minute)
+					return nil
+				})
+			},
+			wantErr: domain.ErrTwoFactorLocked,
+		},
+		{
+			name:   "recovery_code_resets_failures",
+			amount: large,
+			code:   recoveryCode,
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				twoFactorRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(userID)).Return(enabled, nil)
+				twoFactorRepositoryMock.EXPECT().UseRecoveryCode(gomock.Eq(ctx), gomock.Eq(userID), gomock.Any()).Return(true, nil)
+				twoFactorRepositoryMock.EXPECT().ResetCodeFailures(gomock.Eq(ctx), gomock.Eq(userID)).Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s, err := NewTwoFactor(transactorMock, twoFactorRepositoryMock, nil, "Banking", "1000", time.Minute, 5, 3, time.Minute*15)
+			assert.NoError(t, err)
+
+			err = s.VerifyStepUp(ctx, userID, tt.amount, tt.code)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
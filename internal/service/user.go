@@ -2,47 +2,64 @@ package service
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
-	"math/rand"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/lukinairina90/banking_backend/internal/domain"
-	"github.com/lukinairina90/banking_backend/internal/transport/rest/messages"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
-const userRoleName = "user"
+const (
+	userRoleName    = "user"
+	refreshTokenTTL = time.Hour * 24 * 30
+	// maxUserAgentLength length of the refresh_tokens user_agent column.
+	maxUserAgentLength = 255
+)
 
 // User business logic layer struct.
 type User struct {
+	transactor  Transactor
 	userRepo    UsersRepository
 	sessionRepo SessionRepository
+	revokedRepo RevokedTokenRepository
 	roleRepo    RolesRepository
 	eventRepo   EventRepository
 	hasher      PasswordHasher
+	keys        TokenKeys
+	twoFactor   TwoFactorChallenger
+	verifier    EmailVerifier
+	limiter     SignInLimiter
+	access      UserAccessCache
 
-	hmacSecret []byte
-	tokenTtl   time.Duration
+	tokenTtl time.Duration
 }
 
 // NewUsers constructor for transaction.
-func NewUsers(repo UsersRepository, sessionRepo SessionRepository, roleRepo RolesRepository, eventRepo EventRepository, hasher PasswordHasher, hmacSecret []byte, tokenTtl time.Duration) *User {
+func NewUsers(transactor Transactor, repo UsersRepository, sessionRepo SessionRepository, revokedRepo RevokedTokenRepository, roleRepo RolesRepository, eventRepo EventRepository, hasher PasswordHasher, keys TokenKeys, twoFactor TwoFactorChallenger, verifier EmailVerifier, limiter SignInLimiter, access UserAccessCache, tokenTtl time.Duration) *User {
 	return &User{
+		transactor:  transactor,
 		userRepo:    repo,
 		sessionRepo: sessionRepo,
+		revokedRepo: revokedRepo,
 		roleRepo:    roleRepo,
 		eventRepo:   eventRepo,
 		hasher:      hasher,
-		hmacSecret:  hmacSecret,
+		keys:        keys,
+		twoFactor:   twoFactor,
+		verifier:    verifier,
+		limiter:     limiter,
+		access:      access,
 		tokenTtl:    tokenTtl,
 	}
 }
 
-// SignUp checks if the user already exists, hashes the password, sets the user's role, and creates the user.
+// SignUp checks if the user already exists, hashes the password, creates the user and assigns the user role to them.
+// The new user stays unverified until the email address is confirmed by the link sent to it.
 func (s *User) SignUp(ctx context.Context, inp domain.SignUpInput) error {
 	exists, err := s.userRepo.Exists(ctx, inp.Email)
 	if err != nil {
@@ -50,7 +67,7 @@ func (s *User) SignUp(ctx context.Context, inp domain.SignUpInput) error {
 	}
 
 	if exists {
-		return errors.New("user already exists error")
+		return errors.Wrap(domain.ErrUserAlreadyExists, "user already exists error")
 	}
 
 	password, err := s.hasher.Hash(inp.Password)
@@ -58,44 +75,134 @@ func (s *User) SignUp(ctx context.Context, inp domain.SignUpInput) error {
 		return errors.Wrap(err, "password hash error")
 	}
 
-	role, err := s.roleRepo.GetByName(ctx, userRoleName)
-	if err != nil {
-		return errors.Wrap(err, "role getting error")
-	}
-
 	user := domain.User{
 		Name:     inp.Name,
 		Surname:  inp.Surname,
 		Email:    inp.Email,
 		Password: password,
-		RoleId:   role.ID,
 		Blocked:  false,
 	}
 
-	err = s.userRepo.Create(ctx, user)
+	var userID int
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		role, err := s.roleRepo.GetByName(ctx, userRoleName)
+		if err != nil {
+			return errors.Wrap(err, "role getting error")
+		}
+
+		userID, err = s.userRepo.Create(ctx, user)
+		if err != nil {
+			return errors.Wrap(err, "user creation error")
+		}
+
+		if err := s.roleRepo.AssignRole(ctx, userID, role.ID); err != nil {
+			return errors.Wrap(err, "role assigning error")
+		}
+
+		return nil
+	})
 	if err != nil {
-		return errors.Wrap(err, "user creation error")
+		return errors.Wrap(err, "signing up error")
+	}
+
+	if err := s.verifier.RequestEmailVerification(ctx, userID); err != nil {
+		// the user is already created and can request the verification email again
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"layer":   "service",
+			"service": "User",
+			"method":  "SignUp",
+			"user_id": userID,
+		}).Error("sending email verification error")
 	}
 
 	return nil
 }
 
-// SignIn hashes the password, finds the user by the provided email and password, generates a token.
-func (s *User) SignIn(ctx context.Context, inp domain.SignInInput) (string, string, error) {
-	password, err := s.hasher.Hash(inp.Password)
+// SignIn finds the user by the provided email, verifies the password and generates a token starting a new session for the device.
+// Passwords hashed by the legacy algorithm or with outdated cost parameters are rehashed after successful verification.
+// When the user has two-factor authentication enabled only a challenge token is returned, the sign in is completed by SignInTwoFactor.
+// Failed attempts are counted per email and client IP address, too many of them delay and then lock the sign in.
+func (s *User) SignIn(ctx context.Context, inp domain.SignInInput, device domain.Device) (domain.SignInResult, error) {
+	if err := s.limiter.Check(ctx, inp.Email, device.IP); err != nil {
+		return domain.SignInResult{}, errors.Wrap(err, "checking sign in attempts error")
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, inp.Email)
 	if err != nil {
-		return "", "", errors.Wrap(err, "password hash error")
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return domain.SignInResult{}, s.signInFailed(ctx, inp.Email, device.IP, 0)
+		}
+
+		return domain.SignInResult{}, errors.Wrap(err, "getting user by email error")
 	}
 
-	user, err := s.userRepo.GetByCredentials(ctx, inp.Email, password)
+	ok, needsRehash, err := s.hasher.Verify(inp.Password, user.Password)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return "", "", errors.Wrap(messages.ErrUserNotFound, "getting user by credential error")
+		return domain.SignInResult{}, errors.Wrap(err, "password verification error")
+	}
+
+	if !ok {
+		return domain.SignInResult{}, s.signInFailed(ctx, inp.Email, device.IP, user.ID)
+	}
+
+	if err := s.limiter.Reset(ctx, inp.Email); err != nil {
+		return domain.SignInResult{}, errors.Wrap(err, "resetting sign in attempts error")
+	}
+
+	if needsRehash {
+		s.rehashPassword(ctx, user.ID, inp.Password)
+	}
+
+	if user.TwoFactorEnabled {
+		challengeToken, err := s.twoFactor.CreateChallenge(ctx, user.ID)
+		if err != nil {
+			return domain.SignInResult{}, errors.Wrap(err, "creating two-factor challenge error")
 		}
-		return "", "", errors.Wrap(err, "getting user by credential error")
+
+		return domain.SignInResult{ChallengeToken: challengeToken}, nil
 	}
 
-	accessToken, refreshToken, err := s.generateTokens(ctx, user)
+	accessToken, refreshToken, err := s.startSession(ctx, user, device)
+	if err != nil {
+		return domain.SignInResult{}, err
+	}
+
+	return domain.SignInResult{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// signInFailed registers the failed sign in attempt and returns the error of the attempt.
+// Unknown emails and wrong passwords return the same error, the lockout error is returned when the attempt locked the sign in.
+func (s *User) signInFailed(ctx context.Context, email, ip string, userID int) error {
+	if err := s.limiter.RegisterFailure(ctx, email, ip, userID); err != nil {
+		return errors.Wrap(err, "registering failed sign in attempt error")
+	}
+
+	return errors.Wrap(domain.ErrUserNotFound, "password verification error")
+}
+
+// SignInTwoFactor completes the sign in by verifying the TOTP or recovery code against the challenge returned by SignIn.
+func (s *User) SignInTwoFactor(ctx context.Context, challengeToken, code string, device domain.Device) (string, string, error) {
+	userID, err := s.twoFactor.CompleteChallenge(ctx, challengeToken, code)
+	if err != nil {
+		return "", "", errors.Wrap(err, "completing two-factor challenge error")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "", "", errors.Wrap(err, "getting user by ID error")
+	}
+
+	return s.startSession(ctx, user, device)
+}
+
+// startSession generates the tokens of a new session family of the device.
+func (s *User) startSession(ctx context.Context, user domain.User, device domain.Device) (string, string, error) {
+	familyID, err := newRandomToken(16)
+	if err != nil {
+		return "", "", errors.Wrap(err, "creating session family error")
+	}
+
+	accessToken, refreshToken, err := s.generateTokens(ctx, user, familyID, device)
 	if err != nil {
 		return "", "", errors.Wrap(err, "generating tokens error")
 	}
@@ -103,97 +210,270 @@ func (s *User) SignIn(ctx context.Context, inp domain.SignInInput) (string, stri
 	return accessToken, refreshToken, nil
 }
 
-// ParseToken parses the token and returns the user id and role id.
-func (s *User) ParseToken(_ context.Context, token string) (int, int, error) {
-	t, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.Wrapf(nil, "unexpecting signing method %v", token.Header["alg"])
+// rehashPassword stores the password hashed with the current algorithm and parameters.
+// Failures are only logged, the user keeps signing in with the old hash.
+func (s *User) rehashPassword(ctx context.Context, userID int, password string) {
+	fields := logrus.Fields{
+		"layer":   "service",
+		"service": "User",
+		"method":  "rehashPassword",
+		"user_id": userID,
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		logrus.WithError(err).WithFields(fields).Error("password hash error")
+		return
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, userID, hash); err != nil {
+		logrus.WithError(err).WithFields(fields).Error("updating password hash error")
+	}
+}
+
+// accessClaims claims of the access token.
+type accessClaims struct {
+	jwt.RegisteredClaims
+	UserID        int  `json:"uid"`
+	Version       int  `json:"ver"`
+	EmailVerified bool `json:"evf"`
+}
+
+// ParseToken parses and verifies the access token and returns its claims.
+// The signature is verified with the key named by the kid header, the token is rejected when its ID is revoked
+// or its version is behind the token version of the user. Both are read through the access cache.
+func (s *User) ParseToken(ctx context.Context, token string) (domain.AccessClaims, error) {
+	var claims accessClaims
+	t, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("token key ID is missing")
+		}
+
+		key, err := s.keys.VerificationKey(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+
+		if token.Method.Alg() != key.Algorithm {
+			return nil, errors.Errorf("unexpecting signing method %v", token.Header["alg"])
 		}
-		return s.hmacSecret, nil
+
+		return key.PublicKey(), nil
 	})
+	if err != nil {
+		return domain.AccessClaims{}, errors.Wrap(err, "jwt parsing error")
+	}
+
+	if !t.Valid || claims.ID == "" || claims.ExpiresAt == nil {
+		return domain.AccessClaims{}, errors.New("invalid token error")
+	}
 
+	revoked, err := s.access.IsRevoked(ctx, claims.ID)
 	if err != nil {
-		return 0, 0, errors.Wrap(err, "jwt parsing error")
+		return domain.AccessClaims{}, errors.Wrap(err, "checking token revocation error")
 	}
 
-	if !t.Valid {
-		return 0, 0, errors.Wrap(nil, "invalid token error")
+	if revoked {
+		return domain.AccessClaims{}, errors.Wrap(domain.ErrAccessTokenRevoked, "checking token revocation error")
 	}
 
-	claims, ok := t.Claims.(jwt.MapClaims)
-	if !ok {
-		return 0, 0, errors.Wrap(nil, "invalid claims error")
+	version, err := s.access.GetTokenVersion(ctx, claims.UserID)
+	if err != nil {
+		return domain.AccessClaims{}, errors.Wrap(err, "getting token version error")
 	}
 
-	subject, ok := claims["sub"].(string)
-	if !ok {
-		return 0, 0, errors.Wrap(nil, "invalid subject error")
+	if claims.Version > version {
+		// the token was issued after the cached version was bumped, e.g. by an instance sharing no cache backend
+		s.access.InvalidateTokenVersion(ctx, claims.UserID)
+
+		if version, err = s.access.GetTokenVersion(ctx, claims.UserID); err != nil {
+			return domain.AccessClaims{}, errors.Wrap(err, "getting token version error")
+		}
 	}
 
-	subjectParts := strings.Split(subject, ":")
-	if len(subjectParts) != 2 {
-		return 0, 0, errors.Wrap(nil, "token subject content error")
+	if claims.Version != version {
+		return domain.AccessClaims{}, errors.Wrap(domain.ErrAccessTokenRevoked, "checking token version error")
 	}
 
-	userID, err := strconv.Atoi(subjectParts[0])
+	return domain.AccessClaims{
+		ID:            claims.ID,
+		UserID:        claims.UserID,
+		Version:       claims.Version,
+		EmailVerified: claims.EmailVerified,
+		ExpiresAt:     claims.ExpiresAt.Time,
+	}, nil
+}
+
+// RefreshTokens rotates the refresh token: the provided token is marked as used and a new one is issued within the same session family.
+// Presenting an already used token revokes the whole family, since either the client or an attacker holds a stolen copy.
+func (s *User) RefreshTokens(ctx context.Context, refreshToken string, device domain.Device) (string, string, error) {
+	var (
+		accessToken, newRefreshToken string
+		reused                       bool
+	)
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		session, err := s.sessionRepo.Lock(ctx, hashToken(refreshToken))
+		if err != nil {
+			return errors.Wrap(err, "getting refresh session error")
+		}
+
+		if session.Revoked {
+			return errors.Wrap(domain.ErrInvalidRefreshToken, "checking refresh session is not revoked error")
+		}
+
+		if session.Rotated {
+			// the family is revoked in the committed transaction, the error is returned afterwards
+			reused = true
+			logrus.WithFields(logrus.Fields{
+				"layer":     "service",
+				"service":   "User",
+				"method":    "RefreshTokens",
+				"user_id":   session.UserID,
+				"family_id": session.FamilyID,
+			}).Warn("refresh token reuse detected, revoking session family")
+
+			if err := s.sessionRepo.RevokeFamily(ctx, session.FamilyID); err != nil {
+				return errors.Wrap(err, "revoking session family error")
+			}
+
+			return nil
+		}
+
+		if session.ExpiresAt.Before(time.Now()) {
+			return errors.Wrap(domain.ErrRefreshTokenExpired, "checking refresh token expiration error")
+		}
+
+		if err := s.sessionRepo.MarkRotated(ctx, session.ID); err != nil {
+			return errors.Wrap(err, "marking refresh token rotated error")
+		}
+
+		user, err := s.userRepo.GetByID(ctx, session.UserID)
+		if err != nil {
+			return errors.Wrap(err, "getting user by id error")
+		}
+
+		accessToken, newRefreshToken, err = s.generateTokens(ctx, user, session.FamilyID, device)
+		if err != nil {
+			return errors.Wrap(err, "generating tokens error")
+		}
+
+		return nil
+	})
 	if err != nil {
-		return 0, 0, errors.Wrap(err, "invalid userID in from token subject")
+		return "", "", errors.Wrap(err, "refreshing tokens error")
 	}
 
-	roleID, err := strconv.Atoi(subjectParts[1])
-	if err != nil {
-		return 0, 0, errors.Wrap(err, "invalid userID in from token subject error")
+	if reused {
+		return "", "", errors.Wrap(domain.ErrRefreshTokenReused, "refreshing tokens error")
 	}
 
-	return userID, roleID, nil
+	return accessToken, newRefreshToken, nil
 }
 
-// RefreshTokens takes a token from the session, checks it for expiration, takes the user by ID and passes it to token generation, returns accessToken and refreshToken.
-func (s *User) RefreshTokens(ctx context.Context, refreshToken string) (string, string, error) {
-	session, err := s.sessionRepo.Get(ctx, refreshToken)
+// Logout revokes the session family of the provided refresh token.
+// The access token of the session, when provided, is added to the revocation list.
+func (s *User) Logout(ctx context.Context, refreshToken, accessToken string) error {
+	session, err := s.sessionRepo.Get(ctx, hashToken(refreshToken))
 	if err != nil {
-		return "", "", errors.Wrap(err, "getting refresh session error")
+		return errors.Wrap(err, "getting refresh session error")
 	}
 
-	if session.ExpiresAt.Unix() < time.Now().Unix() {
-		return "", "", errors.Wrap(err, "refresh token expired error")
+	if err := s.sessionRepo.RevokeFamily(ctx, session.FamilyID); err != nil {
+		return errors.Wrap(err, "revoking session family error")
 	}
 
-	user, err := s.userRepo.GetByID(ctx, session.UserID)
-	if err != nil {
-		return "", "", errors.Wrap(err, "getting user by id error")
+	if accessToken == "" {
+		return nil
 	}
 
-	accessToken, refreshToken, err := s.generateTokens(ctx, user)
+	claims, err := s.ParseToken(ctx, accessToken)
 	if err != nil {
-		return "", "", errors.Wrap(err, "generating tokens error")
+		// the access token is already unusable
+		return nil
 	}
 
-	return accessToken, refreshToken, nil
+	if claims.UserID != session.UserID {
+		return nil
+	}
+
+	if err := s.revokedRepo.Revoke(ctx, claims.ID, claims.UserID, claims.ExpiresAt); err != nil {
+		return errors.Wrap(err, "revoking access token error")
+	}
+
+	s.access.InvalidateRevokedToken(ctx, claims.ID)
+
+	return nil
 }
 
-// generateTokens generates and returns accessToken, refreshToken.
-func (s *User) generateTokens(ctx context.Context, user domain.User) (string, string, error) {
-	t := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Subject:   fmt.Sprintf("%d:%d", user.ID, user.RoleId),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.tokenTtl)),
+// LogoutAll revokes all sessions of the user and invalidates all issued access tokens by bumping the token version.
+func (s *User) LogoutAll(ctx context.Context, userID int) error {
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.sessionRepo.RevokeAll(ctx, userID); err != nil {
+			return errors.Wrap(err, "revoking user sessions error")
+		}
+
+		if err := s.userRepo.IncrementTokenVersion(ctx, userID); err != nil {
+			return errors.Wrap(err, "incrementing token version error")
+		}
+
+		return nil
 	})
+	if err != nil {
+		return errors.Wrap(err, "logout from all sessions error")
+	}
+
+	s.access.InvalidateTokenVersion(ctx, userID)
+
+	return nil
+}
+
+// GetSessions returns the active sessions of the user, the session of the provided refresh token is marked as current.
+func (s *User) GetSessions(ctx context.Context, userID int, refreshToken string) ([]domain.Session, error) {
+	sessions, err := s.sessionRepo.GetActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting active sessions error")
+	}
 
-	accessToken, err := t.SignedString(s.hmacSecret)
+	if refreshToken == "" {
+		return sessions, nil
+	}
+
+	current, err := s.sessionRepo.Get(ctx, hashToken(refreshToken))
 	if err != nil {
-		return "", "", errors.Wrap(err, "creating and returning a complete, signed JWT token error")
+		if errors.Is(err, domain.ErrInvalidRefreshToken) {
+			return sessions, nil
+		}
+
+		return nil, errors.Wrap(err, "getting current refresh session error")
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].FamilyID == current.FamilyID
 	}
 
-	refreshToken, err := newRefreshToken()
+	return sessions, nil
+}
+
+// generateTokens generates and returns accessToken, refreshToken. The refresh token continues the provided session family.
+func (s *User) generateTokens(ctx context.Context, user domain.User, familyID string, device domain.Device) (string, string, error) {
+	accessToken, err := s.newAccessToken(user)
+	if err != nil {
+		return "", "", errors.Wrap(err, "creating access token error")
+	}
+
+	refreshToken, err := newRandomToken(32)
 	if err != nil {
 		return "", "", errors.Wrap(err, "creating new refresh token error")
 	}
 
 	if err := s.sessionRepo.Create(ctx, domain.RefreshSession{
 		UserID:    user.ID,
-		Token:     refreshToken,
-		ExpiresAt: time.Now().Add(time.Hour * 24 * 30),
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		UserAgent: truncate(device.UserAgent, maxUserAgentLength),
+		IP:        device.IP,
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
 	}); err != nil {
 		return "", "", errors.Wrap(err, "creating refresh session error")
 	}
@@ -201,10 +481,46 @@ func (s *User) generateTokens(ctx context.Context, user domain.User) (string, st
 	return accessToken, refreshToken, nil
 }
 
+// newAccessToken creates the access token of the user with a unique ID and the current token version,
+// signed by the newest signing key.
+func (s *User) newAccessToken(user domain.User) (string, error) {
+	key, err := s.keys.SigningKey()
+	if err != nil {
+		return "", errors.Wrap(err, "getting signing key error")
+	}
+
+	jti, err := newRandomToken(16)
+	if err != nil {
+		return "", errors.Wrap(err, "creating token ID error")
+	}
+
+	now := time.Now()
+	t := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), accessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.tokenTtl)),
+		},
+		UserID:        user.ID,
+		Version:       user.TokenVersion,
+		EmailVerified: user.EmailVerified,
+	})
+
+	t.Header["kid"] = key.ID
+
+	accessToken, err := t.SignedString(key.PrivateKey)
+	if err != nil {
+		return "", errors.Wrap(err, "creating and returning a complete, signed JWT token error")
+	}
+
+	return accessToken, nil
+}
+
 // BlockUser checks if the user is blocking himself, blocks user according to the provided user id and creates an event.
 func (s *User) BlockUser(ctx context.Context, blockUserID, userID int) error {
 	if blockUserID == userID {
-		return errors.New("user cannot block himself error")
+		return errors.Wrap(domain.ErrCannotBlockSelf, "user cannot block himself error")
 	}
 
 	err := s.userRepo.BlockUser(ctx, blockUserID)
@@ -212,6 +528,8 @@ func (s *User) BlockUser(ctx context.Context, blockUserID, userID int) error {
 		return errors.Wrap(err, "block user error")
 	}
 
+	s.access.InvalidateBlockStatus(ctx, blockUserID)
+
 	event := domain.Event{
 		UserID:  blockUserID,
 		Type:    domain.UserBlockedEvent,
@@ -232,6 +550,8 @@ func (s *User) UnblockUser(ctx context.Context, userID int) error {
 		return errors.Wrap(err, "unblock user error")
 	}
 
+	s.access.InvalidateBlockStatus(ctx, userID)
+
 	event := domain.Event{
 		UserID:  userID,
 		Type:    domain.UserUnblockedEvent,
@@ -245,9 +565,23 @@ func (s *User) UnblockUser(ctx context.Context, userID int) error {
 	return nil
 }
 
-// CheckBlockUser checks if the user is blocked out.
+// ClearSignInLockout clears the lockout and the failed sign in attempts of the user.
+func (s *User) ClearSignInLockout(ctx context.Context, userID int) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return errors.Wrap(err, "getting user by ID error")
+	}
+
+	if err := s.limiter.Reset(ctx, user.Email); err != nil {
+		return errors.Wrap(err, "resetting sign in attempts error")
+	}
+
+	return nil
+}
+
+// CheckBlockUser checks if the user is blocked out, the block status is cached.
 func (s *User) CheckBlockUser(ctx context.Context, userID int) (bool, error) {
-	checkBlock, err := s.userRepo.CheckBlockUser(ctx, userID)
+	checkBlock, err := s.access.CheckBlockUser(ctx, userID)
 	if err != nil {
 		return false, errors.Wrap(err, "check block user error")
 	}
@@ -255,16 +589,20 @@ func (s *User) CheckBlockUser(ctx context.Context, userID int) (bool, error) {
 	return checkBlock, nil
 }
 
-// newRefreshToken creates a token from random bytes, converts it to a string, and returns.
-func newRefreshToken() (string, error) {
-	b := make([]byte, 32)
+// newRandomToken creates a hex encoded token from the provided number of cryptographically random bytes.
+func newRandomToken(size int) (string, error) {
+	b := make([]byte, size)
 
-	s := rand.NewSource(time.Now().Unix())
-	r := rand.New(s)
-
-	if _, err := r.Read(b); err != nil {
+	if _, err := rand.Read(b); err != nil {
 		return "", errors.Wrap(err, "read generates random bytes error")
 	}
 
-	return fmt.Sprintf("%x", b), nil
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken returns the hash random tokens such as refresh tokens and recovery codes are stored by.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/pkg/errors"
+)
+
+const (
+	// detailsAccountsLimit max number of accounts in the user details.
+	detailsAccountsLimit = 100
+	// detailsEventsLimit number of the latest events in the user details.
+	detailsEventsLimit = 20
+)
+
+// UserDirectory business logic layer struct of the admin users directory.
+type UserDirectory struct {
+	userRepo    UsersRepository
+	accountRepo AccountRepository
+	cardRepo    CardRepository
+	eventRepo   EventRepository
+}
+
+// NewUserDirectory constructor for UserDirectory.
+func NewUserDirectory(userRepo UsersRepository, accountRepo AccountRepository, cardRepo CardRepository, eventRepo EventRepository) *UserDirectory {
+	return &UserDirectory{
+		userRepo:    userRepo,
+		accountRepo: accountRepo,
+		cardRepo:    cardRepo,
+		eventRepo:   eventRepo,
+	}
+}
+
+// GetUsersList returns the page of users matching the filter.
+func (s *UserDirectory) GetUsersList(ctx context.Context, filter domain.UserFilter, paginator domain.Paginator, ordering domain.Orderings) ([]domain.User, error) {
+	users, err := s.userRepo.GetUsersList(ctx, filter, paginator, ordering)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting users list error")
+	}
+
+	return users, nil
+}
+
+// GetUserDetails returns the user with their accounts, cards and latest events.
+func (s *UserDirectory) GetUserDetails(ctx context.Context, userID int) (domain.UserDetails, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return domain.UserDetails{}, errors.Wrap(err, "getting user by ID error")
+	}
+
+	accounts, err := s.accountRepo.GetAccountsList(ctx, userID, domain.Paginator{Page: 1, PerPage: detailsAccountsLimit}, nil)
+	if err != nil {
+		return domain.UserDetails{}, errors.Wrap(err, "getting accounts list error")
+	}
+
+	cards, err := s.cardRepo.GetCardListUser(ctx, userID)
+	if err != nil {
+		return domain.UserDetails{}, errors.Wrap(err, "getting cards list error")
+	}
+
+	events, err := s.eventRepo.GetRecentEvents(ctx, userID, detailsEventsLimit)
+	if err != nil {
+		return domain.UserDetails{}, errors.Wrap(err, "getting recent events error")
+	}
+
+	return domain.UserDetails{
+		User:     user,
+		Accounts: accounts,
+		Cards:    cards,
+		Events:   events,
+	}, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserDirectory_GetUserDetails(t *testing.T) {
+	controller := gomock.NewController(t)
+	userRepositoryMock := NewMockUsersRepository(controller)
+	accountRepositoryMock := NewMockAccountRepository(controller)
+	cardRepositoryMock := NewMockCardRepository(controller)
+	eventRepositoryMock := NewMockEventRepository(controller)
+
+	ctx := context.Background()
+	user := domain.User{ID: 1, Name: "Bob", Surname: "Marly", Email: "bob@example.com"}
+	accounts := []domain.Account{{ID: 10, UserID: user.ID}}
+	cards := []domain.Card{{Id: 100, AccountID: 10}}
+	events := []domain.Event{{ID: 1000, UserID: user.ID, Type: domain.AccountCreatedEvent}}
+	paginator := domain.Paginator{Page: 1, PerPage: detailsAccountsLimit}
+	testError := errors.New("test error")
+
+	tests := []struct {
+		name          string
+		configureMock func()
+		want          domain.UserDetails
+		wantErr       error
+	}{
+		{
+			name: "user_not_found_error",
+			configureMock: func() {
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(domain.User{}, domain.ErrUserNotFound)
+			},
+			wantErr: domain.ErrUserNotFound,
+		},
+		{
+			name: "account_repository_error",
+			configureMock: func() {
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(user, nil)
+				accountRepositoryMock.EXPECT().GetAccountsList(gomock.Eq(ctx), gomock.Eq(user.ID), gomock.Eq(paginator), gomock.Nil()).Return(nil, testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "event_repository_error",
+			configureMock: func() {
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(user, nil)
+				accountRepositoryMock.EXPECT().GetAccountsList(gomock.Eq(ctx), gomock.Eq(user.ID), gomock.Eq(paginator), gomock.Nil()).Return(accounts, nil)
+				cardRepositoryMock.EXPECT().GetCardListUser(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(cards, nil)
+				eventRepositoryMock.EXPECT().GetRecentEvents(gomock.Eq(ctx), gomock.Eq(user.ID), gomock.Eq(detailsEventsLimit)).Return(nil, testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "success",
+			configureMock: func() {
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(user, nil)
+				accountRepositoryMock.EXPECT().GetAccountsList(gomock.Eq(ctx), gomock.Eq(user.ID), gomock.Eq(paginator), gomock.Nil()).Return(accounts, nil)
+				cardRepositoryMock.EXPECT().GetCardListUser(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(cards, nil)
+				eventRepositoryMock.EXPECT().GetRecentEvents(gomock.Eq(ctx), gomock.Eq(user.ID), gomock.Eq(detailsEventsLimit)).Return(events, nil)
+			},
+			want: domain.UserDetails{User: user, Accounts: accounts, Cards: cards, Events: events},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s := NewUserDirectory(userRepositoryMock, accountRepositoryMock, cardRepositoryMock, eventRepositoryMock)
+
+			got, err := s.GetUserDetails(ctx, user.ID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
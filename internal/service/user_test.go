@@ -13,13 +13,15 @@ import (
 
 func TestUser_SignUp(t *testing.T) {
 	controller := gomock.NewController(t)
+	transactorMock := NewMockTransactor(controller)
 	userRepositoryMock := NewMockUsersRepository(controller)
 	sessionRepositoryMock := NewMockSessionRepository(controller)
 	roleRepositoryMock := NewMockRolesRepository(controller)
 	eventRepositoryMock := NewMockEventRepository(controller)
 	passwordHasher := NewMockPasswordHasher(controller)
+	emailVerifierMock := NewMockEmailVerifier(controller)
 
-	hmacSecret := []byte("secret")
+	keys := newTestKeys(t, nil, "kid")
 	tokenTtl := time.Duration(12) * time.Hour
 
 	const userRoleName = "user"
@@ -40,18 +42,23 @@ func TestUser_SignUp(t *testing.T) {
 		Surname:  inp.Surname,
 		Email:    inp.Email,
 		Password: password,
-		RoleId:   role.ID,
 		Blocked:  false,
 	}
+	userID := 1
 	testError := errors.New("test error")
 
+	withinTransaction := func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}
+
 	type fields struct {
 		userRepo    UsersRepository
 		sessionRepo SessionRepository
 		roleRepo    RolesRepository
 		eventRepo   EventRepository
 		hasher      PasswordHasher
-		hmacSecret  []byte
+		keys        *Keys
+		verifier    EmailVerifier
 		tokenTtl    time.Duration
 	}
 	type args struct {
@@ -73,7 +80,8 @@ func TestUser_SignUp(t *testing.T) {
 				roleRepo:    roleRepositoryMock,
 				eventRepo:   eventRepositoryMock,
 				hasher:      passwordHasher,
-				hmacSecret:  hmacSecret,
+				keys:        keys,
+				verifier:    emailVerifierMock,
 				tokenTtl:    tokenTtl,
 			},
 			args: args{
@@ -93,7 +101,8 @@ func TestUser_SignUp(t *testing.T) {
 				roleRepo:    roleRepositoryMock,
 				eventRepo:   eventRepositoryMock,
 				hasher:      passwordHasher,
-				hmacSecret:  hmacSecret,
+				keys:        keys,
+				verifier:    emailVerifierMock,
 				tokenTtl:    tokenTtl,
 			},
 			args: args{
@@ -113,7 +122,8 @@ func TestUser_SignUp(t *testing.T) {
 				roleRepo:    roleRepositoryMock,
 				eventRepo:   eventRepositoryMock,
 				hasher:      passwordHasher,
-				hmacSecret:  hmacSecret,
+				keys:        keys,
+				verifier:    emailVerifierMock,
 				tokenTtl:    tokenTtl,
 			},
 			args: args{
@@ -134,7 +144,8 @@ func TestUser_SignUp(t *testing.T) {
 				roleRepo:    roleRepositoryMock,
 				eventRepo:   eventRepositoryMock,
 				hasher:      passwordHasher,
-				hmacSecret:  hmacSecret,
+				keys:        keys,
+				verifier:    emailVerifierMock,
 				tokenTtl:    tokenTtl,
 			},
 			args: args{
@@ -144,6 +155,7 @@ func TestUser_SignUp(t *testing.T) {
 			configureMock: func() {
 				userRepositoryMock.EXPECT().Exists(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(false, nil)
 				passwordHasher.EXPECT().Hash(gomock.Eq(inp.Password)).Return(password, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
 				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq(userRoleName)).Return(domain.Role{}, testError)
 			},
 			wantErr: true,
@@ -156,7 +168,33 @@ func TestUser_SignUp(t *testing.T) {
 				roleRepo:    roleRepositoryMock,
 				eventRepo:   eventRepositoryMock,
 				hasher:      passwordHasher,
-				hmacSecret:  hmacSecret,
+				keys:        keys,
+				verifier:    emailVerifierMock,
+				tokenTtl:    tokenTtl,
+			},
+			args: args{
+				ctx: ctx,
+				inp: inp,
+			},
+			configureMock: func() {
+				userRepositoryMock.EXPECT().Exists(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(false, nil)
+				passwordHasher.EXPECT().Hash(gomock.Eq(inp.Password)).Return(password, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq(userRoleName)).Return(role, nil)
+				userRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Eq(user)).Return(0, testError)
+			},
+			wantErr: true,
+		},
+		{
+			name: "role_assigning_error",
+			fields: fields{
+				userRepo:    userRepositoryMock,
+				sessionRepo: sessionRepositoryMock,
+				roleRepo:    roleRepositoryMock,
+				eventRepo:   eventRepositoryMock,
+				hasher:      passwordHasher,
+				keys:        keys,
+				verifier:    emailVerifierMock,
 				tokenTtl:    tokenTtl,
 			},
 			args: args{
@@ -166,11 +204,40 @@ func TestUser_SignUp(t *testing.T) {
 			configureMock: func() {
 				userRepositoryMock.EXPECT().Exists(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(false, nil)
 				passwordHasher.EXPECT().Hash(gomock.Eq(inp.Password)).Return(password, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
 				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq(userRoleName)).Return(role, nil)
-				userRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Eq(user)).Return(testError)
+				userRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Eq(user)).Return(userID, nil)
+				roleRepositoryMock.EXPECT().AssignRole(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(role.ID)).Return(testError)
 			},
 			wantErr: true,
 		},
+		{
+			name: "email_verification_error_is_ignored",
+			fields: fields{
+				userRepo:    userRepositoryMock,
+				sessionRepo: sessionRepositoryMock,
+				roleRepo:    roleRepositoryMock,
+				eventRepo:   eventRepositoryMock,
+				hasher:      passwordHasher,
+				keys:        keys,
+				verifier:    emailVerifierMock,
+				tokenTtl:    tokenTtl,
+			},
+			args: args{
+				ctx: ctx,
+				inp: inp,
+			},
+			configureMock: func() {
+				userRepositoryMock.EXPECT().Exists(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(false, nil)
+				passwordHasher.EXPECT().Hash(gomock.Eq(inp.Password)).Return(password, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq(userRoleName)).Return(role, nil)
+				userRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Eq(user)).Return(userID, nil)
+				roleRepositoryMock.EXPECT().AssignRole(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(role.ID)).Return(nil)
+				emailVerifierMock.EXPECT().RequestEmailVerification(gomock.Eq(ctx), gomock.Eq(userID)).Return(testError)
+			},
+			wantErr: false,
+		},
 		{
 			name: "success",
 			fields: fields{
@@ -179,7 +246,8 @@ func TestUser_SignUp(t *testing.T) {
 				roleRepo:    roleRepositoryMock,
 				eventRepo:   eventRepositoryMock,
 				hasher:      passwordHasher,
-				hmacSecret:  hmacSecret,
+				keys:        keys,
+				verifier:    emailVerifierMock,
 				tokenTtl:    tokenTtl,
 			},
 			args: args{
@@ -189,8 +257,11 @@ func TestUser_SignUp(t *testing.T) {
 			configureMock: func() {
 				userRepositoryMock.EXPECT().Exists(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(false, nil)
 				passwordHasher.EXPECT().Hash(gomock.Eq(inp.Password)).Return(password, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
 				roleRepositoryMock.EXPECT().GetByName(gomock.Eq(ctx), gomock.Eq(userRoleName)).Return(role, nil)
-				userRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Eq(user)).Return(nil)
+				userRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Eq(user)).Return(userID, nil)
+				roleRepositoryMock.EXPECT().AssignRole(gomock.Eq(ctx), gomock.Eq(userID), gomock.Eq(role.ID)).Return(nil)
+				emailVerifierMock.EXPECT().RequestEmailVerification(gomock.Eq(ctx), gomock.Eq(userID)).Return(nil)
 			},
 			wantErr: false,
 		},
@@ -199,7 +270,7 @@ func TestUser_SignUp(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.configureMock()
 
-			u := NewUsers(tt.fields.userRepo, tt.fields.sessionRepo, tt.fields.roleRepo, tt.fields.eventRepo, tt.fields.hasher, tt.fields.hmacSecret, tt.fields.tokenTtl)
+			u := NewUsers(transactorMock, tt.fields.userRepo, tt.fields.sessionRepo, nil, tt.fields.roleRepo, tt.fields.eventRepo, tt.fields.hasher, tt.fields.keys, nil, tt.fields.verifier, nil, nil, tt.fields.tokenTtl)
 			err := u.SignUp(tt.args.ctx, tt.args.inp)
 			assert.Equal(t, tt.wantErr, err != nil)
 		})
@@ -211,8 +282,10 @@ func TestUser_SignIn(t *testing.T) {
 	userRepositoryMock := NewMockUsersRepository(controller)
 	passwordHasher := NewMockPasswordHasher(controller)
 	sessionRepositoryMock := NewMockSessionRepository(controller)
+	twoFactorChallengerMock := NewMockTwoFactorChallenger(controller)
+	signInLimiterMock := NewMockSignInLimiter(controller)
 
-	hmacSecret := []byte("secret")
+	keys := newTestKeys(t, nil, "kid")
 	tokenTtl := time.Duration(12) * time.Hour
 
 	ctx := context.Background()
@@ -220,7 +293,9 @@ func TestUser_SignIn(t *testing.T) {
 		Email:    "example@gmail.com",
 		Password: "marly1234",
 	}
+	device := domain.Device{IP: "10.0.0.1"}
 	password := "73616c74d033e22ae348aeb5660fc2140aec35850c4da997"
+	newPassword := "$argon2id$v=19$m=65536,t=3,p=2$c2FsdHNhbHRzYWx0c2FsdA$aGFzaGhhc2hoYXNoaGFzaGhhc2hoYXNoaGFzaGhhc2g"
 	testError := errors.New("test error")
 
 	user := domain.User{
@@ -229,15 +304,17 @@ func TestUser_SignIn(t *testing.T) {
 		Surname:  "Marly",
 		Email:    inp.Email,
 		Password: password,
-		RoleId:   2,
 		Blocked:  false,
 	}
 
+	twoFactorUser := user
+	twoFactorUser.TwoFactorEnabled = true
+
 	type fields struct {
-		userRepo   UsersRepository
-		hasher     PasswordHasher
-		tokenTtl   time.Duration
-		hmacSecret []byte
+		userRepo UsersRepository
+		hasher   PasswordHasher
+		tokenTtl time.Duration
+		keys     *Keys
 	}
 	type args struct {
 		ctx context.Context
@@ -250,42 +327,123 @@ func TestUser_SignIn(t *testing.T) {
 		configureMock func()
 		want          bool
 		want1         bool
+		wantChallenge bool
 		wantErr       bool
 	}{
 		{
-			name: "password_hasher_error",
+			name: "user_repository_error",
 			fields: fields{
-				userRepo:   userRepositoryMock,
-				hasher:     passwordHasher,
-				hmacSecret: hmacSecret,
-				tokenTtl:   tokenTtl,
+				userRepo: userRepositoryMock,
+				hasher:   passwordHasher,
+				keys:     keys,
+				tokenTtl: tokenTtl,
 			},
 			args: args{
 				ctx: ctx,
 				inp: inp,
 			},
 			configureMock: func() {
-				passwordHasher.EXPECT().Hash(inp.Password).Return("", testError)
+				signInLimiterMock.EXPECT().Check(gomock.Eq(ctx), gomock.Eq(inp.Email), gomock.Eq(device.IP)).Return(nil)
+				userRepositoryMock.EXPECT().GetByEmail(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(domain.User{}, testError)
 			},
 			want:    false,
 			want1:   false,
 			wantErr: true,
 		},
 		{
-			name: "user_repository_error",
+			name: "sign_in_locked_error",
+			fields: fields{
+				userRepo: userRepositoryMock,
+				hasher:   passwordHasher,
+				keys:     keys,
+				tokenTtl: tokenTtl,
+			},
+			args: args{
+				ctx: ctx,
+				inp: inp,
+			},
+			configureMock: func() {
+				signInLimiterMock.EXPECT().Check(gomock.Eq(ctx), gomock.Eq(inp.Email), gomock.Eq(device.IP)).Return(domain.NewRetryError(domain.ErrSignInLocked, time.Now().Add(time.Minute)))
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown_email",
+			fields: fields{
+				userRepo: userRepositoryMock,
+				hasher:   passwordHasher,
+				keys:     keys,
+				tokenTtl: tokenTtl,
+			},
+			args: args{
+				ctx: ctx,
+				inp: inp,
+			},
+			configureMock: func() {
+				signInLimiterMock.EXPECT().Check(gomock.Eq(ctx), gomock.Eq(inp.Email), gomock.Eq(device.IP)).Return(nil)
+				userRepositoryMock.EXPECT().GetByEmail(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(domain.User{}, domain.ErrUserNotFound)
+				signInLimiterMock.EXPECT().RegisterFailure(gomock.Eq(ctx), gomock.Eq(inp.Email), gomock.Eq(device.IP), gomock.Eq(0)).Return(nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "wrong_password_locks_sign_in",
 			fields: fields{
-				userRepo:   userRepositoryMock,
-				hasher:     passwordHasher,
-				hmacSecret: hmacSecret,
-				tokenTtl:   tokenTtl,
+				userRepo: userRepositoryMock,
+				hasher:   passwordHasher,
+				keys:     keys,
+				tokenTtl: tokenTtl,
 			},
 			args: args{
 				ctx: ctx,
 				inp: inp,
 			},
 			configureMock: func() {
-				passwordHasher.EXPECT().Hash(inp.Password).Return(password, nil)
-				userRepositoryMock.EXPECT().GetByCredentials(gomock.Eq(ctx), gomock.Eq(inp.Email), gomock.Eq(password)).Return(domain.User{}, testError)
+				signInLimiterMock.EXPECT().Check(gomock.Eq(ctx), gomock.Eq(inp.Email), gomock.Eq(device.IP)).Return(nil)
+				userRepositoryMock.EXPECT().GetByEmail(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(user, nil)
+				passwordHasher.EXPECT().Verify(gomock.Eq(inp.Password), gomock.Eq(password)).Return(false, false, nil)
+				signInLimiterMock.EXPECT().RegisterFailure(gomock.Eq(ctx), gomock.Eq(inp.Email), gomock.Eq(device.IP), gomock.Eq(user.ID)).Return(domain.NewRetryError(domain.ErrSignInLocked, time.Now().Add(time.Minute)))
+			},
+			wantErr: true,
+		},
+		{
+			name: "password_verification_error",
+			fields: fields{
+				userRepo: userRepositoryMock,
+				hasher:   passwordHasher,
+				keys:     keys,
+				tokenTtl: tokenTtl,
+			},
+			args: args{
+				ctx: ctx,
+				inp: inp,
+			},
+			configureMock: func() {
+				signInLimiterMock.EXPECT().Check(gomock.Eq(ctx), gomock.Eq(inp.Email), gomock.Eq(device.IP)).Return(nil)
+				userRepositoryMock.EXPECT().GetByEmail(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(user, nil)
+				passwordHasher.EXPECT().Verify(gomock.Eq(inp.Password), gomock.Eq(password)).Return(false, false, testError)
+			},
+			want:    false,
+			want1:   false,
+			wantErr: true,
+		},
+		{
+			name: "wrong_password",
+			fields: fields{
+				userRepo: userRepositoryMock,
+				hasher:   passwordHasher,
+				keys:     keys,
+				tokenTtl: tokenTtl,
+			},
+			args: args{
+				ctx: ctx,
+				inp: inp,
+			},
+			configureMock: func() {
+				signInLimiterMock.EXPECT().Check(gomock.Eq(ctx), gomock.Eq(inp.Email), gomock.Eq(device.IP)).Return(nil)
+				userRepositoryMock.EXPECT().GetByEmail(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(user, nil)
+				passwordHasher.EXPECT().Verify(gomock.Eq(inp.Password), gomock.Eq(password)).Return(false, false, nil)
+				signInLimiterMock.EXPECT().RegisterFailure(gomock.Eq(ctx), gomock.Eq(inp.Email), gomock.Eq(device.IP), gomock.Eq(user.ID)).Return(nil)
 			},
 			want:    false,
 			want1:   false,
@@ -294,39 +452,136 @@ func TestUser_SignIn(t *testing.T) {
 		{
 			name: "session_creation_error",
 			fields: fields{
-				userRepo:   userRepositoryMock,
-				hasher:     passwordHasher,
-				hmacSecret: hmacSecret,
-				tokenTtl:   tokenTtl,
+				userRepo: userRepositoryMock,
+				hasher:   passwordHasher,
+				keys:     keys,
+				tokenTtl: tokenTtl,
 			},
 			args: args{
 				ctx: ctx,
 				inp: inp,
 			},
 			configureMock: func() {
-				passwordHasher.EXPECT().Hash(inp.Password).Return(password, nil)
-				userRepositoryMock.EXPECT().GetByCredentials(gomock.Eq(ctx), gomock.Eq(inp.Email), gomock.Eq(password)).Return(user, nil)
+				signInLimiterMock.EXPECT().Check(gomock.Eq(ctx), gomock.Eq(inp.Email), gomock.Eq(device.IP)).Return(nil)
+				userRepositoryMock.EXPECT().GetByEmail(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(user, nil)
+				passwordHasher.EXPECT().Verify(gomock.Eq(inp.Password), gomock.Eq(password)).Return(true, false, nil)
+				signInLimiterMock.EXPECT().Reset(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(nil)
 				sessionRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Any()).Return(testError)
 			},
 			want:    false,
 			want1:   false,
 			wantErr: true,
 		},
+		{
+			name: "rehash_update_error",
+			fields: fields{
+				userRepo: userRepositoryMock,
+				hasher:   passwordHasher,
+				keys:     keys,
+				tokenTtl: tokenTtl,
+			},
+			args: args{
+				ctx: ctx,
+				inp: inp,
+			},
+			configureMock: func() {
+				signInLimiterMock.EXPECT().Check(gomock.Eq(ctx), gomock.Eq(inp.Email), gomock.Eq(device.IP)).Return(nil)
+				userRepositoryMock.EXPECT().GetByEmail(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(user, nil)
+				passwordHasher.EXPECT().Verify(gomock.Eq(inp.Password), gomock.Eq(password)).Return(true, true, nil)
+				signInLimiterMock.EXPECT().Reset(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(nil)
+				passwordHasher.EXPECT().Hash(gomock.Eq(inp.Password)).Return(newPassword, nil)
+				userRepositoryMock.EXPECT().UpdatePassword(gomock.Eq(ctx), gomock.Eq(user.ID), gomock.Eq(newPassword)).Return(testError)
+				sessionRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Any()).Return(nil)
+			},
+			want:    true,
+			want1:   true,
+			wantErr: false,
+		},
+		{
+			name: "rehash_success",
+			fields: fields{
+				userRepo: userRepositoryMock,
+				hasher:   passwordHasher,
+				keys:     keys,
+				tokenTtl: tokenTtl,
+			},
+			args: args{
+				ctx: ctx,
+				inp: inp,
+			},
+			configureMock: func() {
+				signInLimiterMock.EXPECT().Check(gomock.Eq(ctx), gomock.Eq(inp.Email), gomock.Eq(device.IP)).Return(nil)
+				userRepositoryMock.EXPECT().GetByEmail(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(user, nil)
+				passwordHasher.EXPECT().Verify(gomock.Eq(inp.Password), gomock.Eq(password)).Return(true, true, nil)
+				signInLimiterMock.EXPECT().Reset(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(nil)
+				passwordHasher.EXPECT().Hash(gomock.Eq(inp.Password)).Return(newPassword, nil)
+				userRepositoryMock.EXPECT().UpdatePassword(gomock.Eq(ctx), gomock.Eq(user.ID), gomock.Eq(newPassword)).Return(nil)
+				sessionRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Any()).Return(nil)
+			},
+			want:    true,
+			want1:   true,
+			wantErr: false,
+		},
+		{
+			name: "two_factor_challenge_error",
+			fields: fields{
+				userRepo: userRepositoryMock,
+				hasher:   passwordHasher,
+				keys:     keys,
+				tokenTtl: tokenTtl,
+			},
+			args: args{
+				ctx: ctx,
+				inp: inp,
+			},
+			configureMock: func() {
+				signInLimiterMock.EXPECT().Check(gomock.Eq(ctx), gomock.Eq(inp.Email), gomock.Eq(device.IP)).Return(nil)
+				userRepositoryMock.EXPECT().GetByEmail(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(twoFactorUser, nil)
+				passwordHasher.EXPECT().Verify(gomock.Eq(inp.Password), gomock.Eq(password)).Return(true, false, nil)
+				signInLimiterMock.EXPECT().Reset(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(nil)
+				twoFactorChallengerMock.EXPECT().CreateChallenge(gomock.Eq(ctx), gomock.Eq(user.ID)).Return("", testError)
+			},
+			wantErr: true,
+		},
+		{
+			name: "two_factor_challenge_success",
+			fields: fields{
+				userRepo: userRepositoryMock,
+				hasher:   passwordHasher,
+				keys:     keys,
+				tokenTtl: tokenTtl,
+			},
+			args: args{
+				ctx: ctx,
+				inp: inp,
+			},
+			configureMock: func() {
+				signInLimiterMock.EXPECT().Check(gomock.Eq(ctx), gomock.Eq(inp.Email), gomock.Eq(device.IP)).Return(nil)
+				userRepositoryMock.EXPECT().GetByEmail(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(twoFactorUser, nil)
+				passwordHasher.EXPECT().Verify(gomock.Eq(inp.Password), gomock.Eq(password)).Return(true, false, nil)
+				signInLimiterMock.EXPECT().Reset(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(nil)
+				twoFactorChallengerMock.EXPECT().CreateChallenge(gomock.Eq(ctx), gomock.Eq(user.ID)).Return("challenge", nil)
+			},
+			wantChallenge: true,
+			wantErr:       false,
+		},
 		{
 			name: "success",
 			fields: fields{
-				userRepo:   userRepositoryMock,
-				hasher:     passwordHasher,
-				hmacSecret: hmacSecret,
-				tokenTtl:   tokenTtl,
+				userRepo: userRepositoryMock,
+				hasher:   passwordHasher,
+				keys:     keys,
+				tokenTtl: tokenTtl,
 			},
 			args: args{
 				ctx: ctx,
 				inp: inp,
 			},
 			configureMock: func() {
-				passwordHasher.EXPECT().Hash(inp.Password).Return(password, nil)
-				userRepositoryMock.EXPECT().GetByCredentials(gomock.Eq(ctx), gomock.Eq(inp.Email), gomock.Eq(password)).Return(user, nil)
+				signInLimiterMock.EXPECT().Check(gomock.Eq(ctx), gomock.Eq(inp.Email), gomock.Eq(device.IP)).Return(nil)
+				userRepositoryMock.EXPECT().GetByEmail(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(user, nil)
+				passwordHasher.EXPECT().Verify(gomock.Eq(inp.Password), gomock.Eq(password)).Return(true, false, nil)
+				signInLimiterMock.EXPECT().Reset(gomock.Eq(ctx), gomock.Eq(inp.Email)).Return(nil)
 				sessionRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Any()).Return(nil)
 			},
 			want:    true,
@@ -338,11 +593,12 @@ func TestUser_SignIn(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.configureMock()
 
-			u := NewUsers(tt.fields.userRepo, sessionRepositoryMock, nil, nil, tt.fields.hasher, tt.fields.hmacSecret, tt.fields.tokenTtl)
-			got1, got2, err := u.SignIn(tt.args.ctx, tt.args.inp)
+			u := NewUsers(nil, tt.fields.userRepo, sessionRepositoryMock, nil, nil, nil, tt.fields.hasher, tt.fields.keys, twoFactorChallengerMock, nil, signInLimiterMock, nil, tt.fields.tokenTtl)
+			got, err := u.SignIn(tt.args.ctx, tt.args.inp, device)
 			assert.Equal(t, tt.wantErr, err != nil)
-			assert.Equal(t, tt.want, len(got1) != 0)
-			assert.Equal(t, tt.want, len(got2) != 0)
+			assert.Equal(t, tt.want, len(got.AccessToken) != 0)
+			assert.Equal(t, tt.want1, len(got.RefreshToken) != 0)
+			assert.Equal(t, tt.wantChallenge, len(got.ChallengeToken) != 0)
 		})
 	}
 }
@@ -351,6 +607,7 @@ func TestUser_BlockUser(t *testing.T) {
 	controller := gomock.NewController(t)
 	userRepositoryMock := NewMockUsersRepository(controller)
 	eventRepositoryMock := NewMockEventRepository(controller)
+	userAccessCacheMock := NewMockUserAccessCache(controller)
 
 	ctx := context.Background()
 	testError := errors.New("test error")
@@ -409,6 +666,7 @@ func TestUser_BlockUser(t *testing.T) {
 			},
 			configureMock: func() {
 				userRepositoryMock.EXPECT().BlockUser(gomock.Eq(ctx), gomock.Eq(blockUserID)).Return(nil)
+				userAccessCacheMock.EXPECT().InvalidateBlockStatus(gomock.Eq(ctx), gomock.Eq(blockUserID))
 				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Eq(event)).Return(testError)
 			},
 			wantErr: true,
@@ -426,6 +684,7 @@ func TestUser_BlockUser(t *testing.T) {
 			},
 			configureMock: func() {
 				userRepositoryMock.EXPECT().BlockUser(gomock.Eq(ctx), gomock.Eq(blockUserID)).Return(nil)
+				userAccessCacheMock.EXPECT().InvalidateBlockStatus(gomock.Eq(ctx), gomock.Eq(blockUserID))
 				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Eq(event)).Return(nil)
 			},
 			wantErr: false,
@@ -434,7 +693,7 @@ func TestUser_BlockUser(t *testing.T) {
 	for _, tt := range tests {
 		tt.configureMock()
 
-		u := NewUsers(tt.fields.userRepo, nil, nil, tt.fields.eventRepo, nil, nil, time.Duration(0))
+		u := NewUsers(nil, tt.fields.userRepo, nil, nil, nil, tt.fields.eventRepo, nil, nil, nil, nil, nil, userAccessCacheMock, time.Duration(0))
 		err := u.BlockUser(tt.args.ctx, tt.args.blockUserID, tt.args.userID)
 		assert.Equal(t, tt.wantErr, err != nil)
 	}
@@ -444,6 +703,7 @@ func TestUser_UnblockUser(t *testing.T) {
 	controller := gomock.NewController(t)
 	userRepositoryMock := NewMockUsersRepository(controller)
 	eventRepositoryMock := NewMockEventRepository(controller)
+	userAccessCacheMock := NewMockUserAccessCache(controller)
 
 	ctx := context.Background()
 	testError := errors.New("test error")
@@ -498,6 +758,7 @@ func TestUser_UnblockUser(t *testing.T) {
 			},
 			configureMock: func() {
 				userRepositoryMock.EXPECT().UnblockUser(gomock.Eq(ctx), gomock.Eq(userID)).Return(nil)
+				userAccessCacheMock.EXPECT().InvalidateBlockStatus(gomock.Eq(ctx), gomock.Eq(userID))
 				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Eq(event)).Return(testError)
 			},
 			wantErr: true,
@@ -514,6 +775,7 @@ func TestUser_UnblockUser(t *testing.T) {
 			},
 			configureMock: func() {
 				userRepositoryMock.EXPECT().UnblockUser(gomock.Eq(ctx), gomock.Eq(userID)).Return(nil)
+				userAccessCacheMock.EXPECT().InvalidateBlockStatus(gomock.Eq(ctx), gomock.Eq(userID))
 				eventRepositoryMock.EXPECT().CreateEvent(gomock.Eq(ctx), gomock.Eq(event)).Return(nil)
 			},
 			wantErr: false,
@@ -523,7 +785,7 @@ func TestUser_UnblockUser(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.configureMock()
 
-			u := NewUsers(tt.fields.userRepo, nil, nil, tt.fields.eventRepo, nil, nil, time.Duration(0))
+			u := NewUsers(nil, tt.fields.userRepo, nil, nil, nil, tt.fields.eventRepo, nil, nil, nil, nil, nil, userAccessCacheMock, time.Duration(0))
 			err := u.UnblockUser(tt.args.ctx, tt.args.userID)
 			assert.Equal(t, tt.wantErr, err != nil)
 		})
@@ -532,14 +794,14 @@ func TestUser_UnblockUser(t *testing.T) {
 
 func TestUser_CheckBlockUser(t *testing.T) {
 	controller := gomock.NewController(t)
-	userRepositoryMock := NewMockUsersRepository(controller)
+	userAccessCacheMock := NewMockUserAccessCache(controller)
 
 	ctx := context.Background()
 	testError := errors.New("test error")
 	userID := 1
 
 	type fields struct {
-		userRepo UsersRepository
+		access UserAccessCache
 	}
 	type args struct {
 		ctx    context.Context
@@ -556,14 +818,14 @@ func TestUser_CheckBlockUser(t *testing.T) {
 		{
 			name: "user_repository_error",
 			fields: fields{
-				userRepo: userRepositoryMock,
+				access: userAccessCacheMock,
 			},
 			args: args{
 				ctx:    ctx,
 				userID: userID,
 			},
 			configureMock: func() {
-				userRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(userID)).Return(false, testError)
+				userAccessCacheMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(userID)).Return(false, testError)
 			},
 			want:    false,
 			wantErr: true,
@@ -571,14 +833,14 @@ func TestUser_CheckBlockUser(t *testing.T) {
 		{
 			name: "success",
 			fields: fields{
-				userRepo: userRepositoryMock,
+				access: userAccessCacheMock,
 			},
 			args: args{
 				ctx:    ctx,
 				userID: userID,
 			},
 			configureMock: func() {
-				userRepositoryMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(userID)).Return(false, nil)
+				userAccessCacheMock.EXPECT().CheckBlockUser(gomock.Eq(ctx), gomock.Eq(userID)).Return(false, nil)
 			},
 			want:    false,
 			wantErr: false,
@@ -588,10 +850,336 @@ func TestUser_CheckBlockUser(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.configureMock()
 
-			u := NewUsers(tt.fields.userRepo, nil, nil, nil, nil, nil, time.Duration(0))
+			u := NewUsers(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, tt.fields.access, time.Duration(0))
 			got, err := u.CheckBlockUser(tt.args.ctx, tt.args.userID)
 			assert.Equal(t, tt.wantErr, err != nil)
 			assert.Equal(t, tt.want, got)
 		})
 	}
 }
+
+func TestUser_RefreshTokens(t *testing.T) {
+	controller := gomock.NewController(t)
+	transactorMock := NewMockTransactor(controller)
+	userRepositoryMock := NewMockUsersRepository(controller)
+	sessionRepositoryMock := NewMockSessionRepository(controller)
+
+	ctx := context.Background()
+	testError := errors.New("test error")
+	refreshToken := "refresh-token"
+	tokenHash := hashToken(refreshToken)
+	device := domain.Device{UserAgent: "curl/8.0", IP: "127.0.0.1"}
+
+	session := domain.RefreshSession{
+		ID:        1,
+		UserID:    2,
+		FamilyID:  "family",
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	rotatedSession := session
+	rotatedSession.Rotated = true
+	revokedSession := session
+	revokedSession.Revoked = true
+	expiredSession := session
+	expiredSession.ExpiresAt = time.Now().Add(-time.Hour)
+
+	user := domain.User{ID: 2}
+
+	withinTransaction := func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}
+
+	tests := []struct {
+		name          string
+		configureMock func()
+		wantErr       error
+	}{
+		{
+			name: "session_not_found_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				sessionRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(tokenHash)).Return(domain.RefreshSession{}, domain.ErrInvalidRefreshToken)
+			},
+			wantErr: domain.ErrInvalidRefreshToken,
+		},
+		{
+			name: "session_revoked_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				sessionRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(tokenHash)).Return(revokedSession, nil)
+			},
+			wantErr: domain.ErrInvalidRefreshToken,
+		},
+		{
+			name: "token_reused_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				sessionRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(tokenHash)).Return(rotatedSession, nil)
+				sessionRepositoryMock.EXPECT().RevokeFamily(gomock.Eq(ctx), gomock.Eq(session.FamilyID)).Return(nil)
+			},
+			wantErr: domain.ErrRefreshTokenReused,
+		},
+		{
+			name: "revoking_family_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				sessionRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(tokenHash)).Return(rotatedSession, nil)
+				sessionRepositoryMock.EXPECT().RevokeFamily(gomock.Eq(ctx), gomock.Eq(session.FamilyID)).Return(testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "token_expired_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				sessionRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(tokenHash)).Return(expiredSession, nil)
+			},
+			wantErr: domain.ErrRefreshTokenExpired,
+		},
+		{
+			name: "marking_rotated_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				sessionRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(tokenHash)).Return(session, nil)
+				sessionRepositoryMock.EXPECT().MarkRotated(gomock.Eq(ctx), gomock.Eq(session.ID)).Return(testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "success",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				sessionRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(tokenHash)).Return(session, nil)
+				sessionRepositoryMock.EXPECT().MarkRotated(gomock.Eq(ctx), gomock.Eq(session.ID)).Return(nil)
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(session.UserID)).Return(user, nil)
+				sessionRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Any()).DoAndReturn(func(_ context.Context, created domain.RefreshSession) error {
+					assert.Equal(t, session.FamilyID, created.FamilyID)
+					assert.Equal(t, device.UserAgent, created.UserAgent)
+					assert.NotEqual(t, tokenHash, created.TokenHash)
+					return nil
+				})
+			},
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			u := NewUsers(transactorMock, userRepositoryMock, sessionRepositoryMock, nil, nil, nil, nil, newTestKeys(t, nil, "kid"), nil, nil, nil, nil, time.Hour)
+			accessToken, newRefreshToken, err := u.RefreshTokens(ctx, refreshToken, device)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotEmpty(t, accessToken)
+			assert.NotEmpty(t, newRefreshToken)
+			assert.NotEqual(t, refreshToken, newRefreshToken)
+		})
+	}
+}
+
+func TestUser_Logout(t *testing.T) {
+	controller := gomock.NewController(t)
+	sessionRepositoryMock := NewMockSessionRepository(controller)
+
+	ctx := context.Background()
+	testError := errors.New("test error")
+	refreshToken := "refresh-token"
+	session := domain.RefreshSession{ID: 1, UserID: 2, FamilyID: "family"}
+
+	tests := []struct {
+		name          string
+		configureMock func()
+		wantErr       bool
+	}{
+		{
+			name: "session_not_found_error",
+			configureMock: func() {
+				sessionRepositoryMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(hashToken(refreshToken))).Return(domain.RefreshSession{}, domain.ErrInvalidRefreshToken)
+			},
+			wantErr: true,
+		},
+		{
+			name: "revoking_family_error",
+			configureMock: func() {
+				sessionRepositoryMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(hashToken(refreshToken))).Return(session, nil)
+				sessionRepositoryMock.EXPECT().RevokeFamily(gomock.Eq(ctx), gomock.Eq(session.FamilyID)).Return(testError)
+			},
+			wantErr: true,
+		},
+		{
+			name: "success",
+			configureMock: func() {
+				sessionRepositoryMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(hashToken(refreshToken))).Return(session, nil)
+				sessionRepositoryMock.EXPECT().RevokeFamily(gomock.Eq(ctx), gomock.Eq(session.FamilyID)).Return(nil)
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			u := NewUsers(nil, nil, sessionRepositoryMock, nil, nil, nil, nil, nil, nil, nil, nil, nil, time.Duration(0))
+			err := u.Logout(ctx, refreshToken, "")
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestUser_LogoutRevokesAccessToken(t *testing.T) {
+	controller := gomock.NewController(t)
+	sessionRepositoryMock := NewMockSessionRepository(controller)
+	revokedTokenRepositoryMock := NewMockRevokedTokenRepository(controller)
+	userAccessCacheMock := NewMockUserAccessCache(controller)
+
+	ctx := context.Background()
+	refreshToken := "refresh-token"
+	user := domain.User{ID: 2, TokenVersion: 1}
+	session := domain.RefreshSession{ID: 1, UserID: user.ID, FamilyID: "family"}
+
+	u := NewUsers(nil, nil, sessionRepositoryMock, revokedTokenRepositoryMock, nil, nil, nil, newTestKeys(t, nil, "kid"), nil, nil, nil, userAccessCacheMock, time.Hour)
+	accessToken, err := u.newAccessToken(user)
+	assert.NoError(t, err)
+
+	sessionRepositoryMock.EXPECT().Get(gomock.Eq(ctx), gomock.Eq(hashToken(refreshToken))).Return(session, nil)
+	sessionRepositoryMock.EXPECT().RevokeFamily(gomock.Eq(ctx), gomock.Eq(session.FamilyID)).Return(nil)
+	userAccessCacheMock.EXPECT().IsRevoked(gomock.Eq(ctx), gomock.Any()).Return(false, nil)
+	userAccessCacheMock.EXPECT().GetTokenVersion(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(user.TokenVersion, nil)
+
+	// the cached revocation state of the token is removed once it is revoked
+	var revokedID string
+	revokedTokenRepositoryMock.EXPECT().Revoke(gomock.Eq(ctx), gomock.Any(), gomock.Eq(user.ID), gomock.Any()).
+		DoAndReturn(func(_ context.Context, jti string, _ int, _ time.Time) error {
+			revokedID = jti
+			return nil
+		})
+	userAccessCacheMock.EXPECT().InvalidateRevokedToken(gomock.Eq(ctx), gomock.Any()).Do(func(_ context.Context, jti string) {
+		assert.Equal(t, revokedID, jti)
+	})
+
+	assert.NoError(t, u.Logout(ctx, refreshToken, accessToken))
+}
+
+func TestUser_ParseToken(t *testing.T) {
+	controller := gomock.NewController(t)
+	userAccessCacheMock := NewMockUserAccessCache(controller)
+
+	ctx := context.Background()
+	testError := errors.New("test error")
+	user := domain.User{ID: 1, TokenVersion: 3}
+
+	signingKeyRepositoryMock := NewMockSigningKeyRepository(controller)
+
+	u := NewUsers(nil, nil, nil, nil, nil, nil, nil, newTestKeys(t, signingKeyRepositoryMock, "kid"), nil, nil, nil, userAccessCacheMock, time.Hour)
+	token, err := u.newAccessToken(user)
+	assert.NoError(t, err)
+
+	// same kid, different key
+	forged := NewUsers(nil, nil, nil, nil, nil, nil, nil, newTestKeys(t, nil, "kid"), nil, nil, nil, nil, time.Hour)
+	foreignToken, err := forged.newAccessToken(user)
+	assert.NoError(t, err)
+
+	unknown := NewUsers(nil, nil, nil, nil, nil, nil, nil, newTestKeys(t, nil, "unknown"), nil, nil, nil, nil, time.Hour)
+	unknownKeyToken, err := unknown.newAccessToken(user)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		token         string
+		configureMock func()
+		wantErr       bool
+		wantErrIs     error
+	}{
+		{
+			name:          "wrong_signature_error",
+			token:         foreignToken,
+			configureMock: func() {},
+			wantErr:       true,
+		},
+		{
+			name:  "unknown_key_error",
+			token: unknownKeyToken,
+			configureMock: func() {
+				signingKeyRepositoryMock.EXPECT().List(gomock.Eq(ctx)).Return(nil, nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrSigningKeyNotFound,
+		},
+		{
+			name:          "unknown_key_reload_throttled_error",
+			token:         unknownKeyToken,
+			configureMock: func() {},
+			wantErr:       true,
+			wantErrIs:     domain.ErrSigningKeyNotFound,
+		},
+		{
+			name:  "revocation_check_error",
+			token: token,
+			configureMock: func() {
+				userAccessCacheMock.EXPECT().IsRevoked(gomock.Eq(ctx), gomock.Any()).Return(false, testError)
+			},
+			wantErr: true,
+		},
+		{
+			name:  "token_revoked_error",
+			token: token,
+			configureMock: func() {
+				userAccessCacheMock.EXPECT().IsRevoked(gomock.Eq(ctx), gomock.Any()).Return(true, nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrAccessTokenRevoked,
+		},
+		{
+			name:  "token_version_outdated_error",
+			token: token,
+			configureMock: func() {
+				userAccessCacheMock.EXPECT().IsRevoked(gomock.Eq(ctx), gomock.Any()).Return(false, nil)
+				userAccessCacheMock.EXPECT().GetTokenVersion(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(user.TokenVersion+1, nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrAccessTokenRevoked,
+		},
+		{
+			name:  "success",
+			token: token,
+			configureMock: func() {
+				userAccessCacheMock.EXPECT().IsRevoked(gomock.Eq(ctx), gomock.Any()).Return(false, nil)
+				userAccessCacheMock.EXPECT().GetTokenVersion(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(user.TokenVersion, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:  "stale_cached_token_version_reloaded",
+			token: token,
+			configureMock: func() {
+				userAccessCacheMock.EXPECT().IsRevoked(gomock.Eq(ctx), gomock.Any()).Return(false, nil)
+				userAccessCacheMock.EXPECT().GetTokenVersion(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(user.TokenVersion-1, nil)
+				userAccessCacheMock.EXPECT().InvalidateTokenVersion(gomock.Eq(ctx), gomock.Eq(user.ID))
+				userAccessCacheMock.EXPECT().GetTokenVersion(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(user.TokenVersion, nil)
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			claims, err := u.ParseToken(ctx, tt.token)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+			}
+
+			if !tt.wantErr {
+				assert.NotEmpty(t, claims.ID)
+				assert.Equal(t, user.ID, claims.UserID)
+				assert.Equal(t, user.TokenVersion, claims.Version)
+			}
+		})
+	}
+}
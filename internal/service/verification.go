@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// oneTimeTokenSize number of random bytes of email verification and password reset tokens.
+const oneTimeTokenSize = 32
+
+// Verification business logic layer struct of email verification and password reset flows.
+// The tokens are sent by email, they are single-use and expire, only their hashes are stored.
+type Verification struct {
+	transactor  Transactor
+	userRepo    UsersRepository
+	sessionRepo SessionRepository
+	tokenRepo   OneTimeTokenRepository
+	hasher      PasswordHasher
+	mailer      Mailer
+	access      UserAccessCache
+
+	// emailVerificationURL and passwordResetURL are the links put into the emails, %s is replaced by the token.
+	emailVerificationURL string
+	passwordResetURL     string
+	emailVerificationTTL time.Duration
+	passwordResetTTL     time.Duration
+}
+
+// NewVerification constructor for Verification.
+func NewVerification(transactor Transactor, userRepo UsersRepository, sessionRepo SessionRepository, tokenRepo OneTimeTokenRepository, hasher PasswordHasher, mailer Mailer, access UserAccessCache, emailVerificationURL, passwordResetURL string, emailVerificationTTL, passwordResetTTL time.Duration) *Verification {
+	return &Verification{
+		transactor:           transactor,
+		userRepo:             userRepo,
+		sessionRepo:          sessionRepo,
+		tokenRepo:            tokenRepo,
+		hasher:               hasher,
+		mailer:               mailer,
+		access:               access,
+		emailVerificationURL: emailVerificationURL,
+		passwordResetURL:     passwordResetURL,
+		emailVerificationTTL: emailVerificationTTL,
+		passwordResetTTL:     passwordResetTTL,
+	}
+}
+
+// RequestEmailVerification sends the email verification link to the user, previously sent links stop working.
+func (s *Verification) RequestEmailVerification(ctx context.Context, userID int) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return errors.Wrap(err, "getting user error")
+	}
+
+	if user.EmailVerified {
+		return errors.Wrap(domain.ErrEmailAlreadyVerified, "checking email is not verified error")
+	}
+
+	token, err := s.issueToken(ctx, user.ID, domain.EmailVerificationPurpose, s.emailVerificationTTL)
+	if err != nil {
+		return errors.Wrap(err, "issuing email verification token error")
+	}
+
+	body := fmt.Sprintf("Hello %s,\n\nplease confirm your email address by opening the link below:\n%s\n\nThe link expires in %s.\n",
+		user.Name, fmt.Sprintf(s.emailVerificationURL, token), s.emailVerificationTTL)
+
+	if err := s.mailer.Send(ctx, user.Email, "Confirm your email address", body); err != nil {
+		return errors.Wrap(err, "sending email verification error")
+	}
+
+	return nil
+}
+
+// CancelEmailVerification makes the email verification links sent to the user stop working,
+// so a link sent to the previous email can not verify a changed one.
+func (s *Verification) CancelEmailVerification(ctx context.Context, userID int) error {
+	if err := s.tokenRepo.DeleteUnused(ctx, userID, domain.EmailVerificationPurpose); err != nil {
+		return errors.Wrap(err, "deleting email verification tokens error")
+	}
+
+	return nil
+}
+
+// VerifyEmail marks the email of the token owner as verified and uses the token up.
+func (s *Verification) VerifyEmail(ctx context.Context, token string) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		oneTimeToken, err := s.useToken(ctx, token, domain.EmailVerificationPurpose)
+		if err != nil {
+			return err
+		}
+
+		if err := s.userRepo.MarkEmailVerified(ctx, oneTimeToken.UserID); err != nil {
+			return errors.Wrap(err, "marking email verified error")
+		}
+
+		return nil
+	})
+}
+
+// ForgotPassword sends the password reset link to the user with the email, previously sent links stop working.
+// An unknown email is not reported, so the endpoint can not be used to find out registered emails.
+func (s *Verification) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			logrus.WithFields(logrus.Fields{
+				"layer":   "service",
+				"service": "Verification",
+				"method":  "ForgotPassword",
+			}).Info("password reset requested for unknown email")
+
+			return nil
+		}
+
+		return errors.Wrap(err, "getting user by email error")
+	}
+
+	token, err := s.issueToken(ctx, user.ID, domain.PasswordResetPurpose, s.passwordResetTTL)
+	if err != nil {
+		return errors.Wrap(err, "issuing password reset token error")
+	}
+
+	body := fmt.Sprintf("Hello %s,\n\nsomebody requested a password reset for your account. To set a new password open the link below:\n%s\n\n"+
+		"The link expires in %s. If it was not you, ignore this email, your password stays unchanged.\n",
+		user.Name, fmt.Sprintf(s.passwordResetURL, token), s.passwordResetTTL)
+
+	if err := s.mailer.Send(ctx, user.Email, "Reset your password", body); err != nil {
+		return errors.Wrap(err, "sending password reset email error")
+	}
+
+	return nil
+}
+
+// ResetPassword sets the new password of the token owner and uses the token up.
+// All sessions of the user are ended and issued access tokens are invalidated.
+func (s *Verification) ResetPassword(ctx context.Context, token, password string) error {
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return errors.Wrap(err, "password hash error")
+	}
+
+	var userID int
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		oneTimeToken, err := s.useToken(ctx, token, domain.PasswordResetPurpose)
+		if err != nil {
+			return err
+		}
+
+		userID = oneTimeToken.UserID
+
+		if err := s.userRepo.UpdatePassword(ctx, oneTimeToken.UserID, hash); err != nil {
+			return errors.Wrap(err, "updating password error")
+		}
+
+		if err := s.sessionRepo.RevokeAll(ctx, oneTimeToken.UserID); err != nil {
+			return errors.Wrap(err, "revoking user refresh sessions error")
+		}
+
+		if err := s.userRepo.IncrementTokenVersion(ctx, oneTimeToken.UserID); err != nil {
+			return errors.Wrap(err, "incrementing token version error")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.access.InvalidateTokenVersion(ctx, userID)
+
+	return nil
+}
+
+// issueToken creates a new one-time token of the user and returns it.
+func (s *Verification) issueToken(ctx context.Context, userID int, purpose domain.OneTimeTokenPurpose, ttl time.Duration) (string, error) {
+	token, err := newRandomToken(oneTimeTokenSize)
+	if err != nil {
+		return "", errors.Wrap(err, "creating token error")
+	}
+
+	oneTimeToken := domain.OneTimeToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	if err := s.tokenRepo.Create(ctx, oneTimeToken); err != nil {
+		return "", errors.Wrap(err, "storing token error")
+	}
+
+	return token, nil
+}
+
+// useToken checks the unused token of the purpose is not expired and marks it as used.
+// It must be called inside a database transaction.
+func (s *Verification) useToken(ctx context.Context, token string, purpose domain.OneTimeTokenPurpose) (domain.OneTimeToken, error) {
+	oneTimeToken, err := s.tokenRepo.Lock(ctx, hashToken(token), purpose)
+	if err != nil {
+		return domain.OneTimeToken{}, errors.Wrap(err, "getting one-time token error")
+	}
+
+	if time.Now().After(oneTimeToken.ExpiresAt) {
+		return domain.OneTimeToken{}, errors.Wrap(domain.ErrInvalidOneTimeToken, "checking one-time token expiration error")
+	}
+
+	if err := s.tokenRepo.MarkUsed(ctx, oneTimeToken.ID); err != nil {
+		return domain.OneTimeToken{}, errors.Wrap(err, "marking one-time token used error")
+	}
+
+	return oneTimeToken, nil
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testEmailVerificationURL = "http://localhost/auth/email/verify?token=%s"
+	testPasswordResetURL     = "http://localhost/password/reset?token=%s"
+)
+
+func TestVerification_RequestEmailVerification(t *testing.T) {
+	controller := gomock.NewController(t)
+	userRepositoryMock := NewMockUsersRepository(controller)
+	oneTimeTokenRepositoryMock := NewMockOneTimeTokenRepository(controller)
+	mailerMock := NewMockMailer(controller)
+
+	ctx := context.Background()
+	user := domain.User{ID: 1, Name: "Bob", Email: "bob@example.com"}
+	verified := user
+	verified.EmailVerified = true
+	testError := errors.New("test error")
+
+	tests := []struct {
+		name          string
+		configureMock func()
+		wantErr       error
+	}{
+		{
+			name: "already_verified_error",
+			configureMock: func() {
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(verified, nil)
+			},
+			wantErr: domain.ErrEmailAlreadyVerified,
+		},
+		{
+			name: "one_time_token_repository_error",
+			configureMock: func() {
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(user, nil)
+				oneTimeTokenRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Any()).Return(testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "success",
+			configureMock: func() {
+				var stored domain.OneTimeToken
+				userRepositoryMock.EXPECT().GetByID(gomock.Eq(ctx), gomock.Eq(user.ID)).Return(user, nil)
+				oneTimeTokenRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Any()).DoAndReturn(func(_ context.Context, token domain.OneTimeToken) error {
+					assert.Equal(t, user.ID, token.UserID)
+					assert.Equal(t, domain.EmailVerificationPurpose, token.Purpose)
+					assert.WithinDuration(t, time.Now().Add(time.Hour), token.ExpiresAt, time.Minute)
+					stored = token
+					return nil
+				})
+				mailerMock.EXPECT().Send(gomock.Eq(ctx), gomock.Eq(user.Email), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _, _, body string) error {
+					// the link carries the token itself, only its hash is stored
+					i := strings.Index(body, "token=")
+					assert.NotEqual(t, -1, i)
+					assert.Equal(t, stored.TokenHash, hashToken(strings.Fields(body[i+len("token="):])[0]))
+					return nil
+				})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s := NewVerification(nil, userRepositoryMock, nil, oneTimeTokenRepositoryMock, nil, mailerMock, nil, testEmailVerificationURL, testPasswordResetURL, time.Hour, time.Hour)
+
+			err := s.RequestEmailVerification(ctx, user.ID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestVerification_CancelEmailVerification(t *testing.T) {
+	controller := gomock.NewController(t)
+	oneTimeTokenRepositoryMock := NewMockOneTimeTokenRepository(controller)
+
+	ctx := context.Background()
+
+	oneTimeTokenRepositoryMock.EXPECT().DeleteUnused(gomock.Eq(ctx), gomock.Eq(1), gomock.Eq(domain.EmailVerificationPurpose)).Return(nil)
+
+	s := NewVerification(nil, nil, nil, oneTimeTokenRepositoryMock, nil, nil, nil, testEmailVerificationURL, testPasswordResetURL, time.Hour, time.Hour)
+	assert.NoError(t, s.CancelEmailVerification(ctx, 1))
+}
+
+func TestVerification_VerifyEmail(t *testing.T) {
+	controller := gomock.NewController(t)
+	transactorMock := NewMockTransactor(controller)
+	userRepositoryMock := NewMockUsersRepository(controller)
+	oneTimeTokenRepositoryMock := NewMockOneTimeTokenRepository(controller)
+
+	ctx := context.Background()
+	token := "verification-token"
+	oneTimeToken := domain.OneTimeToken{ID: 3, UserID: 1, Purpose: domain.EmailVerificationPurpose, TokenHash: hashToken(token), ExpiresAt: time.Now().Add(time.Hour)}
+	expired := oneTimeToken
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	testError := errors.New("test error")
+
+	withinTransaction := func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}
+
+	tests := []struct {
+		name          string
+		configureMock func()
+		wantErr       error
+	}{
+		{
+			name: "invalid_token_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				oneTimeTokenRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(hashToken(token)), gomock.Eq(domain.EmailVerificationPurpose)).Return(domain.OneTimeToken{}, domain.ErrInvalidOneTimeToken)
+			},
+			wantErr: domain.ErrInvalidOneTimeToken,
+		},
+		{
+			name: "expired_token_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				oneTimeTokenRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(hashToken(token)), gomock.Eq(domain.EmailVerificationPurpose)).Return(expired, nil)
+			},
+			wantErr: domain.ErrInvalidOneTimeToken,
+		},
+		{
+			name: "user_repository_error",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				oneTimeTokenRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(hashToken(token)), gomock.Eq(domain.EmailVerificationPurpose)).Return(oneTimeToken, nil)
+				oneTimeTokenRepositoryMock.EXPECT().MarkUsed(gomock.Eq(ctx), gomock.Eq(oneTimeToken.ID)).Return(nil)
+				userRepositoryMock.EXPECT().MarkEmailVerified(gomock.Eq(ctx), gomock.Eq(oneTimeToken.UserID)).Return(testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "success",
+			configureMock: func() {
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				oneTimeTokenRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(hashToken(token)), gomock.Eq(domain.EmailVerificationPurpose)).Return(oneTimeToken, nil)
+				oneTimeTokenRepositoryMock.EXPECT().MarkUsed(gomock.Eq(ctx), gomock.Eq(oneTimeToken.ID)).Return(nil)
+				userRepositoryMock.EXPECT().MarkEmailVerified(gomock.Eq(ctx), gomock.Eq(oneTimeToken.UserID)).Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s := NewVerification(transactorMock, userRepositoryMock, nil, oneTimeTokenRepositoryMock, nil, nil, nil, testEmailVerificationURL, testPasswordResetURL, time.Hour, time.Hour)
+
+			err := s.VerifyEmail(ctx, token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestVerification_ForgotPassword(t *testing.T) {
+	controller := gomock.NewController(t)
+	userRepositoryMock := NewMockUsersRepository(controller)
+	oneTimeTokenRepositoryMock := NewMockOneTimeTokenRepository(controller)
+	mailerMock := NewMockMailer(controller)
+
+	ctx := context.Background()
+	user := domain.User{ID: 1, Name: "Bob", Email: "bob@example.com"}
+	testError := errors.New("test error")
+
+	tests := []struct {
+		name          string
+		configureMock func()
+		wantErr       error
+	}{
+		{
+			name: "unknown_email",
+			configureMock: func() {
+				userRepositoryMock.EXPECT().GetByEmail(gomock.Eq(ctx), gomock.Eq(user.Email)).Return(domain.User{}, domain.ErrUserNotFound)
+			},
+		},
+		{
+			name: "user_repository_error",
+			configureMock: func() {
+				userRepositoryMock.EXPECT().GetByEmail(gomock.Eq(ctx), gomock.Eq(user.Email)).Return(domain.User{}, testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "mailer_error",
+			configureMock: func() {
+				userRepositoryMock.EXPECT().GetByEmail(gomock.Eq(ctx), gomock.Eq(user.Email)).Return(user, nil)
+				oneTimeTokenRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Any()).Return(nil)
+				mailerMock.EXPECT().Send(gomock.Eq(ctx), gomock.Eq(user.Email), gomock.Any(), gomock.Any()).Return(testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "success",
+			configureMock: func() {
+				userRepositoryMock.EXPECT().GetByEmail(gomock.Eq(ctx), gomock.Eq(user.Email)).Return(user, nil)
+				oneTimeTokenRepositoryMock.EXPECT().Create(gomock.Eq(ctx), gomock.Any()).DoAndReturn(func(_ context.Context, token domain.OneTimeToken) error {
+					assert.Equal(t, domain.PasswordResetPurpose, token.Purpose)
+					assert.WithinDuration(t, time.Now().Add(time.Minute*30), token.ExpiresAt, time.Minute)
+					return nil
+				})
+				mailerMock.EXPECT().Send(gomock.Eq(ctx), gomock.Eq(user.Email), gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s := NewVerification(nil, userRepositoryMock, nil, oneTimeTokenRepositoryMock, nil, mailerMock, nil, testEmailVerificationURL, testPasswordResetURL, time.Hour, time.Minute*30)
+
+			err := s.ForgotPassword(ctx, user.Email)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestVerification_ResetPassword(t *testing.T) {
+	controller := gomock.NewController(t)
+	transactorMock := NewMockTransactor(controller)
+	userRepositoryMock := NewMockUsersRepository(controller)
+	sessionRepositoryMock := NewMockSessionRepository(controller)
+	oneTimeTokenRepositoryMock := NewMockOneTimeTokenRepository(controller)
+	passwordHasherMock := NewMockPasswordHasher(controller)
+	userAccessCacheMock := NewMockUserAccessCache(controller)
+
+	ctx := context.Background()
+	token := "reset-token"
+	password := "new-password"
+	hash := "$argon2id$hash"
+	oneTimeToken := domain.OneTimeToken{ID: 5, UserID: 1, Purpose: domain.PasswordResetPurpose, TokenHash: hashToken(token), ExpiresAt: time.Now().Add(time.Hour)}
+	testError := errors.New("test error")
+
+	withinTransaction := func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}
+
+	tests := []struct {
+		name          string
+		configureMock func()
+		wantErr       error
+	}{
+		{
+			name: "invalid_token_error",
+			configureMock: func() {
+				passwordHasherMock.EXPECT().Hash(gomock.Eq(password)).Return(hash, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				oneTimeTokenRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(hashToken(token)), gomock.Eq(domain.PasswordResetPurpose)).Return(domain.OneTimeToken{}, domain.ErrInvalidOneTimeToken)
+			},
+			wantErr: domain.ErrInvalidOneTimeToken,
+		},
+		{
+			name: "revoking_sessions_error",
+			configureMock: func() {
+				passwordHasherMock.EXPECT().Hash(gomock.Eq(password)).Return(hash, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				oneTimeTokenRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(hashToken(token)), gomock.Eq(domain.PasswordResetPurpose)).Return(oneTimeToken, nil)
+				oneTimeTokenRepositoryMock.EXPECT().MarkUsed(gomock.Eq(ctx), gomock.Eq(oneTimeToken.ID)).Return(nil)
+				userRepositoryMock.EXPECT().UpdatePassword(gomock.Eq(ctx), gomock.Eq(oneTimeToken.UserID), gomock.Eq(hash)).Return(nil)
+				sessionRepositoryMock.EXPECT().RevokeAll(gomock.Eq(ctx), gomock.Eq(oneTimeToken.UserID)).Return(testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "success",
+			configureMock: func() {
+				passwordHasherMock.EXPECT().Hash(gomock.Eq(password)).Return(hash, nil)
+				transactorMock.EXPECT().WithinTransaction(gomock.Eq(ctx), gomock.Any()).DoAndReturn(withinTransaction)
+				oneTimeTokenRepositoryMock.EXPECT().Lock(gomock.Eq(ctx), gomock.Eq(hashToken(token)), gomock.Eq(domain.PasswordResetPurpose)).Return(oneTimeToken, nil)
+				oneTimeTokenRepositoryMock.EXPECT().MarkUsed(gomock.Eq(ctx), gomock.Eq(oneTimeToken.ID)).Return(nil)
+				userRepositoryMock.EXPECT().UpdatePassword(gomock.Eq(ctx), gomock.Eq(oneTimeToken.UserID), gomock.Eq(hash)).Return(nil)
+				sessionRepositoryMock.EXPECT().RevokeAll(gomock.Eq(ctx), gomock.Eq(oneTimeToken.UserID)).Return(nil)
+				userRepositoryMock.EXPECT().IncrementTokenVersion(gomock.Eq(ctx), gomock.Eq(oneTimeToken.UserID)).Return(nil)
+				userAccessCacheMock.EXPECT().InvalidateTokenVersion(gomock.Eq(ctx), gomock.Eq(oneTimeToken.UserID))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s := NewVerification(transactorMock, userRepositoryMock, sessionRepositoryMock, oneTimeTokenRepositoryMock, passwordHasherMock, nil, userAccessCacheMock, testEmailVerificationURL, testPasswordResetURL, time.Hour, time.Hour)
+
+			err := s.ResetPassword(ctx, token, password)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/lukinairina90/banking_backend/pkg/webhook"
+	"github.com/pkg/errors"
+)
+
+const (
+	// webhookSecretSize number of random bytes of the webhook signing secret.
+	webhookSecretSize = 32
+	// webhookDeliveriesLimit number of the latest deliveries listed for a subscription.
+	webhookDeliveriesLimit = 100
+)
+
+// Webhooks business logic layer struct of the webhook subscriptions management.
+// Subscriptions are owned by a user, zero userID manages the subscriptions to the events of all users, which is left to the admins.
+type Webhooks struct {
+	repo           WebhookRepository
+	addressChecker WebhookAddressChecker
+}
+
+// NewWebhooks constructor for Webhooks.
+func NewWebhooks(repo WebhookRepository, addressChecker WebhookAddressChecker) *Webhooks {
+	return &Webhooks{
+		repo:           repo,
+		addressChecker: addressChecker,
+	}
+}
+
+// CreateSubscription subscribes the URL to the events of the provided types, no types means all of them.
+// The host of the URL must resolve to public addresses only, so webhooks can not be used to reach the internal network.
+// The returned subscription carries the secret the payloads are signed with, it is not shown again.
+func (s *Webhooks) CreateSubscription(ctx context.Context, userID int, inp domain.WebhookSubscriptionInput) (domain.WebhookSubscription, error) {
+	u, err := url.Parse(inp.URL)
+	if err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return domain.WebhookSubscription{}, errors.Wrap(domain.ErrInvalidWebhookURL, "webhook URL check error")
+	}
+
+	if err := s.addressChecker.CheckHost(ctx, u.Hostname()); err != nil {
+		if errors.Is(err, webhook.ErrForbiddenAddress) {
+			return domain.WebhookSubscription{}, errors.Wrap(domain.ErrForbiddenWebhookURL, "webhook host check error")
+		}
+
+		return domain.WebhookSubscription{}, errors.Wrapf(domain.ErrInvalidWebhookURL, "webhook host check error: %s", err)
+	}
+
+	subscription := domain.WebhookSubscription{UserID: userID, URL: inp.URL}
+	for _, et := range inp.EventTypes {
+		eventType, err := domain.NewEventTypeFromString(et)
+		if err != nil {
+			return domain.WebhookSubscription{}, errors.Wrapf(domain.ErrUnsupportedEventType, "event type %q check error", et)
+		}
+
+		subscription.EventTypes = append(subscription.EventTypes, eventType)
+	}
+
+	subscription.Secret, err = newRandomToken(webhookSecretSize)
+	if err != nil {
+		return domain.WebhookSubscription{}, errors.Wrap(err, "generating webhook secret error")
+	}
+
+	subscription, err = s.repo.CreateSubscription(ctx, subscription)
+	if err != nil {
+		return domain.WebhookSubscription{}, errors.Wrap(err, "webhook subscription creation error")
+	}
+
+	return subscription, nil
+}
+
+// GetSubscriptions returns the webhook subscriptions of the user.
+func (s *Webhooks) GetSubscriptions(ctx context.Context, userID int) ([]domain.WebhookSubscription, error) {
+	subscriptions, err := s.repo.GetSubscriptions(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting webhook subscriptions error")
+	}
+
+	return subscriptions, nil
+}
+
+// GetSubscription returns the webhook subscription of the user, a subscription of someone else is not found.
+func (s *Webhooks) GetSubscription(ctx context.Context, userID, subscriptionID int) (domain.WebhookSubscription, error) {
+	subscription, err := s.repo.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return domain.WebhookSubscription{}, errors.Wrap(err, "getting webhook subscription error")
+	}
+
+	if subscription.UserID != userID {
+		return domain.WebhookSubscription{}, errors.Wrap(domain.ErrWebhookNotFound, "webhook subscription owner check error")
+	}
+
+	return subscription, nil
+}
+
+// DeleteSubscription deletes the webhook subscription of the user, pending deliveries are dropped.
+func (s *Webhooks) DeleteSubscription(ctx context.Context, userID, subscriptionID int) error {
+	if _, err := s.GetSubscription(ctx, userID, subscriptionID); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteSubscription(ctx, subscriptionID); err != nil {
+		return errors.Wrap(err, "webhook subscription deletion error")
+	}
+
+	return nil
+}
+
+// GetDeliveries returns the latest deliveries of the webhook subscription of the user.
+func (s *Webhooks) GetDeliveries(ctx context.Context, userID, subscriptionID int) ([]domain.WebhookDelivery, error) {
+	if _, err := s.GetSubscription(ctx, userID, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.repo.GetDeliveries(ctx, subscriptionID, webhookDeliveriesLimit)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting webhook deliveries error")
+	}
+
+	return deliveries, nil
+}
+
+// GetDelivery returns the delivery of the webhook subscription of the user with its attempts.
+func (s *Webhooks) GetDelivery(ctx context.Context, userID, subscriptionID, deliveryID int) (domain.WebhookDelivery, []domain.WebhookDeliveryAttempt, error) {
+	delivery, err := s.getDelivery(ctx, userID, subscriptionID, deliveryID)
+	if err != nil {
+		return domain.WebhookDelivery{}, nil, err
+	}
+
+	attempts, err := s.repo.GetDeliveryAttempts(ctx, deliveryID)
+	if err != nil {
+		return domain.WebhookDelivery{}, nil, errors.Wrap(err, "getting webhook delivery attempts error")
+	}
+
+	return delivery, attempts, nil
+}
+
+// Redeliver queues the delivery of the webhook subscription of the user again, whatever its status is.
+// It is sent by the next dispatch with a fresh attempts budget.
+func (s *Webhooks) Redeliver(ctx context.Context, userID, subscriptionID, deliveryID int) error {
+	if _, err := s.getDelivery(ctx, userID, subscriptionID, deliveryID); err != nil {
+		return err
+	}
+
+	if err := s.repo.Redeliver(ctx, deliveryID); err != nil {
+		return errors.Wrap(err, "requeuing webhook delivery error")
+	}
+
+	return nil
+}
+
+// getDelivery returns the delivery after checking it belongs to the webhook subscription of the user.
+func (s *Webhooks) getDelivery(ctx context.Context, userID, subscriptionID, deliveryID int) (domain.WebhookDelivery, error) {
+	if _, err := s.GetSubscription(ctx, userID, subscriptionID); err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	delivery, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return domain.WebhookDelivery{}, errors.Wrap(err, "getting webhook delivery error")
+	}
+
+	if delivery.SubscriptionID != subscriptionID {
+		return domain.WebhookDelivery{}, errors.Wrap(domain.ErrWebhookDeliveryNotFound, "webhook delivery subscription check error")
+	}
+
+	return delivery, nil
+}
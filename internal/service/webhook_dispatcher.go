@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/lukinairina90/banking_backend/pkg/webhook"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// classes of the failed webhook attempts stored instead of the errors, which would tell the user about the network of the service
+	webhookErrorStatus    = "unexpected response status"
+	webhookErrorTimeout   = "request timed out"
+	webhookErrorForbidden = "address is not public"
+	webhookErrorRequest   = "request failed"
+
+	// headers of the webhook requests
+	webhookIDHeader        = "X-Webhook-ID"
+	webhookEventHeader     = "X-Webhook-Event"
+	webhookTimestampHeader = "X-Webhook-Timestamp"
+	webhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// WebhookRetryPolicy retries of the failed webhook deliveries.
+// A failed attempt is retried after BaseBackoff doubled per failed attempt up to MaxBackoff,
+// the delivery fails after MaxAttempts attempts.
+type WebhookRetryPolicy struct {
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// WebhookDispatcher sends the queued webhook deliveries to the subscribed URLs.
+type WebhookDispatcher struct {
+	repo      WebhookRepository
+	sender    WebhookSender
+	batchSize int
+	timeout   time.Duration
+	retry     WebhookRetryPolicy
+}
+
+// NewWebhookDispatcher constructor for WebhookDispatcher.
+// Every dispatch sends at most batchSize deliveries, timeout is the time a single request is allowed to take.
+func NewWebhookDispatcher(repo WebhookRepository, sender WebhookSender, batchSize int, timeout time.Duration, retry WebhookRetryPolicy) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		repo:      repo,
+		sender:    sender,
+		batchSize: batchSize,
+		timeout:   timeout,
+		retry:     retry,
+	}
+}
+
+// Run dispatches due webhook deliveries every interval until the context is done.
+func (d WebhookDispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := d.Dispatch(ctx); err != nil {
+				logrus.WithError(err).
+					WithFields(logrus.Fields{
+						"layer":   "service",
+						"service": "WebhookDispatcher",
+						"method":  "Run",
+					}).
+					Error("dispatching webhook deliveries error")
+			}
+		}
+	}
+}
+
+// Dispatch sends the due deliveries concurrently and returns the number of the sent ones.
+// The deliveries are claimed for twice the request timeout, so a delivery of a stopped process is picked up again later.
+// Every attempt is recorded, a 2xx response completes the delivery, otherwise it is retried with exponential backoff.
+func (d WebhookDispatcher) Dispatch(ctx context.Context) (int, error) {
+	dispatches, err := d.repo.ClaimDueDeliveries(ctx, d.batchSize, d.timeout*2)
+	if err != nil {
+		return 0, errors.Wrap(err, "claiming due webhook deliveries error")
+	}
+
+	var wg sync.WaitGroup
+	for _, dispatch := range dispatches {
+		wg.Add(1)
+		go func(dispatch domain.WebhookDispatch) {
+			defer wg.Done()
+
+			if err := d.deliver(ctx, dispatch); err != nil {
+				logrus.WithError(err).
+					WithFields(logrus.Fields{
+						"layer":       "service",
+						"service":     "WebhookDispatcher",
+						"method":      "Dispatch",
+						"delivery_id": dispatch.Delivery.ID,
+					}).
+					Error("webhook delivery error")
+			}
+		}(dispatch)
+	}
+	wg.Wait()
+
+	return len(dispatches), nil
+}
+
+// webhookPayload body of the webhook request.
+type webhookPayload struct {
+	ID       int            `json:"id"`
+	Type     string         `json:"type"`
+	UserID   int            `json:"user_id,omitempty"`
+	Metadata map[string]any `json:"metadata"`
+	Time     time.Time      `json:"time"`
+}
+
+// deliver makes a single attempt of the delivery and saves its outcome.
+func (d WebhookDispatcher) deliver(ctx context.Context, dispatch domain.WebhookDispatch) error {
+	body, err := json.Marshal(webhookPayload{
+		ID:       dispatch.Event.ID,
+		Type:     dispatch.Event.Type.String(),
+		UserID:   dispatch.Event.UserID,
+		Metadata: dispatch.Event.Metadata,
+		Time:     dispatch.Event.DateTime,
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshaling webhook payload error")
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	headers := map[string]string{
+		webhookIDHeader:        strconv.Itoa(dispatch.Delivery.ID),
+		webhookEventHeader:     dispatch.Event.Type.String(),
+		webhookTimestampHeader: timestamp,
+		webhookSignatureHeader: "sha256=" + signWebhook(dispatch.Secret, timestamp, body),
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	start := time.Now()
+	statusCode, sendErr := d.sender.Send(sendCtx, dispatch.URL, headers, body)
+	attempt := domain.WebhookDeliveryAttempt{
+		DeliveryID: dispatch.Delivery.ID,
+		StatusCode: statusCode,
+		Duration:   time.Since(start),
+	}
+
+	if sendErr == nil && (statusCode < 200 || statusCode > 299) {
+		sendErr = fmt.Errorf("unexpected response status %d", statusCode)
+	}
+
+	if sendErr != nil {
+		attempt.Error = webhookErrorClass(statusCode, sendErr)
+
+		logrus.WithError(sendErr).WithFields(logrus.Fields{
+			"layer":       "service",
+			"service":     "WebhookDispatcher",
+			"method":      "deliver",
+			"delivery_id": dispatch.Delivery.ID,
+		}).Warn("webhook delivery attempt failed")
+	}
+
+	if err := d.repo.CreateDeliveryAttempt(ctx, attempt); err != nil {
+		return errors.Wrap(err, "recording webhook delivery attempt error")
+	}
+
+	delivery := dispatch.Delivery
+	delivery.Attempts++
+	delivery.LastError = attempt.Error
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = domain.WebhookDeliverySucceeded
+	case delivery.Attempts >= d.retry.MaxAttempts:
+		delivery.Status = domain.WebhookDeliveryFailed
+	default:
+		delivery.Status = domain.WebhookDeliveryPending
+		delivery.NextAttemptAt = time.Now().Add(d.backoff(delivery.Attempts))
+	}
+
+	if err := d.repo.UpdateDelivery(ctx, delivery); err != nil {
+		return errors.Wrap(err, "updating webhook delivery error")
+	}
+
+	return nil
+}
+
+// webhookErrorClass returns the class of the failed attempt shown to the user.
+func webhookErrorClass(statusCode int, err error) string {
+	var netErr net.Error
+	switch {
+	case statusCode != 0:
+		return fmt.Sprintf("%s %d", webhookErrorStatus, statusCode)
+	case errors.Is(err, webhook.ErrForbiddenAddress):
+		return webhookErrorForbidden
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return webhookErrorTimeout
+	default:
+		return webhookErrorRequest
+	}
+}
+
+// backoff returns the delay of the next attempt after the number of failed attempts.
+func (d WebhookDispatcher) backoff(attempts int) time.Duration {
+	delay := d.retry.BaseBackoff
+	for i := 1; i < attempts && delay < d.retry.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > d.retry.MaxBackoff {
+		return d.retry.MaxBackoff
+	}
+
+	return delay
+}
+
+// signWebhook returns the hex encoded HMAC-SHA256 of the timestamp and the body joined by a dot, keyed by the subscription secret.
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/lukinairina90/banking_backend/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+var testWebhookRetryPolicy = WebhookRetryPolicy{
+	MaxAttempts: 3,
+	BaseBackoff: time.Minute,
+	MaxBackoff:  time.Minute * 3,
+}
+
+func TestWebhookDispatcher_Dispatch(t *testing.T) {
+	controller := gomock.NewController(t)
+	webhookRepositoryMock := NewMockWebhookRepository(controller)
+	webhookSenderMock := NewMockWebhookSender(controller)
+
+	ctx := context.Background()
+	timeout := time.Second * 10
+	testError := errors.New("test error")
+
+	newDispatch := func(attempts int) domain.WebhookDispatch {
+		return domain.WebhookDispatch{
+			Delivery: domain.WebhookDelivery{ID: 100, SubscriptionID: 10, EventID: 1000, Status: domain.WebhookDeliveryPending, Attempts: attempts},
+			URL:      "https://example.com/hooks",
+			Secret:   "secret",
+			Event: domain.Event{
+				ID:       1000,
+				UserID:   1,
+				Type:     domain.AccountCreatedEvent,
+				Metadata: map[string]any{"account_id": float64(5)},
+				DateTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			},
+		}
+	}
+	claim := func(dispatches ...domain.WebhookDispatch) {
+		webhookRepositoryMock.EXPECT().ClaimDueDeliveries(gomock.Eq(ctx), gomock.Eq(50), gomock.Eq(timeout*2)).Return(dispatches, nil)
+	}
+
+	tests := []struct {
+		name          string
+		configureMock func()
+		want          int
+		wantErr       error
+	}{
+		{
+			name: "claim_error",
+			configureMock: func() {
+				webhookRepositoryMock.EXPECT().ClaimDueDeliveries(gomock.Eq(ctx), gomock.Any(), gomock.Any()).Return(nil, testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "nothing_due",
+			configureMock: func() {
+				claim()
+			},
+		},
+		{
+			name: "signed_delivery_succeeded",
+			configureMock: func() {
+				claim(newDispatch(0))
+				webhookSenderMock.EXPECT().Send(gomock.Any(), gomock.Eq("https://example.com/hooks"), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, headers map[string]string, body []byte) (int, error) {
+						mac := hmac.New(sha256.New, []byte("secret"))
+						mac.Write([]byte(headers[webhookTimestampHeader] + "." + string(body)))
+						assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), headers[webhookSignatureHeader])
+						assert.Equal(t, "100", headers[webhookIDHeader])
+						assert.Equal(t, domain.AccountCreatedEvent.String(), headers[webhookEventHeader])
+
+						var payload map[string]any
+						assert.NoError(t, json.Unmarshal(body, &payload))
+						assert.Equal(t, map[string]any{
+							"id":       float64(1000),
+							"type":     domain.AccountCreatedEvent.String(),
+							"user_id":  float64(1),
+							"metadata": map[string]any{"account_id": float64(5)},
+							"time":     "2024-01-02T03:04:05Z",
+						}, payload)
+
+						return http.StatusNoContent, nil
+					})
+				webhookRepositoryMock.EXPECT().CreateDeliveryAttempt(gomock.Eq(ctx), gomock.Any()).DoAndReturn(func(_ context.Context, attempt domain.WebhookDeliveryAttempt) error {
+					assert.Equal(t, 100, attempt.DeliveryID)
+					assert.Equal(t, http.StatusNoContent, attempt.StatusCode)
+					assert.Empty(t, attempt.Error)
+					return nil
+				})
+				webhookRepositoryMock.EXPECT().UpdateDelivery(gomock.Eq(ctx), gomock.Any()).DoAndReturn(func(_ context.Context, delivery domain.WebhookDelivery) error {
+					assert.Equal(t, domain.WebhookDeliverySucceeded, delivery.Status)
+					assert.Equal(t, 1, delivery.Attempts)
+					return nil
+				})
+			},
+			want: 1,
+		},
+		{
+			name: "failed_attempt_retried_with_backoff",
+			configureMock: func() {
+				claim(newDispatch(1))
+				webhookSenderMock.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(http.StatusInternalServerError, nil)
+				webhookRepositoryMock.EXPECT().CreateDeliveryAttempt(gomock.Eq(ctx), gomock.Any()).DoAndReturn(func(_ context.Context, attempt domain.WebhookDeliveryAttempt) error {
+					assert.Equal(t, http.StatusInternalServerError, attempt.StatusCode)
+					assert.Equal(t, "unexpected response status 500", attempt.Error)
+					return nil
+				})
+				webhookRepositoryMock.EXPECT().UpdateDelivery(gomock.Eq(ctx), gomock.Any()).DoAndReturn(func(_ context.Context, delivery domain.WebhookDelivery) error {
+					assert.Equal(t, domain.WebhookDeliveryPending, delivery.Status)
+					assert.Equal(t, 2, delivery.Attempts)
+					assert.Equal(t, "unexpected response status 500", delivery.LastError)
+					// the second failed attempt doubles the base backoff
+					assert.WithinDuration(t, time.Now().Add(time.Minute*2), delivery.NextAttemptAt, time.Second*5)
+					return nil
+				})
+			},
+			want: 1,
+		},
+		{
+			name: "internal_address_attempt_failed",
+			configureMock: func() {
+				claim(newDispatch(1))
+				webhookSenderMock.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(0, fmt.Errorf("dial tcp 10.0.0.1:6379: %w", webhook.ErrForbiddenAddress))
+				webhookRepositoryMock.EXPECT().CreateDeliveryAttempt(gomock.Eq(ctx), gomock.Any()).DoAndReturn(func(_ context.Context, attempt domain.WebhookDeliveryAttempt) error {
+					assert.Equal(t, webhookErrorForbidden, attempt.Error)
+					return nil
+				})
+				webhookRepositoryMock.EXPECT().UpdateDelivery(gomock.Eq(ctx), gomock.Any()).Return(nil)
+			},
+			want: 1,
+		},
+		{
+			name: "timed_out_attempt_failed",
+			configureMock: func() {
+				claim(newDispatch(1))
+				webhookSenderMock.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(0, fmt.Errorf("sending webhook request error: %w", context.DeadlineExceeded))
+				webhookRepositoryMock.EXPECT().CreateDeliveryAttempt(gomock.Eq(ctx), gomock.Any()).DoAndReturn(func(_ context.Context, attempt domain.WebhookDeliveryAttempt) error {
+					assert.Equal(t, webhookErrorTimeout, attempt.Error)
+					return nil
+				})
+				webhookRepositoryMock.EXPECT().UpdateDelivery(gomock.Eq(ctx), gomock.Any()).Return(nil)
+			},
+			want: 1,
+		},
+		{
+			name: "last_attempt_failed",
+			configureMock: func() {
+				claim(newDispatch(2))
+				webhookSenderMock.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(0, testError)
+				webhookRepositoryMock.EXPECT().CreateDeliveryAttempt(gomock.Eq(ctx), gomock.Any()).DoAndReturn(func(_ context.Context, attempt domain.WebhookDeliveryAttempt) error {
+					assert.Equal(t, 0, attempt.StatusCode)
+					assert.Equal(t, webhookErrorRequest, attempt.Error)
+					return nil
+				})
+				webhookRepositoryMock.EXPECT().UpdateDelivery(gomock.Eq(ctx), gomock.Any()).DoAndReturn(func(_ context.Context, delivery domain.WebhookDelivery) error {
+					assert.Equal(t, domain.WebhookDeliveryFailed, delivery.Status)
+					assert.Equal(t, 3, delivery.Attempts)
+					return nil
+				})
+			},
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			d := NewWebhookDispatcher(webhookRepositoryMock, webhookSenderMock, 50, timeout, testWebhookRetryPolicy)
+
+			got, err := d.Dispatch(ctx)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWebhookDispatcher_backoff(t *testing.T) {
+	d := NewWebhookDispatcher(nil, nil, 50, time.Second, testWebhookRetryPolicy)
+
+	assert.Equal(t, time.Minute, d.backoff(1))
+	assert.Equal(t, time.Minute*2, d.backoff(2))
+	assert.Equal(t, time.Minute*3, d.backoff(3))
+	assert.Equal(t, time.Minute*3, d.backoff(10))
+}
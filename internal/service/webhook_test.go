@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/lukinairina90/banking_backend/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhooks_CreateSubscription(t *testing.T) {
+	controller := gomock.NewController(t)
+	webhookRepositoryMock := NewMockWebhookRepository(controller)
+	webhookAddressCheckerMock := NewMockWebhookAddressChecker(controller)
+
+	ctx := context.Background()
+	userID := 1
+	testError := errors.New("test error")
+
+	tests := []struct {
+		name          string
+		inp           domain.WebhookSubscriptionInput
+		configureMock func()
+		wantErr       error
+	}{
+		{
+			name:          "relative_url_error",
+			inp:           domain.WebhookSubscriptionInput{URL: "/hooks"},
+			configureMock: func() {},
+			wantErr:       domain.ErrInvalidWebhookURL,
+		},
+		{
+			name:          "unsupported_scheme_error",
+			inp:           domain.WebhookSubscriptionInput{URL: "ftp://example.com/hooks"},
+			configureMock: func() {},
+			wantErr:       domain.ErrInvalidWebhookURL,
+		},
+		{
+			name: "internal_address_error",
+			inp:  domain.WebhookSubscriptionInput{URL: "http://169.254.169.254/latest/meta-data"},
+			configureMock: func() {
+				webhookAddressCheckerMock.EXPECT().CheckHost(gomock.Eq(ctx), gomock.Eq("169.254.169.254")).Return(fmt.Errorf("checking address error: %w", webhook.ErrForbiddenAddress))
+			},
+			wantErr: domain.ErrForbiddenWebhookURL,
+		},
+		{
+			name: "unresolved_host_error",
+			inp:  domain.WebhookSubscriptionInput{URL: "https://unknown.invalid/hooks"},
+			configureMock: func() {
+				webhookAddressCheckerMock.EXPECT().CheckHost(gomock.Eq(ctx), gomock.Eq("unknown.invalid")).Return(testError)
+			},
+			wantErr: domain.ErrInvalidWebhookURL,
+		},
+		{
+			name: "unsupported_event_type_error",
+			inp:  domain.WebhookSubscriptionInput{URL: "https://example.com/hooks", EventTypes: []string{"UNKNOWN"}},
+			configureMock: func() {
+				webhookAddressCheckerMock.EXPECT().CheckHost(gomock.Eq(ctx), gomock.Eq("example.com")).Return(nil)
+			},
+			wantErr: domain.ErrUnsupportedEventType,
+		},
+		{
+			name: "repository_error",
+			inp:  domain.WebhookSubscriptionInput{URL: "https://example.com/hooks"},
+			configureMock: func() {
+				webhookAddressCheckerMock.EXPECT().CheckHost(gomock.Eq(ctx), gomock.Eq("example.com")).Return(nil)
+				webhookRepositoryMock.EXPECT().CreateSubscription(gomock.Eq(ctx), gomock.Any()).Return(domain.WebhookSubscription{}, testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name: "success",
+			inp:  domain.WebhookSubscriptionInput{URL: "https://example.com/hooks", EventTypes: []string{domain.AccountCreatedEvent.String()}},
+			configureMock: func() {
+				webhookAddressCheckerMock.EXPECT().CheckHost(gomock.Eq(ctx), gomock.Eq("example.com")).Return(nil)
+				webhookRepositoryMock.EXPECT().CreateSubscription(gomock.Eq(ctx), gomock.Any()).DoAndReturn(func(_ context.Context, subscription domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+					assert.Equal(t, userID, subscription.UserID)
+					assert.Equal(t, "https://example.com/hooks", subscription.URL)
+					assert.Equal(t, domain.AccountCreatedEvent, subscription.EventTypes[0])
+					assert.Len(t, subscription.Secret, webhookSecretSize*2)
+
+					subscription.ID = 1
+					return subscription, nil
+				})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s := NewWebhooks(webhookRepositoryMock, webhookAddressCheckerMock)
+
+			got, err := s.CreateSubscription(ctx, userID, tt.inp)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, 1, got.ID)
+			assert.NotEmpty(t, got.Secret)
+		})
+	}
+}
+
+func TestWebhooks_Redeliver(t *testing.T) {
+	controller := gomock.NewController(t)
+	webhookRepositoryMock := NewMockWebhookRepository(controller)
+
+	ctx := context.Background()
+	userID := 1
+	subscription := domain.WebhookSubscription{ID: 10, UserID: userID, URL: "https://example.com/hooks"}
+	delivery := domain.WebhookDelivery{ID: 100, SubscriptionID: subscription.ID, Status: domain.WebhookDeliveryFailed}
+	testError := errors.New("test error")
+
+	tests := []struct {
+		name          string
+		userID        int
+		deliveryID    int
+		configureMock func()
+		wantErr       error
+	}{
+		{
+			name:       "subscription_not_found_error",
+			userID:     userID,
+			deliveryID: delivery.ID,
+			configureMock: func() {
+				webhookRepositoryMock.EXPECT().GetSubscription(gomock.Eq(ctx), gomock.Eq(subscription.ID)).Return(domain.WebhookSubscription{}, domain.ErrWebhookNotFound)
+			},
+			wantErr: domain.ErrWebhookNotFound,
+		},
+		{
+			name:       "subscription_of_other_user_error",
+			userID:     2,
+			deliveryID: delivery.ID,
+			configureMock: func() {
+				webhookRepositoryMock.EXPECT().GetSubscription(gomock.Eq(ctx), gomock.Eq(subscription.ID)).Return(subscription, nil)
+			},
+			wantErr: domain.ErrWebhookNotFound,
+		},
+		{
+			name:       "user_subscription_is_not_admin_subscription_error",
+			deliveryID: delivery.ID,
+			configureMock: func() {
+				webhookRepositoryMock.EXPECT().GetSubscription(gomock.Eq(ctx), gomock.Eq(subscription.ID)).Return(subscription, nil)
+			},
+			wantErr: domain.ErrWebhookNotFound,
+		},
+		{
+			name:       "delivery_of_other_subscription_error",
+			userID:     userID,
+			deliveryID: 200,
+			configureMock: func() {
+				webhookRepositoryMock.EXPECT().GetSubscription(gomock.Eq(ctx), gomock.Eq(subscription.ID)).Return(subscription, nil)
+				webhookRepositoryMock.EXPECT().GetDelivery(gomock.Eq(ctx), gomock.Eq(200)).Return(domain.WebhookDelivery{ID: 200, SubscriptionID: 20}, nil)
+			},
+			wantErr: domain.ErrWebhookDeliveryNotFound,
+		},
+		{
+			name:       "repository_error",
+			userID:     userID,
+			deliveryID: delivery.ID,
+			configureMock: func() {
+				webhookRepositoryMock.EXPECT().GetSubscription(gomock.Eq(ctx), gomock.Eq(subscription.ID)).Return(subscription, nil)
+				webhookRepositoryMock.EXPECT().GetDelivery(gomock.Eq(ctx), gomock.Eq(delivery.ID)).Return(delivery, nil)
+				webhookRepositoryMock.EXPECT().Redeliver(gomock.Eq(ctx), gomock.Eq(delivery.ID)).Return(testError)
+			},
+			wantErr: testError,
+		},
+		{
+			name:       "success",
+			userID:     userID,
+			deliveryID: delivery.ID,
+			configureMock: func() {
+				webhookRepositoryMock.EXPECT().GetSubscription(gomock.Eq(ctx), gomock.Eq(subscription.ID)).Return(subscription, nil)
+				webhookRepositoryMock.EXPECT().GetDelivery(gomock.Eq(ctx), gomock.Eq(delivery.ID)).Return(delivery, nil)
+				webhookRepositoryMock.EXPECT().Redeliver(gomock.Eq(ctx), gomock.Eq(delivery.ID)).Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configureMock()
+
+			s := NewWebhooks(webhookRepositoryMock, nil)
+
+			err := s.Redeliver(ctx, tt.userID, subscription.ID, tt.deliveryID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
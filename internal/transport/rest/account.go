@@ -1,37 +1,42 @@
 package rest
 
 import (
-	"database/sql"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lukinairina90/banking_backend/internal/domain"
 	"github.com/lukinairina90/banking_backend/internal/transport/rest/messages"
-	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
 // Account transport layer struct.
 type Account struct {
-	accService AccountService
+	accService         AccountService
+	idempotencyService IdempotencyService
 }
 
 // NewAccount constructor for Account.
-func NewAccount(accService AccountService) *Account {
-	return &Account{accService: accService}
+func NewAccount(accService AccountService, idempotencyService IdempotencyService) *Account {
+	return &Account{
+		accService:         accService,
+		idempotencyService: idempotencyService,
+	}
 }
 
 // InjectRoutes injects routes to global router.
 func (t Account) InjectRoutes(r *gin.Engine, middlewares ...gin.HandlerFunc) {
+	idempotency := IdempotencyMiddleware(t.idempotencyService)
+
 	accounts := r.Group("/account").Use(middlewares...)
 	{
 		accounts.POST("/", t.createAccount)
 		accounts.GET("/", t.getAccountsList)
 		accounts.GET("/:id", t.getAccount)
 		accounts.DELETE("/:id", t.deleteAccount)
-		accounts.POST("/:id/deposit", t.depositAccount)
-		accounts.POST("/:id/transfer", t.transferAccount)
+		accounts.POST("/:id/deposit", idempotency, t.depositAccount)
+		accounts.POST("/:id/withdraw", idempotency, t.withdrawAccount)
+		accounts.POST("/:id/transfer", idempotency, t.transferAccount)
 		accounts.POST("/:id/block", t.blockAccount)
 		accounts.POST("/:id/unblock", t.unblockAccount)
 	}
@@ -54,18 +59,11 @@ func (t Account) createAccount(ctx *gin.Context) {
 
 	domainAccount, err := t.accService.Create(ctx, userID, req.CurrencyID)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("account creation error", err))
+		abortWithError(ctx, "account creation error", err)
 		return
 	}
 
-	messageAccount := messages.Account{
-		ID:         domainAccount.ID,
-		Iban:       domainAccount.Iban,
-		UserID:     domainAccount.UserID,
-		CurrencyID: domainAccount.CurrencyID,
-		Blocked:    domainAccount.Blocked,
-		Amount:     domainAccount.Amount,
-	}
+	messageAccount := messages.NewAccount(domainAccount)
 
 	ctx.JSON(http.StatusCreated, messageAccount)
 }
@@ -119,21 +117,13 @@ func (t Account) getAccountsList(ctx *gin.Context) {
 
 	domainAccountsList, err := t.accService.GetAccountsList(ctx, userID, pag, orderings)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting account list error", err))
+		abortWithError(ctx, "getting account list error", err)
 		return
 	}
 
 	messageAccountsList := make([]messages.Account, 0)
 	for _, account := range domainAccountsList {
-		messageAccount := messages.Account{
-			ID:         account.ID,
-			Iban:       account.Iban,
-			UserID:     account.UserID,
-			CurrencyID: account.CurrencyID,
-			Blocked:    account.Blocked,
-			Amount:     account.Amount,
-		}
-		messageAccountsList = append(messageAccountsList, messageAccount)
+		messageAccountsList = append(messageAccountsList, messages.NewAccount(account))
 	}
 	ctx.JSON(http.StatusOK, messageAccountsList)
 }
@@ -156,24 +146,11 @@ func (t Account) getAccount(ctx *gin.Context) {
 
 	domainAccount, err := t.accService.GetAccount(ctx, id, userID)
 	if err != nil {
-		switch {
-		case errors.Is(err, sql.ErrNoRows):
-			ctx.AbortWithStatusJSON(http.StatusNotFound, NewNotFoundError("account not found", err))
-			return
-		default:
-			ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting account error", err))
-			return
-		}
+		abortWithError(ctx, "getting account error", err)
+		return
 	}
 
-	messageAccount := messages.Account{
-		ID:         domainAccount.ID,
-		Iban:       domainAccount.Iban,
-		UserID:     domainAccount.UserID,
-		CurrencyID: domainAccount.CurrencyID,
-		Blocked:    domainAccount.Blocked,
-		Amount:     domainAccount.Amount,
-	}
+	messageAccount := messages.NewAccount(domainAccount)
 
 	ctx.JSON(http.StatusOK, messageAccount)
 }
@@ -188,7 +165,7 @@ func (t Account) deleteAccount(ctx *gin.Context) {
 	}
 
 	if err := t.accService.DeleteAccount(ctx, id); err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("deletion account error", err))
+		abortWithError(ctx, "deletion account error", err)
 		return
 	}
 
@@ -212,7 +189,37 @@ func (t Account) depositAccount(ctx *gin.Context) {
 
 	err = t.accService.DepositAccount(ctx, id, req.Amount)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("deposit founds into account error", err))
+		abortWithError(ctx, "deposit founds into account error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// withdrawAccount gin handler function for withdraw money account endpoint.
+// [POST] /account/:id/withdraw
+func (t Account) withdrawAccount(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("wrong \"id\" request param", err))
+		return
+	}
+
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting current user error", err))
+		return
+	}
+
+	var req messages.WithdrawAccountRequestBody
+	if err = ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("validation request body error", err))
+		return
+	}
+
+	err = t.accService.WithdrawAccount(ctx, id, userID, req.Amount)
+	if err != nil {
+		abortWithError(ctx, "withdrawing founds error", err)
 		return
 	}
 
@@ -240,9 +247,10 @@ func (t Account) transferAccount(ctx *gin.Context) {
 		return
 	}
 
-	err = t.accService.TransferAccount(ctx, id, userID, req.Amount, req.Iban)
+	// the code is sent in a header, so it does not change the idempotency fingerprint of the body
+	err = t.accService.TransferAccount(ctx, id, userID, req.Amount, req.Iban, req.QuoteID, ctx.GetHeader(TwoFactorCodeHeaderName))
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("transferring founds error", err))
+		abortWithError(ctx, "transferring founds error", err)
 		return
 	}
 
@@ -266,13 +274,14 @@ func (t Account) blockAccount(ctx *gin.Context) {
 
 	err = t.accService.BlockAccount(ctx, accountID, userID)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("blocking user error", err))
+		abortWithError(ctx, "blocking user error", err)
+		return
 	}
 
 	ctx.JSON(http.StatusNoContent, nil)
 }
 
-// blockAccount gin handler function for unblock account endpoint.
+// unblockAccount gin handler function for unblock account endpoint, the admin unblocks the account of any user.
 // [POST] /account/:id/unblock
 func (t Account) unblockAccount(ctx *gin.Context) {
 	accountID, err := strconv.Atoi(ctx.Param("id"))
@@ -281,15 +290,16 @@ func (t Account) unblockAccount(ctx *gin.Context) {
 		return
 	}
 
-	userID, err := getUserIDFromContext(ctx)
+	adminID, err := getUserIDFromContext(ctx)
 	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting current user error", err))
 		return
 	}
 
-	err = t.accService.UnblockAccount(ctx, accountID, userID)
+	err = t.accService.UnblockAccount(ctx, accountID, adminID)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("unblocking account error", err))
+		abortWithError(ctx, "unblocking account error", err)
+		return
 	}
 
 	ctx.JSON(http.StatusNoContent, nil)
@@ -0,0 +1,32 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lukinairina90/banking_backend/internal/transport/rest/messages"
+)
+
+// AdminCache transport layer struct of the cache statistics.
+type AdminCache struct {
+	statsService CacheStatsService
+}
+
+// NewAdminCache constructor for AdminCache.
+func NewAdminCache(statsService CacheStatsService) *AdminCache {
+	return &AdminCache{statsService: statsService}
+}
+
+// InjectRoutes injects routes to global router.
+func (t AdminCache) InjectRoutes(r *gin.Engine, middlewares ...gin.HandlerFunc) {
+	cache := r.Group("/admin/cache").Use(middlewares...)
+	{
+		cache.GET("/stats", t.getStats)
+	}
+}
+
+// getStats gin handler function for get cache statistics endpoint.
+// [GET] /admin/cache/stats
+func (t AdminCache) getStats(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, messages.NewCacheStats(t.statsService.GetStats(ctx)))
+}
@@ -0,0 +1,154 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/lukinairina90/banking_backend/internal/transport/rest/messages"
+)
+
+// maxUsersPerPage maximum number of users returned on a page of the users list.
+const maxUsersPerPage = 100
+
+// AdminUsers transport layer struct of the admin users directory.
+type AdminUsers struct {
+	directoryService UserDirectoryService
+}
+
+// NewAdminUsers constructor for AdminUsers.
+func NewAdminUsers(directoryService UserDirectoryService) *AdminUsers {
+	return &AdminUsers{directoryService: directoryService}
+}
+
+// InjectRoutes injects routes to global router.
+func (t AdminUsers) InjectRoutes(r *gin.Engine, middlewares ...gin.HandlerFunc) {
+	users := r.Group("/admin/users").Use(middlewares...)
+	{
+		users.GET("", t.getUsersList)
+		users.GET("/:id", t.getUserDetails)
+	}
+}
+
+// getUsersList gin handler function for get users list endpoint.
+// [GET] /admin/users
+func (t AdminUsers) getUsersList(ctx *gin.Context) {
+	// http://localhost:8080/admin/users?email=example.com&blocked=false&role=user&registered-from=2023-01-01&order=registered_at:desc&page=1&per-page=20
+
+	query := ctx.Request.URL.Query()
+
+	filter := domain.UserFilter{
+		Email: query.Get("email"),
+		Name:  query.Get("name"),
+		Role:  query.Get("role"),
+	}
+
+	if sBlocked := query.Get("blocked"); sBlocked != "" {
+		blocked, err := strconv.ParseBool(sBlocked)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("wrong \"blocked\" query param", err))
+			return
+		}
+
+		filter.Blocked = &blocked
+	}
+
+	var err error
+	if filter.RegisteredFrom, err = parseDateParam(query.Get("registered-from")); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("wrong \"registered-from\" query param", err))
+		return
+	}
+
+	if filter.RegisteredTo, err = parseDateParam(query.Get("registered-to")); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("wrong \"registered-to\" query param", err))
+		return
+	}
+
+	pag := domain.Paginator{
+		Page:    1,
+		PerPage: 5,
+	}
+
+	if sPage := query.Get("page"); sPage != "" {
+		page, err := strconv.Atoi(sPage)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("wrong \"page\" query param", err))
+			return
+		}
+
+		if page < 1 {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("\"page\" query param must be positive", nil))
+			return
+		}
+
+		pag.Page = page
+	}
+
+	if sPerPage := query.Get("per-page"); sPerPage != "" {
+		perPage, err := strconv.Atoi(sPerPage)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("wrong \"per-page\" query param", err))
+			return
+		}
+
+		if perPage < 1 || perPage > maxUsersPerPage {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError(fmt.Sprintf("\"per-page\" query param must be between 1 and %d", maxUsersPerPage), nil))
+			return
+		}
+
+		pag.PerPage = perPage
+	}
+
+	orderings, err := buildOrderingMessage(query.Get("order"), []string{"id", "email", "name", "surname", "registered_at"})
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("wrong ordering query param", err))
+		return
+	}
+
+	domainUsers, err := t.directoryService.GetUsersList(ctx, filter, pag, orderings)
+	if err != nil {
+		abortWithError(ctx, "getting users list error", err)
+		return
+	}
+
+	list := make([]messages.User, 0, len(domainUsers))
+	for _, user := range domainUsers {
+		list = append(list, messages.NewUser(user))
+	}
+
+	ctx.JSON(http.StatusOK, list)
+}
+
+// getUserDetails gin handler function for get user with accounts, cards and recent events endpoint.
+// [GET] /admin/users/:id
+func (t AdminUsers) getUserDetails(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("wrong \"id\" request param", err))
+		return
+	}
+
+	details, err := t.directoryService.GetUserDetails(ctx, userID)
+	if err != nil {
+		abortWithError(ctx, "getting user details error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, messages.NewUserDetails(details))
+}
+
+// parseDateParam parses the date query param given as 2006-01-02 or RFC 3339, an empty param is the zero time.
+func parseDateParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
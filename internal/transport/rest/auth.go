@@ -1,7 +1,6 @@
 package rest
 
 import (
-	"errors"
 	"net/http"
 	"strconv"
 
@@ -9,14 +8,17 @@ import (
 	"github.com/lukinairina90/banking_backend/internal/transport/rest/messages"
 )
 
+const refreshTokenCookieName = "refresh-token"
+
 // Auth transport layer struct.
 type Auth struct {
-	userService UserService
+	userService         UserService
+	verificationService VerificationService
 }
 
 // NewAuth constructor for Auth.
-func NewAuth(userService UserService) *Auth {
-	return &Auth{userService: userService}
+func NewAuth(userService UserService, verificationService VerificationService) *Auth {
+	return &Auth{userService: userService, verificationService: verificationService}
 }
 
 // InjectRoutes injects routes to global router.
@@ -25,13 +27,28 @@ func (a *Auth) InjectRoutes(r *gin.Engine, middlewares ...gin.HandlerFunc) {
 	{
 		auth.POST("/sign-up", a.signUp)
 		auth.POST("/sign-in", a.signIn)
+		auth.POST("/sign-in/2fa", a.signInTwoFactor)
 		auth.GET("/refresh", a.refresh)
+		auth.POST("/logout", a.logout)
+		auth.GET("/email/verify", a.verifyEmail)
+		auth.POST("/password/forgot", a.forgotPassword)
+		auth.POST("/password/reset", a.resetPassword)
+	}
+
+	// session management requires the user to be authenticated before the permissions are checked
+	sessions := r.Group("/auth").Use(append([]gin.HandlerFunc{a.AuthMiddleware()}, middlewares...)...)
+	{
+		sessions.POST("/logout-all", a.logoutAll)
+		sessions.GET("/sessions", a.getSessions)
+		sessions.POST("/email/verify/resend", a.resendEmailVerification)
 	}
 
-	user := r.Group("/user").Use(middlewares...)
+	// user management is an admin operation, the user must be authenticated before the permissions are checked
+	user := r.Group("/user").Use(append([]gin.HandlerFunc{a.AuthMiddleware()}, middlewares...)...)
 	{
 		user.POST("/:id/block", a.blockUser)
 		user.POST("/:id/unblock", a.unblockUser)
+		user.POST("/:id/clear-lockout", a.clearSignInLockout)
 	}
 }
 
@@ -49,11 +66,16 @@ func (a *Auth) signUp(ctx *gin.Context) {
 
 	err := a.userService.SignUp(ctx, domainInp)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("signing up error", err))
+		abortWithError(ctx, "signing up error", err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, inp)
+	// the request is not echoed back, it carries the password
+	ctx.JSON(http.StatusOK, gin.H{
+		"name":    inp.Name,
+		"surname": inp.Surname,
+		"email":   inp.Email,
+	})
 }
 
 // signIn gin handler function for user login endpoint.
@@ -67,18 +89,43 @@ func (a *Auth) signIn(ctx *gin.Context) {
 
 	domainInp := inp.ToDomain()
 
-	accessToken, refreshToken, err := a.userService.SignIn(ctx, domainInp)
+	result, err := a.userService.SignIn(ctx, domainInp, getDevice(ctx))
 	if err != nil {
-		if errors.Is(err, messages.ErrUserNotFound) {
-			ctx.AbortWithStatusJSON(http.StatusNotFound, NewNotFoundError("user not found", err))
-			return
-		}
+		abortWithError(ctx, "signing in error", err)
+		return
+	}
+
+	if result.ChallengeToken != "" {
+		ctx.JSON(http.StatusOK, gin.H{
+			"two_factor_required": true,
+			"challenge_token":     result.ChallengeToken,
+		})
+		return
+	}
+
+	ctx.SetCookie(refreshTokenCookieName, result.RefreshToken, 3600, "/auth", "localhost", false, true)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"token": result.AccessToken,
+	})
+}
+
+// signInTwoFactor gin handler function for completing the sign in with a two-factor code endpoint.
+// [POST] /auth/sign-in/2fa
+func (a *Auth) signInTwoFactor(ctx *gin.Context) {
+	var inp messages.SignInTwoFactorInput
+	if err := ctx.ShouldBindJSON(&inp); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("validation request body error", err))
+		return
+	}
 
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("signing in error", err))
+	accessToken, refreshToken, err := a.userService.SignInTwoFactor(ctx, inp.ChallengeToken, inp.Code, getDevice(ctx))
+	if err != nil {
+		abortWithError(ctx, "signing in with two-factor code error", err)
 		return
 	}
 
-	ctx.SetCookie("refresh-token", refreshToken, 3600, "/auth", "localhost", false, true)
+	ctx.SetCookie(refreshTokenCookieName, refreshToken, 3600, "/auth", "localhost", false, true)
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"token": accessToken,
@@ -88,25 +135,163 @@ func (a *Auth) signIn(ctx *gin.Context) {
 // refresh gin handler function for refresh the token endpoint.
 // [GET] /auth/refresh
 func (a *Auth) refresh(ctx *gin.Context) {
-	cookie, err := ctx.Cookie("refresh-token")
+	cookie, err := ctx.Cookie(refreshTokenCookieName)
 	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("get cookie from request error", err))
 		return
 	}
 
-	accessToken, refreshToken, err := a.userService.RefreshTokens(ctx, cookie)
+	accessToken, refreshToken, err := a.userService.RefreshTokens(ctx, cookie, getDevice(ctx))
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("refresh token error", err))
+		abortWithError(ctx, "refresh token error", err)
 		return
 	}
 
-	ctx.SetCookie("refresh-token", refreshToken, 3600, "/auth", "localhost", false, true)
+	ctx.SetCookie(refreshTokenCookieName, refreshToken, 3600, "/auth", "localhost", false, true)
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"token": accessToken,
 	})
 }
 
+// logout gin handler function for ending the current session endpoint.
+// [POST] /auth/logout
+func (a *Auth) logout(ctx *gin.Context) {
+	cookie, err := ctx.Cookie(refreshTokenCookieName)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("get cookie from request error", err))
+		return
+	}
+
+	// the access token is optional, when provided it is revoked together with the session
+	accessToken, _ := getTokenFromRequest(ctx)
+
+	if err := a.userService.Logout(ctx, cookie, accessToken); err != nil {
+		abortWithError(ctx, "logout error", err)
+		return
+	}
+
+	ctx.SetCookie(refreshTokenCookieName, "", -1, "/auth", "localhost", false, true)
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// logoutAll gin handler function for ending all sessions of the user endpoint.
+// [POST] /auth/logout-all
+func (a *Auth) logoutAll(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting current user error", err))
+		return
+	}
+
+	if err := a.userService.LogoutAll(ctx, userID); err != nil {
+		abortWithError(ctx, "logout from all sessions error", err)
+		return
+	}
+
+	ctx.SetCookie(refreshTokenCookieName, "", -1, "/auth", "localhost", false, true)
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// getSessions gin handler function for getting active sessions of the user endpoint.
+// [GET] /auth/sessions
+func (a *Auth) getSessions(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting current user error", err))
+		return
+	}
+
+	// the cookie is optional, it only marks the current session
+	cookie, _ := ctx.Cookie(refreshTokenCookieName)
+
+	domainSessions, err := a.userService.GetSessions(ctx, userID, cookie)
+	if err != nil {
+		abortWithError(ctx, "getting sessions error", err)
+		return
+	}
+
+	sessions := make([]messages.Session, 0, len(domainSessions))
+	for _, session := range domainSessions {
+		sessions = append(sessions, messages.NewSession(session))
+	}
+
+	ctx.JSON(http.StatusOK, sessions)
+}
+
+// verifyEmail gin handler function for verifying the email with the token from the verification email endpoint.
+// [GET] /auth/email/verify?token=
+func (a *Auth) verifyEmail(ctx *gin.Context) {
+	token := ctx.Query("token")
+	if token == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("missing \"token\" query param", nil))
+		return
+	}
+
+	if err := a.verificationService.VerifyEmail(ctx, token); err != nil {
+		abortWithError(ctx, "verifying email error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"email_verified": true,
+	})
+}
+
+// resendEmailVerification gin handler function for sending the verification email again endpoint.
+// [POST] /auth/email/verify/resend
+func (a *Auth) resendEmailVerification(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting current user error", err))
+		return
+	}
+
+	if err := a.verificationService.RequestEmailVerification(ctx, userID); err != nil {
+		abortWithError(ctx, "sending email verification error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// forgotPassword gin handler function for sending the password reset email endpoint.
+// The response does not depend on whether the email is registered.
+// [POST] /auth/password/forgot
+func (a *Auth) forgotPassword(ctx *gin.Context) {
+	var inp messages.ForgotPasswordInput
+	if err := ctx.ShouldBindJSON(&inp); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("validation request body error", err))
+		return
+	}
+
+	if err := a.verificationService.ForgotPassword(ctx, inp.Email); err != nil {
+		abortWithError(ctx, "requesting password reset error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, nil)
+}
+
+// resetPassword gin handler function for setting a new password with the token from the password reset email endpoint.
+// [POST] /auth/password/reset
+func (a *Auth) resetPassword(ctx *gin.Context) {
+	var inp messages.ResetPasswordInput
+	if err := ctx.ShouldBindJSON(&inp); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("validation request body error", err))
+		return
+	}
+
+	if err := a.verificationService.ResetPassword(ctx, inp.Token, inp.Password); err != nil {
+		abortWithError(ctx, "resetting password error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
 // blockUser gin handler function to block a user endpoint.
 // [GET] /user/:id/block
 func (a *Auth) blockUser(ctx *gin.Context) {
@@ -124,7 +309,7 @@ func (a *Auth) blockUser(ctx *gin.Context) {
 
 	err = a.userService.BlockUser(ctx, blockUserID, userID)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("blocking user error", err))
+		abortWithError(ctx, "blocking user error", err)
 		return
 	}
 
@@ -142,7 +327,24 @@ func (a *Auth) unblockUser(ctx *gin.Context) {
 
 	err = a.userService.UnblockUser(ctx, userID)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("unblocking user error", err))
+		abortWithError(ctx, "unblocking user error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// clearSignInLockout gin handler function to clear the sign in lockout of a user endpoint.
+// [POST] /user/:id/clear-lockout
+func (a *Auth) clearSignInLockout(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("wrong \"id\" request param", err))
+		return
+	}
+
+	if err := a.userService.ClearSignInLockout(ctx, userID); err != nil {
+		abortWithError(ctx, "clearing sign in lockout error", err)
 		return
 	}
 
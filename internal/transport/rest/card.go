@@ -1,7 +1,6 @@
 package rest
 
 import (
-	"database/sql"
 	"net/http"
 	"strconv"
 
@@ -51,7 +50,7 @@ func (t Card) createCard(ctx *gin.Context) {
 
 	domainCard, err := t.cardService.CreateCard(ctx, accountID, userID)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("card creation error", err))
+		abortWithError(ctx, "card creation error", err)
 		return
 	}
 
@@ -78,7 +77,7 @@ func (t Card) GetCardListByUser(ctx *gin.Context) {
 
 	domainListCards, err := t.cardService.GetCardListUser(ctx, userID)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting cards list error", err))
+		abortWithError(ctx, "getting cards list error", err)
 		return
 	}
 
@@ -115,7 +114,7 @@ func (t Card) getCardListByAccount(ctx *gin.Context) {
 
 	domainListCards, err := t.cardService.GetCardListByAccount(ctx, userID, accountID)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting cards list for account error", err))
+		abortWithError(ctx, "getting cards list for account error", err)
 		return
 	}
 
@@ -158,13 +157,7 @@ func (t Card) getCard(ctx *gin.Context) {
 
 	domainCard, err := t.cardService.GetCard(ctx, cardID, accountID, userID)
 	if err != nil {
-		switch err {
-		case sql.ErrNoRows:
-			ctx.AbortWithStatusJSON(http.StatusNotFound, NewNotFoundError("card not found", err))
-		default:
-			ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting card error", err))
-		}
-
+		abortWithError(ctx, "getting card error", err)
 		return
 	}
 
@@ -2,6 +2,7 @@ package rest
 
 import (
 	"context"
+	"time"
 
 	"github.com/lukinairina90/banking_backend/internal/domain"
 )
@@ -11,22 +12,53 @@ type AccountService interface {
 	GetAccountsList(ctx context.Context, userID int, paginator domain.Paginator, ordering domain.Orderings) ([]domain.Account, error)
 	GetAccount(ctx context.Context, accountID, userID int) (domain.Account, error)
 	DeleteAccount(ctx context.Context, accountID int) error
-	DepositAccount(ctx context.Context, accountID int, amount float64) error
-	TransferAccount(ctx context.Context, fromAccountID, userID int, amount float64, toAccountIban string) error
+	DepositAccount(ctx context.Context, accountID int, amount string) error
+	WithdrawAccount(ctx context.Context, accountID, userID int, amount string) error
+	TransferAccount(ctx context.Context, fromAccountID, userID int, amount string, toAccountIban string, quoteID int, twoFactorCode string) error
 	BlockAccount(ctx context.Context, accountID, userID int) error
-	UnblockAccount(ctx context.Context, accountID, userID int) error
+	UnblockAccount(ctx context.Context, accountID, adminID int) error
 }
 
 type UserService interface {
 	SignUp(ctx context.Context, inp domain.SignUpInput) error
-	SignIn(ctx context.Context, inp domain.SignInInput) (string, string, error)
-	ParseToken(ctx context.Context, token string) (int, int, error)
-	RefreshTokens(ctx context.Context, refreshToken string) (string, string, error)
+	SignIn(ctx context.Context, inp domain.SignInInput, device domain.Device) (domain.SignInResult, error)
+	SignInTwoFactor(ctx context.Context, challengeToken, code string, device domain.Device) (string, string, error)
+	ParseToken(ctx context.Context, token string) (domain.AccessClaims, error)
+	RefreshTokens(ctx context.Context, refreshToken string, device domain.Device) (string, string, error)
+	Logout(ctx context.Context, refreshToken, accessToken string) error
+	LogoutAll(ctx context.Context, userID int) error
+	GetSessions(ctx context.Context, userID int, refreshToken string) ([]domain.Session, error)
 	BlockUser(ctx context.Context, blockUserID, userID int) error
 	UnblockUser(ctx context.Context, userID int) error
+	ClearSignInLockout(ctx context.Context, userID int) error
 	CheckBlockUser(ctx context.Context, userID int) (bool, error)
 }
 
+type VerificationService interface {
+	RequestEmailVerification(ctx context.Context, userID int) error
+	VerifyEmail(ctx context.Context, token string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+}
+
+type ProfileService interface {
+	GetProfile(ctx context.Context, userID int) (domain.User, error)
+	UpdateProfile(ctx context.Context, userID int, inp domain.UpdateProfileInput) (domain.User, error)
+	ChangePassword(ctx context.Context, userID int, inp domain.ChangePasswordInput) error
+}
+
+type UserDirectoryService interface {
+	GetUsersList(ctx context.Context, filter domain.UserFilter, paginator domain.Paginator, ordering domain.Orderings) ([]domain.User, error)
+	GetUserDetails(ctx context.Context, userID int) (domain.UserDetails, error)
+}
+
+type TwoFactorService interface {
+	Enroll(ctx context.Context, userID int) (domain.TOTPEnrollment, error)
+	Confirm(ctx context.Context, userID int, code string) ([]string, error)
+	Disable(ctx context.Context, userID int, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error)
+}
+
 type CardService interface {
 	CreateCard(ctx context.Context, accountID, UserID int) (domain.Card, error)
 	GetCardListUser(ctx context.Context, userID int) ([]domain.Card, error)
@@ -39,9 +71,60 @@ type EventService interface {
 }
 
 type RoleRepository interface {
-	GetByID(ctx context.Context, id int) (domain.Role, error)
+	GetUserRoles(ctx context.Context, userID int) ([]domain.Role, error)
+}
+
+type OwnershipResolver interface {
+	CheckOwnership(ctx context.Context, userID int, resources []domain.ResourceRef) error
+}
+
+type CacheStatsService interface {
+	GetStats(ctx context.Context) []domain.CacheStats
+}
+
+type RoleService interface {
+	GetRoles(ctx context.Context) ([]domain.Role, error)
+	CreateRole(ctx context.Context, name string) (domain.Role, error)
+	GetUserRoles(ctx context.Context, userID int) ([]domain.Role, error)
+	AssignRole(ctx context.Context, userID int, roleName string) ([]domain.Role, error)
+	RevokeRole(ctx context.Context, userID int, roleName string) ([]domain.Role, error)
+}
+
+type PolicyService interface {
+	GetPolicies(ctx context.Context) []domain.Policy
+	AddPolicy(ctx context.Context, policy domain.Policy) error
+	RemovePolicy(ctx context.Context, policy domain.Policy) error
+	GetRoleInheritances(ctx context.Context) []domain.RoleInheritance
+	AddRoleInheritance(ctx context.Context, inheritance domain.RoleInheritance) error
+	RemoveRoleInheritance(ctx context.Context, inheritance domain.RoleInheritance) error
 }
 
 type TransactionService interface {
 	GetTransactionList(ctx context.Context, accountID, userID int, ordering domain.Orderings, paginator domain.Paginator) ([]domain.Transaction, error)
+	ReverseTransaction(ctx context.Context, transactionID, adminID int, amount string, force bool) (domain.Transaction, error)
+}
+
+type IdempotencyService interface {
+	Begin(ctx context.Context, userID int, key, fingerprint string) (domain.IdempotencyKey, bool, error)
+	RequestTimeout() time.Duration
+	Complete(ctx context.Context, userID int, key, token string, statusCode int, responseBody []byte) error
+	Release(ctx context.Context, userID int, key, token string) error
+}
+
+type FXService interface {
+	CreateQuote(ctx context.Context, userID int, fromCurrencyCode, toCurrencyCode, amount string) (domain.FXQuote, error)
+}
+
+type KeysService interface {
+	PublicKeys() []domain.SigningKey
+}
+
+type WebhookService interface {
+	CreateSubscription(ctx context.Context, userID int, inp domain.WebhookSubscriptionInput) (domain.WebhookSubscription, error)
+	GetSubscriptions(ctx context.Context, userID int) ([]domain.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, userID, subscriptionID int) (domain.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, userID, subscriptionID int) error
+	GetDeliveries(ctx context.Context, userID, subscriptionID int) ([]domain.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, userID, subscriptionID, deliveryID int) (domain.WebhookDelivery, []domain.WebhookDeliveryAttempt, error)
+	Redeliver(ctx context.Context, userID, subscriptionID, deliveryID int) error
 }
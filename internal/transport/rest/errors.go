@@ -1,15 +1,22 @@
 package rest
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"github.com/lukinairina90/banking_backend/internal/domain"
 )
 
 type CommonError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Error   string `json:"error,omitempty"`
+	Code      int    `json:"code"`
+	ErrorCode string `json:"error_code,omitempty"`
+	Message   string `json:"message"`
+	Error     string `json:"error,omitempty"`
 }
 
 type BadRequestError struct {
@@ -88,6 +95,94 @@ func NewForbiddenError(message string, err error) ForbiddenError {
 	}}
 }
 
+type ConflictError struct {
+	CommonError
+}
+
+func NewConflictError(message string, err error) ConflictError {
+	return ConflictError{CommonError: CommonError{
+		Code:    http.StatusConflict,
+		Message: message,
+		Error:   stringifyError(err),
+	}}
+}
+
+type UnprocessableEntityError struct {
+	CommonError
+}
+
+func NewUnprocessableEntityError(message string, err error) UnprocessableEntityError {
+	return UnprocessableEntityError{CommonError: CommonError{
+		Code:    http.StatusUnprocessableEntity,
+		Message: message,
+		Error:   stringifyError(err),
+	}}
+}
+
+type LockedError struct {
+	CommonError
+}
+
+func NewLockedError(message string, err error) LockedError {
+	return LockedError{CommonError: CommonError{
+		Code:    http.StatusLocked,
+		Message: message,
+		Error:   stringifyError(err),
+	}}
+}
+
+// errorKindStatuses HTTP status codes of the domain error kinds.
+var errorKindStatuses = map[domain.ErrorKind]int{
+	domain.KindInvalid:         http.StatusBadRequest,
+	domain.KindForbidden:       http.StatusForbidden,
+	domain.KindNotFound:        http.StatusNotFound,
+	domain.KindConflict:        http.StatusConflict,
+	domain.KindUnprocessable:   http.StatusUnprocessableEntity,
+	domain.KindLocked:          http.StatusLocked,
+	domain.KindUnauthorized:    http.StatusUnauthorized,
+	domain.KindTooManyRequests: http.StatusTooManyRequests,
+}
+
+// translateError maps the error to the response status code and body.
+// Errors from the domain catalog keep their stable code, any other error is an internal server error.
+func translateError(message string, err error) (int, CommonError) {
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		if status, ok := errorKindStatuses[domainErr.Kind]; ok {
+			return status, CommonError{
+				Code:      status,
+				ErrorCode: domainErr.Code,
+				Message:   message,
+				Error:     stringifyError(err),
+			}
+		}
+	}
+
+	return http.StatusInternalServerError, NewInternalServerError(message, err).CommonError
+}
+
+// abortWithError stops the request handling and attaches the error, the response is written by ErrorMiddleware.
+func abortWithError(ctx *gin.Context, message string, err error) {
+	_ = ctx.Error(err).SetMeta(message)
+	ctx.Abort()
+}
+
+// writeError writes the response for the last error attached to the request.
+func writeError(ctx *gin.Context) {
+	last := ctx.Errors.Last()
+	message, _ := last.Meta.(string)
+
+	status, body := translateError(message, last.Err)
+
+	var retryErr *domain.RetryError
+	if errors.As(last.Err, &retryErr) {
+		seconds := math.Ceil(time.Until(retryErr.RetryAt).Seconds())
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Max(seconds, 1))))
+	}
+
+	ctx.JSON(status, body)
+}
+
 func stringifyError(err error) string {
 	if err != nil {
 		return err.Error()
@@ -36,7 +36,7 @@ func (t Event) getEventList(ctx *gin.Context) {
 
 	domainList, err := t.eventService.GetEventList(ctx, userID)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting events list error", err))
+		abortWithError(ctx, "getting events list error", err)
 		return
 	}
 
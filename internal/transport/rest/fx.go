@@ -0,0 +1,50 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lukinairina90/banking_backend/internal/transport/rest/messages"
+)
+
+// FX transport layer struct.
+type FX struct {
+	fxService FXService
+}
+
+// NewFX constructor for FX.
+func NewFX(fxService FXService) *FX {
+	return &FX{fxService: fxService}
+}
+
+// InjectRoutes injects routes to global router.
+func (t FX) InjectRoutes(r *gin.Engine, middlewares ...gin.HandlerFunc) {
+	fx := r.Group("/fx").Use(middlewares...)
+	{
+		fx.POST("/quote", t.createQuote)
+	}
+}
+
+// createQuote gin handler function for exchange quote creation endpoint.
+// [POST] /fx/quote
+func (t FX) createQuote(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting current user error", err))
+		return
+	}
+
+	var req messages.CreateFXQuoteRequestBody
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("request body validation error", err))
+		return
+	}
+
+	quote, err := t.fxService.CreateQuote(ctx, userID, req.FromCurrency, req.ToCurrency, req.Amount)
+	if err != nil {
+		abortWithError(ctx, "quote creation error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, messages.NewFXQuote(quote))
+}
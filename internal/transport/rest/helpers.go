@@ -19,6 +19,15 @@ func getUserIDFromContext(ctx *gin.Context) (int, error) {
 	return val.(int), nil
 }
 
+// getDevice returns the client device of the request.
+// The IP address comes from X-Forwarded-For only when the request is sent by a trusted proxy, otherwise it is the remote address.
+func getDevice(ctx *gin.Context) domain.Device {
+	return domain.Device{
+		UserAgent: ctx.Request.UserAgent(),
+		IP:        ctx.ClientIP(),
+	}
+}
+
 // buildOrderingMessage takes the filter from the url and returns domain.Orderings.
 func buildOrderingMessage(input string, supportedFields []string) (domain.Orderings, error) {
 	if input == "" {
@@ -60,12 +69,3 @@ func buildOrderingMessage(input string, supportedFields []string) (domain.Orderi
 
 	return orderings, nil
 }
-
-//func getUserRoleIDFromContext(ctx *gin.Context) (int, error) {
-//	val, ok := ctx.Get(ctxUserRoleIDKey)
-//	if !ok {
-//		return 0, errors.New("request context doesn't contains user role id")
-//	}
-//
-//	return val.(int), nil
-//}
@@ -0,0 +1,33 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lukinairina90/banking_backend/internal/transport/rest/messages"
+)
+
+// JWKS transport layer struct.
+type JWKS struct {
+	keysService KeysService
+}
+
+// NewJWKS constructor for JWKS.
+func NewJWKS(keysService KeysService) *JWKS {
+	return &JWKS{keysService: keysService}
+}
+
+// InjectRoutes injects routes to global router.
+func (t JWKS) InjectRoutes(r *gin.Engine, middlewares ...gin.HandlerFunc) {
+	wellKnown := r.Group("/.well-known").Use(middlewares...)
+	{
+		wellKnown.GET("/jwks.json", t.getKeys)
+	}
+}
+
+// getKeys gin handler function for access token verification keys endpoint.
+// [GET] /.well-known/jwks.json
+func (t JWKS) getKeys(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, messages.NewJWKS(t.keysService.PublicKeys()))
+}
@@ -1,13 +1,29 @@
 package messages
 
+import "github.com/lukinairina90/banking_backend/internal/domain"
+
 // Account object representation of response connection with accounts functionality.
 type Account struct {
-	ID         int     `json:"id"`
-	Iban       string  `json:"iban"`
-	UserID     int     `json:"user_id"`
-	CurrencyID int     `json:"currency_id"`
-	Blocked    bool    `json:"blocked"`
-	Amount     float64 `json:"amount"`
+	ID         int    `json:"id"`
+	Iban       string `json:"iban"`
+	UserID     int    `json:"user_id"`
+	CurrencyID int    `json:"currency_id"`
+	Currency   string `json:"currency"`
+	Blocked    bool   `json:"blocked"`
+	Amount     string `json:"amount"`
+}
+
+// NewAccount converts domain.Account to Account.
+func NewAccount(a domain.Account) Account {
+	return Account{
+		ID:         a.ID,
+		Iban:       a.Iban,
+		UserID:     a.UserID,
+		CurrencyID: a.CurrencyID,
+		Currency:   a.Amount.Currency,
+		Blocked:    a.Blocked,
+		Amount:     a.Amount.String(),
+	}
 }
 
 // CreateAccountRequestBody object representation of response.
@@ -16,12 +32,22 @@ type CreateAccountRequestBody struct {
 }
 
 // DepositAccountRequestBody object representation of response.
+// Amount is a decimal string such as "100.50", its precision is validated against the account currency.
 type DepositAccountRequestBody struct {
-	Amount float64 `json:"amount" binding:"required,gte=1"`
+	Amount string `json:"amount" binding:"required,numeric"`
+}
+
+// WithdrawAccountRequestBody object representation of response.
+// Amount is a decimal string such as "100.50", its precision is validated against the account currency.
+type WithdrawAccountRequestBody struct {
+	Amount string `json:"amount" binding:"required,numeric"`
 }
 
 // TransferAccountRequestBody object representation of response.
+// Amount is a decimal string such as "100.50" in the sender account currency, its precision is validated against the currency.
+// QuoteID optionally refers to the exchange quote locking the rate of a transfer between accounts of different currencies.
 type TransferAccountRequestBody struct {
-	Iban   string  `json:"iban" binding:"required,gte=29"`
-	Amount float64 `json:"amount" binding:"required,gte=1"`
+	Iban    string `json:"iban" binding:"required,gte=29"`
+	Amount  string `json:"amount" binding:"required,numeric"`
+	QuoteID int    `json:"quote_id" binding:"omitempty,gte=1"`
 }
@@ -0,0 +1,71 @@
+package messages
+
+import (
+	"strings"
+	"time"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+)
+
+// UserDetails object representation of response with the user, their accounts, cards and latest events for admins.
+type UserDetails struct {
+	User     User          `json:"user"`
+	Accounts []Account     `json:"accounts"`
+	Cards    []CardSummary `json:"cards"`
+	Events   []Event       `json:"events"`
+}
+
+// CardSummary object representation of the card for admins, the card number is masked and the CVV code is never returned.
+type CardSummary struct {
+	Id             int       `json:"id"`
+	AccountID      int       `json:"account_id"`
+	CardNumber     string    `json:"card_number"`
+	CardholderName string    `json:"cardholder_name"`
+	ExpirationDate time.Time `json:"expiration_date"`
+}
+
+// NewUserDetails converts domain.UserDetails to UserDetails.
+func NewUserDetails(d domain.UserDetails) UserDetails {
+	details := UserDetails{
+		User:     NewUser(d.User),
+		Accounts: make([]Account, 0, len(d.Accounts)),
+		Cards:    make([]CardSummary, 0, len(d.Cards)),
+		Events:   make([]Event, 0, len(d.Events)),
+	}
+
+	for _, account := range d.Accounts {
+		details.Accounts = append(details.Accounts, NewAccount(account))
+	}
+
+	for _, card := range d.Cards {
+		details.Cards = append(details.Cards, CardSummary{
+			Id:             card.Id,
+			AccountID:      card.AccountID,
+			CardNumber:     maskCardNumber(card.CardNumber),
+			CardholderName: card.CardholderName,
+			ExpirationDate: card.ExpirationDate,
+		})
+	}
+
+	for _, event := range d.Events {
+		details.Events = append(details.Events, Event{
+			ID:       event.ID,
+			UserID:   event.UserID,
+			Type:     event.Type.String(),
+			Message:  event.Message,
+			Metadata: event.Metadata,
+			DateTime: event.DateTime,
+		})
+	}
+
+	return details
+}
+
+// maskCardNumber hides all digits of the card number except the last four.
+func maskCardNumber(number string) string {
+	if len(number) <= 4 {
+		return number
+	}
+
+	return strings.Repeat("*", len(number)-4) + number[len(number)-4:]
+}
@@ -0,0 +1,22 @@
+package messages
+
+import "github.com/lukinairina90/banking_backend/internal/domain"
+
+// CacheStats object representation of the cache statistics.
+type CacheStats struct {
+	Name     string  `json:"name"`
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	Errors   int64   `json:"errors"`
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+// NewCacheStats converts domain.CacheStats list to CacheStats list.
+func NewCacheStats(stats []domain.CacheStats) []CacheStats {
+	list := make([]CacheStats, 0, len(stats))
+	for _, s := range stats {
+		list = append(list, CacheStats{Name: s.Name, Hits: s.Hits, Misses: s.Misses, Errors: s.Errors, HitRatio: s.HitRatio})
+	}
+
+	return list
+}
@@ -0,0 +1,39 @@
+package messages
+
+import (
+	"time"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+)
+
+// CreateFXQuoteRequestBody object representation of request for exchange quote creation.
+// Amount is a decimal string in the source currency.
+type CreateFXQuoteRequestBody struct {
+	FromCurrency string `json:"from_currency" binding:"required,len=3"`
+	ToCurrency   string `json:"to_currency" binding:"required,len=3"`
+	Amount       string `json:"amount" binding:"required,numeric"`
+}
+
+// FXQuote object representation of response connection with exchange quotes functionality.
+type FXQuote struct {
+	ID             int       `json:"id"`
+	Rate           string    `json:"rate"`
+	SourceAmount   string    `json:"source_amount"`
+	SourceCurrency string    `json:"source_currency"`
+	TargetAmount   string    `json:"target_amount"`
+	TargetCurrency string    `json:"target_currency"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// NewFXQuote converts domain.FXQuote to FXQuote.
+func NewFXQuote(q domain.FXQuote) FXQuote {
+	return FXQuote{
+		ID:             q.ID,
+		Rate:           domain.FormatRate(q.Rate),
+		SourceAmount:   q.SourceAmount.String(),
+		SourceCurrency: q.SourceAmount.Currency,
+		TargetAmount:   q.TargetAmount.String(),
+		TargetCurrency: q.TargetAmount.Currency,
+		ExpiresAt:      q.ExpiresAt,
+	}
+}
@@ -0,0 +1,56 @@
+package messages
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+)
+
+// JWKS object representation of the JSON Web Key Set (RFC 7517) of the access token verification keys.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK object representation of a single public key: n and e are set for RSA keys, crv and x for Ed25519 keys.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// NewJWKS converts list of domain.SigningKey to JWKS, keys of unsupported types are skipped.
+func NewJWKS(keys []domain.SigningKey) JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(keys))}
+	for _, key := range keys {
+		jwk := JWK{
+			Kid: key.ID,
+			Alg: key.Algorithm,
+			Use: "sig",
+		}
+
+		switch pub := key.PublicKey().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
@@ -0,0 +1,17 @@
+package messages
+
+// Policy object representation of the RBAC policy rule.
+// Object is a path template such as /account/{id}, Action is * or a regular expression of HTTP methods such as (GET)|(POST).
+// OwnerOnly rules allow the call only to the owner of the account and the card of the path.
+type Policy struct {
+	Subject   string `json:"subject" form:"subject" binding:"required,max=255"`
+	Object    string `json:"object" form:"object" binding:"required,startswith=/,max=255"`
+	Action    string `json:"action" form:"action" binding:"required,max=255"`
+	OwnerOnly bool   `json:"owner_only" form:"owner_only"`
+}
+
+// RoleInheritance object representation of the RBAC role inheritance rule, Role gets all permissions of Parent.
+type RoleInheritance struct {
+	Role   string `json:"role" form:"role" binding:"required,max=255"`
+	Parent string `json:"parent" form:"parent" binding:"required,max=255"`
+}
@@ -0,0 +1,29 @@
+package messages
+
+import "github.com/lukinairina90/banking_backend/internal/domain"
+
+// Role object representation of response connection with roles functionality.
+type Role struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// NewRoles converts domain.Role list to Role list.
+func NewRoles(roles []domain.Role) []Role {
+	list := make([]Role, 0, len(roles))
+	for _, role := range roles {
+		list = append(list, Role{ID: role.ID, Name: role.Name})
+	}
+
+	return list
+}
+
+// CreateRoleInput object representation of the role creation request.
+type CreateRoleInput struct {
+	Name string `json:"name" binding:"required,max=30,lowercase,alphanum"`
+}
+
+// AssignRoleInput object representation of the role assignment request.
+type AssignRoleInput struct {
+	Role string `json:"role" binding:"required,max=30"`
+}
@@ -1,15 +1,58 @@
 package messages
 
-import "time"
+import (
+	"time"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+)
 
 // Transaction object representation of response connection with transactions functionality.
 type Transaction struct {
-	ID          int       `json:"id"`
-	FromAccount int       `json:"from_account"`
-	ToAccount   int       `json:"to_account"`
-	Amount      float64   `json:"amount"`
-	Type        string    `json:"type"`
-	Status      string    `json:"status"`
-	DateCreated time.Time `json:"date_created"`
-	DateUpdated time.Time `json:"date_updated"`
+	ID            int       `json:"id"`
+	FromAccount   int       `json:"from_account"`
+	ToAccount     int       `json:"to_account"`
+	Amount        string    `json:"amount"`
+	Currency      string    `json:"currency"`
+	ToAmount      string    `json:"to_amount"`
+	ToCurrency    string    `json:"to_currency"`
+	ExchangeRate  string    `json:"exchange_rate,omitempty"`
+	Type          string    `json:"type"`
+	Status        string    `json:"status"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	ReversalOf    int       `json:"reversal_of,omitempty"`
+	DateCreated   time.Time `json:"date_created"`
+	DateUpdated   time.Time `json:"date_updated"`
+}
+
+// NewTransaction converts domain.Transaction to Transaction.
+func NewTransaction(t domain.Transaction) Transaction {
+	transaction := Transaction{
+		ID:            t.ID,
+		FromAccount:   t.FromAccount,
+		ToAccount:     t.ToAccount,
+		Amount:        t.Amount.String(),
+		Currency:      t.Amount.Currency,
+		ToAmount:      t.ToAmount.String(),
+		ToCurrency:    t.ToAmount.Currency,
+		Type:          t.Type,
+		Status:        t.Status.String(),
+		FailureReason: t.FailureReason,
+		ReversalOf:    t.ReversalOf,
+		DateCreated:   t.DateCreated,
+		DateUpdated:   t.DateUpdated,
+	}
+
+	if t.ExchangeRate != nil {
+		transaction.ExchangeRate = domain.FormatRate(t.ExchangeRate)
+	}
+
+	return transaction
+}
+
+// ReverseTransactionRequestBody object representation of response.
+// Amount is an optional decimal string in the currency of the reversed transaction amount, the whole amount left to reverse is used if it is empty.
+// Force allows the reversal to make the recipient account balance negative.
+type ReverseTransactionRequestBody struct {
+	Amount string `json:"amount" binding:"omitempty,numeric"`
+	Force  bool   `json:"force"`
 }
@@ -0,0 +1,33 @@
+package messages
+
+import "github.com/lukinairina90/banking_backend/internal/domain"
+
+// SignInTwoFactorInput object representation of request for completing the sign in with a two-factor code.
+type SignInTwoFactorInput struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// TwoFactorCodeInput object representation of request confirmed by a TOTP or recovery code.
+type TwoFactorCodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TOTPEnrollment object representation of response connection with TOTP enrollment functionality.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// NewTOTPEnrollment converts domain.TOTPEnrollment to TOTPEnrollment.
+func NewTOTPEnrollment(e domain.TOTPEnrollment) TOTPEnrollment {
+	return TOTPEnrollment{
+		Secret: e.Secret,
+		URI:    e.URI,
+	}
+}
+
+// RecoveryCodes object representation of response with the new recovery codes, they are shown only once.
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
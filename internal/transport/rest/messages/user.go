@@ -1,24 +1,70 @@
 package messages
 
 import (
-	"errors"
 	"time"
 
 	"github.com/lukinairina90/banking_backend/internal/domain"
 )
 
-var ErrUserNotFound = errors.New("user with such credentials not found")
-
 // User object representation of response connection with users functionality.
+// The password hash is never returned.
 type User struct {
-	ID           int64     `json:"id"`
-	Name         string    `json:"name"`
-	Surname      string    `json:"surname"`
-	Email        string    `json:"email"`
-	Password     string    `json:"password"`
-	RoleId       int       `json:"role_id"`
-	Blocked      bool      `json:"blocked"`
-	RegisteredAt time.Time `json:"registered_at"`
+	ID               int       `json:"id"`
+	Name             string    `json:"name"`
+	Surname          string    `json:"surname"`
+	Email            string    `json:"email"`
+	EmailVerified    bool      `json:"email_verified"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+	Roles            []string  `json:"roles"`
+	Blocked          bool      `json:"blocked"`
+	RegisteredAt     time.Time `json:"registered_at"`
+}
+
+// NewUser converts domain.User to User.
+func NewUser(u domain.User) User {
+	return User{
+		ID:               u.ID,
+		Name:             u.Name,
+		Surname:          u.Surname,
+		Email:            u.Email,
+		EmailVerified:    u.EmailVerified,
+		TwoFactorEnabled: u.TwoFactorEnabled,
+		Roles:            u.Roles,
+		Blocked:          u.Blocked,
+		RegisteredAt:     u.RegisteredAt,
+	}
+}
+
+// UpdateProfileInput object representation of request for changing the user profile, omitted fields stay unchanged.
+type UpdateProfileInput struct {
+	Name                 *string `json:"name" binding:"omitempty,gte=2"`
+	Surname              *string `json:"surname" binding:"omitempty,gte=2"`
+	Email                *string `json:"email" binding:"omitempty,email"`
+	UpdateCardholderName bool    `json:"update_cardholder_name"`
+}
+
+// ToDomain converts UpdateProfileInput to domain.UpdateProfileInput
+func (i UpdateProfileInput) ToDomain() domain.UpdateProfileInput {
+	return domain.UpdateProfileInput{
+		Name:                 i.Name,
+		Surname:              i.Surname,
+		Email:                i.Email,
+		UpdateCardholderName: i.UpdateCardholderName,
+	}
+}
+
+// ChangePasswordInput object representation of request for changing the password.
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,gte=6"`
+}
+
+// ToDomain converts ChangePasswordInput to domain.ChangePasswordInput
+func (i ChangePasswordInput) ToDomain() domain.ChangePasswordInput {
+	return domain.ChangePasswordInput{
+		CurrentPassword: i.CurrentPassword,
+		NewPassword:     i.NewPassword,
+	}
 }
 
 // SignUpInput object representation of response.
@@ -52,3 +98,38 @@ func (i SignInInput) ToDomain() domain.SignInInput {
 		Password: i.Password,
 	}
 }
+
+// ForgotPasswordInput object representation of request for sending the password reset email.
+type ForgotPasswordInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordInput object representation of request for setting a new password with the token from the password reset email.
+type ResetPasswordInput struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,gte=6"`
+}
+
+// Session object representation of response connection with sessions functionality.
+type Session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// NewSession converts domain.Session to Session.
+func NewSession(s domain.Session) Session {
+	return Session{
+		ID:         s.FamilyID,
+		UserAgent:  s.UserAgent,
+		IP:         s.IP,
+		CreatedAt:  s.CreatedAt,
+		LastUsedAt: s.LastUsedAt,
+		ExpiresAt:  s.ExpiresAt,
+		Current:    s.Current,
+	}
+}
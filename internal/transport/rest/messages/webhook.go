@@ -0,0 +1,122 @@
+package messages
+
+import (
+	"time"
+
+	"github.com/lukinairina90/banking_backend/internal/domain"
+)
+
+// CreateWebhookInput object representation of the webhook subscription creation request.
+type CreateWebhookInput struct {
+	URL        string   `json:"url" binding:"required,url,max=2048"`
+	EventTypes []string `json:"event_types" binding:"omitempty,dive,required"`
+}
+
+// ToDomain converts CreateWebhookInput to domain.WebhookSubscriptionInput.
+func (i CreateWebhookInput) ToDomain() domain.WebhookSubscriptionInput {
+	return domain.WebhookSubscriptionInput{URL: i.URL, EventTypes: i.EventTypes}
+}
+
+// Webhook object representation of response connection with webhooks functionality.
+type Webhook struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id,omitempty"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CreatedWebhook object representation of the created webhook subscription, the only response carrying the signing secret.
+type CreatedWebhook struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+// NewWebhook converts domain.WebhookSubscription to Webhook.
+func NewWebhook(subscription domain.WebhookSubscription) Webhook {
+	eventTypes := make([]string, 0, len(subscription.EventTypes))
+	for _, eventType := range subscription.EventTypes {
+		eventTypes = append(eventTypes, eventType.String())
+	}
+
+	return Webhook{
+		ID:         subscription.ID,
+		UserID:     subscription.UserID,
+		URL:        subscription.URL,
+		EventTypes: eventTypes,
+		CreatedAt:  subscription.CreatedAt,
+	}
+}
+
+// NewWebhooks converts domain.WebhookSubscription list to Webhook list.
+func NewWebhooks(subscriptions []domain.WebhookSubscription) []Webhook {
+	list := make([]Webhook, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		list = append(list, NewWebhook(subscription))
+	}
+
+	return list
+}
+
+// WebhookDelivery object representation of the delivery of an event to the webhook subscription.
+type WebhookDelivery struct {
+	ID            int                      `json:"id"`
+	EventID       int                      `json:"event_id"`
+	EventType     string                   `json:"event_type"`
+	Status        string                   `json:"status"`
+	Attempts      int                      `json:"attempts"`
+	NextAttemptAt *time.Time               `json:"next_attempt_at,omitempty"`
+	LastError     string                   `json:"last_error,omitempty"`
+	CreatedAt     time.Time                `json:"created_at"`
+	UpdatedAt     time.Time                `json:"updated_at"`
+	History       []WebhookDeliveryAttempt `json:"history,omitempty"`
+}
+
+// WebhookDeliveryAttempt object representation of a single attempt of the webhook delivery.
+type WebhookDeliveryAttempt struct {
+	StatusCode  int       `json:"status_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+// NewWebhookDelivery converts domain.WebhookDelivery with its attempts to WebhookDelivery.
+// The next attempt time is shown for the pending deliveries only.
+func NewWebhookDelivery(delivery domain.WebhookDelivery, attempts []domain.WebhookDeliveryAttempt) WebhookDelivery {
+	msg := WebhookDelivery{
+		ID:        delivery.ID,
+		EventID:   delivery.EventID,
+		EventType: delivery.EventType.String(),
+		Status:    delivery.Status.String(),
+		Attempts:  delivery.Attempts,
+		LastError: delivery.LastError,
+		CreatedAt: delivery.CreatedAt,
+		UpdatedAt: delivery.UpdatedAt,
+	}
+
+	if delivery.Status == domain.WebhookDeliveryPending {
+		nextAttemptAt := delivery.NextAttemptAt
+		msg.NextAttemptAt = &nextAttemptAt
+	}
+
+	for _, attempt := range attempts {
+		msg.History = append(msg.History, WebhookDeliveryAttempt{
+			StatusCode:  attempt.StatusCode,
+			Error:       attempt.Error,
+			DurationMs:  attempt.Duration.Milliseconds(),
+			AttemptedAt: attempt.AttemptedAt,
+		})
+	}
+
+	return msg
+}
+
+// NewWebhookDeliveries converts domain.WebhookDelivery list to WebhookDelivery list.
+func NewWebhookDeliveries(deliveries []domain.WebhookDelivery) []WebhookDelivery {
+	list := make([]WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		list = append(list, NewWebhookDelivery(delivery, nil))
+	}
+
+	return list
+}
@@ -1,23 +1,40 @@
 package rest
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/casbin/casbin/v2"
 	"github.com/gin-gonic/gin"
+	"github.com/lukinairina90/banking_backend/internal/domain"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
 const (
-	ctxUserIDKey     = "user-id"
-	ctxUserRoleIDKey = "user-role-id"
+	ctxUserIDKey        = "user-id"
+	ctxEmailVerifiedKey = "email-verified"
 )
 
 const AuthorizationHeaderName = "Authorization"
 
+// TwoFactorCodeHeaderName header carrying the TOTP or recovery code of the step-up verification.
+const TwoFactorCodeHeaderName = "X-Two-Factor-Code"
+
+const (
+	IdempotencyKeyHeaderName = "Idempotency-Key"
+	maxIdempotencyKeyLength  = 255
+	// idempotencyStoreTimeout how long storing the outcome of the request with the idempotency key may take
+	idempotencyStoreTimeout = 5 * time.Second
+)
+
 // LoggingMiddleware middleware for logging
 func LoggingMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -37,7 +54,22 @@ func LoggingMiddleware() gin.HandlerFunc {
 	}
 }
 
-// AuthMiddleware middleware for api, takes a token from the request, checks the authorization token, checks if the user is blocked, sets the user id and role id to the context.
+// ErrorMiddleware writes the response for errors attached by handlers with abortWithError.
+// Domain errors are translated to their status codes and error codes, other errors become internal server errors.
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		writeError(c)
+	}
+}
+
+// AuthMiddleware middleware for api, takes a token from the request, checks the authorization token against the revocation list and the user token version,
+// checks if the user is blocked, sets the user id and email verification state to the context.
 func (a *Auth) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token, err := getTokenFromRequest(c)
@@ -46,15 +78,15 @@ func (a *Auth) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		userID, roleID, err := a.userService.ParseToken(c, token)
+		claims, err := a.userService.ParseToken(c, token)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, NewUnauthorizedError("wrong authorization token", err))
 			return
 		}
 
-		checkBlockUser, err := a.userService.CheckBlockUser(c, userID)
+		checkBlockUser, err := a.userService.CheckBlockUser(c, claims.UserID)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusNotFound, NewNotFoundError("user block check error user not found", err))
+			abortWithError(c, "user block check error", err)
 			return
 		}
 
@@ -63,47 +95,226 @@ func (a *Auth) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		c.Set(ctxUserIDKey, userID)
-		c.Set(ctxUserRoleIDKey, roleID)
+		c.Set(ctxUserIDKey, claims.UserID)
+		c.Set(ctxEmailVerifiedKey, claims.EmailVerified)
 
 		c.Next()
 	}
 }
 
 // RBACMiddleware checks if the user has access to certain endpoints.
-func RBACMiddleware(enforcer casbin.IEnforcer, roleRepository RoleRepository) gin.HandlerFunc {
+// The request is allowed when any of the user roles is allowed, the roles are loaded for every request, so role changes apply immediately.
+// Users with an unverified email are checked against the unverified group instead of their roles, requests without a user against the anonymous group.
+// Owner scoped rules (p2) allow the request only when the user owns the resources of the path parameters,
+// e.g. the account of /account/{id} and the card of /account/{id}/card/{card_id}, which must belong to that account.
+func RBACMiddleware(enforcer casbin.IEnforcer, roleRepository RoleRepository, ownershipResolver OwnershipResolver) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var group, method, path string
-		r, exists := c.Get(ctxUserRoleIDKey)
+		var groups []string
+		userID, exists := c.Get(ctxUserIDKey)
 		if !exists {
-			group = "anonymous"
+			groups = []string{domain.AnonymousRoleName}
+		} else if !c.GetBool(ctxEmailVerifiedKey) {
+			groups = []string{domain.UnverifiedRoleName}
 		} else {
-			roleID := r.(int)
-			role, err := roleRepository.GetByID(c.Request.Context(), roleID)
+			roles, err := roleRepository.GetUserRoles(c.Request.Context(), userID.(int))
 			if err != nil {
-				c.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting role error", err))
+				c.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting user roles error", err))
+				return
+			}
+
+			for _, role := range roles {
+				groups = append(groups, role.Name)
+			}
+		}
+
+		method := c.Request.Method
+		path := c.Request.URL.Path
+
+		for _, group := range groups {
+			ok, err := enforcer.Enforce(group, path, method)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("checking permissions error", err))
+				return
+			}
+
+			if ok {
+				c.Next()
 				return
 			}
+		}
+
+		if exists {
+			ownerScope := casbin.NewEnforceContext("2")
+			for _, group := range groups {
+				ok, err := enforcer.Enforce(ownerScope, group, path, method)
+				if err != nil {
+					c.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("checking permissions error", err))
+					return
+				}
+
+				if !ok {
+					continue
+				}
+
+				resources, err := pathResources(c)
+				if err != nil {
+					c.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("wrong \"id\" request param", err))
+					return
+				}
 
-			group = role.Name
+				if err := ownershipResolver.CheckOwnership(c.Request.Context(), userID.(int), resources); err != nil {
+					abortWithError(c, "checking resource ownership error", err)
+					return
+				}
+
+				c.Next()
+				return
+			}
 		}
 
-		method = c.Request.Method
-		path = c.Request.URL.Path
+		c.AbortWithStatusJSON(http.StatusForbidden, NewForbiddenError("user does not have rights to perform an operation", nil))
+	}
+}
+
+// pathResources returns the resources of the route path parameters in the order of the path,
+// the kind of the resource is the path segment preceding the parameter, e.g. account for /account/:id.
+func pathResources(c *gin.Context) ([]domain.ResourceRef, error) {
+	var resources []domain.ResourceRef
+
+	segments := strings.Split(c.FullPath(), "/")
+	for i := 1; i < len(segments); i++ {
+		if !strings.HasPrefix(segments[i], ":") {
+			continue
+		}
 
-		ok, err := enforcer.Enforce(group, path, method)
+		id, err := strconv.Atoi(c.Param(segments[i][1:]))
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("checking permissions error", err))
-			return
+			return nil, errors.Wrapf(err, "parsing %q path param error", segments[i][1:])
 		}
 
-		if ok {
+		resources = append(resources, domain.ResourceRef{Kind: domain.ResourceKind(segments[i-1]), ID: id})
+	}
+
+	return resources, nil
+}
+
+// IdempotencyMiddleware makes money-moving endpoints safe to retry.
+// The first request with an Idempotency-Key header stores its response, replays with the same key and request return the stored response,
+// reusing the key for a different request is rejected. Requests without the header are handled as usual.
+// The request reserving the key is cancelled after the request timeout of the service, before a retry can take the key over,
+// and the response is stored only while the request still holds the key.
+func IdempotencyMiddleware(idempotencyService IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeaderName)
+		if key == "" {
 			c.Next()
-		} else {
-			c.AbortWithStatusJSON(http.StatusForbidden, NewForbiddenError("user does not have rights to perform an operation", nil))
 			return
 		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("idempotency key is too long", nil))
+			return
+		}
+
+		userID, err := getUserIDFromContext(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting current user error", err))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("reading request body error", err))
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		stored, replay, err := idempotencyService.Begin(c, userID, key, requestFingerprint(c.Request.Method, c.Request.URL.Path, body))
+		if err != nil {
+			abortWithError(c, "idempotency key check error", err)
+			return
+		}
+
+		if replay {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(stored.StatusCode, gin.MIMEJSON, stored.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		requestCtx, cancelRequest := context.WithTimeout(c.Request.Context(), idempotencyService.RequestTimeout())
+		defer cancelRequest()
+		c.Request = c.Request.WithContext(requestCtx)
+
+		writer := &responseCaptureWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		// the error response is written here, so it is stored with the key
+		if len(c.Errors) > 0 && !c.Writer.Written() {
+			writeError(c)
+		}
+
+		// the outcome is stored even if the request has timed out or the client has gone
+		storeCtx, cancelStore := context.WithTimeout(context.Background(), idempotencyStoreTimeout)
+		defer cancelStore()
+
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError || awaitsTwoFactorCode(c) {
+			if err := idempotencyService.Release(storeCtx, userID, key, stored.Token); err != nil {
+				logrus.WithError(err).WithField("key", key).Error("releasing idempotency key error")
+			}
+			return
+		}
+
+		if err := idempotencyService.Complete(storeCtx, userID, key, stored.Token, status, writer.body.Bytes()); err != nil {
+			logrus.WithError(err).WithField("key", key).Error("completing idempotency key error")
+		}
+	}
+}
+
+// awaitsTwoFactorCode reports whether the request failed for a missing or wrong two-factor code.
+// The code is not part of the fingerprint, so the key is released to let the client retry the same request with the code.
+func awaitsTwoFactorCode(c *gin.Context) bool {
+	last := c.Errors.Last()
+	if last == nil {
+		return false
 	}
+
+	return errors.Is(last.Err, domain.ErrStepUpRequired) || errors.Is(last.Err, domain.ErrInvalidTwoFactorCode) ||
+		errors.Is(last.Err, domain.ErrTwoFactorLocked)
+}
+
+// responseCaptureWriter gin.ResponseWriter keeping a copy of the written response body.
+type responseCaptureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write writes the data to the response and to the captured body.
+func (w *responseCaptureWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString writes the string to the response and to the captured body.
+func (w *responseCaptureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// requestFingerprint returns the hash identifying the request by its method, path and body.
+func requestFingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 // getTokenFromRequest takes a token from a request.
@@ -0,0 +1,126 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+	"github.com/lukinairina90/banking_backend/internal/transport/rest/messages"
+)
+
+// Policy transport layer struct of the RBAC policy management.
+type Policy struct {
+	policyService PolicyService
+}
+
+// NewPolicy constructor for Policy.
+func NewPolicy(policyService PolicyService) *Policy {
+	return &Policy{policyService: policyService}
+}
+
+// InjectRoutes injects routes to global router.
+func (t Policy) InjectRoutes(r *gin.Engine, middlewares ...gin.HandlerFunc) {
+	policies := r.Group("/admin/policies").Use(middlewares...)
+	{
+		policies.GET("", t.getPolicies)
+		policies.POST("", t.addPolicy)
+		policies.DELETE("", t.removePolicy)
+		policies.GET("/inheritance", t.getRoleInheritances)
+		policies.POST("/inheritance", t.addRoleInheritance)
+		policies.DELETE("/inheritance", t.removeRoleInheritance)
+	}
+}
+
+// getPolicies gin handler function for get policy rules endpoint.
+// [GET] /admin/policies
+func (t Policy) getPolicies(ctx *gin.Context) {
+	domainPolicies := t.policyService.GetPolicies(ctx)
+
+	list := make([]messages.Policy, 0, len(domainPolicies))
+	for _, policy := range domainPolicies {
+		list = append(list, messages.Policy{Subject: policy.Subject, Object: policy.Object, Action: policy.Action, OwnerOnly: policy.OwnerOnly})
+	}
+
+	ctx.JSON(http.StatusOK, list)
+}
+
+// addPolicy gin handler function for policy rule creation endpoint.
+// [POST] /admin/policies
+func (t Policy) addPolicy(ctx *gin.Context) {
+	var req messages.Policy
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("request body validation error", err))
+		return
+	}
+
+	if err := t.policyService.AddPolicy(ctx, domain.Policy{Subject: req.Subject, Object: req.Object, Action: req.Action, OwnerOnly: req.OwnerOnly}); err != nil {
+		abortWithError(ctx, "adding policy error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, req)
+}
+
+// removePolicy gin handler function for policy rule removal endpoint.
+// [DELETE] /admin/policies?subject=user&object=/card/&action=GET&owner_only=false
+func (t Policy) removePolicy(ctx *gin.Context) {
+	var req messages.Policy
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("query params validation error", err))
+		return
+	}
+
+	if err := t.policyService.RemovePolicy(ctx, domain.Policy{Subject: req.Subject, Object: req.Object, Action: req.Action, OwnerOnly: req.OwnerOnly}); err != nil {
+		abortWithError(ctx, "removing policy error", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// getRoleInheritances gin handler function for get role inheritance rules endpoint.
+// [GET] /admin/policies/inheritance
+func (t Policy) getRoleInheritances(ctx *gin.Context) {
+	domainInheritances := t.policyService.GetRoleInheritances(ctx)
+
+	list := make([]messages.RoleInheritance, 0, len(domainInheritances))
+	for _, inheritance := range domainInheritances {
+		list = append(list, messages.RoleInheritance{Role: inheritance.Role, Parent: inheritance.Parent})
+	}
+
+	ctx.JSON(http.StatusOK, list)
+}
+
+// addRoleInheritance gin handler function for role inheritance rule creation endpoint.
+// [POST] /admin/policies/inheritance
+func (t Policy) addRoleInheritance(ctx *gin.Context) {
+	var req messages.RoleInheritance
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("request body validation error", err))
+		return
+	}
+
+	if err := t.policyService.AddRoleInheritance(ctx, domain.RoleInheritance{Role: req.Role, Parent: req.Parent}); err != nil {
+		abortWithError(ctx, "adding role inheritance error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, req)
+}
+
+// removeRoleInheritance gin handler function for role inheritance rule removal endpoint.
+// [DELETE] /admin/policies/inheritance?role=admin&parent=user
+func (t Policy) removeRoleInheritance(ctx *gin.Context) {
+	var req messages.RoleInheritance
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("query params validation error", err))
+		return
+	}
+
+	if err := t.policyService.RemoveRoleInheritance(ctx, domain.RoleInheritance{Role: req.Role, Parent: req.Parent}); err != nil {
+		abortWithError(ctx, "removing role inheritance error", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
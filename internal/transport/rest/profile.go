@@ -0,0 +1,95 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lukinairina90/banking_backend/internal/transport/rest/messages"
+)
+
+// Profile transport layer struct.
+type Profile struct {
+	profileService ProfileService
+}
+
+// NewProfile constructor for Profile.
+func NewProfile(profileService ProfileService) *Profile {
+	return &Profile{profileService: profileService}
+}
+
+// InjectRoutes injects routes to global router.
+func (p Profile) InjectRoutes(r *gin.Engine, middlewares ...gin.HandlerFunc) {
+	profile := r.Group("/user/me").Use(middlewares...)
+	{
+		profile.GET("", p.getProfile)
+		profile.PATCH("", p.updateProfile)
+		profile.POST("/password", p.changePassword)
+	}
+}
+
+// getProfile gin handler function for getting the profile of the current user endpoint.
+// [GET] /user/me
+func (p Profile) getProfile(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting current user error", err))
+		return
+	}
+
+	user, err := p.profileService.GetProfile(ctx, userID)
+	if err != nil {
+		abortWithError(ctx, "getting profile error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, messages.NewUser(user))
+}
+
+// updateProfile gin handler function for changing the profile of the current user endpoint.
+// [PATCH] /user/me
+func (p Profile) updateProfile(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting current user error", err))
+		return
+	}
+
+	var req messages.UpdateProfileInput
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("request body validation error", err))
+		return
+	}
+
+	user, err := p.profileService.UpdateProfile(ctx, userID, req.ToDomain())
+	if err != nil {
+		abortWithError(ctx, "updating profile error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, messages.NewUser(user))
+}
+
+// changePassword gin handler function for changing the password of the current user endpoint.
+// [POST] /user/me/password
+func (p Profile) changePassword(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting current user error", err))
+		return
+	}
+
+	var req messages.ChangePasswordInput
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("request body validation error", err))
+		return
+	}
+
+	if err := p.profileService.ChangePassword(ctx, userID, req.ToDomain()); err != nil {
+		abortWithError(ctx, "changing password error", err)
+		return
+	}
+
+	ctx.SetCookie(refreshTokenCookieName, "", -1, "/auth", "localhost", false, true)
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,125 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lukinairina90/banking_backend/internal/transport/rest/messages"
+)
+
+// Role transport layer struct of the role management.
+type Role struct {
+	roleService RoleService
+}
+
+// NewRole constructor for Role.
+func NewRole(roleService RoleService) *Role {
+	return &Role{roleService: roleService}
+}
+
+// InjectRoutes injects routes to global router.
+func (t Role) InjectRoutes(r *gin.Engine, middlewares ...gin.HandlerFunc) {
+	roles := r.Group("/admin/roles").Use(middlewares...)
+	{
+		roles.GET("", t.getRoles)
+		roles.POST("", t.createRole)
+	}
+
+	userRoles := r.Group("/admin/users/:id/roles").Use(middlewares...)
+	{
+		userRoles.GET("", t.getUserRoles)
+		userRoles.POST("", t.assignRole)
+		userRoles.DELETE("/:role", t.revokeRole)
+	}
+}
+
+// getRoles gin handler function for get roles list endpoint.
+// [GET] /admin/roles
+func (t Role) getRoles(ctx *gin.Context) {
+	roles, err := t.roleService.GetRoles(ctx)
+	if err != nil {
+		abortWithError(ctx, "getting roles error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, messages.NewRoles(roles))
+}
+
+// createRole gin handler function for role creation endpoint.
+// [POST] /admin/roles
+func (t Role) createRole(ctx *gin.Context) {
+	var req messages.CreateRoleInput
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("request body validation error", err))
+		return
+	}
+
+	role, err := t.roleService.CreateRole(ctx, req.Name)
+	if err != nil {
+		abortWithError(ctx, "role creation error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, messages.Role{ID: role.ID, Name: role.Name})
+}
+
+// getUserRoles gin handler function for get user roles endpoint.
+// [GET] /admin/users/:id/roles
+func (t Role) getUserRoles(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("wrong \"id\" request param", err))
+		return
+	}
+
+	roles, err := t.roleService.GetUserRoles(ctx, userID)
+	if err != nil {
+		abortWithError(ctx, "getting user roles error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, messages.NewRoles(roles))
+}
+
+// assignRole gin handler function for role assignment endpoint.
+// [POST] /admin/users/:id/roles
+func (t Role) assignRole(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("wrong \"id\" request param", err))
+		return
+	}
+
+	var req messages.AssignRoleInput
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("request body validation error", err))
+		return
+	}
+
+	roles, err := t.roleService.AssignRole(ctx, userID, req.Role)
+	if err != nil {
+		abortWithError(ctx, "role assignment error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, messages.NewRoles(roles))
+}
+
+// revokeRole gin handler function for role revocation endpoint.
+// [DELETE] /admin/users/:id/roles/:role
+func (t Role) revokeRole(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("wrong \"id\" request param", err))
+		return
+	}
+
+	roles, err := t.roleService.RevokeRole(ctx, userID, ctx.Param("role"))
+	if err != nil {
+		abortWithError(ctx, "role revocation error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, messages.NewRoles(roles))
+}
@@ -0,0 +1,62 @@
+package rest
+
+import "github.com/gin-gonic/gin"
+
+// Transports transport layer structs serving the API.
+type Transports struct {
+	Auth        *Auth
+	JWKS        *JWKS
+	TwoFactor   *TwoFactor
+	Profile     *Profile
+	Account     *Account
+	Transaction *Transaction
+	Card        *Card
+	Event       *Event
+	FX          *FX
+	AdminUsers  *AdminUsers
+	Role        *Role
+	Policy      *Policy
+	AdminCache  *AdminCache
+	Webhook     *Webhook
+}
+
+// RouteTransports returns the transports without services, they can only register the routes, e.g. to enumerate the routes of the API.
+func RouteTransports() Transports {
+	return Transports{
+		Auth:        NewAuth(nil, nil),
+		JWKS:        NewJWKS(nil),
+		TwoFactor:   NewTwoFactor(nil),
+		Profile:     NewProfile(nil),
+		Account:     NewAccount(nil, nil),
+		Transaction: NewTransaction(nil),
+		Card:        NewCard(nil),
+		Event:       NewEvent(nil),
+		FX:          NewFX(nil),
+		AdminUsers:  NewAdminUsers(nil),
+		Role:        NewRole(nil),
+		Policy:      NewPolicy(nil),
+		AdminCache:  NewAdminCache(nil),
+		Webhook:     NewWebhook(nil),
+	}
+}
+
+// InjectRoutes injects the routes of all transports to the router.
+// The auth and JWKS routes are served without the authentication, the RBAC middleware checks all routes.
+func (t Transports) InjectRoutes(r *gin.Engine, rbacMiddleware gin.HandlerFunc) {
+	authMiddleware := t.Auth.AuthMiddleware()
+
+	t.Auth.InjectRoutes(r, rbacMiddleware)
+	t.JWKS.InjectRoutes(r, rbacMiddleware)
+	t.TwoFactor.InjectRoutes(r, authMiddleware, rbacMiddleware)
+	t.Profile.InjectRoutes(r, authMiddleware, rbacMiddleware)
+	t.Account.InjectRoutes(r, authMiddleware, rbacMiddleware)
+	t.Transaction.InjectRoutes(r, authMiddleware, rbacMiddleware)
+	t.Card.InjectRoutes(r, authMiddleware, rbacMiddleware)
+	t.Event.InjectRoutes(r, authMiddleware, rbacMiddleware)
+	t.FX.InjectRoutes(r, authMiddleware, rbacMiddleware)
+	t.AdminUsers.InjectRoutes(r, authMiddleware, rbacMiddleware)
+	t.Role.InjectRoutes(r, authMiddleware, rbacMiddleware)
+	t.Policy.InjectRoutes(r, authMiddleware, rbacMiddleware)
+	t.AdminCache.InjectRoutes(r, authMiddleware, rbacMiddleware)
+	t.Webhook.InjectRoutes(r, authMiddleware, rbacMiddleware)
+}
@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lukinairina90/banking_backend/internal/domain"
+)
+
+// Routes registry of the routes registered in the gin engine.
+type Routes struct {
+	engine *gin.Engine
+}
+
+// NewRoutes constructor for Routes.
+func NewRoutes(engine *gin.Engine) *Routes {
+	return &Routes{engine: engine}
+}
+
+// Routes returns the routes registered in the engine so far, path parameters are written as {name} like in the RBAC policy.
+func (r Routes) Routes() []domain.Route {
+	infos := r.engine.Routes()
+
+	routes := make([]domain.Route, 0, len(infos))
+	for _, info := range infos {
+		routes = append(routes, domain.Route{Method: info.Method, Path: policyPath(info.Path)})
+	}
+
+	return routes
+}
+
+// policyPath converts the gin route path to the RBAC policy path template, /account/:id becomes /account/{id}.
+func policyPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -1,6 +1,8 @@
 package rest
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -25,6 +27,11 @@ func (t Transaction) InjectRoutes(r *gin.Engine, middlewares ...gin.HandlerFunc)
 	{
 		transaction.GET("/", t.getTransactionList)
 	}
+
+	transactions := r.Group("transaction").Use(middlewares...)
+	{
+		transactions.POST("/:id/reverse", t.reverseTransaction)
+	}
 }
 
 // getTransactionList gin handler function for get list transaction endpoint.
@@ -83,24 +90,45 @@ func (t Transaction) getTransactionList(ctx *gin.Context) {
 	domainTransactionsList, err := t.TransactionService.GetTransactionList(ctx, accountID, userID, orderings, pag)
 
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting transactions list error", err))
+		abortWithError(ctx, "getting transactions list error", err)
 		return
 	}
 
 	messageTransactionsList := make([]messages.Transaction, 0, len(domainTransactionsList))
 	for _, transaction := range domainTransactionsList {
-		messageTransaction := messages.Transaction{
-			ID:          transaction.ID,
-			FromAccount: transaction.FromAccount,
-			ToAccount:   transaction.ToAccount,
-			Amount:      transaction.Amount,
-			Type:        transaction.Type,
-			Status:      transaction.Status,
-			DateCreated: transaction.DateCreated,
-			DateUpdated: transaction.DateUpdated,
-		}
-		messageTransactionsList = append(messageTransactionsList, messageTransaction)
+		messageTransactionsList = append(messageTransactionsList, messages.NewTransaction(transaction))
 	}
 
 	ctx.JSON(http.StatusOK, messageTransactionsList)
 }
+
+// reverseTransaction gin handler function for reverse transaction endpoint.
+// [POST] /transaction/:id/reverse
+func (t Transaction) reverseTransaction(ctx *gin.Context) {
+	transactionID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("wrong \"id\" request param", err))
+		return
+	}
+
+	adminID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting current user error", err))
+		return
+	}
+
+	// the body is optional, the whole transaction is reversed without it
+	var req messages.ReverseTransactionRequestBody
+	if err = ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("validation request body error", err))
+		return
+	}
+
+	reversal, err := t.TransactionService.ReverseTransaction(ctx, transactionID, adminID, req.Amount, req.Force)
+	if err != nil {
+		abortWithError(ctx, "reversing transaction error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, messages.NewTransaction(reversal))
+}
@@ -0,0 +1,118 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lukinairina90/banking_backend/internal/transport/rest/messages"
+)
+
+// TwoFactor transport layer struct.
+type TwoFactor struct {
+	twoFactorService TwoFactorService
+}
+
+// NewTwoFactor constructor for TwoFactor.
+func NewTwoFactor(twoFactorService TwoFactorService) *TwoFactor {
+	return &TwoFactor{twoFactorService: twoFactorService}
+}
+
+// InjectRoutes injects routes to global router.
+func (t TwoFactor) InjectRoutes(r *gin.Engine, middlewares ...gin.HandlerFunc) {
+	twoFactor := r.Group("/auth/2fa").Use(middlewares...)
+	{
+		twoFactor.POST("/enroll", t.enroll)
+		twoFactor.POST("/confirm", t.confirm)
+		twoFactor.POST("/disable", t.disable)
+		twoFactor.POST("/recovery-codes", t.regenerateRecoveryCodes)
+	}
+}
+
+// enroll gin handler function for TOTP secret generation endpoint.
+// [POST] /auth/2fa/enroll
+func (t TwoFactor) enroll(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting current user error", err))
+		return
+	}
+
+	enrollment, err := t.twoFactorService.Enroll(ctx, userID)
+	if err != nil {
+		abortWithError(ctx, "two-factor enrollment error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, messages.NewTOTPEnrollment(enrollment))
+}
+
+// confirm gin handler function for enabling two-factor authentication with the first TOTP code endpoint.
+// [POST] /auth/2fa/confirm
+func (t TwoFactor) confirm(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting current user error", err))
+		return
+	}
+
+	var req messages.TwoFactorCodeInput
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("request body validation error", err))
+		return
+	}
+
+	codes, err := t.twoFactorService.Confirm(ctx, userID, req.Code)
+	if err != nil {
+		abortWithError(ctx, "two-factor confirmation error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, messages.RecoveryCodes{RecoveryCodes: codes})
+}
+
+// disable gin handler function for disabling two-factor authentication endpoint.
+// [POST] /auth/2fa/disable
+func (t TwoFactor) disable(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting current user error", err))
+		return
+	}
+
+	var req messages.TwoFactorCodeInput
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("request body validation error", err))
+		return
+	}
+
+	if err := t.twoFactorService.Disable(ctx, userID, req.Code); err != nil {
+		abortWithError(ctx, "disabling two-factor authentication error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// regenerateRecoveryCodes gin handler function for replacing the recovery codes endpoint.
+// [POST] /auth/2fa/recovery-codes
+func (t TwoFactor) regenerateRecoveryCodes(ctx *gin.Context) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting current user error", err))
+		return
+	}
+
+	var req messages.TwoFactorCodeInput
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("request body validation error", err))
+		return
+	}
+
+	codes, err := t.twoFactorService.RegenerateRecoveryCodes(ctx, userID, req.Code)
+	if err != nil {
+		abortWithError(ctx, "regenerating recovery codes error", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, messages.RecoveryCodes{RecoveryCodes: codes})
+}
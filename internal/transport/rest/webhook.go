@@ -0,0 +1,228 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lukinairina90/banking_backend/internal/transport/rest/messages"
+)
+
+// webhookOwner returns the user the webhook subscriptions of the request belong to.
+type webhookOwner func(ctx *gin.Context) (int, error)
+
+// Webhook transport layer struct of the webhook subscriptions.
+// Users manage the subscriptions to their own events under /webhook, admins manage the subscriptions to the events of all users under /admin/webhooks.
+type Webhook struct {
+	webhookService WebhookService
+}
+
+// NewWebhook constructor for Webhook.
+func NewWebhook(webhookService WebhookService) *Webhook {
+	return &Webhook{webhookService: webhookService}
+}
+
+// InjectRoutes injects routes to global router.
+func (t Webhook) InjectRoutes(r *gin.Engine, middlewares ...gin.HandlerFunc) {
+	webhooks := r.Group("/webhook").Use(middlewares...)
+	{
+		webhooks.GET("/", t.getSubscriptions(getUserIDFromContext))
+		webhooks.POST("/", t.createSubscription(getUserIDFromContext))
+		webhooks.GET("/:id", t.getSubscription(getUserIDFromContext))
+		webhooks.DELETE("/:id", t.deleteSubscription(getUserIDFromContext))
+		webhooks.GET("/:id/deliveries/", t.getDeliveries(getUserIDFromContext))
+		webhooks.GET("/:id/deliveries/:delivery_id", t.getDelivery(getUserIDFromContext))
+		webhooks.POST("/:id/deliveries/:delivery_id/redeliver", t.redeliver(getUserIDFromContext))
+	}
+
+	adminWebhooks := r.Group("/admin/webhooks").Use(middlewares...)
+	{
+		adminWebhooks.GET("", t.getSubscriptions(allUsers))
+		adminWebhooks.POST("", t.createSubscription(allUsers))
+		adminWebhooks.GET("/:id", t.getSubscription(allUsers))
+		adminWebhooks.DELETE("/:id", t.deleteSubscription(allUsers))
+		adminWebhooks.GET("/:id/deliveries", t.getDeliveries(allUsers))
+		adminWebhooks.GET("/:id/deliveries/:delivery_id", t.getDelivery(allUsers))
+		adminWebhooks.POST("/:id/deliveries/:delivery_id/redeliver", t.redeliver(allUsers))
+	}
+}
+
+// allUsers webhookOwner of the subscriptions to the events of all users.
+func allUsers(*gin.Context) (int, error) {
+	return 0, nil
+}
+
+// getSubscriptions gin handler function for get webhook subscriptions list endpoint.
+// [GET] /webhook/
+// [GET] /admin/webhooks
+func (t Webhook) getSubscriptions(owner webhookOwner) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, err := owner(ctx)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting current user error", err))
+			return
+		}
+
+		subscriptions, err := t.webhookService.GetSubscriptions(ctx, userID)
+		if err != nil {
+			abortWithError(ctx, "getting webhook subscriptions error", err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, messages.NewWebhooks(subscriptions))
+	}
+}
+
+// createSubscription gin handler function for webhook subscription creation endpoint.
+// [POST] /webhook/
+// [POST] /admin/webhooks
+func (t Webhook) createSubscription(owner webhookOwner) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, err := owner(ctx)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting current user error", err))
+			return
+		}
+
+		var req messages.CreateWebhookInput
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("request body validation error", err))
+			return
+		}
+
+		subscription, err := t.webhookService.CreateSubscription(ctx, userID, req.ToDomain())
+		if err != nil {
+			abortWithError(ctx, "webhook subscription creation error", err)
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, messages.CreatedWebhook{Webhook: messages.NewWebhook(subscription), Secret: subscription.Secret})
+	}
+}
+
+// getSubscription gin handler function for get webhook subscription endpoint.
+// [GET] /webhook/:id
+// [GET] /admin/webhooks/:id
+func (t Webhook) getSubscription(owner webhookOwner) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, subscriptionID, ok := t.subscriptionParams(ctx, owner)
+		if !ok {
+			return
+		}
+
+		subscription, err := t.webhookService.GetSubscription(ctx, userID, subscriptionID)
+		if err != nil {
+			abortWithError(ctx, "getting webhook subscription error", err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, messages.NewWebhook(subscription))
+	}
+}
+
+// deleteSubscription gin handler function for webhook subscription deletion endpoint.
+// [DELETE] /webhook/:id
+// [DELETE] /admin/webhooks/:id
+func (t Webhook) deleteSubscription(owner webhookOwner) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, subscriptionID, ok := t.subscriptionParams(ctx, owner)
+		if !ok {
+			return
+		}
+
+		if err := t.webhookService.DeleteSubscription(ctx, userID, subscriptionID); err != nil {
+			abortWithError(ctx, "webhook subscription deletion error", err)
+			return
+		}
+
+		ctx.JSON(http.StatusNoContent, nil)
+	}
+}
+
+// getDeliveries gin handler function for get webhook deliveries list endpoint.
+// [GET] /webhook/:id/deliveries/
+// [GET] /admin/webhooks/:id/deliveries
+func (t Webhook) getDeliveries(owner webhookOwner) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, subscriptionID, ok := t.subscriptionParams(ctx, owner)
+		if !ok {
+			return
+		}
+
+		deliveries, err := t.webhookService.GetDeliveries(ctx, userID, subscriptionID)
+		if err != nil {
+			abortWithError(ctx, "getting webhook deliveries error", err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, messages.NewWebhookDeliveries(deliveries))
+	}
+}
+
+// getDelivery gin handler function for get webhook delivery with its attempts endpoint.
+// [GET] /webhook/:id/deliveries/:delivery_id
+// [GET] /admin/webhooks/:id/deliveries/:delivery_id
+func (t Webhook) getDelivery(owner webhookOwner) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, subscriptionID, ok := t.subscriptionParams(ctx, owner)
+		if !ok {
+			return
+		}
+
+		deliveryID, err := strconv.Atoi(ctx.Param("delivery_id"))
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("wrong \"delivery_id\" request param", err))
+			return
+		}
+
+		delivery, attempts, err := t.webhookService.GetDelivery(ctx, userID, subscriptionID, deliveryID)
+		if err != nil {
+			abortWithError(ctx, "getting webhook delivery error", err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, messages.NewWebhookDelivery(delivery, attempts))
+	}
+}
+
+// redeliver gin handler function for webhook redelivery endpoint.
+// [POST] /webhook/:id/deliveries/:delivery_id/redeliver
+// [POST] /admin/webhooks/:id/deliveries/:delivery_id/redeliver
+func (t Webhook) redeliver(owner webhookOwner) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, subscriptionID, ok := t.subscriptionParams(ctx, owner)
+		if !ok {
+			return
+		}
+
+		deliveryID, err := strconv.Atoi(ctx.Param("delivery_id"))
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("wrong \"delivery_id\" request param", err))
+			return
+		}
+
+		if err := t.webhookService.Redeliver(ctx, userID, subscriptionID, deliveryID); err != nil {
+			abortWithError(ctx, "webhook redelivery error", err)
+			return
+		}
+
+		ctx.JSON(http.StatusAccepted, nil)
+	}
+}
+
+// subscriptionParams returns the owner and the subscription ID of the request, the request is aborted when they are not available.
+func (t Webhook) subscriptionParams(ctx *gin.Context, owner webhookOwner) (int, int, bool) {
+	userID, err := owner(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, NewInternalServerError("getting current user error", err))
+		return 0, 0, false
+	}
+
+	subscriptionID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, NewBadRequestError("wrong \"id\" request param", err))
+		return 0, 0, false
+	}
+
+	return userID, subscriptionID, true
+}
@@ -0,0 +1,288 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxIdleConns number of idle connections kept for reuse.
+const maxIdleConns = 8
+
+// Redis cache client of a Redis compatible server (Redis, Valkey, KeyDB or a local fake speaking RESP).
+// Only the GET, SET with expiration and DEL commands are used.
+type Redis struct {
+	addr     string
+	password string
+	db       int
+	timeout  time.Duration
+	idle     chan *redisConn
+}
+
+// redisConn connection to the server with its buffered reader.
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// redisError error reply of the server.
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+// NewRedis constructor for Redis cache, authentication is skipped when the password is empty.
+// Every command including dialing is limited by timeout.
+func NewRedis(addr, password string, db int, timeout time.Duration) *Redis {
+	return &Redis{
+		addr:     addr,
+		password: password,
+		db:       db,
+		timeout:  timeout,
+		idle:     make(chan *redisConn, maxIdleConns),
+	}
+}
+
+// Get returns the value of the key, false when the key is missing.
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := c.do(ctx, "GET", key)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "redis GET error")
+	}
+
+	if reply == nil {
+		return nil, false, nil
+	}
+
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, errors.Errorf("redis GET unexpected reply %v", reply)
+	}
+
+	return value, true, nil
+}
+
+// Set stores the value of the key for ttl. Expiration has millisecond precision, a ttl under a millisecond
+// would be sent as PX 0, which the server refuses, so such a value is not stored at all.
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl < time.Millisecond {
+		return nil
+	}
+
+	if _, err := c.do(ctx, "SET", key, string(value), "PX", strconv.FormatInt(ttl.Milliseconds(), 10)); err != nil {
+		return errors.Wrap(err, "redis SET error")
+	}
+
+	return nil
+}
+
+// Delete removes the keys.
+func (c *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if _, err := c.do(ctx, "DEL", keys...); err != nil {
+		return errors.Wrap(err, "redis DEL error")
+	}
+
+	return nil
+}
+
+// Close closes the idle connections.
+func (c *Redis) Close() error {
+	for {
+		select {
+		case rc := <-c.idle:
+			_ = rc.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do sends the command and returns its reply, the connection is dropped after any error but an error reply.
+// An idle connection may have been closed by the server meanwhile, e.g. on restart, so a command failing on it
+// is sent once more on a new connection.
+func (c *Redis) do(ctx context.Context, name string, args ...string) (interface{}, error) {
+	rc, reused, err := c.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := c.send(ctx, rc, name, args...)
+	if err == nil || !reused || !retryable(err) || ctx.Err() != nil {
+		return reply, err
+	}
+
+	if rc, err = c.dial(ctx); err != nil {
+		return nil, err
+	}
+
+	return c.send(ctx, rc, name, args...)
+}
+
+// send sends the command on the connection and releases it, the connection is closed after any error but an error reply.
+func (c *Redis) send(ctx context.Context, rc *redisConn, name string, args ...string) (interface{}, error) {
+	reply, err := rc.command(c.deadline(ctx), name, args...)
+	if err != nil {
+		var replyErr redisError
+		if errors.As(err, &replyErr) {
+			c.release(rc)
+		} else {
+			_ = rc.conn.Close()
+		}
+
+		return nil, err
+	}
+
+	c.release(rc)
+
+	return reply, nil
+}
+
+// conn returns an idle connection, reused is true, or dials a new one.
+func (c *Redis) conn(ctx context.Context) (rc *redisConn, reused bool, err error) {
+	select {
+	case rc := <-c.idle:
+		return rc, true, nil
+	default:
+	}
+
+	rc, err = c.dial(ctx)
+
+	return rc, false, err
+}
+
+// dial connects to the server, authenticates the connection and selects the database.
+func (c *Redis) dial(ctx context.Context) (*redisConn, error) {
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "dialing redis error")
+	}
+
+	rc := &redisConn{conn: conn, reader: bufio.NewReader(conn)}
+
+	if c.password != "" {
+		if _, err := rc.command(c.deadline(ctx), "AUTH", c.password); err != nil {
+			_ = conn.Close()
+			return nil, errors.Wrap(err, "redis authentication error")
+		}
+	}
+
+	if c.db != 0 {
+		if _, err := rc.command(c.deadline(ctx), "SELECT", strconv.Itoa(c.db)); err != nil {
+			_ = conn.Close()
+			return nil, errors.Wrap(err, "redis database selection error")
+		}
+	}
+
+	return rc, nil
+}
+
+// retryable reports whether the command may be sent again on a new connection: error replies and timeouts
+// would fail the same way.
+func retryable(err error) bool {
+	var replyErr redisError
+	if errors.As(err, &replyErr) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+
+	return true
+}
+
+// release returns the connection to the idle ones or closes it when there are enough.
+func (c *Redis) release(rc *redisConn) {
+	select {
+	case c.idle <- rc:
+	default:
+		_ = rc.conn.Close()
+	}
+}
+
+// deadline returns the deadline of a command, the earliest of the context deadline and the timeout.
+func (c *Redis) deadline(ctx context.Context) time.Time {
+	deadline := time.Now().Add(c.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		return ctxDeadline
+	}
+
+	return deadline
+}
+
+// command writes the command as a RESP array of bulk strings and reads the reply.
+func (rc *redisConn) command(deadline time.Time, name string, args ...string) (interface{}, error) {
+	if err := rc.conn.SetDeadline(deadline); err != nil {
+		return nil, errors.Wrap(err, "setting redis connection deadline error")
+	}
+
+	w := bufio.NewWriter(rc.conn)
+	fmt.Fprintf(w, "*%d\r\n$%d\r\n%s\r\n", len(args)+1, len(name), name)
+	for _, arg := range args {
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	if err := w.Flush(); err != nil {
+		return nil, errors.Wrap(err, "writing redis command error")
+	}
+
+	return rc.reply()
+}
+
+// reply reads a simple string, error, integer or bulk string reply, a missing bulk string is nil.
+func (rc *redisConn) reply() (interface{}, error) {
+	line, err := rc.reader.ReadString('\n')
+	if err != nil {
+		return nil, errors.Wrap(err, "reading redis reply error")
+	}
+
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.Errorf("malformed redis reply %q", line)
+	}
+
+	payload := line[1 : len(line)-2]
+	switch line[0] {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, redisError(payload)
+	case ':':
+		n, err := strconv.ParseInt(payload, 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing redis integer reply error")
+		}
+
+		return n, nil
+	case '$':
+		size, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing redis bulk string size error")
+		}
+
+		if size < 0 {
+			return nil, nil
+		}
+
+		value := make([]byte, size+2)
+		if _, err := io.ReadFull(rc.reader, value); err != nil {
+			return nil, errors.Wrap(err, "reading redis bulk string error")
+		}
+
+		return value[:size], nil
+	default:
+		return nil, errors.Errorf("unsupported redis reply %q", line)
+	}
+}
@@ -0,0 +1,374 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis in-process server speaking the subset of RESP used by the Redis cache.
+type fakeRedis struct {
+	listener net.Listener
+	password string
+	silent   bool
+
+	mu       sync.Mutex
+	values   map[string]string
+	commands [][]string
+	conns    []net.Conn
+}
+
+// newFakeRedis starts the server, it is stopped by the test cleanup. A silent server accepts commands but never replies.
+func newFakeRedis(t *testing.T, password string, silent bool) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeRedis{listener: listener, password: password, silent: silent, values: make(map[string]string)}
+	t.Cleanup(s.stop)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			s.mu.Lock()
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+// addr returns the address the server listens on.
+func (s *fakeRedis) addr() string {
+	return s.listener.Addr().String()
+}
+
+// dropConnections closes the open connections like a restarted server.
+func (s *fakeRedis) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.conns = nil
+}
+
+// stop stops listening and closes the open connections.
+func (s *fakeRedis) stop() {
+	_ = s.listener.Close()
+	s.dropConnections()
+}
+
+// commandNames returns the names of the received commands.
+func (s *fakeRedis) commandNames() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.commands))
+	for _, command := range s.commands {
+		names = append(names, command[0])
+	}
+
+	return names
+}
+
+// lastCommand returns the last received command.
+func (s *fakeRedis) lastCommand() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.commands) == 0 {
+		return nil
+	}
+
+	return s.commands[len(s.commands)-1]
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	for {
+		command, err := readCommand(reader)
+		if err != nil {
+			_ = conn.Close()
+			return
+		}
+
+		s.mu.Lock()
+		s.commands = append(s.commands, command)
+		reply := s.execute(command)
+		s.mu.Unlock()
+
+		if s.silent {
+			continue
+		}
+
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeRedis) execute(command []string) string {
+	switch command[0] {
+	case "AUTH":
+		if command[1] != s.password {
+			return "-WRONGPASS invalid username-password pair\r\n"
+		}
+
+		return "+OK\r\n"
+	case "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		value, ok := s.values[command[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		if ms, err := strconv.Atoi(command[4]); err != nil || ms <= 0 {
+			return "-ERR invalid expire time in 'set' command\r\n"
+		}
+
+		s.values[command[1]] = command[2]
+
+		return "+OK\r\n"
+	case "DEL":
+		var deleted int
+		for _, key := range command[1:] {
+			if _, ok := s.values[key]; ok {
+				delete(s.values, key)
+				deleted++
+			}
+		}
+
+		return fmt.Sprintf(":%d\r\n", deleted)
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+// readCommand reads a command sent as a RESP array of bulk strings.
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	command := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		size, err := strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+		if err != nil {
+			return nil, err
+		}
+
+		value := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return nil, err
+		}
+
+		command = append(command, string(value[:size]))
+	}
+
+	return command, nil
+}
+
+func TestRedisConn_Reply(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "simple_string", input: "+OK\r\n", want: "OK"},
+		{name: "integer", input: ":42\r\n", want: int64(42)},
+		{name: "bulk_string", input: "$5\r\nhello\r\n", want: []byte("hello")},
+		{name: "bulk_string_with_line_break", input: "$7\r\nhe\r\nllo\r\n", want: []byte("he\r\nllo")},
+		{name: "empty_bulk_string", input: "$0\r\n\r\n", want: []byte{}},
+		{name: "missing_bulk_string", input: "$-1\r\n", want: nil},
+		{name: "error_reply", input: "-ERR wrong type\r\n", wantErr: true},
+		{name: "missing_carriage_return_error", input: "+OK\n", wantErr: true},
+		{name: "too_short_error", input: "+\n", wantErr: true},
+		{name: "malformed_integer_error", input: ":4x\r\n", wantErr: true},
+		{name: "malformed_bulk_string_size_error", input: "$x\r\n", wantErr: true},
+		{name: "truncated_bulk_string_error", input: "$5\r\nhel", wantErr: true},
+		{name: "unsupported_reply_error", input: "*1\r\n", wantErr: true},
+		{name: "closed_connection_error", input: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &redisConn{reader: bufio.NewReader(strings.NewReader(tt.input))}
+
+			got, err := rc.reply()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRedisConn_ReplyErrorType(t *testing.T) {
+	rc := &redisConn{reader: bufio.NewReader(strings.NewReader("-ERR wrong type\r\n"))}
+
+	_, err := rc.reply()
+	assert.Equal(t, redisError("ERR wrong type"), err)
+}
+
+func TestRedis_GetSetDelete(t *testing.T) {
+	server := newFakeRedis(t, "secret", false)
+	c := NewRedis(server.addr(), "secret", 2, time.Second)
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx := context.Background()
+
+	_, ok, err := c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set(ctx, "key", []byte("value"), time.Minute))
+	assert.Equal(t, []string{"SET", "key", "value", "PX", "60000"}, server.lastCommand())
+
+	value, ok, err := c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value"), value)
+
+	require.NoError(t, c.Delete(ctx, "key", "other"))
+	require.NoError(t, c.Delete(ctx))
+
+	_, ok, err = c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// the connection is reused, it is authenticated and the database is selected once
+	assert.Equal(t, []string{"AUTH", "SELECT", "GET", "SET", "GET", "DEL", "GET"}, server.commandNames())
+}
+
+func TestRedis_SetTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		wantSent []string
+	}{
+		{name: "sub_millisecond_ttl_skipped", ttl: 500 * time.Microsecond},
+		{name: "zero_ttl_skipped", ttl: 0},
+		{name: "negative_ttl_skipped", ttl: -time.Second},
+		{name: "millisecond_ttl", ttl: time.Millisecond, wantSent: []string{"SET", "key", "value", "PX", "1"}},
+		{name: "fractional_millisecond_ttl_truncated", ttl: 1500 * time.Microsecond, wantSent: []string{"SET", "key", "value", "PX", "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeRedis(t, "", false)
+			c := NewRedis(server.addr(), "", 0, time.Second)
+			t.Cleanup(func() { _ = c.Close() })
+
+			assert.NoError(t, c.Set(context.Background(), "key", []byte("value"), tt.ttl))
+			assert.Equal(t, tt.wantSent, server.lastCommand())
+		})
+	}
+}
+
+func TestRedis_AuthenticationError(t *testing.T) {
+	server := newFakeRedis(t, "secret", false)
+	c := NewRedis(server.addr(), "wrong", 0, time.Second)
+	t.Cleanup(func() { _ = c.Close() })
+
+	_, _, err := c.Get(context.Background(), "key")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"AUTH"}, server.commandNames())
+}
+
+func TestRedis_ErrorReplyKeepsConnection(t *testing.T) {
+	server := newFakeRedis(t, "", false)
+	c := NewRedis(server.addr(), "", 0, time.Second)
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx := context.Background()
+
+	_, err := c.do(ctx, "UNKNOWN")
+	var replyErr redisError
+	assert.ErrorAs(t, err, &replyErr)
+
+	_, _, err = c.Get(ctx, "key")
+	assert.NoError(t, err)
+
+	// the error reply is not retried and the connection stays usable
+	assert.Equal(t, []string{"UNKNOWN", "GET"}, server.commandNames())
+	server.mu.Lock()
+	assert.Len(t, server.conns, 1)
+	server.mu.Unlock()
+}
+
+func TestRedis_Reconnect(t *testing.T) {
+	server := newFakeRedis(t, "secret", false)
+	c := NewRedis(server.addr(), "secret", 0, time.Second)
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key", []byte("value"), time.Minute))
+
+	// the idle connection is closed by the server, the command is sent again on a new authenticated connection
+	server.dropConnections()
+
+	value, ok, err := c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value"), value)
+	assert.Equal(t, []string{"AUTH", "SET", "AUTH", "GET"}, server.commandNames())
+}
+
+func TestRedis_ServerDown(t *testing.T) {
+	server := newFakeRedis(t, "", false)
+	c := NewRedis(server.addr(), "", 0, time.Second)
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key", []byte("value"), time.Minute))
+
+	server.stop()
+
+	_, _, err := c.Get(ctx, "key")
+	assert.Error(t, err)
+}
+
+func TestRedis_Timeout(t *testing.T) {
+	server := newFakeRedis(t, "", true)
+	c := NewRedis(server.addr(), "", 0, 50*time.Millisecond)
+	t.Cleanup(func() { _ = c.Close() })
+
+	start := time.Now()
+	_, _, err := c.Get(context.Background(), "key")
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
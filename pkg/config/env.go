@@ -7,23 +7,110 @@ import (
 )
 
 type Config struct {
-	Port        string        `env:"PORT,required"`
-	DBHost      string        `env:"DB_HOST,required"`
-	DBPort      string        `env:"DB_PORT,required"`
-	DBUser      string        `env:"DB_USER,required"`
-	DBPass      string        `env:"DB_PASS,required"`
-	DBName      string        `env:"DB_NAME,required"`
-	SSLMode     bool          `env:"DB_SSL_MODE,required"`
-	TokenSecret string        `env:"TOKEN_SECRET,required"`
-	TokenTTL    time.Duration `env:"TOKEN_TTL,required"`
+	Port     string        `env:"PORT,required"`
+	DBHost   string        `env:"DB_HOST,required"`
+	DBPort   string        `env:"DB_PORT,required"`
+	DBUser   string        `env:"DB_USER,required"`
+	DBPass   string        `env:"DB_PASS,required"`
+	DBName   string        `env:"DB_NAME,required"`
+	SSLMode  bool          `env:"DB_SSL_MODE,required"`
+	TokenTTL time.Duration `env:"TOKEN_TTL,required"`
 
-	UserPasswordSalt string     `env:"USER_PASSWORD_SALT" envDefault:"salt"`
-	RBACConfig       RBACConfig `envPrefix:"RBAC_"`
+	UserPasswordSalt  string          `env:"USER_PASSWORD_SALT" envDefault:"salt"`
+	IdempotencyKeyTTL time.Duration   `env:"IDEMPOTENCY_KEY_TTL" envDefault:"24h"`
+	IdempotencyLock   time.Duration   `env:"IDEMPOTENCY_LOCK_TIMEOUT" envDefault:"1m"`
+	RBACConfig        RBACConfig      `envPrefix:"RBAC_"`
+	FXConfig          FXConfig        `envPrefix:"FX_"`
+	PasswordConfig    PasswordConfig  `envPrefix:"PASSWORD_"`
+	JWTConfig         JWTConfig       `envPrefix:"JWT_"`
+	TwoFactorConfig   TwoFactorConfig `envPrefix:"TWO_FACTOR_"`
+	MailConfig        MailConfig      `envPrefix:"MAIL_"`
+	SignInConfig      SignInConfig    `envPrefix:"SIGN_IN_"`
+	CacheConfig       CacheConfig     `envPrefix:"CACHE_"`
+	WebhookConfig     WebhookConfig   `envPrefix:"WEBHOOK_"`
+
+	TransactionSweepInterval   time.Duration `env:"TRANSACTION_SWEEP_INTERVAL" envDefault:"1m"`
+	TransactionPreparedTimeout time.Duration `env:"TRANSACTION_PREPARED_TIMEOUT" envDefault:"5m"`
+	BlockedReceiveOnly         bool          `env:"BLOCKED_RECEIVE_ONLY" envDefault:"false"`
+	TrustedProxies             []string      `env:"TRUSTED_PROXIES"`
 }
 
 type RBACConfig struct {
-	ModelFilePath  string `env:"MODEL_FILE_PATH,required"`
-	PolicyFilePath string `env:"POLICY_FILE_PATH,required"`
+	ModelFilePath  string        `env:"MODEL_FILE_PATH,required"`
+	PolicyFilePath string        `env:"POLICY_FILE_PATH,required"`
+	ReloadInterval time.Duration `env:"RELOAD_INTERVAL" envDefault:"10s"`
+}
+
+type CacheConfig struct {
+	Driver        string        `env:"DRIVER" envDefault:"memory"`
+	TTL           time.Duration `env:"TTL" envDefault:"30s"`
+	Size          int           `env:"SIZE" envDefault:"10000"`
+	RedisAddr     string        `env:"REDIS_ADDR" envDefault:"localhost:6379"`
+	RedisPassword string        `env:"REDIS_PASSWORD"`
+	RedisDB       int           `env:"REDIS_DB" envDefault:"0"`
+	RedisTimeout  time.Duration `env:"REDIS_TIMEOUT" envDefault:"200ms"`
+}
+
+type WebhookConfig struct {
+	DispatchInterval time.Duration `env:"DISPATCH_INTERVAL" envDefault:"5s"`
+	BatchSize        int           `env:"BATCH_SIZE" envDefault:"50"`
+	Timeout          time.Duration `env:"TIMEOUT" envDefault:"10s"`
+	MaxAttempts      int           `env:"MAX_ATTEMPTS" envDefault:"8"`
+	BaseBackoff      time.Duration `env:"BASE_BACKOFF" envDefault:"30s"`
+	MaxBackoff       time.Duration `env:"MAX_BACKOFF" envDefault:"6h"`
+}
+
+type FXConfig struct {
+	RateProvider  string        `env:"RATE_PROVIDER" envDefault:"db"`
+	RatesFilePath string        `env:"RATES_FILE_PATH"`
+	SpreadBps     int64         `env:"SPREAD_BPS" envDefault:"50"`
+	QuoteTTL      time.Duration `env:"QUOTE_TTL" envDefault:"30s"`
+}
+
+type PasswordConfig struct {
+	Argon2Memory      uint32 `env:"ARGON2_MEMORY" envDefault:"65536"`
+	Argon2Iterations  uint32 `env:"ARGON2_ITERATIONS" envDefault:"3"`
+	Argon2Parallelism uint8  `env:"ARGON2_PARALLELISM" envDefault:"2"`
+}
+
+type JWTConfig struct {
+	SigningAlgorithm    string        `env:"SIGNING_ALGORITHM" envDefault:"RS256"`
+	KeysDir             string        `env:"KEYS_DIR" envDefault:"./keys"`
+	KeyRotationInterval time.Duration `env:"KEY_ROTATION_INTERVAL" envDefault:"720h"`
+	KeyOverlap          time.Duration `env:"KEY_OVERLAP" envDefault:"24h"`
+	KeyCheckInterval    time.Duration `env:"KEY_CHECK_INTERVAL" envDefault:"1m"`
+}
+
+type TwoFactorConfig struct {
+	Issuer               string        `env:"ISSUER" envDefault:"Banking"`
+	StepUpAmount         string        `env:"STEP_UP_AMOUNT" envDefault:"1000"`
+	ChallengeTTL         time.Duration `env:"CHALLENGE_TTL" envDefault:"5m"`
+	ChallengeMaxAttempts int           `env:"CHALLENGE_MAX_ATTEMPTS" envDefault:"5"`
+	CodeMaxFailures      int           `env:"CODE_MAX_FAILURES" envDefault:"5"`
+	CodeLockout          time.Duration `env:"CODE_LOCKOUT" envDefault:"15m"`
+}
+
+type MailConfig struct {
+	Driver               string        `env:"DRIVER" envDefault:"log"`
+	From                 string        `env:"FROM" envDefault:"Banking <no-reply@banking.local>"`
+	FileDir              string        `env:"FILE_DIR" envDefault:"./mail"`
+	SMTPHost             string        `env:"SMTP_HOST"`
+	SMTPPort             int           `env:"SMTP_PORT" envDefault:"587"`
+	SMTPUsername         string        `env:"SMTP_USERNAME"`
+	SMTPPassword         string        `env:"SMTP_PASSWORD"`
+	EmailVerificationURL string        `env:"EMAIL_VERIFICATION_URL" envDefault:"http://localhost:8080/auth/email/verify?token=%s"`
+	PasswordResetURL     string        `env:"PASSWORD_RESET_URL" envDefault:"http://localhost:3000/password/reset?token=%s"`
+	EmailVerificationTTL time.Duration `env:"EMAIL_VERIFICATION_TTL" envDefault:"24h"`
+	PasswordResetTTL     time.Duration `env:"PASSWORD_RESET_TTL" envDefault:"1h"`
+}
+
+type SignInConfig struct {
+	MaxEmailFailures int           `env:"MAX_EMAIL_FAILURES" envDefault:"5"`
+	MaxIPFailures    int           `env:"MAX_IP_FAILURES" envDefault:"20"`
+	FailureWindow    time.Duration `env:"FAILURE_WINDOW" envDefault:"15m"`
+	LockoutDuration  time.Duration `env:"LOCKOUT_DURATION" envDefault:"15m"`
+	BaseDelay        time.Duration `env:"BASE_DELAY" envDefault:"1s"`
+	MaxDelay         time.Duration `env:"MAX_DELAY" envDefault:"30s"`
 }
 
 func Parse() (Config, error) {
@@ -1,27 +1,113 @@
 package hash
 
 import (
+	"crypto/rand"
 	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
 	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	argon2idScheme = "argon2id"
+	saltLength     = 16
+	keyLength      = 32
 )
 
-// SHA1Hasher uses SHA1 to hash passwords with provided salt.
-type SHA1Hasher struct {
-	salt string
+// Argon2Params cost parameters of argon2id hashing.
+// Memory is set in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
 }
 
-// NewSHA1Hasher constructor for SHA1Hasher.
-func NewSHA1Hasher(salt string) *SHA1Hasher {
-	return &SHA1Hasher{salt: salt}
+// Argon2Hasher hashes passwords with argon2id and a random per-user salt.
+// Hashes are encoded in the versioned PHC string format: $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>.
+// Legacy SHA1 hashes created with the global salt are still verified, so existing users can sign in and get rehashed.
+type Argon2Hasher struct {
+	params     Argon2Params
+	legacySalt string
 }
 
-// Hash creates SHA1 hash of given password.
-func (h *SHA1Hasher) Hash(password string) (string, error) {
-	hash := sha1.New()
+// NewArgon2Hasher constructor for Argon2Hasher.
+func NewArgon2Hasher(params Argon2Params, legacySalt string) *Argon2Hasher {
+	return &Argon2Hasher{params: params, legacySalt: legacySalt}
+}
+
+// Hash creates argon2id hash of given password with a new random salt.
+func (h *Argon2Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", errors.Wrap(err, "generating salt error")
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, keyLength)
+
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2idScheme, argon2.Version,
+		h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify checks the password against the encoded hash.
+// needsRehash reports that the hash is valid but was created by the legacy algorithm or with other cost parameters.
+func (h *Argon2Hasher) Verify(password, encoded string) (ok bool, needsRehash bool, err error) {
+	if !strings.HasPrefix(encoded, "$") {
+		return h.verifyLegacy(password, encoded), true, nil
+	}
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != argon2idScheme {
+		return false, false, errors.New("unsupported password hash format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false, errors.New("unsupported argon2 version")
+	}
 
-	if _, err := hash.Write([]byte(password)); err != nil {
-		return "", err
+	var params Argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return false, false, errors.Wrap(err, "parsing argon2 parameters error")
 	}
 
-	return fmt.Sprintf("%x", hash.Sum([]byte(h.salt))), nil
+	// argon2 panics on zero cost parameters
+	if params.Iterations == 0 || params.Parallelism == 0 {
+		return false, false, errors.New("invalid argon2 parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, errors.Wrap(err, "decoding salt error")
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false, errors.Wrap(err, "decoding hash error")
+	}
+
+	if len(key) == 0 {
+		return false, false, errors.New("empty argon2 hash")
+	}
+
+	actual := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(actual, key) != 1 {
+		return false, false, nil
+	}
+
+	return true, params != h.params, nil
+}
+
+// verifyLegacy checks the password against the hex encoded SHA1 hash prefixed with the global salt.
+func (h *Argon2Hasher) verifyLegacy(password, encoded string) bool {
+	hash := sha1.New()
+	hash.Write([]byte(password))
+
+	actual := fmt.Sprintf("%x", hash.Sum([]byte(h.legacySalt)))
+
+	return subtle.ConstantTimeCompare([]byte(actual), []byte(encoded)) == 1
 }
@@ -0,0 +1,121 @@
+package hash
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testParams cheap argon2id parameters keeping the tests fast.
+var testParams = Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1}
+
+const (
+	testPassword   = "correct horse battery staple"
+	testLegacySalt = "legacy-salt"
+)
+
+func TestArgon2Hasher_HashVerify(t *testing.T) {
+	h := NewArgon2Hasher(testParams, testLegacySalt)
+
+	encoded, err := h.Hash(testPassword)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$"))
+
+	ok, needsRehash, err := h.Verify(testPassword, encoded)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, needsRehash)
+
+	ok, needsRehash, err = h.Verify("wrong password", encoded)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.False(t, needsRehash)
+
+	// every hash gets its own salt
+	other, err := h.Hash(testPassword)
+	require.NoError(t, err)
+	assert.NotEqual(t, encoded, other)
+}
+
+func TestArgon2Hasher_VerifyLegacy(t *testing.T) {
+	h := NewArgon2Hasher(testParams, testLegacySalt)
+
+	// the legacy hash is the hex encoded global salt followed by the hex encoded SHA1 of the password
+	sum := sha1.Sum([]byte(testPassword))
+	legacy := hex.EncodeToString([]byte(testLegacySalt)) + hex.EncodeToString(sum[:])
+
+	ok, needsRehash, err := h.Verify(testPassword, legacy)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, needsRehash)
+
+	ok, _, err = h.Verify("wrong password", legacy)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// a hash made with another global salt does not match
+	ok, _, err = NewArgon2Hasher(testParams, "other-salt").Verify(testPassword, legacy)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestArgon2Hasher_VerifyNeedsRehash(t *testing.T) {
+	encoded, err := NewArgon2Hasher(testParams, testLegacySalt).Hash(testPassword)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params Argon2Params
+		want   bool
+	}{
+		{name: "same_params", params: testParams, want: false},
+		{name: "more_memory", params: Argon2Params{Memory: 128, Iterations: 1, Parallelism: 1}, want: true},
+		{name: "more_iterations", params: Argon2Params{Memory: 64, Iterations: 2, Parallelism: 1}, want: true},
+		{name: "more_parallelism", params: Argon2Params{Memory: 64, Iterations: 1, Parallelism: 2}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the hash is verified with its own parameters, the configured ones only decide the rehash
+			ok, needsRehash, err := NewArgon2Hasher(tt.params, testLegacySalt).Verify(testPassword, encoded)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, needsRehash)
+		})
+	}
+}
+
+func TestArgon2Hasher_VerifyMalformed(t *testing.T) {
+	h := NewArgon2Hasher(testParams, testLegacySalt)
+
+	encoded, err := h.Hash(testPassword)
+	require.NoError(t, err)
+	parts := strings.Split(encoded, "$")
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{name: "unknown_scheme", encoded: "$bcrypt$v=19$m=64,t=1,p=1$" + parts[4] + "$" + parts[5]},
+		{name: "missing_parts", encoded: "$argon2id$v=19$m=64,t=1,p=1$" + parts[4]},
+		{name: "unsupported_version", encoded: "$argon2id$v=16$m=64,t=1,p=1$" + parts[4] + "$" + parts[5]},
+		{name: "malformed_version", encoded: "$argon2id$version$m=64,t=1,p=1$" + parts[4] + "$" + parts[5]},
+		{name: "malformed_params", encoded: "$argon2id$v=19$m=x,t=1,p=1$" + parts[4] + "$" + parts[5]},
+		{name: "zero_iterations", encoded: "$argon2id$v=19$m=64,t=0,p=1$" + parts[4] + "$" + parts[5]},
+		{name: "zero_parallelism", encoded: "$argon2id$v=19$m=64,t=1,p=0$" + parts[4] + "$" + parts[5]},
+		{name: "malformed_salt", encoded: "$argon2id$v=19$m=64,t=1,p=1$!!!$" + parts[5]},
+		{name: "malformed_hash", encoded: "$argon2id$v=19$m=64,t=1,p=1$" + parts[4] + "$!!!"},
+		{name: "empty_hash", encoded: "$argon2id$v=19$m=64,t=1,p=1$" + parts[4] + "$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash, err := h.Verify(testPassword, tt.encoded)
+			assert.Error(t, err)
+			assert.False(t, ok)
+			assert.False(t, needsRehash)
+		})
+	}
+}
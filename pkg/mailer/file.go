@@ -0,0 +1,58 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// File mailer for local development, writes every email as an .eml file into the directory instead of sending it.
+type File struct {
+	dir  string
+	from string
+}
+
+// NewFile constructor for File mailer, creates the directory if it does not exist.
+func NewFile(dir, from string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.Wrap(err, "creating mail directory error")
+	}
+
+	return &File{dir: dir, from: from}, nil
+}
+
+// Send writes the email into the directory.
+func (m *File) Send(_ context.Context, to, subject, body string) error {
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), strings.NewReplacer("@", "_at_", "/", "_").Replace(to))
+
+	if err := os.WriteFile(filepath.Join(m.dir, name), message(m.from, to, subject, body), 0o600); err != nil {
+		return errors.Wrap(err, "writing email file error")
+	}
+
+	return nil
+}
+
+// Log mailer for local development, writes every email to the log instead of sending it.
+type Log struct{}
+
+// NewLog constructor for Log mailer.
+func NewLog() *Log {
+	return &Log{}
+}
+
+// Send writes the email to the log.
+func (m *Log) Send(_ context.Context, to, subject, body string) error {
+	logrus.WithFields(logrus.Fields{
+		"mailer":  "log",
+		"to":      to,
+		"subject": subject,
+	}).Info(body)
+
+	return nil
+}
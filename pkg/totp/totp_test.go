@@ -0,0 +1,110 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret SHA1 secret "12345678901234567890" of the RFC 6238 test vectors, base32 encoded.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_RFC6238(t *testing.T) {
+	// RFC 6238 Appendix B lists 8-digit SHA1 codes, the 6-digit codes are their last 6 digits
+	tests := []struct {
+		time  int64
+		rfc   string
+		want  string
+		steps int64
+	}{
+		{time: 59, rfc: "94287082", want: "287082", steps: 0x1},
+		{time: 1111111109, rfc: "07081804", want: "081804", steps: 0x23523EC},
+		{time: 1111111111, rfc: "14050471", want: "050471", steps: 0x23523ED},
+		{time: 1234567890, rfc: "89005924", want: "005924", steps: 0x273EF07},
+		{time: 2000000000, rfc: "69279037", want: "279037", steps: 0x3F940AA},
+		{time: 20000000000, rfc: "65353130", want: "353130", steps: 0x27BC86AA},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rfc, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.rfc[len(tt.rfc)-Digits:])
+
+			step := Step(time.Unix(tt.time, 0).UTC())
+			assert.Equal(t, tt.steps, step)
+
+			code, err := Code(rfcSecret, step)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, code)
+		})
+	}
+}
+
+func TestCode_LowercaseSecret(t *testing.T) {
+	code, err := Code(strings.ToLower(rfcSecret), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "287082", code)
+}
+
+func TestCode_InvalidSecret(t *testing.T) {
+	_, err := Code("not base32!", 1)
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	codeAt := func(step int64) string {
+		code, err := Code(rfcSecret, step)
+		require.NoError(t, err)
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current_step", code: codeAt(current), skew: 1, wantStep: current, wantOK: true},
+		{name: "previous_step_within_skew", code: codeAt(current - 1), skew: 1, wantStep: current - 1, wantOK: true},
+		{name: "next_step_within_skew", code: codeAt(current + 1), skew: 1, wantStep: current + 1, wantOK: true},
+		{name: "step_before_skew", code: codeAt(current - 2), skew: 1},
+		{name: "step_after_skew", code: codeAt(current + 2), skew: 1},
+		{name: "previous_step_without_skew", code: codeAt(current - 1), skew: 0},
+		{name: "two_steps_back_with_wider_skew", code: codeAt(current - 2), skew: 2, wantStep: current - 2, wantOK: true},
+		{name: "short_code", code: "12345", skew: 1},
+		{name: "rfc_8_digit_code", code: "14050471", skew: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok, err := Validate(rfcSecret, tt.code, now, tt.skew)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantStep, step)
+		})
+	}
+}
+
+func TestValidate_InvalidSecret(t *testing.T) {
+	_, ok, err := Validate("not base32!", "123456", time.Now(), 1)
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	assert.NoError(t, err)
+	assert.Len(t, key, secretSize)
+
+	other, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+}
@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// ErrForbiddenAddress is returned for the hosts resolving to an address webhooks must not be sent to.
+var ErrForbiddenAddress = errors.New("webhook address is not public")
+
+// sharedAddressSpace carrier-grade NAT range (RFC 6598), not reachable from the internet either.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// PublicIP reports whether webhooks may be sent to the address: loopback, private, link-local,
+// unspecified, multicast and shared addresses are internal to the network the service runs in.
+func PublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() || sharedAddressSpace.Contains(ip))
+}
+
+// AddressChecker checks the webhook hosts before the subscriptions are created.
+type AddressChecker struct {
+	resolver *net.Resolver
+}
+
+// NewAddressChecker constructor for AddressChecker.
+func NewAddressChecker() *AddressChecker {
+	return &AddressChecker{resolver: net.DefaultResolver}
+}
+
+// CheckHost resolves the host and returns ErrForbiddenAddress when any of its addresses is not public.
+func (c *AddressChecker) CheckHost(ctx context.Context, host string) error {
+	addrs, err := c.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return errors.Wrap(err, "resolving webhook host error")
+	}
+
+	for _, addr := range addrs {
+		if !PublicIP(addr.IP) {
+			return errors.Wrapf(ErrForbiddenAddress, "checking address %s error", addr.IP)
+		}
+	}
+
+	return nil
+}
+
+// controlPublicDial rejects the connections to the addresses which are not public. It runs after the host is resolved
+// for every dialed address, so a host resolving to another address than when it was checked is rejected too.
+func controlPublicDial(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return errors.Wrap(err, "parsing dialed address error")
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !PublicIP(ip) {
+		return errors.Wrapf(ErrForbiddenAddress, "dialing address %s error", host)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxDrainedBody size of the response body read to let the connection be reused.
+const maxDrainedBody = 4 << 10
+
+// HTTPSender sends the webhook requests over HTTP.
+type HTTPSender struct {
+	client *http.Client
+}
+
+// NewHTTPSender constructor for HTTPSender, a request not answered within timeout fails.
+// Redirects are not followed, the receiver must answer on the registered URL.
+// Only public addresses are dialed and no proxy is used, so webhooks can not reach the internal network.
+func NewHTTPSender(timeout time.Duration) *HTTPSender {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout: timeout,
+		Control: controlPublicDial,
+	}).DialContext
+
+	return &HTTPSender{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Send posts the body with the headers to the url and returns the status code of the response.
+func (s *HTTPSender) Send(ctx context.Context, url string, headers map[string]string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, errors.Wrap(err, "creating webhook request error")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "banking-backend-webhooks")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "sending webhook request error")
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainedBody))
+
+	return resp.StatusCode, nil
+}